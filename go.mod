@@ -1,3 +1,3 @@
 module github.com/zchee/go-darkness
 
-go 1.14
+go 1.18
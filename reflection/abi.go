@@ -0,0 +1,195 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"unsafe"
+)
+
+// ABIReg is an argument register of the internal register-based calling
+// convention of Go, ABIInternal.
+type ABIReg struct {
+	Float bool // a floating-point register, otherwise an integer one
+	Index int  // index among the argument registers of its class
+}
+
+// String returns the name of r as the compiler prints it, such as "I0" or
+// "F3".
+func (r ABIReg) String() string {
+	if r.Float {
+		return "F" + strconv.Itoa(r.Index)
+	}
+	return "I" + strconv.Itoa(r.Index)
+}
+
+// ABIParam describes how a parameter or a result is passed.
+type ABIParam struct {
+	Type *rtype
+
+	// Regs lists the registers holding the parts of the value, in memory
+	// order, or is empty for a value passed on the stack. Values of size
+	// zero are always on the stack.
+	Regs []ABIReg
+
+	// Offset is the offset of a value passed on the stack from the start of
+	// the argument area, or the offset of the spill slot of a parameter
+	// passed in registers from the start of the spill area. It is zero for
+	// a result passed in registers.
+	Offset uintptr
+}
+
+// ABIInfo describes how the parameters and results of a function type are
+// passed.
+type ABIInfo struct {
+	In, Out []ABIParam
+
+	// StackSize is the size of the argument area, for the parameters and
+	// results passed on the stack, and SpillSize the size of the area
+	// after it where the parameters passed in registers may be spilled.
+	StackSize, SpillSize uintptr
+}
+
+// abiRegCounts returns the number of integer and floating-point argument
+// registers of ABIInternal on the architecture running the program.
+func abiRegCounts() (ints, floats int, ok bool) {
+	switch runtime.GOARCH {
+	case "amd64":
+		return 9, 15, true
+	case "arm64":
+		return 16, 16, true
+	}
+	return 0, 0, false
+}
+
+// ABILayout returns how the functions of type ft are called under
+// ABIInternal, the calling convention of Go functions since Go 1.17, as
+// cmd/compile/internal/abi assigns registers and stack slots: each value is
+// flattened into its scalar parts, and passed in registers if enough of them
+// remain for all of its parts, else on the stack. Registers are counted
+// afresh for the results. Arrays of more than one element are always
+// passed on the stack.
+//
+// Only amd64 and arm64 are supported; ABILayout returns ErrUnsupported on
+// other architectures.
+func ABILayout(ft *FuncType) (ABIInfo, error) {
+	var info ABIInfo
+	ints, floats, ok := abiRegCounts()
+	if !ok {
+		return info, fmt.Errorf("%w: ABILayout on %s", ErrUnsupported, runtime.GOARCH)
+	}
+	s := abiState{ints: ints, floats: floats}
+	for i := 0; i < ft.NumIn(); i++ {
+		info.In = append(info.In, s.assign(ft.In(i), false))
+	}
+	s.stack = alignUp(s.stack, ptrSize)
+	s.usedInts, s.usedFloats = 0, 0
+	for i := 0; i < ft.NumOut(); i++ {
+		info.Out = append(info.Out, s.assign(ft.Out(i), true))
+	}
+	info.StackSize = alignUp(s.stack, ptrSize)
+	info.SpillSize = alignUp(s.spill, ptrSize)
+	return info, nil
+}
+
+// abiState holds the registers and stack used so far by ABILayout.
+type abiState struct {
+	ints, floats         int // registers available
+	usedInts, usedFloats int
+	stack, spill         uintptr
+}
+
+func (s *abiState) assign(t *rtype, result bool) ABIParam {
+	p := ABIParam{Type: t}
+	ints, floats := abiRegsOf(t)
+	if t.size == 0 || ints > s.ints-s.usedInts || floats > s.floats-s.usedFloats {
+		p.Offset = alignUp(s.stack, uintptr(t.align))
+		s.stack = p.Offset + t.size
+		return p
+	}
+	p.Regs = s.allocate(p.Regs, t)
+	if !result {
+		p.Offset = alignUp(s.spill, uintptr(t.align))
+		s.spill = p.Offset + t.size
+	}
+	return p
+}
+
+// allocate appends to regs the registers holding the parts of t, which
+// must fit in the registers left.
+func (s *abiState) allocate(regs []ABIReg, t *rtype) []ABIReg {
+	if t.size == 0 {
+		return regs
+	}
+	switch t.Kind() {
+	case Float32, Float64:
+		regs = append(regs, ABIReg{Float: true, Index: s.usedFloats})
+		s.usedFloats++
+	case Complex64, Complex128:
+		regs = append(regs, ABIReg{Float: true, Index: s.usedFloats}, ABIReg{Float: true, Index: s.usedFloats + 1})
+		s.usedFloats += 2
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			regs = s.allocate(regs, at.Elem)
+		}
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			regs = s.allocate(regs, st.Fields[i].typ)
+		}
+	default:
+		ints, _ := abiRegsOf(t)
+		for i := 0; i < ints; i++ {
+			regs = append(regs, ABIReg{Index: s.usedInts})
+			s.usedInts++
+		}
+	}
+	return regs
+}
+
+// abiUnassignable exceeds any number of argument registers.
+const abiUnassignable = 1 << 30
+
+// abiRegsOf returns the number of integer and floating-point registers
+// needed to pass a value of type t, as types.Type.Registers does.
+func abiRegsOf(t *rtype) (ints, floats int) {
+	switch t.Kind() {
+	case Float32, Float64:
+		return 0, 1
+	case Complex64, Complex128:
+		return 0, 2
+	case String, Interface:
+		return 2, 0
+	case Slice:
+		return 3, 0
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		switch at.Len {
+		case 0:
+			return 0, 0
+		case 1:
+			return abiRegsOf(at.Elem)
+		}
+		return abiUnassignable, abiUnassignable
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			fi, ff := abiRegsOf(st.Fields[i].typ)
+			ints += fi
+			floats += ff
+		}
+		return ints, floats
+	}
+	// Integers, booleans and pointer-shaped types.
+	return int((t.size + ptrSize - 1) / ptrSize), 0
+}
+
+// alignUp rounds x up to a multiple of a, a power of 2.
+func alignUp(x, a uintptr) uintptr {
+	return (x + a - 1) &^ (a - 1)
+}
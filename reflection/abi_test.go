@@ -0,0 +1,87 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// abiRegs formats the registers of the params as "I0,I1 F0 stack@8".
+func abiRegs(params []ABIParam) string {
+	s := ""
+	for i, p := range params {
+		if i > 0 {
+			s += " "
+		}
+		if len(p.Regs) == 0 {
+			s += fmt.Sprintf("stack@%d", p.Offset)
+			continue
+		}
+		for j, r := range p.Regs {
+			if j > 0 {
+				s += ","
+			}
+			s += r.String()
+		}
+	}
+	return s
+}
+
+func TestABILayout(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		ft := (*FuncType)(unsafe.Pointer(TypeFor[func(int)]()))
+		if _, err := ABILayout(ft); runtime.GOARCH != "arm64" && !errors.Is(err, ErrUnsupported) {
+			t.Errorf("ABILayout on %s = %v, want ErrUnsupported", runtime.GOARCH, err)
+		}
+		t.Skip("register counts checked on amd64")
+	}
+	tests := []struct {
+		fn           *rtype
+		in, out      string
+		stack, spill uintptr
+	}{
+		{
+			TypeFor[func(int, string, float64) (bool, error)](),
+			"I0 I1,I2 F0", "I0 I1,I2", 0, 32,
+		},
+		{
+			TypeFor[func([2]int, struct {
+				A int8
+				B float32
+			}) [0]int](),
+			"stack@0 I0,F0", "stack@16", 16, 8,
+		},
+		{
+			TypeFor[func(int, int, int, int, int, []int, int, int)](),
+			"I0 I1 I2 I3 I4 I5,I6,I7 I8 stack@0", "", 8, 72,
+		},
+		{
+			// The slice does not fit in the two registers left, but the
+			// next int does.
+			TypeFor[func(int, int, int, int, int, int, int, []int, int)](),
+			"I0 I1 I2 I3 I4 I5 I6 stack@0 I7", "", 24, 64,
+		},
+		{
+			TypeFor[func(complex128, [1]float32, struct{}) (int8, struct{}, float64)](),
+			"F0,F1 F2 stack@0", "I0 stack@0 F0", 0, 24,
+		},
+	}
+	for _, tt := range tests {
+		info, err := ABILayout((*FuncType)(unsafe.Pointer(tt.fn)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if in, out := abiRegs(info.In), abiRegs(info.Out); in != tt.in || out != tt.out {
+			t.Errorf("ABILayout(%s) = (%s) (%s), want (%s) (%s)", tt.fn, in, out, tt.in, tt.out)
+		}
+		if info.StackSize != tt.stack || info.SpillSize != tt.spill {
+			t.Errorf("ABILayout(%s): StackSize, SpillSize = %d, %d, want %d, %d", tt.fn, info.StackSize, info.SpillSize, tt.stack, tt.spill)
+		}
+	}
+}
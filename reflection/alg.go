@@ -0,0 +1,213 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"runtime"
+	"unsafe"
+)
+
+var (
+	// ErrUnhashable is returned when hashing a value whose dynamic type is not hashable.
+	ErrUnhashable = errors.New("reflection: hash of unhashable type")
+
+	// ErrUncomparable is returned when comparing values whose dynamic type is not comparable.
+	ErrUncomparable = errors.New("reflection: comparing uncomparable type")
+)
+
+// efaceOf returns the header of the empty interface pointed to by ep.
+func efaceOf(ep *interface{}) *InterfaceHeader {
+	return (*InterfaceHeader)(unsafe.Pointer(ep))
+}
+
+// ifaceOf returns the type and the data word of the value held by the
+// interface of type t at p, an empty or non-empty interface type.
+func ifaceOf(t *rtype, p unsafe.Pointer) InterfaceHeader {
+	if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+		return *(*InterfaceHeader)(p)
+	}
+	if tab := (*IfaceHeader)(p).Tab; tab != nil {
+		return InterfaceHeader{Type: tab.Type, Word: (*IfaceHeader)(p).Word}
+	}
+	return InterfaceHeader{}
+}
+
+// data returns a pointer to the value held by e.
+// For direct interface types the value is the data word itself.
+func (e *InterfaceHeader) data() unsafe.Pointer {
+	if ifaceIndir(e.Type) {
+		return e.Word
+	}
+	return unsafe.Pointer(&e.Word)
+}
+
+// IsComparable reports whether values of type t can be compared with ==,
+// and therefore used as map keys.
+//
+// Interface types are always comparable, but comparing two interface values
+// panics at run time if their dynamic type is not comparable. IsComparable
+// answers for the static type only; use IfaceComparable to check the dynamic
+// type held by an interface value.
+func IsComparable(t *rtype) bool {
+	return t.equalFunc() != nil
+}
+
+// EqualFunc returns the function comparing two values of type t,
+// or nil if t is not comparable.
+//
+// The returned function takes pointers to the two values being compared.
+func EqualFunc(t *rtype) func(unsafe.Pointer, unsafe.Pointer) bool {
+	return t.equalFunc()
+}
+
+// equalFunc returns t.equal.
+//
+// It must not be inlined: when t is known to be a static type symbol at the
+// call site, the compiler turns the load of the func field into a relocation
+// the linker cannot resolve.
+//
+//go:noinline
+func (t *rtype) equalFunc() func(unsafe.Pointer, unsafe.Pointer) bool {
+	return t.equal
+}
+
+// IfaceComparable reports whether the dynamic type of i is comparable,
+// that is, whether i can be used as a key of a map[interface{}]X without panicking.
+//
+// A nil interface value is comparable.
+func IfaceComparable(i interface{}) bool {
+	e := efaceOf(&i)
+	if e.Type == nil {
+		return true
+	}
+	return IsComparable(e.Type)
+}
+
+// EfaceHash returns the hash of i with the given seed, consistent with Go
+// equality: if i == j then EfaceHash(i, seed) == EfaceHash(j, seed).
+//
+// It mirrors runtime.nilinterhash, additionally mixing in the hash of the
+// dynamic type, but returns ErrUnhashable instead of panicking when the
+// dynamic type of i, or of any interface nested in it, is not hashable.
+// It hashes with the backend HashFeatures().Backend reports, so hashes are
+// only stable within a process.
+func EfaceHash(i interface{}, seed uintptr) (h uintptr, err error) {
+	e := efaceOf(&i)
+	if e.Type == nil {
+		return seed, nil
+	}
+	if !IsComparable(e.Type) {
+		return 0, ErrUnhashable
+	}
+	defer recoverRuntimeError(&err, ErrUnhashable)
+	return typehash(e.Type, e.data(), seed^uintptr(e.Type.hash)), nil
+}
+
+// NilInterHash returns the hash of i with the given seed as the runtime
+// computes it for the keys of a map[interface{}]X, or ErrUnhashable instead
+// of panicking when the dynamic type of i, or of any interface nested in it,
+// is not hashable.
+//
+// Unlike EfaceHash, the hash of the dynamic type is not mixed in, so values
+// of different types with the same memory representation are more likely
+// to collide. NilInterHash is the function to use to reproduce the bucket
+// placement of runtime maps; EfaceHash suits tables of their own.
+func NilInterHash(i interface{}, seed uintptr) (h uintptr, err error) {
+	e := efaceOf(&i)
+	if e.Type != nil && !IsComparable(e.Type) {
+		return 0, ErrUnhashable
+	}
+	defer recoverRuntimeError(&err, ErrUnhashable)
+	return nilinterhash(unsafe.Pointer(e), seed), nil
+}
+
+// InterHash is like NilInterHash for a value of a non-empty interface type,
+// given by its itab and data word, as the keys of a map[I]X are hashed.
+// The hash only depends on the dynamic type and value, so that it is the
+// NilInterHash of the same value held in an empty interface.
+func InterHash(tab *ITab, word unsafe.Pointer, seed uintptr) (uintptr, error) {
+	var i interface{}
+	if tab != nil {
+		*efaceOf(&i) = InterfaceHeader{Type: tab.Type, Word: word}
+	}
+	return NilInterHash(i, seed)
+}
+
+// EfaceEqual reports whether a and b are equal, as a == b would.
+//
+// Values with different dynamic types are never equal, even when their memory
+// representation is identical. It returns ErrUncomparable instead of panicking
+// when the dynamic type, or that of any interface nested in it, is not comparable.
+func EfaceEqual(a, b interface{}) (eq bool, err error) {
+	x, y := efaceOf(&a), efaceOf(&b)
+	if x.Type != y.Type {
+		return false, nil
+	}
+	if x.Type == nil {
+		return true, nil
+	}
+	equal := EqualFunc(x.Type)
+	if equal == nil {
+		return false, ErrUncomparable
+	}
+	if !ifaceIndir(x.Type) {
+		// Direct interface types are compared by their data word, see runtime.efaceeq.
+		return x.Word == y.Word, nil
+	}
+	defer recoverRuntimeError(&err, ErrUncomparable)
+	return equal(x.Word, y.Word), nil
+}
+
+// SafeEqual reports whether a == b, and whether the comparison is possible:
+// comparable is false, where == would panic, when a and b have the same
+// dynamic type and that type, or that of an interface nested in it, is not
+// comparable.
+func SafeEqual(a, b interface{}) (equal, comparable bool) {
+	eq, err := EfaceEqual(a, b)
+	return eq, err == nil
+}
+
+// SafeIfaceEqual is like SafeEqual for two values of the same non-empty
+// interface type, given by their itab and data words.
+func SafeIfaceEqual(tabA *ITab, a unsafe.Pointer, tabB *ITab, b unsafe.Pointer) (equal, comparable bool) {
+	if tabA != tabB {
+		return false, true
+	}
+	if tabA == nil {
+		return true, true
+	}
+	var x, y interface{}
+	*efaceOf(&x) = InterfaceHeader{Type: tabA.Type, Word: a}
+	*efaceOf(&y) = InterfaceHeader{Type: tabB.Type, Word: b}
+	return SafeEqual(x, y)
+}
+
+// recoverRuntimeError converts a runtime panic into *errp = target.
+// Any other panic is propagated.
+func recoverRuntimeError(errp *error, target error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(runtime.Error); !ok {
+		panic(r)
+	}
+	*errp = target
+}
+
+//go:linkname typehash runtime.typehash
+
+// typehash computes the hash of the object of type t at address p.
+// h is the seed.
+// Implemented in the runtime package.
+func typehash(t *rtype, p unsafe.Pointer, h uintptr) uintptr
+
+//go:linkname nilinterhash runtime.nilinterhash
+
+// nilinterhash computes the hash of the empty interface value at p.
+// h is the seed.
+// Implemented in the runtime package.
+func nilinterhash(p unsafe.Pointer, h uintptr) uintptr
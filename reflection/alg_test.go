@@ -0,0 +1,325 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type algHolder struct {
+	I interface{}
+	N int
+}
+
+func TestIsComparable(t *testing.T) {
+	tests := []struct {
+		t    *rtype
+		want bool
+	}{
+		{TypeFor[int](), true},
+		{TypeFor[string](), true},
+		{TypeFor[float64](), true},
+		{TypeFor[*int](), true},
+		{TypeFor[chan int](), true},
+		{TypeFor[[3]int](), true},
+		{TypeFor[[0][]int](), false},
+		{TypeFor[[2][]int](), false},
+		{TypeFor[struct{ A, B int }](), true},
+		{TypeFor[struct{ S []int }](), false},
+		{TypeFor[struct {
+			N int
+			M map[int]int
+		}](), false},
+		{TypeFor[[]int](), false},
+		{TypeFor[map[int]int](), false},
+		{TypeFor[func()](), false},
+		{TypeFor[interface{}](), true},
+		{TypeFor[error](), true},
+		{TypeFor[algHolder](), true},
+	}
+	for _, tt := range tests {
+		if got := IsComparable(tt.t); got != tt.want {
+			t.Errorf("IsComparable(%s) = %v, want %v", tt.t, got, tt.want)
+		}
+		if got := EqualFunc(tt.t) != nil; got != tt.want {
+			t.Errorf("EqualFunc(%s) != nil = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestEqualFunc(t *testing.T) {
+	eq := EqualFunc(TypeFor[[3]int]())
+	a, b, c := [3]int{1, 2, 3}, [3]int{1, 2, 3}, [3]int{1, 2, 4}
+	if !eq(unsafe.Pointer(&a), unsafe.Pointer(&b)) || eq(unsafe.Pointer(&a), unsafe.Pointer(&c)) {
+		t.Error("EqualFunc([3]int) disagrees with ==")
+	}
+
+	eq = EqualFunc(TypeFor[algHolder]())
+	x, y := algHolder{I: "s", N: 1}, algHolder{I: "s", N: 1}
+	if !eq(unsafe.Pointer(&x), unsafe.Pointer(&y)) {
+		t.Error("EqualFunc(algHolder) of equal values = false")
+	}
+	y.I = 1
+	if eq(unsafe.Pointer(&x), unsafe.Pointer(&y)) {
+		t.Error("EqualFunc(algHolder) of different dynamic types = true")
+	}
+
+	// The static type is comparable, but the dynamic type held is not: the
+	// function panics as == would.
+	x.I, y.I = []int{1}, []int{1}
+	defer func() {
+		if _, ok := recover().(runtime.Error); !ok {
+			t.Error("EqualFunc of interfaces holding slices did not panic with a runtime.Error")
+		}
+	}()
+	eq(unsafe.Pointer(&x), unsafe.Pointer(&y))
+}
+
+func TestIfaceComparable(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want bool
+	}{
+		{nil, true},
+		{1, true},
+		{"s", true},
+		{[2]string{}, true},
+		{[]int{}, false},
+		{map[int]int{}, false},
+		{func() {}, false},
+		{struct{ S []int }{}, false},
+		// The dynamic type is comparable, though the value it holds is
+		// not: IfaceComparable only answers for the type.
+		{algHolder{I: []int{}}, true},
+	}
+	for _, tt := range tests {
+		if got := IfaceComparable(tt.v); got != tt.want {
+			t.Errorf("IfaceComparable(%T) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+type (
+	algInt64  int64
+	algString string
+)
+
+// algPairs returns pairs of equal values built separately from r, so that
+// the values of reference types do not share their memory.
+func algPairs(r *rand.Rand) [][2]interface{} {
+	v := r.Int63() - r.Int63()
+	s := strconv.FormatInt(v, 36)
+	f := float64(v) / 7
+	return [][2]interface{}{
+		{v, v},
+		{algInt64(v), algInt64(v)},
+		{uint8(v), uint8(v)},
+		{s, string([]byte(s))},
+		{f, f},
+		{0.0, math.Copysign(0, -1)},
+		{complex(f, -f), complex(f, -f)},
+		{[2]string{s, s}, [2]string{string([]byte(s)), s}},
+		{algHolder{I: s, N: int(v)}, algHolder{I: string([]byte(s)), N: int(v)}},
+		{struct {
+			A int8
+			B int64
+		}{1, v}, struct {
+			A int8
+			B int64
+		}{1, v}},
+	}
+}
+
+func TestEfaceHashEqual(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seed := uintptr(r.Uint64())
+	for i := 0; i < 200; i++ {
+		for _, p := range algPairs(r) {
+			if eq, err := EfaceEqual(p[0], p[1]); !eq || err != nil {
+				t.Fatalf("EfaceEqual(%#v, %#v) = %v, %v", p[0], p[1], eq, err)
+			}
+			h0, err0 := EfaceHash(p[0], seed)
+			h1, err1 := EfaceHash(p[1], seed)
+			if err0 != nil || err1 != nil || h0 != h1 {
+				t.Fatalf("EfaceHash of equal %#v, %#v = %#x, %#x, %v, %v", p[0], p[1], h0, h1, err0, err1)
+			}
+		}
+	}
+}
+
+func TestEfaceEqualTypes(t *testing.T) {
+	// Values of different types with identical bytes are never equal.
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		v := r.Int63()
+		s := strconv.FormatInt(v, 10)
+		same := []interface{}{v, uint64(v), algInt64(v), math.Float64frombits(uint64(v)), uintptr(v), [1]int64{v}, struct{ V int64 }{v}}
+		same = append(same, s, algString(s))
+		for a := range same {
+			for b := range same {
+				eq, err := EfaceEqual(same[a], same[b])
+				if want := a == b && same[a] == same[a]; eq != want || err != nil {
+					t.Fatalf("EfaceEqual(%T(%v), %T(%v)) = %v, %v, want %v", same[a], same[a], same[b], same[b], eq, err, want)
+				}
+			}
+		}
+	}
+
+	nan := math.NaN()
+	if eq, err := EfaceEqual(nan, nan); eq || err != nil {
+		t.Errorf("EfaceEqual(NaN, NaN) = %v, %v", eq, err)
+	}
+	if eq, err := EfaceEqual(nil, nil); !eq || err != nil {
+		t.Errorf("EfaceEqual(nil, nil) = %v, %v", eq, err)
+	}
+	if eq, err := EfaceEqual(nil, (*int)(nil)); eq || err != nil {
+		t.Errorf("EfaceEqual(nil, (*int)(nil)) = %v, %v", eq, err)
+	}
+	if h, err := EfaceHash(nil, 7); h != 7 || err != nil {
+		t.Errorf("EfaceHash(nil, 7) = %#x, %v", h, err)
+	}
+}
+
+func TestEfaceHashErrors(t *testing.T) {
+	for _, v := range []interface{}{[]int{1}, map[int]int{}, func() {}, algHolder{I: []int{}}, [1]interface{}{map[int]int{}}} {
+		if _, err := EfaceHash(v, 0); !errors.Is(err, ErrUnhashable) {
+			t.Errorf("EfaceHash(%T) = %v, want ErrUnhashable", v, err)
+		}
+		if _, err := EfaceEqual(v, v); !errors.Is(err, ErrUncomparable) {
+			t.Errorf("EfaceEqual(%T) = %v, want ErrUncomparable", v, err)
+		}
+	}
+	// Different dynamic types are unequal before their comparability matters.
+	if eq, err := EfaceEqual([]int{}, 1); eq || err != nil {
+		t.Errorf("EfaceEqual([]int, int) = %v, %v", eq, err)
+	}
+}
+
+func TestNilInterHash(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	seed := uintptr(r.Uint64())
+	for i := 0; i < 200; i++ {
+		for _, p := range algPairs(r) {
+			h0, err0 := NilInterHash(p[0], seed)
+			h1, err1 := NilInterHash(p[1], seed)
+			if err0 != nil || err1 != nil || h0 != h1 {
+				t.Fatalf("NilInterHash of equal %#v, %#v = %#x, %#x, %v, %v", p[0], p[1], h0, h1, err0, err1)
+			}
+		}
+	}
+	for _, v := range []interface{}{[]int{1}, map[int]int{}, func() {}, algHolder{I: []int{}}, [1]interface{}{map[int]int{}}} {
+		if _, err := NilInterHash(v, seed); !errors.Is(err, ErrUnhashable) {
+			t.Errorf("NilInterHash(%T) = %v, want ErrUnhashable", v, err)
+		}
+	}
+	if h, err := NilInterHash(nil, seed); err != nil {
+		t.Errorf("NilInterHash(nil) = %#x, %v", h, err)
+	}
+}
+
+func TestInterHash(t *testing.T) {
+	seed := uintptr(12345)
+	for _, r := range []io.Reader{nil, algNamedReader(1), algNamedReader(2), new(algReader)} {
+		h := (*IfaceHeader)(unsafe.Pointer(&r))
+		got, err := InterHash(h.Tab, h.Word, seed)
+		want, _ := NilInterHash(interface{}(r), seed)
+		if err != nil || got != want {
+			t.Errorf("InterHash(%#v) = %#x, %v, want the NilInterHash %#x", r, got, err, want)
+		}
+	}
+	var unhashable io.Reader = algReader{}
+	h := (*IfaceHeader)(unsafe.Pointer(&unhashable))
+	if _, err := InterHash(h.Tab, h.Word, seed); !errors.Is(err, ErrUnhashable) {
+		t.Errorf("InterHash of a slice-holding struct = %v, want ErrUnhashable", err)
+	}
+}
+
+func FuzzEfaceHash(f *testing.F) {
+	f.Add(int64(0), "")
+	f.Add(int64(-1), "x")
+	f.Fuzz(func(t *testing.T, v int64, s string) {
+		a := algHolder{I: s, N: int(v)}
+		b := algHolder{I: string([]byte(s)), N: int(v)}
+		ha, _ := EfaceHash(a, 1)
+		hb, _ := EfaceHash(b, 1)
+		if ha != hb {
+			t.Errorf("EfaceHash of equal values differ: %#x, %#x", ha, hb)
+		}
+		if eq, _ := EfaceEqual(v, uint64(v)); eq {
+			t.Errorf("EfaceEqual(int64, uint64) = true")
+		}
+	})
+}
+
+func TestSafeEqual(t *testing.T) {
+	f := func() {}
+	var nilPtr *int
+	x := 1
+	tests := []struct {
+		a, b              interface{}
+		equal, comparable bool
+	}{
+		{1, 1, true, true},
+		{1, 2, false, true},
+		{1, algInt64(1), false, true},
+		{nil, nil, true, true},
+		{nil, nilPtr, false, true},
+		{nilPtr, nilPtr, true, true},
+		{nilPtr, &x, false, true},
+		{f, f, false, false},
+		{[]int{1}, []int{1}, false, false},
+		{map[int]int{}, map[int]int{}, false, false},
+		{algHolder{I: []int{}}, algHolder{I: []int{}}, false, false},
+		{algHolder{I: f}, algHolder{I: 1}, false, true},
+		// Values of different types compare unequal without panicking.
+		{[]int{1}, 1, false, true},
+		{f, nil, false, true},
+	}
+	for _, tt := range tests {
+		equal, comparable := SafeEqual(tt.a, tt.b)
+		if equal != tt.equal || comparable != tt.comparable {
+			t.Errorf("SafeEqual(%T, %T) = %v, %v, want %v, %v", tt.a, tt.b, equal, comparable, tt.equal, tt.comparable)
+		}
+	}
+}
+
+type algReader struct{ buf []byte }
+
+func (r algReader) Read(p []byte) (int, error) { return copy(p, r.buf), nil }
+
+type algNamedReader int
+
+func (algNamedReader) Read(p []byte) (int, error) { return 0, nil }
+
+func TestSafeIfaceEqual(t *testing.T) {
+	header := func(r io.Reader) *IfaceHeader { return (*IfaceHeader)(unsafe.Pointer(&r)) }
+	var nilReader *algReader
+	tests := []struct {
+		a, b              io.Reader
+		equal, comparable bool
+	}{
+		{algNamedReader(1), algNamedReader(1), true, true},
+		{algNamedReader(1), algNamedReader(2), false, true},
+		{nil, nil, true, true},
+		{nil, nilReader, false, true},
+		{nilReader, nilReader, true, true},
+		{algReader{}, algReader{}, false, false},
+		{algReader{}, algNamedReader(0), false, true},
+	}
+	for _, tt := range tests {
+		a, b := header(tt.a), header(tt.b)
+		equal, comparable := SafeIfaceEqual(a.Tab, a.Word, b.Tab, b.Word)
+		if equal != tt.equal || comparable != tt.comparable {
+			t.Errorf("SafeIfaceEqual(%T, %T) = %v, %v, want %v, %v", tt.a, tt.b, equal, comparable, tt.equal, tt.comparable)
+		}
+	}
+}
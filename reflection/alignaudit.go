@@ -0,0 +1,291 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// AlignmentIssue is a field that AuditAtomicAlignment finds misaligned, or
+// cannot find.
+type AlignmentIssue struct {
+	// Path is the path of the field as resolved, with the embedded fields
+	// it is promoted through, and the index of an array element, such as
+	// ".Shards[1].Count".
+	Path string
+
+	// Offset is the offset of the field from the start of the struct.
+	Offset uintptr
+
+	// Align is the alignment the field requires.
+	Align uintptr
+
+	// PtrSize is the size of pointers of the layout in which the field is
+	// misaligned: 8 on 64-bit platforms and 4 on 32-bit ones.
+	PtrSize uintptr
+
+	// Err is the error resolving the field; the other fields but Path are
+	// then zero.
+	Err error
+}
+
+func (i AlignmentIssue) String() string {
+	if i.Err != nil {
+		return "field " + i.Path + ": " + i.Err.Error()
+	}
+	return fmt.Sprintf("field %s at offset %d is not %d-byte aligned with %d-byte pointers", i.Path, i.Offset, i.Align, i.PtrSize)
+}
+
+// AuditAtomicAlignment reports the fields of st named by fieldNames, dotted
+// paths as for OffsetOf, whose offset is not a multiple of 8, or of their
+// alignment if larger, as the 64-bit operations of sync/atomic require on
+// 32-bit platforms. The struct is assumed allocated, and so 8-byte aligned.
+//
+// The fields are checked in the layout of the running platform and, on a
+// 64-bit one, in the layout the struct has on 32-bit platforms, where
+// 64-bit integers are only 4-byte aligned and pointers are 4 bytes long.
+// The fields of embedded structs may be named as promoted, and the fields
+// of the structs in an array as those of its element: all the elements
+// must then be aligned, and so the stride of the array too. Pointers are
+// not followed. A field that cannot be resolved is reported with Err set.
+func AuditAtomicAlignment(st *StructType, fieldNames []string) []AlignmentIssue {
+	layouts := []alignLayout{{ptrSize}}
+	if ptrSize == 8 {
+		layouts = append(layouts, alignLayout{4})
+	}
+	var issues []AlignmentIssue
+	for _, name := range fieldNames {
+		for _, l := range layouts {
+			issue, ok := l.audit(st, name)
+			if ok {
+				continue
+			}
+			issues = append(issues, issue)
+			if issue.Err != nil {
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// AssertAligned is like AuditAtomicAlignment, but panics with an error
+// wrapping ErrMisaligned and describing each issue, if any. It is meant for
+// init functions, to catch a field prepended before an atomic one.
+func AssertAligned(st *StructType, fieldNames []string) {
+	issues := AuditAtomicAlignment(st, fieldNames)
+	if len(issues) == 0 {
+		return
+	}
+	msgs := make([]string, len(issues))
+	for i, issue := range issues {
+		msgs[i] = issue.String()
+	}
+	panic(fmt.Errorf("%w in %s: %s", ErrMisaligned, &st.rtype, strings.Join(msgs, "; ")))
+}
+
+// alignLayout computes the layout of types on a platform with pointers of
+// ptrSize bytes.
+type alignLayout struct {
+	ptrSize uintptr
+}
+
+// audit checks the alignment of the field at path in st, and returns the
+// issue and false if it is misaligned.
+func (l alignLayout) audit(st *StructType, path string) (AlignmentIssue, bool) {
+	// array is an array the field lies in: the element at segs[seg] of the
+	// path, of a stride and a length.
+	type array struct {
+		seg         int
+		stride, len uintptr
+	}
+	var (
+		typ    = &st.rtype
+		off    uintptr
+		segs   []string
+		arrays []array
+	)
+	descend := func() {
+		for typ.Kind() == Array {
+			at := (*ArrayType)(unsafe.Pointer(typ))
+			stride, _ := l.sizeAlign(at.Elem)
+			arrays = append(arrays, array{len(segs), stride, at.Len})
+			segs = append(segs, "[0]")
+			typ = at.Elem
+		}
+	}
+	for _, name := range strings.Split(path, ".") {
+		descend()
+		if typ.Kind() != Struct {
+			return AlignmentIssue{Path: "." + path, Err: &KindError{Op: "AuditAtomicAlignment", Kind: typ.Kind()}}, false
+		}
+		chain := promotedField((*StructType)(unsafe.Pointer(typ)), name)
+		if chain == nil {
+			return AlignmentIssue{Path: "." + path, Err: fmt.Errorf("%w: %q in %s", ErrFieldNotFound, name, typ)}, false
+		}
+		for _, i := range chain {
+			s := (*StructType)(unsafe.Pointer(typ))
+			off += l.fieldOffsets(s)[i]
+			segs = append(segs, "."+s.Fields[i].Name.Name())
+			typ = s.Fields[i].typ
+		}
+	}
+	descend()
+
+	align := uintptr(8)
+	if a := uintptr(typ.align); a > align {
+		align = a
+	}
+	issue := AlignmentIssue{Offset: off, Align: align, PtrSize: l.ptrSize}
+	if off%align != 0 {
+		issue.Path = strings.Join(segs, "")
+		return issue, false
+	}
+	for _, a := range arrays {
+		if a.len > 1 && a.stride%align != 0 {
+			segs[a.seg] = "[1]"
+			issue.Path = strings.Join(segs, "")
+			issue.Offset += a.stride
+			return issue, false
+		}
+	}
+	return AlignmentIssue{}, true
+}
+
+// promotedField returns the indices of the fields leading to the field
+// named name in st, through embedded structs if promoted, or nil if there
+// is no such field, or several at the shallowest depth.
+func promotedField(st *StructType, name string) []int {
+	type candidate struct {
+		st    *StructType
+		chain []int
+	}
+	level := []candidate{{st, nil}}
+	seen := map[*StructType]bool{st: true}
+	for len(level) > 0 {
+		var found []int
+		n := 0
+		var next []candidate
+		for _, c := range level {
+			if i := fieldIndex(c.st, name); i >= 0 {
+				found = append(c.chain[:len(c.chain):len(c.chain)], i)
+				n++
+				continue
+			}
+			for j := range c.st.Fields {
+				f := &c.st.Fields[j]
+				if !f.Embedded() || f.typ.Kind() != Struct {
+					continue
+				}
+				es := (*StructType)(unsafe.Pointer(f.typ))
+				if !seen[es] {
+					seen[es] = true
+					next = append(next, candidate{es, append(c.chain[:len(c.chain):len(c.chain)], j)})
+				}
+			}
+		}
+		switch {
+		case n == 1:
+			return found
+		case n > 1:
+			return nil
+		}
+		level = next
+	}
+	return nil
+}
+
+// sizeAlign returns the size and the alignment of t in the layout.
+func (l alignLayout) sizeAlign(t *rtype) (size, align uintptr) {
+	if l.ptrSize == ptrSize {
+		return t.size, uintptr(t.align)
+	}
+	switch t.Kind() {
+	case Bool, Int8, Uint8:
+		return 1, 1
+	case Int16, Uint16:
+		return 2, 2
+	case Int32, Uint32, Float32:
+		return 4, 4
+	case Int64, Uint64, Float64, Complex64:
+		return 8, l.wordAlign(8)
+	case Complex128:
+		return 16, l.wordAlign(8)
+	case Int, Uint, Uintptr, Ptr, UnsafePointer, Chan, Map, Func:
+		return l.ptrSize, l.ptrSize
+	case String, Interface:
+		return 2 * l.ptrSize, l.ptrSize
+	case Slice:
+		return 3 * l.ptrSize, l.ptrSize
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		size, align := l.sizeAlign(at.Elem)
+		return size * at.Len, align
+	case Struct:
+		if isAlign64(t) {
+			return 0, 8
+		}
+		st := (*StructType)(unsafe.Pointer(t))
+		offs := l.fieldOffsets(st)
+		size, align = 0, 1
+		for i := range st.Fields {
+			fs, fa := l.sizeAlign(st.Fields[i].typ)
+			size = offs[i] + fs
+			if fa > align {
+				align = fa
+			}
+		}
+		// A final zero-size field is padded, so that its address does not
+		// point past the struct.
+		if n := len(st.Fields); n > 0 && size > 0 {
+			if fs, _ := l.sizeAlign(st.Fields[n-1].typ); fs == 0 {
+				size++
+			}
+		}
+		return alignUp(size, align), align
+	}
+	return t.size, uintptr(t.align)
+}
+
+// wordAlign returns the alignment of a value of size bytes of at most a
+// word in the layout.
+func (l alignLayout) wordAlign(size uintptr) uintptr {
+	if size > l.ptrSize {
+		return l.ptrSize
+	}
+	return size
+}
+
+// fieldOffsets returns the offsets of the fields of st in the layout.
+func (l alignLayout) fieldOffsets(st *StructType) []uintptr {
+	offs := make([]uintptr, len(st.Fields))
+	var off uintptr
+	for i := range st.Fields {
+		if l.ptrSize == ptrSize {
+			offs[i] = st.Fields[i].Offset()
+			continue
+		}
+		size, align := l.sizeAlign(st.Fields[i].typ)
+		off = alignUp(off, align)
+		offs[i] = off
+		off += size
+	}
+	return offs
+}
+
+// isAlign64 reports whether t is the align64 type of sync/atomic, or of the
+// runtime, which the compiler aligns to 8 bytes on all platforms.
+func isAlign64(t *rtype) bool {
+	if t.Name() != "align64" {
+		return false
+	}
+	switch t.PkgPath() {
+	case "sync/atomic", "internal/runtime/atomic", "runtime/internal/atomic":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type alignA struct {
+	A bool
+	B int64
+	C int32
+	D uint64
+}
+
+type alignB struct {
+	S string
+	I interface{}
+	P *int
+	X []int
+	Y int64
+	M map[int]int
+	F float64
+	Z complex128
+	W [3]int16
+	V int64
+	E struct{}
+}
+
+type alignShard struct {
+	Count int64
+	Flag  int32
+}
+
+type alignArr struct {
+	Pad    int64
+	Shards [2]alignShard
+}
+
+type alignEmb struct {
+	X int32
+	alignA
+}
+
+type alignAmbiguous struct {
+	alignA
+	alignEmb2
+}
+
+type alignEmb2 struct{ B int64 }
+
+// alignLayouts32 holds the field offsets and sizes of the fixtures on 32-bit
+// platforms.
+var alignLayouts32 = []struct {
+	v    interface{}
+	offs []uintptr
+	size uintptr
+}{
+	{alignA{}, []uintptr{0, 4, 12, 16}, 24},
+	{alignB{}, []uintptr{0, 8, 16, 20, 32, 40, 44, 52, 68, 76, 84}, 88},
+	{alignShard{}, []uintptr{0, 8}, 12},
+	{alignArr{}, []uintptr{0, 8}, 32},
+	{alignEmb{}, []uintptr{0, 4}, 28},
+}
+
+func TestAlignLayout32(t *testing.T) {
+	l := alignLayout{4}
+	for _, tt := range alignLayouts32 {
+		st := (*StructType)(unsafe.Pointer(efaceOf(&tt.v).Type))
+		offs := l.fieldOffsets(st)
+		size, _ := l.sizeAlign(&st.rtype)
+		if ptrSize == 4 {
+			// The layout is the platform's own: compare the offsets of
+			// the compiler too.
+			rt := reflect.TypeOf(tt.v)
+			offs, size = make([]uintptr, rt.NumField()), rt.Size()
+			for i := range offs {
+				offs[i] = rt.Field(i).Offset
+			}
+		}
+		if !reflect.DeepEqual(offs, tt.offs) || size != tt.size {
+			t.Errorf("%T: 32-bit offsets %v, size %d, want %v, %d", tt.v, offs, size, tt.offs, tt.size)
+		}
+	}
+}
+
+func TestAuditAtomicAlignment(t *testing.T) {
+	tests := []struct {
+		v      interface{}
+		fields []string
+		want   []AlignmentIssue
+	}{
+		{alignA{}, []string{"B", "D"}, []AlignmentIssue{{Path: ".B", Offset: 4, Align: 8, PtrSize: 4}}},
+		{alignB{}, []string{"Y", "V", "Z"}, []AlignmentIssue{{Path: ".V", Offset: 76, Align: 8, PtrSize: 4}, {Path: ".Z", Offset: 52, Align: 8, PtrSize: 4}}},
+		{alignEmb{}, []string{"B", "D"}, []AlignmentIssue{{Path: ".alignA.D", Offset: 20, Align: 8, PtrSize: 4}}},
+		{alignArr{}, []string{"Pad", "Shards.Count"}, []AlignmentIssue{{Path: ".Shards[1].Count", Offset: 20, Align: 8, PtrSize: 4}}},
+		{alignShard{}, []string{"Count"}, nil},
+	}
+	for _, tt := range tests {
+		st := (*StructType)(unsafe.Pointer(efaceOf(&tt.v).Type))
+		if got := AuditAtomicAlignment(st, tt.fields); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AuditAtomicAlignment(%T, %q) = %+v, want %+v", tt.v, tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestAuditAtomicAlignmentErrors(t *testing.T) {
+	tests := []struct {
+		v     interface{}
+		field string
+		check func(error) bool
+	}{
+		{alignA{}, "Nope", func(err error) bool { return errors.Is(err, ErrFieldNotFound) }},
+		{alignAmbiguous{}, "B", func(err error) bool { return errors.Is(err, ErrFieldNotFound) }},
+		{alignA{}, "C.X", func(err error) bool {
+			var ke *KindError
+			return errors.As(err, &ke) && ke.Kind == Int32
+		}},
+	}
+	for _, tt := range tests {
+		st := (*StructType)(unsafe.Pointer(efaceOf(&tt.v).Type))
+		issues := AuditAtomicAlignment(st, []string{tt.field})
+		if len(issues) != 1 || issues[0].Path != "."+tt.field || !tt.check(issues[0].Err) {
+			t.Errorf("AuditAtomicAlignment(%T, %s) = %+v", tt.v, tt.field, issues)
+		}
+	}
+}
+
+func TestAssertAligned(t *testing.T) {
+	AssertAligned((*StructType)(unsafe.Pointer(TypeFor[alignShard]())), []string{"Count"})
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrMisaligned) || !strings.Contains(err.Error(), "field .B at offset 4 is not 8-byte aligned with 4-byte pointers") {
+			t.Errorf("AssertAligned panicked with %v", err)
+		}
+	}()
+	AssertAligned((*StructType)(unsafe.Pointer(TypeFor[alignA]())), []string{"B"})
+}
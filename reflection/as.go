@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// AsStructType returns t as a *StructType, or a *KindError if t is not a
+// struct type.
+func AsStructType(t *rtype) (*StructType, error) {
+	if k := kindOf(t); k != Struct {
+		return nil, &KindError{Op: "AsStructType", Kind: k}
+	}
+	return (*StructType)(unsafe.Pointer(t)), nil
+}
+
+// AsArrayType returns t as an *ArrayType, or a *KindError if t is not an
+// array type.
+func AsArrayType(t *rtype) (*ArrayType, error) {
+	if k := kindOf(t); k != Array {
+		return nil, &KindError{Op: "AsArrayType", Kind: k}
+	}
+	return (*ArrayType)(unsafe.Pointer(t)), nil
+}
+
+// AsChanType returns t as a *ChanType, or a *KindError if t is not a
+// channel type.
+func AsChanType(t *rtype) (*ChanType, error) {
+	if k := kindOf(t); k != Chan {
+		return nil, &KindError{Op: "AsChanType", Kind: k}
+	}
+	return (*ChanType)(unsafe.Pointer(t)), nil
+}
+
+// AsFuncType returns t as a *FuncType, or a *KindError if t is not a
+// function type.
+func AsFuncType(t *rtype) (*FuncType, error) {
+	if k := kindOf(t); k != Func {
+		return nil, &KindError{Op: "AsFuncType", Kind: k}
+	}
+	return (*FuncType)(unsafe.Pointer(t)), nil
+}
+
+// AsInterfaceType returns t as an *InterfaceType, or a *KindError if t is
+// not an interface type.
+func AsInterfaceType(t *rtype) (*InterfaceType, error) {
+	if k := kindOf(t); k != Interface {
+		return nil, &KindError{Op: "AsInterfaceType", Kind: k}
+	}
+	return (*InterfaceType)(unsafe.Pointer(t)), nil
+}
+
+// AsMapType returns t as a *MapType, or a *KindError if t is not a map type.
+func AsMapType(t *rtype) (*MapType, error) {
+	if k := kindOf(t); k != Map {
+		return nil, &KindError{Op: "AsMapType", Kind: k}
+	}
+	return (*MapType)(unsafe.Pointer(t)), nil
+}
+
+// AsPtrType returns t as a *PtrType, or a *KindError if t is not a pointer
+// type.
+func AsPtrType(t *rtype) (*PtrType, error) {
+	if k := kindOf(t); k != Ptr {
+		return nil, &KindError{Op: "AsPtrType", Kind: k}
+	}
+	return (*PtrType)(unsafe.Pointer(t)), nil
+}
+
+// AsSliceType returns t as a *SliceType, or a *KindError if t is not a
+// slice type.
+func AsSliceType(t *rtype) (*SliceType, error) {
+	if k := kindOf(t); k != Slice {
+		return nil, &KindError{Op: "AsSliceType", Kind: k}
+	}
+	return (*SliceType)(unsafe.Pointer(t)), nil
+}
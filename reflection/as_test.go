@@ -0,0 +1,60 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"unsafe"
+)
+
+func TestAsKind(t *testing.T) {
+	types := map[Kind]*rtype{
+		Struct:    TypeFor[struct{ A int }](),
+		Array:     TypeFor[[2]int](),
+		Chan:      TypeFor[chan int](),
+		Func:      TypeFor[func()](),
+		Interface: TypeFor[io.Reader](),
+		Map:       TypeFor[map[string]int](),
+		Ptr:       TypeFor[*int](),
+		Slice:     TypeFor[[]int](),
+		Int:       TypeFor[int](),
+	}
+	as := []struct {
+		kind Kind
+		op   string
+		fn   func(*rtype) (unsafe.Pointer, error)
+	}{
+		{Struct, "AsStructType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsStructType(t); return unsafe.Pointer(p), err }},
+		{Array, "AsArrayType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsArrayType(t); return unsafe.Pointer(p), err }},
+		{Chan, "AsChanType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsChanType(t); return unsafe.Pointer(p), err }},
+		{Func, "AsFuncType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsFuncType(t); return unsafe.Pointer(p), err }},
+		{Interface, "AsInterfaceType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsInterfaceType(t); return unsafe.Pointer(p), err }},
+		{Map, "AsMapType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsMapType(t); return unsafe.Pointer(p), err }},
+		{Ptr, "AsPtrType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsPtrType(t); return unsafe.Pointer(p), err }},
+		{Slice, "AsSliceType", func(t *rtype) (unsafe.Pointer, error) { p, err := AsSliceType(t); return unsafe.Pointer(p), err }},
+	}
+	for _, a := range as {
+		for k, typ := range types {
+			p, err := a.fn(typ)
+			if k == a.kind {
+				if err != nil || p != unsafe.Pointer(typ) {
+					t.Errorf("%s(%s) = %p, %v, want %p", a.op, typ, p, err, typ)
+				}
+				continue
+			}
+			var ke *KindError
+			if !errors.As(err, &ke) || ke.Op != a.op || ke.Kind != k || p != nil {
+				t.Errorf("%s(%s) = %p, %v, want a *KindError for %v", a.op, typ, p, err, k)
+			}
+		}
+		// A nil type has the Invalid kind.
+		var ke *KindError
+		if _, err := a.fn(nil); !errors.As(err, &ke) || ke.Kind != Invalid {
+			t.Errorf("%s(nil) = %v, want a *KindError for invalid", a.op, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// Identical reports whether a and b are the same type. Besides the same type
+// descriptor, this holds for the duplicate descriptors of a type in a plugin
+// and in the host binary, recognized by their Fingerprint, and confirmed by
+// comparing them as StructurallyIdentical does, names and tags included, as
+// distinct types may share a fingerprint.
+func Identical(a, b *rtype) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Kind() != b.Kind() || a.size != b.size {
+		return false
+	}
+	if cachedFingerprint(a) != cachedFingerprint(b) {
+		return false
+	}
+	c := identity{cmpTags: true}
+	return c.typ(a, b)
+}
+
+// Implements reports whether the type v implements the interface type t.
+// It returns false if t is not an interface type.
+func Implements(t, v *rtype) bool {
+	if t.Kind() != Interface {
+		return false
+	}
+	it := (*InterfaceType)(unsafe.Pointer(t))
+	if len(it.Methods) == 0 {
+		return true
+	}
+
+	// Method tables are sorted by name, with no duplicates, so a linear scan
+	// over both lists must match each method of t in turn; see reflect.implements.
+	if v.Kind() == Interface {
+		vt := (*InterfaceType)(unsafe.Pointer(v))
+		i := 0
+		for j := range vt.Methods {
+			tm, vm := &it.Methods[i], &vt.Methods[j]
+			tmName, vmName := t.NameOff(tm.Name), v.NameOff(vm.Name)
+			if vmName.Name() == tmName.Name() && v.TypeOff(vm.Typ) == t.TypeOff(tm.Typ) {
+				if !tmName.IsExported() && methodPkgPath(tmName, it.PkgPath.Name()) != methodPkgPath(vmName, vt.PkgPath.Name()) {
+					continue
+				}
+				if i++; i >= len(it.Methods) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	u := v.Uncommon()
+	if u == nil {
+		return false
+	}
+	i := 0
+	for _, vm := range u.Methods() {
+		tm := &it.Methods[i]
+		tmName, vmName := t.NameOff(tm.Name), v.NameOff(vm.Name)
+		if vmName.Name() == tmName.Name() && v.TypeOff(vm.Mtyp) == t.TypeOff(tm.Typ) {
+			if !tmName.IsExported() && methodPkgPath(tmName, it.PkgPath.Name()) != methodPkgPath(vmName, v.NameOff(u.PkgPath).Name()) {
+				continue
+			}
+			if i++; i >= len(it.Methods) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// methodPkgPath returns the package path of the unexported method name n,
+// which is that of its type, def, unless recorded in n.
+func methodPkgPath(n Name, def string) string {
+	if p := n.PkgPath(); p != "" {
+		return p
+	}
+	return def
+}
+
+// AssignableTo reports whether a value of the type from is assignable to the
+// type to, following the language rules as reflect.Type.AssignableTo does.
+func AssignableTo(from, to *rtype) bool {
+	if from == to {
+		return true
+	}
+	if to.Kind() == Interface {
+		return Implements(to, from)
+	}
+	return directlyAssignable(to, from)
+}
+
+// ConvertibleTo reports whether a value of the type from is convertible to the
+// type to, following the language rules as reflect.Type.ConvertibleTo does.
+// Conversions of slices to arrays and array pointers are reported convertible,
+// though they panic when the slice is too short.
+func ConvertibleTo(from, to *rtype) bool {
+	switch from.Kind() {
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		switch to.Kind() {
+		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr,
+			Float32, Float64, String:
+			return true
+		}
+
+	case Float32, Float64:
+		switch to.Kind() {
+		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr,
+			Float32, Float64:
+			return true
+		}
+
+	case Complex64, Complex128:
+		switch to.Kind() {
+		case Complex64, Complex128:
+			return true
+		}
+
+	case String:
+		if to.Kind() == Slice {
+			switch elem(to).Kind() {
+			case Uint8, Int32:
+				return true
+			}
+		}
+
+	case Slice:
+		if to.Kind() == String {
+			switch elem(from).Kind() {
+			case Uint8, Int32:
+				return true
+			}
+		}
+		// A slice converts to an array, or a pointer to an array, of
+		// identical element type.
+		if to.Kind() == Ptr && elem(to).Kind() == Array && elem(from) == elem(elem(to)) {
+			return true
+		}
+		if to.Kind() == Array && elem(from) == elem(to) {
+			return true
+		}
+
+	case Chan:
+		if to.Kind() == Chan && specialChannelAssignability(to, from) {
+			return true
+		}
+	}
+
+	if haveIdenticalUnderlyingType(to, from, false) {
+		return true
+	}
+
+	// Non-defined pointer types with the same underlying base type.
+	if to.Kind() == Ptr && !to.HasName() && from.Kind() == Ptr && !from.HasName() &&
+		haveIdenticalUnderlyingType(elem(to), elem(from), false) {
+		return true
+	}
+
+	return Implements(to, from)
+}
+
+// elem returns the element type of t, which must have one.
+func elem(t *rtype) *rtype {
+	e, _ := t.Elem()
+	return e
+}
+
+// chanDir returns the direction of the channel type t.
+func chanDir(t *rtype) ChanDir {
+	return ChanDir((*ChanType)(unsafe.Pointer(t)).Dir)
+}
+
+// directlyAssignable reports whether a value of type v is assignable to the
+// non-interface type t.
+func directlyAssignable(t, v *rtype) bool {
+	if t == v {
+		return true
+	}
+	// At least one of t and v must not be defined and they must have the
+	// same kind.
+	if t.HasName() && v.HasName() || t.Kind() != v.Kind() {
+		return false
+	}
+	if t.Kind() == Chan && specialChannelAssignability(t, v) {
+		return true
+	}
+	return haveIdenticalUnderlyingType(t, v, true)
+}
+
+// specialChannelAssignability reports whether a value of the bidirectional
+// channel type v is assignable to the channel type t of identical element type,
+// when at least one of them is not a defined type.
+func specialChannelAssignability(t, v *rtype) bool {
+	return chanDir(v) == BothDir && (!t.HasName() || !v.HasName()) && haveIdenticalType(elem(t), elem(v), true)
+}
+
+// StructurallyIdentical reports whether a and b have identical underlying
+// types, ignoring their own names: the language's type identity applied to
+// the type literals defining them. Struct field tags are compared only if
+// compareTags is set.
+//
+// Unlike reflect, which relies on each type having a single descriptor,
+// nested types are compared structurally too, so that distinct descriptors
+// of the same type, as found in a plugin and its host, are identical.
+// Defined types nested in a and b must still have the same names.
+func StructurallyIdentical(a, b *rtype, compareTags bool) bool {
+	c := identity{cmpTags: compareTags}
+	return c.underlying(a, b)
+}
+
+// identity compares types as reflect.haveIdenticalType does.
+//
+// Setting exact makes nested types identical only if they have the same
+// descriptor, and setting defined does so for nested defined types only, as
+// reflect does. Otherwise recursive types are compared structurally; pairs of
+// defined types are assumed identical while their underlying types are being
+// compared, as the type checker does, which ends the recursion.
+type identity struct {
+	cmpTags bool
+	exact   bool
+	defined bool
+	assumed map[[2]*rtype]bool
+}
+
+// haveIdenticalType and haveIdenticalUnderlyingType implement the rules of
+// assignability and convertibility, under which the duplicate descriptors of
+// a defined type in a plugin and its host are distinct types, as in reflect.
+
+func haveIdenticalType(t, v *rtype, cmpTags bool) bool {
+	c := identity{cmpTags: cmpTags, exact: cmpTags, defined: true}
+	return c.typ(t, v)
+}
+
+func haveIdenticalUnderlyingType(t, v *rtype, cmpTags bool) bool {
+	c := identity{cmpTags: cmpTags, exact: cmpTags, defined: true}
+	return c.underlying(t, v)
+}
+
+func (c *identity) typ(t, v *rtype) bool {
+	if c.exact || t == v {
+		return t == v
+	}
+	if c.defined && (t.HasName() || v.HasName()) {
+		return false
+	}
+	if t.Name() != v.Name() || t.Kind() != v.Kind() || t.PkgPath() != v.PkgPath() {
+		return false
+	}
+	if t.HasName() {
+		pair := [2]*rtype{t, v}
+		if c.assumed[pair] {
+			return true
+		}
+		if c.assumed == nil {
+			c.assumed = make(map[[2]*rtype]bool)
+		}
+		c.assumed[pair] = true
+	}
+	return c.underlying(t, v)
+}
+
+func (c *identity) underlying(t, v *rtype) bool {
+	if t == v {
+		return true
+	}
+	kind := t.Kind()
+	if kind != v.Kind() {
+		return false
+	}
+
+	// Non-composite types of equal kind have the same underlying type.
+	if Bool <= kind && kind <= Complex128 || kind == String || kind == UnsafePointer {
+		return true
+	}
+
+	switch kind {
+	case Array:
+		return (*ArrayType)(unsafe.Pointer(t)).Len == (*ArrayType)(unsafe.Pointer(v)).Len &&
+			c.typ(elem(t), elem(v))
+
+	case Chan:
+		return chanDir(t) == chanDir(v) && c.typ(elem(t), elem(v))
+
+	case Func:
+		ft, fv := (*FuncType)(unsafe.Pointer(t)), (*FuncType)(unsafe.Pointer(v))
+		if ft.OutCount != fv.OutCount || ft.InCount != fv.InCount {
+			return false
+		}
+		for i := 0; i < ft.NumIn(); i++ {
+			if !c.typ(ft.In(i), fv.In(i)) {
+				return false
+			}
+		}
+		for i := 0; i < ft.NumOut(); i++ {
+			if !c.typ(ft.Out(i), fv.Out(i)) {
+				return false
+			}
+		}
+		return true
+
+	case Interface:
+		it, iv := (*InterfaceType)(unsafe.Pointer(t)), (*InterfaceType)(unsafe.Pointer(v))
+		if c.exact {
+			// Interfaces with the same methods may still need a run time
+			// conversion, only empty interfaces are identical.
+			return len(it.Methods) == 0 && len(iv.Methods) == 0
+		}
+		// Distinct descriptors of one interface type have the same
+		// methods, sorted by name.
+		if len(it.Methods) != len(iv.Methods) {
+			return false
+		}
+		for i := range it.Methods {
+			tm, vm := &it.Methods[i], &iv.Methods[i]
+			tn, vn := t.NameOff(tm.Name), v.NameOff(vm.Name)
+			if tn.Name() != vn.Name() ||
+				!tn.IsExported() && methodPkgPath(tn, it.PkgPath.Name()) != methodPkgPath(vn, iv.PkgPath.Name()) ||
+				!c.typ(t.TypeOff(tm.Typ), v.TypeOff(vm.Typ)) {
+				return false
+			}
+		}
+		return true
+
+	case Map:
+		mt, mv := (*MapType)(unsafe.Pointer(t)), (*MapType)(unsafe.Pointer(v))
+		return c.typ(mt.Key, mv.Key) && c.typ(mt.Elem, mv.Elem)
+
+	case Ptr, Slice:
+		return c.typ(elem(t), elem(v))
+
+	case Struct:
+		st, sv := (*StructType)(unsafe.Pointer(t)), (*StructType)(unsafe.Pointer(v))
+		if len(st.Fields) != len(sv.Fields) || st.PkgPath.Name() != sv.PkgPath.Name() {
+			return false
+		}
+		for i := range st.Fields {
+			tf, vf := &st.Fields[i], &sv.Fields[i]
+			if tf.Name.Name() != vf.Name.Name() ||
+				!c.typ(tf.typ, vf.typ) ||
+				c.cmpTags && tf.Name.Tag() != vf.Name.Tag() ||
+				tf.Offset() != vf.Offset() ||
+				tf.Embedded() != vf.Embedded() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
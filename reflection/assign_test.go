@@ -0,0 +1,267 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type (
+	assignInt    int
+	assignInt2   int
+	assignBytes  []byte
+	assignString string
+	assignPtr    *int
+	assignChan   chan int
+	assignReader interface{ Read([]byte) (int, error) }
+	assignStruct struct {
+		A int `json:"a"`
+	}
+	assignFunc func(int) error
+)
+
+func (assignInt) Read([]byte) (int, error) { return 0, nil }
+
+// assignTypes are the types of the matrix AssignableTo and ConvertibleTo are
+// checked against reflect over.
+var assignTypes = []reflect.Type{
+	reflect.TypeOf(0),
+	reflect.TypeOf(int8(0)),
+	reflect.TypeOf(uint(0)),
+	reflect.TypeOf(0.0),
+	reflect.TypeOf(complex64(0)),
+	reflect.TypeOf(complex128(0)),
+	reflect.TypeOf(""),
+	reflect.TypeOf(true),
+	reflect.TypeOf(assignInt(0)),
+	reflect.TypeOf(assignInt2(0)),
+	reflect.TypeOf(assignString("")),
+	reflect.TypeOf([]byte(nil)),
+	reflect.TypeOf([]rune(nil)),
+	reflect.TypeOf(assignBytes(nil)),
+	reflect.TypeOf([4]byte{}),
+	reflect.TypeOf((*[4]byte)(nil)),
+	reflect.TypeOf((*int)(nil)),
+	reflect.TypeOf(assignPtr(nil)),
+	reflect.TypeOf((*assignInt)(nil)),
+	reflect.TypeOf(make(chan int)),
+	reflect.TypeOf(make(<-chan int)),
+	reflect.TypeOf(make(chan<- int)),
+	reflect.TypeOf(assignChan(nil)),
+	reflect.TypeOf(map[string]int(nil)),
+	reflect.TypeOf((func(int) error)(nil)),
+	reflect.TypeOf(assignFunc(nil)),
+	reflect.TypeOf(struct{ A int }{}),
+	reflect.TypeOf(struct {
+		A int `json:"a"`
+	}{}),
+	reflect.TypeOf(assignStruct{}),
+	reflect.TypeOf((*interface{})(nil)).Elem(),
+	reflect.TypeOf((*io.Reader)(nil)).Elem(),
+	reflect.TypeOf((*assignReader)(nil)).Elem(),
+	reflect.TypeOf((*io.ReadWriter)(nil)).Elem(),
+	reflect.TypeOf((*error)(nil)).Elem(),
+}
+
+func TestAssignableConvertible(t *testing.T) {
+	for _, from := range assignTypes {
+		for _, to := range assignTypes {
+			f, tt := FromReflect(from), FromReflect(to)
+			if got, want := AssignableTo(f, tt), from.AssignableTo(to); got != want {
+				t.Errorf("AssignableTo(%v, %v) = %v, want %v", from, to, got, want)
+			}
+			if got, want := ConvertibleTo(f, tt), from.ConvertibleTo(to); got != want {
+				t.Errorf("ConvertibleTo(%v, %v) = %v, want %v", from, to, got, want)
+			}
+			if got, want := Identical(f, tt), from == to; got != want {
+				t.Errorf("Identical(%v, %v) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestImplements(t *testing.T) {
+	reader := FromReflect(reflect.TypeOf((*io.Reader)(nil)).Elem())
+	for _, v := range assignTypes {
+		if got, want := Implements(reader, FromReflect(v)), v.Implements(ToReflect(reader)); got != want {
+			t.Errorf("Implements(io.Reader, %v) = %v, want %v", v, got, want)
+		}
+	}
+	if Implements(TypeFor[int](), TypeFor[int]()) {
+		t.Error("Implements with a non-interface type reported true")
+	}
+}
+
+// The fixtures below are declared in two functions, with the same names and
+// structures, as the duplicate descriptors of one type in a plugin and its
+// host would be.
+
+func identTypesA() []*rtype {
+	type list struct {
+		Next *list
+		R    io.Reader
+		V    int `json:"v"`
+	}
+	type iface interface {
+		M(*list) iface
+	}
+	type tagged struct {
+		A int `json:"a"`
+	}
+	return []*rtype{TypeFor[list](), TypeFor[iface](), TypeFor[tagged](), TypeFor[[]list](), TypeFor[map[string]iface]()}
+}
+
+func identTypesB() []*rtype {
+	type list struct {
+		Next *list
+		R    io.Reader
+		V    int `json:"v"`
+	}
+	type iface interface {
+		M(*list) iface
+	}
+	type tagged struct {
+		A int `json:"b"`
+	}
+	return []*rtype{TypeFor[list](), TypeFor[iface](), TypeFor[tagged](), TypeFor[[]list](), TypeFor[map[string]iface]()}
+}
+
+func TestIdenticalDuplicates(t *testing.T) {
+	a, b := identTypesA(), identTypesB()
+	want := []bool{true, true, false, true, true}
+	for i := range a {
+		if a[i] == b[i] {
+			t.Fatalf("%s: the fixtures share a descriptor", a[i])
+		}
+		if got := Identical(a[i], b[i]); got != want[i] {
+			t.Errorf("Identical(%s, %s) = %v, want %v", a[i], b[i], got, want[i])
+		}
+		if got := Identical(b[i], a[i]); got != want[i] {
+			t.Errorf("Identical(%s, %s) = %v, want %v", b[i], a[i], got, want[i])
+		}
+	}
+	if !StructurallyIdentical(a[2], b[2], false) || StructurallyIdentical(a[2], b[2], true) {
+		t.Error("StructurallyIdentical does not honor compareTags")
+	}
+}
+
+// The recursive fixtures below are duplicated the same way. Local types can
+// only refer to those declared before them, so the way back from *recB to
+// recA goes through a struct literal identical to recB.
+
+func recTypesA() []*rtype {
+	type recA struct {
+		B *struct {
+			A *recA `json:"a"`
+		} `json:"b"`
+	}
+	type recB struct {
+		A *recA `json:"a"`
+	}
+	return []*rtype{TypeFor[recA](), TypeFor[recB](), TypeFor[[]*recB]()}
+}
+
+func recTypesB() []*rtype {
+	type recA struct {
+		B *struct {
+			A *recA `json:"a"`
+		} `json:"b"`
+	}
+	type recB struct {
+		A *recA `json:"a"`
+	}
+	return []*rtype{TypeFor[recA](), TypeFor[recB](), TypeFor[[]*recB]()}
+}
+
+// recTypesTagged differs from recTypesA only in the tag of the field of recA
+// reached through the struct literal.
+func recTypesTagged() []*rtype {
+	type recA struct {
+		B *struct {
+			A *recA `json:"other"`
+		} `json:"b"`
+	}
+	type recB struct {
+		A *recA `json:"a"`
+	}
+	return []*rtype{TypeFor[recA](), TypeFor[recB](), TypeFor[[]*recB]()}
+}
+
+func TestStructurallyIdenticalRecursive(t *testing.T) {
+	a, b, tagged := recTypesA(), recTypesB(), recTypesTagged()
+	for i := range a {
+		if a[i] == b[i] || a[i] == tagged[i] {
+			t.Fatalf("%s: the fixtures share a descriptor", a[i])
+		}
+		for _, compareTags := range []bool{false, true} {
+			if !StructurallyIdentical(a[i], b[i], compareTags) || !StructurallyIdentical(b[i], a[i], compareTags) {
+				t.Errorf("StructurallyIdentical(%s, %s, %v) = false, want true", a[i], b[i], compareTags)
+			}
+			if got := StructurallyIdentical(a[i], tagged[i], compareTags); got == compareTags {
+				t.Errorf("StructurallyIdentical(%s, %s, %v) = %v, want %v", a[i], tagged[i], compareTags, got, !compareTags)
+			}
+			if got := StructurallyIdentical(tagged[i], a[i], compareTags); got == compareTags {
+				t.Errorf("StructurallyIdentical(%s, %s, %v) = %v, want %v", tagged[i], a[i], compareTags, got, !compareTags)
+			}
+		}
+	}
+	// recA and recB are distinct types, as the types of their fields are.
+	if StructurallyIdentical(a[0], b[1], false) {
+		t.Errorf("StructurallyIdentical(%s, %s, false) = true, want false", a[0], b[1])
+	}
+}
+
+// The duplicate descriptors of a defined type are distinct types for the
+// language rules AssignableTo and ConvertibleTo follow, as they are for
+// reflect, though Identical reports them identical.
+func TestAssignableConvertibleDuplicates(t *testing.T) {
+	a, b := identTypesA(), identTypesB()
+	// Only the tagged structs, which differ in their tags only, convert.
+	convertible := []bool{false, false, true, false, false}
+	for i := range a {
+		for _, p := range [][2]*rtype{{a[i], b[i]}, {b[i], a[i]}} {
+			if AssignableTo(p[0], p[1]) {
+				t.Errorf("AssignableTo(%s, %s) = true, want false", p[0], p[1])
+			}
+			if got := ConvertibleTo(p[0], p[1]); got != convertible[i] {
+				t.Errorf("ConvertibleTo(%s, %s) = %v, want %v", p[0], p[1], got, convertible[i])
+			}
+		}
+	}
+}
+
+func TestIdenticalFingerprintCollision(t *testing.T) {
+	a := TypeFor[struct{ A int }]()
+	b := TypeFor[struct{ B int }]()
+	fp := cachedFingerprint(a)
+	typeRegistry.Lock()
+	old, ok := typeRegistry.fingerprints[b]
+	typeRegistry.fingerprints[b] = fp
+	typeRegistry.Unlock()
+	defer func() {
+		typeRegistry.Lock()
+		if ok {
+			typeRegistry.fingerprints[b] = old
+		} else {
+			delete(typeRegistry.fingerprints, b)
+		}
+		typeRegistry.Unlock()
+	}()
+
+	if Identical(a, b) {
+		t.Error("types of colliding fingerprints are Identical")
+	}
+}
+
+func ExampleIdentical() {
+	fmt.Println(Identical(TypeFor[[]int](), TypeFor[[]int]()))
+	fmt.Println(Identical(TypeFor[assignInt](), TypeFor[int]()))
+	// Output:
+	// true
+	// false
+}
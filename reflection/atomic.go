@@ -0,0 +1,154 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// atomic64Field returns a pointer to the 64-bit integer field f of the struct
+// at base, checking its kind and alignment.
+func atomic64Field(op string, base unsafe.Pointer, f *StructField) (*uint64, error) {
+	if !isAtomic64(f.typ) {
+		return nil, &KindError{Op: op, Kind: f.typ.Kind()}
+	}
+	p := Add(base, f.Offset(), "field offset is within the struct")
+	if uintptr(p)%8 != 0 {
+		return nil, fmt.Errorf("%w: %s at %#x", ErrMisaligned, f.Name.Name(), uintptr(p))
+	}
+	return (*uint64)(p), nil
+}
+
+// atomicPointerField returns a pointer to the pointer field f of the struct
+// at base, checking its kind and alignment.
+func atomicPointerField(op string, base unsafe.Pointer, f *StructField) (*unsafe.Pointer, error) {
+	if !isAtomicPointer(f.typ) {
+		return nil, &KindError{Op: op, Kind: f.typ.Kind()}
+	}
+	p := Add(base, f.Offset(), "field offset is within the struct")
+	if uintptr(p)%unsafe.Sizeof(uintptr(0)) != 0 {
+		return nil, fmt.Errorf("%w: %s at %#x", ErrMisaligned, f.Name.Name(), uintptr(p))
+	}
+	return (*unsafe.Pointer)(p), nil
+}
+
+// isAtomic64 reports whether t is a 64-bit integer type.
+func isAtomic64(t *rtype) bool {
+	switch t.Kind() {
+	case Int, Int64, Uint, Uint64, Uintptr:
+		return t.size == 8
+	}
+	return false
+}
+
+// isAtomicPointer reports whether t is a pointer type.
+func isAtomicPointer(t *rtype) bool {
+	k := t.Kind()
+	return k == Ptr || k == UnsafePointer
+}
+
+// AtomicLoadUint64Field atomically loads the 64-bit integer field f of the
+// struct at base.
+func AtomicLoadUint64Field(base unsafe.Pointer, f *StructField) (uint64, error) {
+	p, err := atomic64Field("AtomicLoadUint64Field", base, f)
+	if err != nil {
+		return 0, err
+	}
+	return atomic.LoadUint64(p), nil
+}
+
+// AtomicStoreUint64Field atomically stores v into the 64-bit integer field f
+// of the struct at base.
+func AtomicStoreUint64Field(base unsafe.Pointer, f *StructField, v uint64) error {
+	p, err := atomic64Field("AtomicStoreUint64Field", base, f)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(p, v)
+	return nil
+}
+
+// AtomicAddUint64Field atomically adds delta to the 64-bit integer field f of
+// the struct at base and returns the new value.
+func AtomicAddUint64Field(base unsafe.Pointer, f *StructField, delta uint64) (uint64, error) {
+	p, err := atomic64Field("AtomicAddUint64Field", base, f)
+	if err != nil {
+		return 0, err
+	}
+	return atomic.AddUint64(p, delta), nil
+}
+
+// AtomicCompareAndSwapUint64Field executes the compare-and-swap operation on
+// the 64-bit integer field f of the struct at base.
+func AtomicCompareAndSwapUint64Field(base unsafe.Pointer, f *StructField, old, new uint64) (bool, error) {
+	p, err := atomic64Field("AtomicCompareAndSwapUint64Field", base, f)
+	if err != nil {
+		return false, err
+	}
+	return atomic.CompareAndSwapUint64(p, old, new), nil
+}
+
+// AtomicLoadPointerField atomically loads the pointer field f of the struct
+// at base.
+func AtomicLoadPointerField(base unsafe.Pointer, f *StructField) (unsafe.Pointer, error) {
+	p, err := atomicPointerField("AtomicLoadPointerField", base, f)
+	if err != nil {
+		return nil, err
+	}
+	return atomic.LoadPointer(p), nil
+}
+
+// AtomicStorePointerField atomically stores v into the pointer field f of the
+// struct at base.
+func AtomicStorePointerField(base unsafe.Pointer, f *StructField, v unsafe.Pointer) error {
+	p, err := atomicPointerField("AtomicStorePointerField", base, f)
+	if err != nil {
+		return err
+	}
+	atomic.StorePointer(p, v)
+	return nil
+}
+
+// AtomicCompareAndSwapPointerField executes the compare-and-swap operation on
+// the pointer field f of the struct at base.
+func AtomicCompareAndSwapPointerField(base unsafe.Pointer, f *StructField, old, new unsafe.Pointer) (bool, error) {
+	p, err := atomicPointerField("AtomicCompareAndSwapPointerField", base, f)
+	if err != nil {
+		return false, err
+	}
+	return atomic.CompareAndSwapPointer(p, old, new), nil
+}
+
+// CheckAtomicFields checks that the fields of st named by names, which may be
+// dotted paths as accepted by OffsetOf, are 64-bit integers or pointers at an
+// offset suitably aligned for atomic access. It is meant for init-time
+// assertions.
+//
+// The offset of a 64-bit field is checked to be a multiple of 8. On 32-bit
+// platforms the struct must then also be 8-byte aligned itself, which holds
+// for the first word of an allocated struct, array or slice.
+func CheckAtomicFields(st *StructType, names ...string) error {
+	for _, name := range names {
+		off, ft, _, err := lookupField(&st.rtype, "CheckAtomicFields", name)
+		if err != nil {
+			return err
+		}
+		var align uintptr
+		switch {
+		case isAtomic64(ft):
+			align = 8
+		case isAtomicPointer(ft):
+			align = unsafe.Sizeof(uintptr(0))
+		default:
+			return &KindError{Op: "CheckAtomicFields", Kind: ft.Kind()}
+		}
+		if off%align != 0 {
+			return fmt.Errorf("%w: %s at offset %d in %s", ErrMisaligned, name, off, st)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+type atomicT struct {
+	N uint64
+	P *int
+	I int32
+	U unsafe.Pointer
+}
+
+// atomicBool has a uint64 after a leading bool, at offset 4 on 32-bit
+// platforms.
+type atomicBool struct {
+	B bool
+	N uint64
+}
+
+type atomicOuter struct {
+	A     uint32
+	Inner atomicT
+}
+
+func TestAtomicUint64Field(t *testing.T) {
+	v := new(atomicT)
+	base, f := unsafe.Pointer(v), columnField[atomicT]("N")
+	if err := AtomicStoreUint64Field(base, f, 40); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				AtomicAddUint64Field(base, f, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, err := AtomicLoadUint64Field(base, f); n != 440 || err != nil {
+		t.Errorf("AtomicLoadUint64Field = %d, %v, want 440", n, err)
+	}
+	if ok, err := AtomicCompareAndSwapUint64Field(base, f, 1, 2); ok || err != nil {
+		t.Errorf("AtomicCompareAndSwapUint64Field(1, 2) = %v, %v, want false", ok, err)
+	}
+	if ok, err := AtomicCompareAndSwapUint64Field(base, f, 440, 2); !ok || err != nil || v.N != 2 {
+		t.Errorf("AtomicCompareAndSwapUint64Field(440, 2) = %v, %v, field %d", ok, err, v.N)
+	}
+}
+
+func TestAtomicPointerField(t *testing.T) {
+	v := new(atomicT)
+	x, y := 1, 2
+	base, f := unsafe.Pointer(v), columnField[atomicT]("P")
+	if err := AtomicStorePointerField(base, f, unsafe.Pointer(&x)); err != nil || v.P != &x {
+		t.Fatalf("AtomicStorePointerField = %v, field %p", err, v.P)
+	}
+	if p, err := AtomicLoadPointerField(base, f); p != unsafe.Pointer(&x) || err != nil {
+		t.Errorf("AtomicLoadPointerField = %p, %v", p, err)
+	}
+	if ok, err := AtomicCompareAndSwapPointerField(base, f, unsafe.Pointer(&x), unsafe.Pointer(&y)); !ok || err != nil || v.P != &y {
+		t.Errorf("AtomicCompareAndSwapPointerField = %v, %v, field %p", ok, err, v.P)
+	}
+	if _, err := AtomicLoadPointerField(base, columnField[atomicT]("U")); err != nil {
+		t.Errorf("AtomicLoadPointerField(unsafe.Pointer) = %v", err)
+	}
+}
+
+func TestAtomicFieldKind(t *testing.T) {
+	v := new(atomicT)
+	var ke *KindError
+	if _, err := AtomicLoadUint64Field(unsafe.Pointer(v), columnField[atomicT]("I")); !errors.As(err, &ke) || ke.Kind != Int32 {
+		t.Errorf("AtomicLoadUint64Field(int32) = %v, want *KindError for int32", err)
+	}
+	if _, err := AtomicLoadPointerField(unsafe.Pointer(v), columnField[atomicT]("N")); !errors.As(err, &ke) || ke.Kind != Uint64 {
+		t.Errorf("AtomicLoadPointerField(uint64) = %v, want *KindError for uint64", err)
+	}
+}
+
+// TestAtomicMisaligned places a struct in a buffer for the uint64 after its
+// leading bool to be misaligned: as is on 32-bit platforms, where the field
+// is at offset 4, and 4 bytes into the buffer otherwise.
+func TestAtomicMisaligned(t *testing.T) {
+	buf := new([8]uint64)
+	f := columnField[atomicBool]("N")
+	shift := (12 - f.Offset()%8) % 8
+	base := Add(unsafe.Pointer(buf), shift, "shift < len(buf)*8")
+	if (uintptr(base)+f.Offset())%8 == 0 {
+		t.Fatalf("field N at %#x is aligned", uintptr(base)+f.Offset())
+	}
+	if _, err := AtomicLoadUint64Field(base, f); !errors.Is(err, ErrMisaligned) {
+		t.Errorf("AtomicLoadUint64Field = %v, want ErrMisaligned", err)
+	}
+	if err := AtomicStoreUint64Field(base, f, 1); !errors.Is(err, ErrMisaligned) {
+		t.Errorf("AtomicStoreUint64Field = %v, want ErrMisaligned", err)
+	}
+	if *buf != ([8]uint64{}) {
+		t.Errorf("misaligned store wrote %v", *buf)
+	}
+
+	// Moved by 2 bytes, the pointer field is misaligned too.
+	base = Add(unsafe.Pointer(buf), 2, "2 < len(buf)*8")
+	if _, err := AtomicLoadPointerField(base, columnField[atomicT]("U")); !errors.Is(err, ErrMisaligned) {
+		t.Errorf("AtomicLoadPointerField = %v, want ErrMisaligned", err)
+	}
+}
+
+func TestCheckAtomicFields(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[atomicT]()))
+	if err := CheckAtomicFields(st, "P", "U"); err != nil {
+		t.Errorf("CheckAtomicFields(P, U) = %v", err)
+	}
+	var ke *KindError
+	if err := CheckAtomicFields(st, "I"); !errors.As(err, &ke) {
+		t.Errorf("CheckAtomicFields(I) = %v, want a *KindError", err)
+	}
+
+	// The uint64 after a leading bool, or after a leading uint32 for the
+	// nested one, is at offset 4 on 32-bit platforms, and misaligned there.
+	outer := (*StructType)(unsafe.Pointer(TypeFor[atomicOuter]()))
+	for _, tt := range []struct {
+		st   *StructType
+		name string
+		off  uintptr
+	}{
+		{st, "N", unsafe.Offsetof(atomicT{}.N)},
+		{(*StructType)(unsafe.Pointer(TypeFor[atomicBool]())), "N", unsafe.Offsetof(atomicBool{}.N)},
+		{outer, "Inner.N", unsafe.Offsetof(atomicOuter{}.Inner) + unsafe.Offsetof(atomicT{}.N)},
+	} {
+		err := CheckAtomicFields(tt.st, tt.name)
+		if misaligned := tt.off%8 != 0; misaligned != errors.Is(err, ErrMisaligned) || !misaligned && err != nil {
+			t.Errorf("CheckAtomicFields(%s) at offset %d = %v", tt.name, tt.off, err)
+		}
+	}
+	if unsafe.Sizeof(uintptr(0)) == 4 && unsafe.Offsetof(atomicBool{}.N) != 4 {
+		t.Errorf("atomicBool.N at offset %d, want 4 on 32-bit platforms", unsafe.Offsetof(atomicBool{}.N))
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// FromReflect returns the type descriptor behind the reflect.Type t,
+// or nil if t is nil.
+func FromReflect(t reflect.Type) *rtype {
+	if t == nil {
+		return nil
+	}
+	// The dynamic type of t is *reflect.rtype, a pointer-shaped type,
+	// so the data word is the type descriptor itself.
+	return (*rtype)((*InterfaceHeader)(unsafe.Pointer(&t)).Word)
+}
+
+// ToReflect returns the reflect.Type for the type descriptor t,
+// or nil if t is nil.
+func ToReflect(t *rtype) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	// reflect.TypeOf only looks at the type word of its argument.
+	var i interface{}
+	efaceOf(&i).Type = t
+	return reflect.TypeOf(i)
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+)
+
+// LineGroup is the group of the fields of a struct whose bytes lie on the
+// same cache line, as reported by CacheLineReport.
+type LineGroup struct {
+	// Line is the index of the cache line from the start of the struct,
+	// which starts at Line*lineSize.
+	Line int
+
+	// Fields are the indices into the fields of the struct of the fields
+	// with bytes on the line, in offset order. A field spanning several
+	// lines is in the group of each.
+	Fields []int
+}
+
+// CacheLineReport groups the fields of st by the cache lines of lineSize
+// bytes that their bytes lie on, assuming the struct starts at the start of
+// a line, to find the fields that may suffer false sharing: writes to a
+// field slowing down the accesses from other processors to the fields on
+// the same line. Fields of size zero lie on no line, and lines holding no
+// field are not reported. It panics if lineSize is not a power of two.
+func CacheLineReport(st *StructType, lineSize int) []LineGroup {
+	if lineSize <= 0 || lineSize&(lineSize-1) != 0 {
+		panic("reflection: CacheLineReport line size must be a power of two")
+	}
+	line := uintptr(lineSize)
+	var groups []LineGroup
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if f.typ.size == 0 {
+			continue
+		}
+		first, last := f.Offset()/line, (f.Offset()+f.typ.size-1)/line
+		for l := int(first); l <= int(last); l++ {
+			if n := len(groups); n == 0 || groups[n-1].Line != l {
+				groups = append(groups, LineGroup{Line: l})
+			}
+			g := &groups[len(groups)-1]
+			g.Fields = append(g.Fields, i)
+		}
+	}
+	return groups
+}
+
+// Padding is an amount of padding to insert before a field of a struct, as
+// suggested by SuggestPadding.
+type Padding struct {
+	// Field is the index into the fields of the struct of the field
+	// before which to insert the padding.
+	Field int
+
+	// Bytes is the number of bytes of padding, such as a field of type
+	// [Bytes]byte, to insert after the field preceding Field.
+	Bytes uintptr
+}
+
+// SuggestPadding returns the padding to insert before fields of st, in field
+// order, so that none of its fields named by hotFields shares a cache line
+// of lineSize bytes with another, assuming the struct starts at the start
+// of a line: each hot field sharing the line of the previous one is moved
+// to the start of the next line. The fields are laid out again with the
+// padding inserted, as the compiler would, so each suggestion accounts for
+// the ones before it. A field larger than a line still spans several.
+//
+// SuggestPadding returns an error wrapping ErrFieldNotFound if a hot field
+// is not a field of st, and panics if lineSize is not a power of two.
+func SuggestPadding(st *StructType, hotFields []string, lineSize int) ([]Padding, error) {
+	if lineSize <= 0 || lineSize&(lineSize-1) != 0 {
+		panic("reflection: SuggestPadding line size must be a power of two")
+	}
+	hot := make([]bool, len(st.Fields))
+	for _, name := range hotFields {
+		i := fieldIndex(st, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q in %s", ErrFieldNotFound, name, &st.rtype)
+		}
+		hot[i] = true
+	}
+
+	line := uintptr(lineSize)
+	var pads []Padding
+	var end uintptr // end of the previous field, with the padding
+	lastLine := -1  // line of the last byte of the previous hot field
+	for i := range st.Fields {
+		t := st.Fields[i].typ
+		off := alignUp(end, uintptr(t.align))
+		if hot[i] && t.size > 0 {
+			if int(off/line) <= lastLine {
+				pad := alignUp(end, line) - end
+				pads = append(pads, Padding{Field: i, Bytes: pad})
+				off = alignUp(end+pad, uintptr(t.align))
+			}
+			lastLine = int((off + t.size - 1) / line)
+		}
+		end = off + t.size
+	}
+	return pads, nil
+}
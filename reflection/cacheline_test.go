@@ -0,0 +1,130 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+// lineT has the same layout on all platforms.
+type lineT struct {
+	A int32
+	B [60]byte
+	C int32
+	D [100]byte
+	E int32
+	Z struct{}
+	F [8]byte
+}
+
+func lineStruct() *StructType {
+	return (*StructType)(unsafe.Pointer(TypeFor[lineT]()))
+}
+
+func TestCacheLineReport(t *testing.T) {
+	tests := []struct {
+		lineSize int
+		want     []LineGroup
+	}{
+		{64, []LineGroup{{0, []int{0, 1}}, {1, []int{2, 3}}, {2, []int{3, 4, 6}}}},
+		{128, []LineGroup{{0, []int{0, 1, 2, 3}}, {1, []int{3, 4, 6}}}},
+	}
+	for _, tt := range tests {
+		got := CacheLineReport(lineStruct(), tt.lineSize)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CacheLineReport(%d) = %v, want %v", tt.lineSize, got, tt.want)
+		}
+	}
+	if got := CacheLineReport((*StructType)(unsafe.Pointer(TypeFor[struct{}]())), 64); got != nil {
+		t.Errorf("CacheLineReport of an empty struct = %v", got)
+	}
+}
+
+func TestSuggestPadding(t *testing.T) {
+	tests := []struct {
+		hot      []string
+		lineSize int
+		want     []Padding
+	}{
+		{[]string{"A", "C", "E"}, 64, nil},
+		{[]string{"A", "E", "F"}, 64, []Padding{{6, 20}}},
+		{[]string{"A", "C"}, 128, []Padding{{2, 64}}},
+		{[]string{"A", "C", "E"}, 128, []Padding{{2, 64}, {4, 24}}},
+		{[]string{"Z", "A"}, 64, nil},
+		{nil, 64, nil},
+	}
+	for _, tt := range tests {
+		got, err := SuggestPadding(lineStruct(), tt.hot, tt.lineSize)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SuggestPadding(%q, %d) = %v, %v, want %v", tt.hot, tt.lineSize, got, err, tt.want)
+			continue
+		}
+		if padded := linePadded(got); !lineHotApart(padded, tt.hot, tt.lineSize) {
+			t.Errorf("SuggestPadding(%q, %d): hot fields still share lines", tt.hot, tt.lineSize)
+		}
+	}
+
+	if _, err := SuggestPadding(lineStruct(), []string{"A", "Nope"}, 64); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("SuggestPadding of an unknown field = %v, want ErrFieldNotFound", err)
+	}
+}
+
+// linePadded returns lineT with the padding inserted, built by the runtime.
+func linePadded(pads []Padding) *StructType {
+	rt := reflect.TypeOf(lineT{})
+	var fields []reflect.StructField
+	for i := 0; i < rt.NumField(); i++ {
+		for _, p := range pads {
+			if p.Field == i {
+				fields = append(fields, reflect.StructField{Name: "Pad" + strconv.Itoa(i), Type: reflect.ArrayOf(int(p.Bytes), reflect.TypeOf(byte(0)))})
+			}
+		}
+		f := rt.Field(i)
+		fields = append(fields, reflect.StructField{Name: f.Name, Type: f.Type})
+	}
+	return (*StructType)(unsafe.Pointer(FromReflect(reflect.StructOf(fields))))
+}
+
+// lineHotApart reports whether no two fields of st named by hot share a line.
+func lineHotApart(st *StructType, hot []string, lineSize int) bool {
+	isHot := make(map[int]bool)
+	for _, name := range hot {
+		isHot[fieldIndex(st, name)] = true
+	}
+	for _, g := range CacheLineReport(st, lineSize) {
+		n := 0
+		for _, i := range g.Fields {
+			if isHot[i] && st.Fields[i].typ.size > 0 {
+				n++
+			}
+		}
+		if n > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCacheLinePanics(t *testing.T) {
+	for _, size := range []int{0, -64, 48} {
+		for name, f := range map[string]func(){
+			"CacheLineReport": func() { CacheLineReport(lineStruct(), size) },
+			"SuggestPadding":  func() { SuggestPadding(lineStruct(), nil, size) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s(%d) did not panic", name, size)
+					}
+				}()
+				f()
+			}()
+		}
+	}
+}
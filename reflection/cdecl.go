@@ -0,0 +1,251 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// CDeclOptions controls the output of CDecl.
+type CDeclOptions struct {
+	// Name is the name of the emitted C struct.
+	// If empty, the name of the Go struct type is used.
+	Name string
+
+	// AllowPointers maps pointer and unsafe.Pointer fields to void *.
+	// The Go garbage collector does not know about references kept on
+	// the C side, see the cgo pointer passing rules.
+	AllowPointers bool
+}
+
+var cScalarTypes = [...]string{
+	Bool:       "_Bool",
+	Int:        "intptr_t",
+	Int8:       "int8_t",
+	Int16:      "int16_t",
+	Int32:      "int32_t",
+	Int64:      "int64_t",
+	Uint:       "uintptr_t",
+	Uint8:      "uint8_t",
+	Uint16:     "uint16_t",
+	Uint32:     "uint32_t",
+	Uint64:     "uint64_t",
+	Uintptr:    "uintptr_t",
+	Float32:    "float",
+	Float64:    "double",
+	Complex64:  "float _Complex",
+	Complex128: "double _Complex",
+}
+
+// cKeywords are the keywords of C11 and C23, which C member names must avoid.
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extern": true, "float": true, "for": true, "goto": true,
+	"if": true, "inline": true, "int": true, "long": true, "register": true,
+	"restrict": true, "return": true, "short": true, "signed": true,
+	"sizeof": true, "static": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true,
+	"volatile": true, "while": true,
+
+	// C11
+	"_Alignas": true, "_Alignof": true, "_Atomic": true, "_Bool": true,
+	"_Complex": true, "_Generic": true, "_Imaginary": true, "_Noreturn": true,
+	"_Static_assert": true, "_Thread_local": true,
+
+	// C23
+	"alignas": true, "alignof": true, "bool": true, "constexpr": true,
+	"false": true, "nullptr": true, "static_assert": true, "thread_local": true,
+	"true": true, "typeof": true, "typeof_unqual": true,
+}
+
+// CDecl emits a C struct definition with the same memory layout as the Go
+// struct type st, for sharing memory between Go and C.
+//
+// Go scalar kinds are mapped to <stdint.h> types, nested structs and arrays
+// are emitted as their own definitions and array members, and explicit
+// padding members are inserted so that every offset and the total size match
+// the Go layout exactly. The output ends with _Static_assert lines checking
+// sizeof and offsetof, so that the C compiler verifies the contract.
+//
+// Fields whose type has no C representation (strings, slices, maps,
+// interfaces, channels, funcs, and pointers unless opts.AllowPointers is set)
+// make CDecl return an error naming the field. Zero-sized fields are omitted.
+func CDecl(st *StructType, opts CDeclOptions) (string, error) {
+	name := opts.Name
+	if name == "" {
+		name = st.Name()
+	}
+	if name == "" {
+		return "", errors.New("reflection: CDecl of unnamed struct type without CDeclOptions.Name")
+	}
+	c := cWriter{opts: &opts, names: make(map[*StructType]string), used: map[string]bool{name: true}}
+	c.defs.WriteString("#include <stddef.h>\n#include <stdint.h>\n")
+	if err := c.structDef(st, name); err != nil {
+		return "", err
+	}
+	return c.defs.String() + "\n" + c.asserts.String(), nil
+}
+
+type cWriter struct {
+	opts    *CDeclOptions
+	names   map[*StructType]string
+	used    map[string]bool // the names of the C structs emitted
+	defs    strings.Builder
+	asserts strings.Builder
+}
+
+// structDef emits the definition of st as the C struct name, after the
+// definitions of the struct types it contains.
+func (c *cWriter) structDef(st *StructType, name string) error {
+	c.names[st] = name
+	// The member names of the fields, and the names of the padding members
+	// inserted between them, must all differ.
+	fnames := make([]string, len(st.Fields))
+	used := make(map[string]bool, len(st.Fields))
+	for i := range st.Fields {
+		if st.Fields[i].typ.size != 0 {
+			used[st.Fields[i].Name.Name()] = true
+		}
+	}
+	for i := range st.Fields {
+		if st.Fields[i].typ.size == 0 {
+			continue
+		}
+		if n := st.Fields[i].Name.Name(); n != "_" && !cKeywords[n] {
+			fnames[i] = n
+			continue
+		}
+		fnames[i] = cUniqueName(cFieldName(st.Fields[i].Name.Name(), i), used)
+	}
+	pad := 0
+	padMember := func(size uintptr) string {
+		n := "_pad" + strconv.Itoa(pad)
+		for ; used[n]; n = "_pad" + strconv.Itoa(pad) {
+			pad++
+		}
+		used[n] = true
+		return "\tuint8_t " + n + "[" + strconv.FormatUint(uint64(size), 10) + "];\n"
+	}
+
+	var body strings.Builder
+	var off uintptr
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if f.typ.size == 0 {
+			continue
+		}
+		fname := fnames[i]
+		ctype, dims, err := c.memberType(f.typ, name+"_"+fname)
+		if err != nil {
+			return errors.New("reflection: CDecl: field " + name + "." + f.Name.Name() + ": " + err.Error())
+		}
+		if f.Offset() > off {
+			body.WriteString(padMember(f.Offset() - off))
+		}
+		if !strings.HasSuffix(ctype, "*") {
+			ctype += " "
+		}
+		body.WriteString("\t" + ctype + fname + dims + ";\n")
+		c.asserts.WriteString("_Static_assert(offsetof(struct " + name + ", " + fname + ") == " +
+			strconv.FormatUint(uint64(f.Offset()), 10) + ", \"" + name + "." + fname + " offset\");\n")
+		off = f.Offset() + f.typ.size
+	}
+	if st.size > off {
+		body.WriteString(padMember(st.size - off))
+	}
+	c.defs.WriteString("\nstruct " + name + " {\n" + body.String() + "};\n")
+	c.asserts.WriteString("_Static_assert(sizeof(struct " + name + ") == " +
+		strconv.FormatUint(uint64(st.size), 10) + ", \"" + name + " size\");\n")
+	return nil
+}
+
+// memberType returns the C type and array dimensions of a member of type t.
+// anon names the C struct emitted for t when t is an unnamed struct.
+func (c *cWriter) memberType(t *rtype, anon string) (string, string, error) {
+	switch k := t.Kind(); k {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		ctype, dims, err := c.memberType(at.Elem, anon)
+		return ctype, "[" + strconv.FormatUint(uint64(at.Len), 10) + "]" + dims, err
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		if name, ok := c.names[st]; ok {
+			return "struct " + name, "", nil
+		}
+		name := anon
+		if t.HasName() {
+			name = cTypeName(t)
+		}
+		name = c.structName(name)
+		if err := c.structDef(st, name); err != nil {
+			return "", "", err
+		}
+		return "struct " + name, "", nil
+	case Ptr, UnsafePointer:
+		if !c.opts.AllowPointers {
+			return "", "", errors.New("pointer type " + t.String() + " requires AllowPointers")
+		}
+		return "void *", "", nil
+	default:
+		if int(k) < len(cScalarTypes) && cScalarTypes[k] != "" {
+			return cScalarTypes[k], "", nil
+		}
+		return "", "", errors.New("type " + t.String() + " has no C representation")
+	}
+}
+
+// cFieldName returns a C member name for the Go field name.
+func cFieldName(name string, i int) string {
+	if name == "_" {
+		return "_blank" + strconv.Itoa(i)
+	}
+	if cKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// cUniqueName returns name, or name followed by underscores, whichever is
+// first not in used, and adds it to used.
+func cUniqueName(name string, used map[string]bool) string {
+	for used[name] {
+		name += "_"
+	}
+	used[name] = true
+	return name
+}
+
+// structName returns name, or name followed by an underscore and the
+// smallest number from 2 that is not the name of another C struct of the
+// declaration, and reserves it. Distinct Go types may map to the same C
+// identifier, as a/b.T and a_b.T do.
+func (c *cWriter) structName(name string) string {
+	n := name
+	for i := 2; c.used[n]; i++ {
+		n = name + "_" + strconv.Itoa(i)
+	}
+	c.used[n] = true
+	return n
+}
+
+// cTypeName returns a C identifier derived from the package path and the
+// name of the defined type t, such as example_com_geo_Point for
+// example.com/geo.Point.
+func cTypeName(t *rtype) string {
+	name := t.Name()
+	if pkg := t.PkgPath(); pkg != "" {
+		name = pkg + "." + name
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type cdeclFixture struct {
+	A     uint8
+	B     int64
+	int   int32
+	int_  int16
+	_pad0 uint8
+	_     [3]byte
+	_     uint16
+	bool  bool
+	true  uint8
+	C     complex128
+	Inner struct {
+		typeof  uint32
+		nullptr uint8
+	}
+	Arr   [2][3]float32
+	Empty struct{}
+	U     uintptr
+}
+
+func TestCDeclNames(t *testing.T) {
+	out, err := CDecl((*StructType)(unsafe.Pointer(TypeFor[cdeclFixture]())), CDeclOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := make(map[string]int)
+	for _, m := range regexp.MustCompile(`(?m)^\t[^\t]+ (\w+)(?:\[\d+\])*;$`).FindAllStringSubmatch(out, -1) {
+		members[m[1]]++
+	}
+	for name, n := range members {
+		if n > 1 && !strings.HasPrefix(name, "_pad") {
+			t.Errorf("member %s declared %d times", name, n)
+		}
+		if cKeywords[name] {
+			t.Errorf("member %s is a C keyword", name)
+		}
+	}
+	for _, name := range []string{"int_", "int__", "_pad0", "bool_", "true_", "typeof_", "nullptr_"} {
+		if members[name] == 0 {
+			t.Errorf("missing member %s in\n%s", name, out)
+		}
+	}
+	if strings.Contains(out, "Empty") {
+		t.Errorf("zero-sized field emitted:\n%s", out)
+	}
+}
+
+// TestCDeclOffsets checks that the offsets and sizes asserted by the output
+// of CDecl are those of the Go layout, and that a C compiler agrees.
+func TestCDeclOffsets(t *testing.T) {
+	rt := reflect.TypeOf(cdeclFixture{})
+	out, err := CDecl((*StructType)(unsafe.Pointer(FromReflect(rt))), CDeclOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asserts := regexp.MustCompile(`_Static_assert\(offsetof\(struct cdeclFixture, \w+\) == (\d+), "cdeclFixture\.\w+ offset"\);`).FindAllStringSubmatch(out, -1)
+	var want []uintptr
+	for i := 0; i < rt.NumField(); i++ {
+		if f := rt.Field(i); f.Type.Size() != 0 {
+			want = append(want, f.Offset)
+		}
+	}
+	if len(asserts) != len(want) {
+		t.Fatalf("%d offset assertions, want %d:\n%s", len(asserts), len(want), out)
+	}
+	for i, a := range asserts {
+		if off, _ := strconv.ParseUint(a[1], 10, 64); uintptr(off) != want[i] {
+			t.Errorf("assertion %d: offset %d, want %d", i, off, want[i])
+		}
+	}
+	if !strings.Contains(out, "_Static_assert(sizeof(struct cdeclFixture) == "+strconv.Itoa(int(rt.Size()))+",") {
+		t.Errorf("no size assertion of %d:\n%s", rt.Size(), out)
+	}
+
+	// The C compiler targets the host, whose layout is that of the Go
+	// architecture for 64-bit builds only.
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("C compiler not checked on 32-bit platforms")
+	}
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	src := filepath.Join(t.TempDir(), "cdecl.c")
+	if err := os.WriteFile(src, []byte(out), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := exec.Command(cc, "-std=c11", "-fsyntax-only", src).CombinedOutput(); err != nil {
+		t.Errorf("%s: %v\n%s\n%s", cc, err, b, out)
+	}
+}
+
+type cdeclPoint struct{ X, Y int32 }
+
+func TestCDeclTypeNames(t *testing.T) {
+	// The root takes the name cTypeName gives cdeclPoint, which must then
+	// get another, and image.Point is named after its package path.
+	root := cTypeName(TypeFor[cdeclPoint]())
+	st := (*StructType)(unsafe.Pointer(TypeFor[struct {
+		P cdeclPoint
+		I image.Point
+	}]()))
+	out, err := CDecl(st, CDeclOptions{Name: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, def := range []string{"struct " + root + " {", "struct " + root + "_2 {", "struct image_Point {"} {
+		if strings.Count(out, def) != 1 {
+			t.Errorf("want one %q in\n%s", def, out)
+		}
+	}
+}
+
+func TestCDeclErrors(t *testing.T) {
+	if _, err := CDecl((*StructType)(unsafe.Pointer(TypeFor[struct{ A int }]())), CDeclOptions{}); err == nil {
+		t.Error("CDecl of an unnamed struct without a name: no error")
+	}
+	for _, tt := range []struct {
+		st   *StructType
+		opts CDeclOptions
+		ok   bool
+	}{
+		{(*StructType)(unsafe.Pointer(TypeFor[struct{ S string }]())), CDeclOptions{Name: "s"}, false},
+		{(*StructType)(unsafe.Pointer(TypeFor[struct{ P *int }]())), CDeclOptions{Name: "p"}, false},
+		{(*StructType)(unsafe.Pointer(TypeFor[struct{ P *int }]())), CDeclOptions{Name: "p", AllowPointers: true}, true},
+	} {
+		_, err := CDecl(tt.st, tt.opts)
+		if (err == nil) != tt.ok {
+			t.Errorf("CDecl(%s, %+v) = %v", &tt.st.rtype, tt.opts, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrNilElem is returned when a nil element pointer is passed for a channel
+// whose element type is not zero-sized.
+var ErrNilElem = errors.New("reflection: nil element pointer")
+
+// ErrNoBuffer is returned when inspecting the buffer of a nil or unbuffered
+// channel.
+var ErrNoBuffer = errors.New("reflection: channel has no buffer")
+
+// chanOf validates that c holds a channel usable in direction dir and
+// returns the channel pointer.
+func chanOf(c *interface{}, op string, dir ChanDir, elem unsafe.Pointer) (unsafe.Pointer, error) {
+	e := efaceOf(c)
+	if k := kindOf(e.Type); k != Chan {
+		return nil, &KindError{Op: op, Kind: k}
+	}
+	ct := (*ChanType)(unsafe.Pointer(e.Type))
+	if ChanDir(ct.Dir)&dir == 0 {
+		return nil, ErrChanDir
+	}
+	if elem == nil && ct.Elem.size != 0 {
+		return nil, ErrNilElem
+	}
+	return e.Word, nil
+}
+
+// ChanSend sends the value of the channel's element type pointed to by elem
+// on the channel held by c, without boxing it. If block is false, the send
+// does not wait and the result reports whether the value was sent, which gives
+// an allocation-free try-send for channels of any type.
+//
+// As with the send statement, sending on a closed channel panics and a
+// blocking send on a nil channel blocks forever; a non-blocking send on a nil
+// channel reports false.
+func ChanSend(c interface{}, elem unsafe.Pointer, block bool) (bool, error) {
+	ch, err := chanOf(&c, "ChanSend", SendDir, elem)
+	if err != nil {
+		return false, err
+	}
+	return chansend(ch, elem, !block), nil
+}
+
+// ChanRecv receives a value from the channel held by c into dst, which must
+// point to a value of the channel's element type. If block is false, the
+// receive does not wait and received reports whether a value was taken.
+// ok is false if the value is the zero value written because the channel is
+// closed, as with the "v, ok := <-c" form.
+//
+// A non-blocking receive on a nil channel reports received == false.
+func ChanRecv(c interface{}, dst unsafe.Pointer, block bool) (received bool, ok bool, err error) {
+	ch, err := chanOf(&c, "ChanRecv", RecvDir, dst)
+	if err != nil {
+		return false, false, err
+	}
+	received, ok = chanrecv(ch, !block, dst)
+	return received, ok, nil
+}
+
+// ChanPeek copies into dst, which must point to a value of the channel's
+// element type, the i'th of the values buffered in the channel held by c,
+// the one the i'th next receive would take, without receiving it. It returns
+// ErrNoBuffer for a nil or unbuffered channel, and an error if i is not less
+// than the number of buffered values.
+//
+// The channel is not locked, since the runtime does not expose its locks: the
+// count and position of the buffered values are read once, and a concurrent
+// send or receive may change the value at i while it is copied. ChanPeek is
+// meant for diagnostics, on channels that are quiescent or whose exact
+// contents do not matter. Under the darkness_safe build tag, ChanPeek returns
+// an error wrapping ErrUnsupported.
+func ChanPeek(c interface{}, i int, dst unsafe.Pointer) error {
+	e := efaceOf(&c)
+	if k := kindOf(e.Type); k != Chan {
+		return &KindError{Op: "ChanPeek", Kind: k}
+	}
+	if safeMode {
+		return fmt.Errorf("%w: ChanPeek reads the channel buffer, not done under darkness_safe", ErrUnsupported)
+	}
+	h := (*hchan)(e.Word)
+	if h == nil || h.dataqsiz == 0 {
+		return ErrNoBuffer
+	}
+	elem := (*ChanType)(unsafe.Pointer(e.Type)).Elem
+	if dst == nil && elem.size != 0 {
+		return ErrNilElem
+	}
+	qcount := atomic.LoadUintptr((*uintptr)(unsafe.Pointer(&h.qcount)))
+	recvx := atomic.LoadUintptr((*uintptr)(unsafe.Pointer(&h.recvx)))
+	if i < 0 || uintptr(i) >= qcount {
+		return fmt.Errorf("reflection: ChanPeek index %d out of range with %d buffered values", i, qcount)
+	}
+	x := (recvx + uintptr(i)) % uintptr(h.dataqsiz)
+	TypedMemmove(elem, dst, Add(h.buf, x*elem.size, "x < dataqsiz"))
+	return nil
+}
+
+//go:linkname chanrecv reflect.chanrecv
+
+// chanrecv receives from the channel ch into elem.
+// Implemented in the runtime package.
+func chanrecv(ch unsafe.Pointer, nb bool, elem unsafe.Pointer) (selected, received bool)
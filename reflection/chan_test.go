@@ -0,0 +1,210 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestChanSendRecvBuffered(t *testing.T) {
+	c := make(chan string, 2)
+	for _, s := range []string{"a", "b"} {
+		if sent, err := ChanSend(c, unsafe.Pointer(&s), false); !sent || err != nil {
+			t.Fatalf("ChanSend(%s) = %v, %v", s, sent, err)
+		}
+	}
+	s := "c"
+	if sent, err := ChanSend(c, unsafe.Pointer(&s), false); sent || err != nil {
+		t.Errorf("ChanSend to a full channel = %v, %v, want false", sent, err)
+	}
+	for _, want := range []string{"a", "b"} {
+		var got string
+		if received, ok, err := ChanRecv(c, unsafe.Pointer(&got), false); !received || !ok || err != nil || got != want {
+			t.Errorf("ChanRecv = %q, %v, %v, %v, want %q", got, received, ok, err, want)
+		}
+	}
+	var got string
+	if received, _, err := ChanRecv(c, unsafe.Pointer(&got), false); received || err != nil {
+		t.Errorf("ChanRecv from an empty channel = %v, %v, want false", received, err)
+	}
+
+	// A blocking send and receive, as the statements.
+	if sent, err := ChanSend((chan<- string)(c), unsafe.Pointer(&s), true); !sent || err != nil {
+		t.Fatalf("blocking ChanSend = %v, %v", sent, err)
+	}
+	if received, ok, err := ChanRecv((<-chan string)(c), unsafe.Pointer(&got), true); !received || !ok || err != nil || got != "c" {
+		t.Errorf("blocking ChanRecv = %q, %v, %v, %v", got, received, ok, err)
+	}
+}
+
+func TestChanSendRecvUnbuffered(t *testing.T) {
+	c := make(chan int64)
+	v := int64(42)
+	if sent, err := ChanSend(c, unsafe.Pointer(&v), false); sent || err != nil {
+		t.Errorf("ChanSend with no receiver = %v, %v, want false", sent, err)
+	}
+	done := make(chan int64)
+	go func() { done <- <-c }()
+	if sent, err := ChanSend(c, unsafe.Pointer(&v), true); !sent || err != nil {
+		t.Fatalf("blocking ChanSend = %v, %v", sent, err)
+	}
+	if got := <-done; got != 42 {
+		t.Errorf("received %d, want 42", got)
+	}
+
+	go func() { c <- 7 }()
+	var got int64
+	if received, ok, err := ChanRecv(c, unsafe.Pointer(&got), true); !received || !ok || err != nil || got != 7 {
+		t.Errorf("blocking ChanRecv = %d, %v, %v, %v", got, received, ok, err)
+	}
+
+	// A zero-size element needs no pointer.
+	z := make(chan struct{}, 1)
+	if sent, err := ChanSend(z, nil, false); !sent || err != nil {
+		t.Errorf("ChanSend of struct{} = %v, %v", sent, err)
+	}
+	if received, ok, err := ChanRecv(z, nil, false); !received || !ok || err != nil {
+		t.Errorf("ChanRecv of struct{} = %v, %v, %v", received, ok, err)
+	}
+}
+
+func TestChanRecvClosed(t *testing.T) {
+	c := make(chan int64, 1)
+	c <- 5
+	close(c)
+	var got int64
+	if received, ok, err := ChanRecv(c, unsafe.Pointer(&got), false); !received || !ok || got != 5 || err != nil {
+		t.Errorf("ChanRecv of a buffered value = %d, %v, %v, %v", got, received, ok, err)
+	}
+	got = 9
+	for _, block := range []bool{false, true} {
+		if received, ok, err := ChanRecv(c, unsafe.Pointer(&got), block); !received || ok || got != 0 || err != nil {
+			t.Errorf("ChanRecv(block %v) of a closed channel = %d, %v, %v, %v, want the zero value and ok false", block, got, received, ok, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ChanSend on a closed channel did not panic")
+		}
+	}()
+	ChanSend(c, unsafe.Pointer(&got), false)
+}
+
+func TestChanNil(t *testing.T) {
+	var c chan int64
+	v := int64(1)
+	if sent, err := ChanSend(c, unsafe.Pointer(&v), false); sent || err != nil {
+		t.Errorf("non-blocking ChanSend on a nil channel = %v, %v", sent, err)
+	}
+	if received, ok, err := ChanRecv(c, unsafe.Pointer(&v), false); received || ok || err != nil {
+		t.Errorf("non-blocking ChanRecv on a nil channel = %v, %v, %v", received, ok, err)
+	}
+
+	// A blocking operation on a nil channel blocks forever.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var w int64
+		ChanRecv(c, unsafe.Pointer(&w), true)
+	}()
+	select {
+	case <-done:
+		t.Error("blocking ChanRecv on a nil channel returned")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestChanErrors(t *testing.T) {
+	v := 0
+	if _, err := ChanSend((<-chan int)(make(chan int)), unsafe.Pointer(&v), false); !errors.Is(err, ErrChanDir) {
+		t.Errorf("ChanSend on a receive-only channel = %v, want ErrChanDir", err)
+	}
+	if _, _, err := ChanRecv((chan<- int)(make(chan int)), unsafe.Pointer(&v), false); !errors.Is(err, ErrChanDir) {
+		t.Errorf("ChanRecv on a send-only channel = %v, want ErrChanDir", err)
+	}
+	if _, err := ChanSend(make(chan int, 1), nil, false); !errors.Is(err, ErrNilElem) {
+		t.Errorf("ChanSend of nil = %v, want ErrNilElem", err)
+	}
+	var ke *KindError
+	if _, err := ChanSend(1, unsafe.Pointer(&v), false); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("ChanSend on an int = %v, want a *KindError", err)
+	}
+	if _, _, err := ChanRecv(nil, unsafe.Pointer(&v), false); !errors.As(err, &ke) || ke.Kind != Invalid {
+		t.Errorf("ChanRecv on nil = %v, want a *KindError", err)
+	}
+}
+
+func TestChanSendAllocs(t *testing.T) {
+	c := make(chan [4]int64, 1)
+	var v [4]int64
+	var x interface{} = c
+	allocs := testing.AllocsPerRun(100, func() {
+		ChanSend(x, unsafe.Pointer(&v), false)
+		ChanRecv(x, unsafe.Pointer(&v), false)
+	})
+	if allocs != 0 {
+		t.Errorf("ChanSend and ChanRecv allocate %v times", allocs)
+	}
+}
+
+func TestChanPeek(t *testing.T) {
+	c := make(chan string, 4)
+	if safeMode {
+		var s string
+		if err := ChanPeek(c, 0, unsafe.Pointer(&s)); !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("ChanPeek under darkness_safe = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	// Move the receive index so that the buffered values wrap around.
+	c <- "x"
+	c <- "y"
+	<-c
+	<-c
+	want := []string{"a", "b", "c", "d"}
+	for _, s := range want {
+		c <- s
+	}
+	for i, w := range want {
+		var got string
+		if err := ChanPeek(c, i, unsafe.Pointer(&got)); err != nil || got != w {
+			t.Errorf("ChanPeek(%d) = %q, %v, want %q", i, got, err, w)
+		}
+	}
+	var got string
+	for _, i := range []int{-1, 4} {
+		if err := ChanPeek(c, i, unsafe.Pointer(&got)); err == nil {
+			t.Errorf("ChanPeek(%d) succeeded", i)
+		}
+	}
+	if err := ChanPeek(c, 0, nil); !errors.Is(err, ErrNilElem) {
+		t.Errorf("ChanPeek into nil = %v, want ErrNilElem", err)
+	}
+
+	// Peeking receives nothing.
+	for _, w := range want {
+		if s := <-c; s != w {
+			t.Errorf("received %q after peeking, want %q", s, w)
+		}
+	}
+	if err := ChanPeek(c, 0, unsafe.Pointer(&got)); err == nil {
+		t.Error("ChanPeek of an empty channel succeeded")
+	}
+
+	if err := ChanPeek((chan string)(nil), 0, unsafe.Pointer(&got)); !errors.Is(err, ErrNoBuffer) {
+		t.Errorf("ChanPeek of a nil channel = %v, want ErrNoBuffer", err)
+	}
+	if err := ChanPeek(make(chan string), 0, unsafe.Pointer(&got)); !errors.Is(err, ErrNoBuffer) {
+		t.Errorf("ChanPeek of an unbuffered channel = %v, want ErrNoBuffer", err)
+	}
+	var ke *KindError
+	if err := ChanPeek([]string{}, 0, unsafe.Pointer(&got)); !errors.As(err, &ke) || ke.Kind != Slice {
+		t.Errorf("ChanPeek of a slice = %v, want a *KindError", err)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.21
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+//go:linkname chansend reflect.chansend
+
+// chansend sends the value pointed to by elem on the channel ch.
+// Implemented in the runtime package.
+func chansend(ch unsafe.Pointer, elem unsafe.Pointer, nb bool) bool
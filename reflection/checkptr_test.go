@@ -0,0 +1,280 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+// The pointer arithmetic of the package must satisfy the checkptr rules,
+// which -race enables. The whole suite is expected to pass with
+//
+//	go test -race ./...
+//	go test -gcflags=all=-d=checkptr ./...
+//
+// The tests below exercise the helpers every other unsafe path is built
+// upon: Add, the decoding of names and tags, and the unpacking and packing
+// of interfaces; and the paths that reach into runtime memory: maps,
+// channel buffers, raw allocations, and the swapping and sorting of slice
+// elements.
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type checkptrT struct {
+	A     int `json:"a"`
+	b     string
+	Long  [3]byte `long:"01234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789"` // a tag length of two varint bytes
+	Empty struct{}
+}
+
+func TestAdd(t *testing.T) {
+	var a [4]uint64
+	base := unsafe.Pointer(&a)
+	for i := range a {
+		p := Add(base, uintptr(i)*8, "i < len(a)")
+		if p != unsafe.Pointer(&a[i]) {
+			t.Errorf("Add(%d) = %p, want %p", i*8, p, &a[i])
+		}
+		*(*uint64)(p) = uint64(i)
+	}
+	if a != [4]uint64{0, 1, 2, 3} {
+		t.Errorf("writes through Add: %v", a)
+	}
+}
+
+func TestNameTag(t *testing.T) {
+	rt := reflect.TypeOf(checkptrT{})
+	st := (*StructType)(unsafe.Pointer(FromReflect(rt)))
+	for i := range st.Fields {
+		f, want := &st.Fields[i], rt.Field(i)
+		if got := f.Name.Name(); got != want.Name {
+			t.Errorf("field %d: Name = %q, want %q", i, got, want.Name)
+		}
+		if got := f.Name.Tag(); got != string(want.Tag) {
+			t.Errorf("field %s: Tag = %q, want %q", want.Name, got, want.Tag)
+		}
+		if got := f.Name.IsExported(); got != want.IsExported() {
+			t.Errorf("field %s: IsExported = %v, want %v", want.Name, got, want.IsExported())
+		}
+		// Exported field names carry no package path.
+		if want.IsExported() {
+			if got := f.Name.PkgPath(); got != "" {
+				t.Errorf("field %s: PkgPath = %q, want empty", want.Name, got)
+			}
+		}
+	}
+	if got := (Name{}).Name(); got != "" {
+		t.Errorf("zero Name: Name = %q, want empty", got)
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	tests := []interface{}{
+		0, "", checkptrT{}, &checkptrT{}, []*checkptrT{}, map[string][]int{}, (func(int) error)(nil),
+	}
+	for _, v := range tests {
+		want := reflect.TypeOf(v).String()
+		if got := efaceOf(&v).Type.String(); got != want {
+			t.Errorf("String = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestEface(t *testing.T) {
+	x := 7
+	big := checkptrT{A: 1, b: strings.Repeat("b", 3)}
+	tests := []struct {
+		name string
+		in   interface{}
+	}{
+		{"int", 3},
+		{"pointer", &x},
+		{"struct", big},
+		{"zero-sized", struct{}{}},
+		{"func", func() {}},
+		{"map", map[int]int{1: 2}},
+		{"single pointer struct", struct{ p *int }{&x}},
+		{"single pointer array", [1]*int{&x}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, p, err := TryUnpackEface(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ToReflect(typ) != reflect.TypeOf(tt.in) {
+				t.Errorf("type = %v, want %v", ToReflect(typ), reflect.TypeOf(tt.in))
+			}
+			out := PackEface(typ, p)
+			if typ.Kind() == Func {
+				if reflect.ValueOf(out).Pointer() != reflect.ValueOf(tt.in).Pointer() {
+					t.Error("PackEface of the unpacked func is another func")
+				}
+				return
+			}
+			if !reflect.DeepEqual(out, tt.in) {
+				t.Errorf("PackEface(TryUnpackEface(%v)) = %v", tt.in, out)
+			}
+		})
+	}
+
+	var ke *KindError
+	if _, _, err := TryUnpackEface(nil); !errors.As(err, &ke) {
+		t.Errorf("TryUnpackEface(nil) error = %v, want a *KindError", err)
+	}
+}
+
+// checkptrElem mixes pointers and scalars, for the typed copies of the paths
+// below to move both.
+type checkptrElem struct {
+	N int32
+	S string
+	P *int
+}
+
+func TestCheckptrMap(t *testing.T) {
+	x := 1
+	m := make(map[string]checkptrElem)
+	for i := 0; i < 50; i++ {
+		m["k"+strconv.Itoa(i)] = checkptrElem{N: int32(i), S: strconv.Itoa(i), P: &x}
+	}
+	keys := make([]string, len(m))
+	if n, err := MapKeys(m, unsafe.Pointer(&keys[0]), len(keys)); n != len(m) || err != nil {
+		t.Fatalf("MapKeys = %d, %v", n, err)
+	}
+	vals := make([]checkptrElem, len(m))
+	if n, err := MapValues(m, unsafe.Pointer(&vals[0]), len(vals)); n != len(m) || err != nil {
+		t.Fatalf("MapValues = %d, %v", n, err)
+	}
+	sort.Strings(keys)
+	sort.Slice(vals, func(i, j int) bool { return vals[i].S < vals[j].S })
+	for i, k := range keys {
+		if v := m[k]; v != vals[i] {
+			t.Errorf("key %s: value %v, want %v", k, vals[i], v)
+		}
+	}
+
+	key := []byte("k7")
+	p, ok, err := MapAccessBytesKey(m, key)
+	if !ok || err != nil || (*checkptrElem)(p).S != "7" {
+		t.Fatalf("MapAccessBytesKey(k7) = %v, %v", ok, err)
+	}
+	v := checkptrElem{S: "new", P: &x}
+	if err := MapAssignBytesKey(m, []byte("new"), unsafe.Pointer(&v)); err != nil || m["new"] != v {
+		t.Errorf("MapAssignBytesKey = %v, map has %v", err, m["new"])
+	}
+
+	var prev string
+	if err := SortedStringMapRange(m, func(k string, v unsafe.Pointer) bool {
+		if k < prev || (*checkptrElem)(v).P != &x {
+			t.Errorf("SortedStringMapRange: %s after %s, value %v", k, prev, *(*checkptrElem)(v))
+		}
+		prev = k
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckptrChan(t *testing.T) {
+	if safeMode {
+		t.Skip("ChanPeek is not supported under darkness_safe")
+	}
+	x := 2
+	c := make(chan checkptrElem, 4)
+	// Wrap the buffer around, for the element offsets to cover all of it.
+	for i := 0; i < 6; i++ {
+		e := checkptrElem{N: int32(i), S: strconv.Itoa(i), P: &x}
+		if sent, err := ChanSend(c, unsafe.Pointer(&e), false); !sent || err != nil {
+			t.Fatalf("ChanSend(%d) = %v, %v", i, sent, err)
+		}
+		if i < 2 {
+			<-c
+		}
+	}
+	for i := 0; i < 4; i++ {
+		var got checkptrElem
+		if err := ChanPeek(c, i, unsafe.Pointer(&got)); err != nil || got.N != int32(i+2) || got.P != &x {
+			t.Errorf("ChanPeek(%d) = %v, %v", i, got, err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		var got checkptrElem
+		if received, ok, err := ChanRecv(c, unsafe.Pointer(&got), false); !received || !ok || err != nil || got.N != int32(i+2) {
+			t.Errorf("ChanRecv = %v, %v, %v, %v", got, received, ok, err)
+		}
+	}
+}
+
+func TestCheckptrAlloc(t *testing.T) {
+	for _, align := range []uintptr{1, 8, 64, 4096} {
+		size := uintptr(3 << 10)
+		p := AllocNoScan(size, align, true)
+		if uintptr(p)%align != 0 {
+			t.Errorf("AllocNoScan(%d, %d) = %p, misaligned", size, align, p)
+		}
+		b := unsafe.Slice((*byte)(p), size)
+		for i := range b {
+			if b[i] != 0 {
+				t.Fatalf("AllocNoScan(%d, %d): byte %d = %d, want zeroed", size, align, i, b[i])
+			}
+			b[i] = byte(i)
+		}
+		// The last byte is reached through Add, as element accesses are.
+		if *(*byte)(Add(p, size-1, "size-1 < size")) != byte(size-1) {
+			t.Errorf("AllocNoScan(%d, %d): last byte not written", size, align)
+		}
+	}
+
+	et := TypeFor[checkptrElem]()
+	p := AllocTyped(et, false)
+	x := 3
+	v := checkptrElem{S: "s", P: &x}
+	TypedMemmove(et, p, unsafe.Pointer(&v))
+	runtime.GC()
+	if *(*checkptrElem)(p) != v {
+		t.Errorf("AllocTyped value = %v, want %v", *(*checkptrElem)(p), v)
+	}
+}
+
+func TestCheckptrSwapSort(t *testing.T) {
+	x := 4
+	s := make([]checkptrElem, 37)
+	for i := range s {
+		s[i] = checkptrElem{N: int32((i * 7) % len(s)), S: strconv.Itoa(i), P: &x}
+	}
+	swap, err := Swapper(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap(0, len(s)-1)
+	if s[0].S != strconv.Itoa(len(s)-1) || s[len(s)-1].S != "0" {
+		t.Errorf("Swapper(0, %d): %v, %v", len(s)-1, s[0], s[len(s)-1])
+	}
+
+	less := func(a, b unsafe.Pointer) bool { return (*checkptrElem)(a).N < (*checkptrElem)(b).N }
+	if err := SortSlice(s, less); err != nil {
+		t.Fatal(err)
+	}
+	for i := range s {
+		if s[i].N != int32(i) || s[i].P != &x {
+			t.Fatalf("SortSlice: element %d = %v", i, s[i])
+		}
+	}
+	for i := range s {
+		s[i].N = int32(i % 3)
+	}
+	if err := SortSliceStable(s, less); err != nil {
+		t.Fatal(err)
+	}
+	if !sort.SliceIsSorted(s, func(i, j int) bool { return s[i].N < s[j].N }) {
+		t.Errorf("SortSliceStable: not sorted: %v", s)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// GatherField copies the field of each element of the slice of structs held
+// by src to the array of dstCap values of the type of the field at dst, such
+// as &col[0] for a []F of that length, and returns the number of elements.
+// If the slice has more than dstCap elements, GatherField copies nothing and
+// returns their number, for the caller to retry with a larger array.
+//
+// field must be one of the Fields of the element type of the slice, such as
+// returned by its Field method. Fields without pointers are copied as plain
+// memory; otherwise with the write barriers the garbage collector requires.
+//
+// GatherField returns a *KindError if src does not hold a slice of structs,
+// an error wrapping ErrFieldNotFound if field is not a field of its element
+// type, and ErrNegativeSize if dstCap is negative.
+func GatherField(src interface{}, field *StructField, dst unsafe.Pointer, dstCap int) (int, error) {
+	hdr, st, err := columnOf("GatherField", src, field)
+	if err != nil {
+		return 0, err
+	}
+	if dstCap < 0 {
+		return 0, fmt.Errorf("%w: GatherField with cap %d", ErrNegativeSize, dstCap)
+	}
+	if hdr.Len > dstCap {
+		return hdr.Len, nil
+	}
+	gather(hdr, st.size, field.Offset(), field.typ, dst)
+	return hdr.Len, nil
+}
+
+// GatherInto returns the values of the field of type F named by name, which
+// may be a dotted path as accepted by OffsetOf, of the elements of the slice
+// of structs held by src. It returns an error wrapping ErrTypeMismatch if
+// the field is not of type F.
+func GatherInto[F any](src interface{}, name string) ([]F, error) {
+	e := efaceOf(&src)
+	if k := kindOf(e.Type); k != Slice {
+		return nil, &KindError{Op: "GatherInto", Kind: k}
+	}
+	et := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	off, ft, _, err := lookupField(et, "GatherInto", name)
+	if err != nil {
+		return nil, err
+	}
+	if want := TypeFor[F](); ft != want {
+		return nil, fmt.Errorf("%w: GatherInto of %s field %s into %s", ErrTypeMismatch, ft, name, want)
+	}
+	hdr := (*SliceHeader)(e.Word)
+	col := make([]F, hdr.Len)
+	if hdr.Len > 0 {
+		gather(hdr, et.size, off, ft, unsafe.Pointer(&col[0]))
+	}
+	return col, nil
+}
+
+// ScatterField copies the n values of the type of field in the array at src,
+// such as &col[0] for a []F, to the field of the first n elements of the
+// slice of structs held by dst, leaving the other fields untouched. It is the
+// inverse of GatherField, with which it allows processing the columns of a
+// slice of structs as flat slices.
+//
+// ScatterField returns the errors GatherField returns, and an error if the
+// slice has fewer than n elements, in which case it copies nothing.
+func ScatterField(dst interface{}, field *StructField, src unsafe.Pointer, n int) error {
+	hdr, st, err := columnOf("ScatterField", dst, field)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return fmt.Errorf("%w: ScatterField of %d values", ErrNegativeSize, n)
+	}
+	if n > hdr.Len {
+		return fmt.Errorf("reflection: ScatterField of %d values into []%s of length %d", n, &st.rtype, hdr.Len)
+	}
+	scatter(SliceHeader{Data: hdr.Data, Len: n, Cap: n}, st.size, field.Offset(), field.typ, src)
+	return nil
+}
+
+// columnOf returns the slice header and the element type of s, which must
+// hold a slice of structs of which field is a field.
+func columnOf(op string, s interface{}, field *StructField) (*SliceHeader, *StructType, error) {
+	e := efaceOf(&s)
+	if k := kindOf(e.Type); k != Slice {
+		return nil, nil, &KindError{Op: op, Kind: k}
+	}
+	et := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	if k := et.Kind(); k != Struct {
+		return nil, nil, &KindError{Op: op, Kind: k}
+	}
+	st := (*StructType)(unsafe.Pointer(et))
+	for i := range st.Fields {
+		if &st.Fields[i] == field {
+			return (*SliceHeader)(e.Word), st, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %s field %p not in %s", ErrFieldNotFound, op, field, et)
+}
+
+// gather copies the values of type ft at offset off of the hdr.Len elements
+// of size stride of hdr to dst.
+func gather(hdr *SliceHeader, stride, off uintptr, ft *rtype, dst unsafe.Pointer) {
+	if ft.size == 0 || hdr.Len == 0 {
+		return
+	}
+	if ft.size == stride {
+		// The field is the whole element.
+		TypedSliceCopy(ft, SliceHeader{Data: dst, Len: hdr.Len, Cap: hdr.Len}, *hdr)
+		return
+	}
+	if HasPointers(ft) {
+		for i := 0; i < hdr.Len; i++ {
+			s := Add(hdr.Data, uintptr(i)*stride+off, "i < len")
+			typedmemmove(ft, Add(dst, uintptr(i)*ft.size, "i < dstCap"), s)
+		}
+		return
+	}
+	src := Add(hdr.Data, off, "the field lies within the element")
+	switch {
+	case ft.size == 8 && ft.align >= 8:
+		gatherWords[uint64](src, stride, dst, hdr.Len)
+	case ft.size == 4 && ft.align >= 4:
+		gatherWords[uint32](src, stride, dst, hdr.Len)
+	case ft.size == 2 && ft.align >= 2:
+		gatherWords[uint16](src, stride, dst, hdr.Len)
+	case ft.size == 1:
+		gatherWords[uint8](src, stride, dst, hdr.Len)
+	default:
+		for i := 0; i < hdr.Len; i++ {
+			s := Add(src, uintptr(i)*stride, "i < len")
+			d := Add(dst, uintptr(i)*ft.size, "i < dstCap")
+			copy(unsafe.Slice((*byte)(d), ft.size), unsafe.Slice((*byte)(s), ft.size))
+		}
+	}
+}
+
+// gatherWords copies n values of type W, found every stride bytes from src,
+// to the array at dst, with plain loads and stores that the compiler keeps
+// in registers.
+func gatherWords[W uint8 | uint16 | uint32 | uint64](src unsafe.Pointer, stride uintptr, dst unsafe.Pointer, n int) {
+	d := unsafe.Slice((*W)(dst), n)
+	for i := range d {
+		d[i] = *(*W)(unsafe.Add(src, uintptr(i)*stride))
+	}
+}
+
+// scatter copies the hdr.Len values of type ft at src to offset off of the
+// elements of size stride of hdr.
+func scatter(hdr SliceHeader, stride, off uintptr, ft *rtype, src unsafe.Pointer) {
+	if ft.size == 0 || hdr.Len == 0 {
+		return
+	}
+	if ft.size == stride {
+		TypedSliceCopy(ft, hdr, SliceHeader{Data: src, Len: hdr.Len, Cap: hdr.Len})
+		return
+	}
+	if HasPointers(ft) {
+		for i := 0; i < hdr.Len; i++ {
+			d := Add(hdr.Data, uintptr(i)*stride+off, "i < len")
+			typedmemmove(ft, d, Add(src, uintptr(i)*ft.size, "i < n"))
+		}
+		return
+	}
+	dst := Add(hdr.Data, off, "the field lies within the element")
+	switch {
+	case ft.size == 8 && ft.align >= 8:
+		scatterWords[uint64](dst, stride, src, hdr.Len)
+	case ft.size == 4 && ft.align >= 4:
+		scatterWords[uint32](dst, stride, src, hdr.Len)
+	case ft.size == 2 && ft.align >= 2:
+		scatterWords[uint16](dst, stride, src, hdr.Len)
+	case ft.size == 1:
+		scatterWords[uint8](dst, stride, src, hdr.Len)
+	default:
+		for i := 0; i < hdr.Len; i++ {
+			d := Add(dst, uintptr(i)*stride, "i < len")
+			s := Add(src, uintptr(i)*ft.size, "i < n")
+			copy(unsafe.Slice((*byte)(d), ft.size), unsafe.Slice((*byte)(s), ft.size))
+		}
+	}
+}
+
+// scatterWords is the inverse of gatherWords.
+func scatterWords[W uint8 | uint16 | uint32 | uint64](dst unsafe.Pointer, stride uintptr, src unsafe.Pointer, n int) {
+	s := unsafe.Slice((*W)(src), n)
+	for i, w := range s {
+		*(*W)(unsafe.Add(dst, uintptr(i)*stride)) = w
+	}
+}
+
+// GroupByString groups the elements of the slice of structs held by src by
+// the value of their field, of string kind, and returns the indices of the
+// elements of each value, in increasing order. The strings are read in place
+// and only copied once for each distinct value, into a key that does not
+// share memory with the slice, so that it keeps no large string alive.
+//
+// GroupByString returns the errors GatherField returns, and a *KindError if
+// the field is not of string kind.
+func GroupByString(src interface{}, field *StructField) (map[string][]int, error) {
+	hdr, st, err := columnOf("GroupByString", src, field)
+	if err != nil {
+		return nil, err
+	}
+	if k := field.typ.Kind(); k != String {
+		return nil, &KindError{Op: "GroupByString", Kind: k}
+	}
+	// Assigning to an existing key of a map[string]V replaces the key with
+	// the one assigned, so the groups are only stored in the result once
+	// they are complete, under the copied keys.
+	index := make(map[string]int)
+	var keys []string
+	var groups [][]int
+	for i := 0; i < hdr.Len; i++ {
+		s := *(*string)(Add(hdr.Data, uintptr(i)*st.size+field.Offset(), "i < len"))
+		g, ok := index[s]
+		if !ok {
+			g = len(keys)
+			s = string(unsafe.Slice((*byte)((*StringHeader)(unsafe.Pointer(&s)).Data), len(s)))
+			index[s] = g
+			keys = append(keys, s)
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	m := make(map[string][]int, len(keys))
+	for g, k := range keys {
+		m[k] = groups[g]
+	}
+	return m, nil
+}
+
+// GroupBy groups the elements of s by the value of their field of type K
+// named by name, which may be a dotted path as accepted by OffsetOf, as
+// GroupByString does. The keys are copies of the field values, as m[x.F]
+// would copy them. It returns an error wrapping ErrTypeMismatch if the
+// field is not of type K.
+func GroupBy[T any, K comparable](s []T, name string) (map[K][]int, error) {
+	off, ft, _, err := lookupField(TypeFor[T](), "GroupBy", name)
+	if err != nil {
+		return nil, err
+	}
+	if kt := TypeFor[K](); ft != kt {
+		return nil, fmt.Errorf("%w: GroupBy of %s field %s by %s", ErrTypeMismatch, ft, name, kt)
+	}
+	m := make(map[K][]int)
+	for i := range s {
+		k := *(*K)(Add(unsafe.Pointer(&s[i]), off, "the field lies within T"))
+		m[k] = append(m[k], i)
+	}
+	return m, nil
+}
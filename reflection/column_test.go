@@ -0,0 +1,304 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type columnInner struct{ X int64 }
+
+type columnT struct {
+	I8  int8
+	U16 uint16
+	I32 int32
+	F64 float64
+	S   string
+	P   *int
+	A   [3]byte
+	In  columnInner
+}
+
+type columnOne struct{ V int32 }
+
+// columnField returns the field of T named name.
+func columnField[T any](name string) *StructField {
+	st := (*StructType)(unsafe.Pointer(TypeFor[T]()))
+	for i := 0; i < st.NumField(); i++ {
+		if f := st.Field(i); f.Name.Name() == name {
+			return f
+		}
+	}
+	panic("no field " + name)
+}
+
+func columnFixture() []columnT {
+	one, two := 1, 2
+	return []columnT{
+		{I8: -1, U16: 1, I32: -10, F64: 0.5, S: "a", P: &one, A: [3]byte{1, 2, 3}, In: columnInner{7}},
+		{I8: 2, U16: 65535, I32: 20, F64: -1.5, S: "bb", P: nil, A: [3]byte{4, 5, 6}, In: columnInner{8}},
+		{I8: 3, U16: 3, I32: 30, F64: 2, S: "", P: &two, A: [3]byte{7, 8, 9}, In: columnInner{9}},
+	}
+}
+
+// columnValues returns the column of name of s, as a slice of the field
+// type, built with package reflect.
+func columnValues(s []columnT, name string) reflect.Value {
+	rv := reflect.ValueOf(s)
+	f, _ := reflect.TypeOf(columnT{}).FieldByName(name)
+	col := reflect.MakeSlice(reflect.SliceOf(f.Type), len(s), len(s))
+	for i := range s {
+		col.Index(i).Set(rv.Index(i).FieldByIndex(f.Index))
+	}
+	return col
+}
+
+func TestGatherField(t *testing.T) {
+	s := columnFixture()
+	for _, name := range []string{"I8", "U16", "I32", "F64", "S", "P", "A", "In"} {
+		t.Run(name, func(t *testing.T) {
+			f := columnField[columnT](name)
+			want := columnValues(s, name)
+			got := reflect.MakeSlice(want.Type(), len(s)+1, len(s)+1)
+			n, err := GatherField(s, f, got.Index(0).Addr().UnsafePointer(), got.Len())
+			if err != nil || n != len(s) {
+				t.Fatalf("GatherField = %d, %v", n, err)
+			}
+			if !reflect.DeepEqual(got.Slice(0, n).Interface(), want.Interface()) {
+				t.Errorf("gathered %v, want %v", got.Slice(0, n), want)
+			}
+			if !got.Index(n).IsZero() {
+				t.Errorf("GatherField wrote past the elements: %v", got.Index(n))
+			}
+		})
+	}
+
+	// The field is the whole element.
+	ones := []columnOne{{1}, {2}}
+	col := make([]int32, 2)
+	if n, err := GatherField(ones, columnField[columnOne]("V"), unsafe.Pointer(&col[0]), 2); err != nil || n != 2 || col[0] != 1 || col[1] != 2 {
+		t.Errorf("GatherField of the whole element = %d, %v, %v", n, err, col)
+	}
+}
+
+func TestGatherFieldErrors(t *testing.T) {
+	s := columnFixture()
+	f := columnField[columnT]("I32")
+	col := make([]int32, 1)
+	if n, err := GatherField(s, f, unsafe.Pointer(&col[0]), 1); err != nil || n != 3 || col[0] != 0 {
+		t.Errorf("GatherField with a short array = %d, %v, copied %v", n, err, col)
+	}
+	if n, err := GatherField([]columnT(nil), f, nil, 0); err != nil || n != 0 {
+		t.Errorf("GatherField of a nil slice = %d, %v", n, err)
+	}
+	if _, err := GatherField(s, f, nil, -1); !errors.Is(err, ErrNegativeSize) {
+		t.Errorf("GatherField with cap -1 = %v, want ErrNegativeSize", err)
+	}
+	if _, err := GatherField(s, columnField[columnOne]("V"), nil, 0); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("GatherField of a foreign field = %v, want ErrFieldNotFound", err)
+	}
+	var ke *KindError
+	if _, err := GatherField(columnT{}, f, nil, 0); !errors.As(err, &ke) || ke.Kind != Struct {
+		t.Errorf("GatherField of a struct = %v, want a *KindError", err)
+	}
+	if _, err := GatherField([]int{1}, f, nil, 0); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("GatherField of a []int = %v, want a *KindError", err)
+	}
+}
+
+func TestGatherInto(t *testing.T) {
+	s := columnFixture()
+	xs, err := GatherInto[int64](s, "In.X")
+	if err != nil || !reflect.DeepEqual(xs, []int64{7, 8, 9}) {
+		t.Errorf("GatherInto(In.X) = %v, %v", xs, err)
+	}
+	ss, err := GatherInto[string](s, "S")
+	if err != nil || !reflect.DeepEqual(ss, []string{"a", "bb", ""}) {
+		t.Errorf("GatherInto(S) = %q, %v", ss, err)
+	}
+	if empty, err := GatherInto[string]([]columnT{}, "S"); err != nil || len(empty) != 0 {
+		t.Errorf("GatherInto of an empty slice = %v, %v", empty, err)
+	}
+	if _, err := GatherInto[int](s, "S"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("GatherInto[int](S) = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := GatherInto[int](s, "Missing"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("GatherInto(Missing) = %v, want ErrFieldNotFound", err)
+	}
+	var ke *KindError
+	if _, err := GatherInto[int](map[int]int{}, "S"); !errors.As(err, &ke) || ke.Kind != Map {
+		t.Errorf("GatherInto of a map = %v, want a *KindError", err)
+	}
+}
+
+func BenchmarkGatherField(b *testing.B) {
+	s := make([]columnT, 1024)
+	f := columnField[columnT]("F64")
+	col := make([]float64, len(s))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GatherField(s, f, unsafe.Pointer(&col[0]), len(col))
+	}
+}
+
+func TestScatterField(t *testing.T) {
+	for _, name := range []string{"I8", "U16", "I32", "F64", "S", "P", "A", "In"} {
+		t.Run(name, func(t *testing.T) {
+			s := columnFixture()
+			col := columnValues(s, name)
+			// Reverse the column, and scatter it back.
+			for i, j := 0, col.Len()-1; i < j; i, j = i+1, j-1 {
+				a, b := col.Index(i).Interface(), col.Index(j).Interface()
+				col.Index(i).Set(reflect.ValueOf(b))
+				col.Index(j).Set(reflect.ValueOf(a))
+			}
+			want := columnFixture()
+			rv := reflect.ValueOf(want)
+			for i := range want {
+				rv.Index(i).FieldByName(name).Set(col.Index(i))
+			}
+			if err := ScatterField(s, columnField[columnT](name), col.Index(0).Addr().UnsafePointer(), col.Len()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s, want) {
+				t.Errorf("scattered %+v, want %+v", s, want)
+			}
+		})
+	}
+
+	// A prefix of the elements, and the whole element.
+	ones := []columnOne{{1}, {2}, {3}}
+	col := []int32{7, 8}
+	if err := ScatterField(ones, columnField[columnOne]("V"), unsafe.Pointer(&col[0]), 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := []columnOne{{7}, {8}, {3}}; !reflect.DeepEqual(ones, want) {
+		t.Errorf("scattered %v, want %v", ones, want)
+	}
+	if err := ScatterField(ones, columnField[columnOne]("V"), nil, 0); err != nil {
+		t.Errorf("ScatterField of no values = %v", err)
+	}
+}
+
+func TestScatterFieldErrors(t *testing.T) {
+	s := columnFixture()
+	f := columnField[columnT]("I32")
+	col := make([]int32, 4)
+	if err := ScatterField(s, f, unsafe.Pointer(&col[0]), 4); err == nil || s[0].I32 != -10 {
+		t.Errorf("ScatterField of 4 values into 3 elements = %v, leaving %d", err, s[0].I32)
+	}
+	if err := ScatterField(s, f, nil, -1); !errors.Is(err, ErrNegativeSize) {
+		t.Errorf("ScatterField of -1 values = %v, want ErrNegativeSize", err)
+	}
+	if err := ScatterField(s, columnField[columnOne]("V"), nil, 0); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("ScatterField of a foreign field = %v, want ErrFieldNotFound", err)
+	}
+	var ke *KindError
+	if err := ScatterField("s", f, nil, 0); !errors.As(err, &ke) || ke.Kind != String {
+		t.Errorf("ScatterField of a string = %v, want a *KindError", err)
+	}
+}
+
+func TestGroupByString(t *testing.T) {
+	big := string(make([]byte, 1<<10))
+	s := []columnT{{S: "a"}, {S: big[:3]}, {S: "a"}, {S: ""}, {S: big[:3]}, {S: "b"}}
+	got, err := GroupByString(s, columnField[columnT]("S"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]int{"a": {0, 2}, big[:3]: {1, 4}, "": {3}, "b": {5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupByString = %v, want %v", got, want)
+	}
+	data := uintptr(unsafe.Pointer((*StringHeader)(unsafe.Pointer(&big)).Data))
+	for k := range got {
+		if p := uintptr(unsafe.Pointer((*StringHeader)(unsafe.Pointer(&k)).Data)); len(k) > 0 && data <= p && p < data+uintptr(len(big)) {
+			t.Errorf("key %q shares memory with the slice", k)
+		}
+	}
+
+	if got, err := GroupByString([]columnT(nil), columnField[columnT]("S")); err != nil || len(got) != 0 {
+		t.Errorf("GroupByString of nil = %v, %v", got, err)
+	}
+	var ke *KindError
+	if _, err := GroupByString(s, columnField[columnT]("I32")); !errors.As(err, &ke) || ke.Kind != Int32 {
+		t.Errorf("GroupByString of an int32 field = %v, want a *KindError", err)
+	}
+	if _, err := GroupByString(s, columnField[columnOne]("V")); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("GroupByString of a foreign field = %v, want ErrFieldNotFound", err)
+	}
+	if _, err := GroupByString(columnT{}, columnField[columnT]("S")); !errors.As(err, &ke) || ke.Kind != Struct {
+		t.Errorf("GroupByString of a struct = %v, want a *KindError", err)
+	}
+}
+
+// groupByClosure groups the indices of s by the key key returns, as code
+// written for a single struct type would.
+func groupByClosure(s []columnT, key func(*columnT) string) map[string][]int {
+	groups := make(map[string][]int)
+	for i := range s {
+		k := key(&s[i])
+		groups[k] = append(groups[k], i)
+	}
+	return groups
+}
+
+func BenchmarkGroupByString(b *testing.B) {
+	s := make([]columnT, 1024)
+	for i := range s {
+		s[i].S = "group-" + strconv.Itoa(i%16)
+	}
+	f := columnField[columnT]("S")
+	b.Run("GroupByString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GroupByString(s, f)
+		}
+	})
+	b.Run("closure", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			groupByClosure(s, func(e *columnT) string { return e.S })
+		}
+	})
+}
+
+func TestGroupBy(t *testing.T) {
+	s := columnFixture()
+	s = append(s, s[0], s[2])
+
+	byI32, err := GroupBy[columnT, int32](s, "I32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int32][]int{-10: {0, 3}, 20: {1}, 30: {2, 4}}; !reflect.DeepEqual(byI32, want) {
+		t.Errorf("GroupBy I32 = %v, want %v", byI32, want)
+	}
+	byX, err := GroupBy[columnT, int64](s, "In.X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int64][]int{7: {0, 3}, 8: {1}, 9: {2, 4}}; !reflect.DeepEqual(byX, want) {
+		t.Errorf("GroupBy In.X = %v, want %v", byX, want)
+	}
+	byA, err := GroupBy[columnT, [3]byte](s, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byA) != 3 || len(byA[[3]byte{1, 2, 3}]) != 2 {
+		t.Errorf("GroupBy A = %v", byA)
+	}
+
+	if _, err := GroupBy[columnT, int64](s, "I32"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("GroupBy I32 by int64 = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := GroupBy[columnT, int](s, "Missing"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("GroupBy Missing = %v, want ErrFieldNotFound", err)
+	}
+}
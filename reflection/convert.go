@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// ConvertToInterface converts i to the non-empty interface type target, as
+// the type assertion i.(T) does for an interface type T known only at run
+// time. It reports false if i is nil, if its dynamic type does not implement
+// target, or if target has no methods, as an empty interface has no itab.
+func ConvertToInterface(i interface{}, target *InterfaceType) (IfaceHeader, bool) {
+	e := efaceOf(&i)
+	if e.Type == nil || len(target.Methods) == 0 {
+		return IfaceHeader{}, false
+	}
+	tab := getitab(target, e.Type, true)
+	if tab == nil {
+		return IfaceHeader{}, false
+	}
+	return IfaceHeader{Tab: tab, Word: e.Word}, true
+}
+
+// ConvertToInterfaceAt is like ConvertToInterface but stores the result in
+// the variable of type target pointed to by dst, which is left unchanged if
+// the conversion fails.
+func ConvertToInterfaceAt(dst unsafe.Pointer, i interface{}, target *InterfaceType) bool {
+	h, ok := ConvertToInterface(i, target)
+	if ok {
+		*(*IfaceHeader)(dst) = h
+	}
+	return ok
+}
+
+//go:linkname getitab runtime.getitab
+
+// getitab returns the itab of the interface type inter and the type typ,
+// or nil if canfail is set and typ does not implement inter.
+// Implemented in the runtime package.
+func getitab(inter *InterfaceType, typ *rtype, canfail bool) *ITab
@@ -0,0 +1,65 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"unsafe"
+)
+
+// convertWriter makes the itab of *bytes.Buffer for io.Writer, through which
+// TypeByName finds io.Writer, part of the binary.
+var convertWriter io.Writer = new(bytes.Buffer)
+
+type convertNotWriter struct{ N int }
+
+func TestConvertToInterface(t *testing.T) {
+	if !modulesSupported {
+		t.Skip("TypeByName only finds defined types through ITabs")
+	}
+	wt := TypeByName("io.Writer")
+	if wt == nil {
+		t.Fatal(`TypeByName("io.Writer") = nil`)
+	}
+	it, err := AsInterfaceType(wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	var w io.Writer
+	if !ConvertToInterfaceAt(unsafe.Pointer(&w), buf, it) {
+		t.Fatal("ConvertToInterfaceAt(*bytes.Buffer, io.Writer) = false")
+	}
+	if _, err := w.Write([]byte("hello")); err != nil || buf.String() != "hello" {
+		t.Errorf("Write through the converted interface: %v, buffer %q", err, buf.String())
+	}
+	if w != io.Writer(buf) {
+		t.Error("converted interface differs from the conversion of the compiler")
+	}
+
+	h, ok := ConvertToInterface(buf, it)
+	if want := (*IfaceHeader)(unsafe.Pointer(&w)); !ok || *want != h {
+		t.Errorf("ConvertToInterface = %v, %v, want %v", h, ok, *want)
+	}
+
+	// The failing conversions leave dst unchanged.
+	for _, v := range []interface{}{nil, 1, convertNotWriter{}, bytes.Buffer{}} {
+		if h, ok := ConvertToInterface(v, it); ok || h != (IfaceHeader{}) {
+			t.Errorf("ConvertToInterface(%T) = %v, %v, want false", v, h, ok)
+		}
+		if ConvertToInterfaceAt(unsafe.Pointer(&w), v, it) || w != io.Writer(buf) {
+			t.Errorf("ConvertToInterfaceAt(%T) succeeded or changed dst", v)
+		}
+	}
+
+	// An empty interface has no itab.
+	et, _ := AsInterfaceType(TypeFor[interface{}]())
+	if _, ok := ConvertToInterface(buf, et); ok {
+		t.Error("ConvertToInterface to interface{} succeeded")
+	}
+}
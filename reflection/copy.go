@@ -0,0 +1,74 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// CopyOptions controls CopyExportedFields.
+type CopyOptions struct {
+	// SkipTagged leaves alone the exported fields tagged `copy:"-"`.
+	SkipTagged bool
+}
+
+// CopyExportedFields copies the exported fields of the struct pointed to by
+// src into the struct pointed to by dst, leaving the unexported fields of dst
+// untouched. dst and src must hold pointers to the same struct type.
+//
+// Embedded structs are copied field by field in turn, as their exported
+// fields are promoted, so that an embedded sync.Mutex is not copied. Other
+// exported fields are copied whole with TypedMemmove, unexported state in
+// their values included.
+func CopyExportedFields(dst, src interface{}) error {
+	var o CopyOptions
+	return o.CopyExportedFields(dst, src)
+}
+
+// CopyExportedFields is like the top-level CopyExportedFields, with the
+// options of o.
+func (o *CopyOptions) CopyExportedFields(dst, src interface{}) error {
+	d, s := efaceOf(&dst), efaceOf(&src)
+	if k := kindOf(d.Type); k != Ptr {
+		return &KindError{Op: "CopyExportedFields", Kind: k}
+	}
+	if d.Type != s.Type {
+		return fmt.Errorf("%w: CopyExportedFields of %v into %s", ErrTypeMismatch, ToReflect(s.Type), d.Type)
+	}
+	st := (*PtrType)(unsafe.Pointer(d.Type)).Elem
+	if k := st.Kind(); k != Struct {
+		return &KindError{Op: "CopyExportedFields", Kind: k}
+	}
+	if d.Word == nil || s.Word == nil {
+		return errors.New("reflection: CopyExportedFields of nil pointer")
+	}
+	if d.Word != s.Word {
+		o.copy((*StructType)(unsafe.Pointer(st)), d.Word, s.Word)
+	}
+	return nil
+}
+
+func (o *CopyOptions) copy(st *StructType, dst, src unsafe.Pointer) {
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		ft := f.Type()
+		df := Add(dst, f.Offset(), "field offset is within the struct")
+		sf := Add(src, f.Offset(), "field offset is within the struct")
+		if f.Embedded() && ft.Kind() == Struct {
+			o.copy((*StructType)(unsafe.Pointer(ft)), df, sf)
+			continue
+		}
+		if !f.Name.IsExported() {
+			continue
+		}
+		if o.SkipTagged && reflect.StructTag(f.Name.Tag()).Get("copy") == "-" {
+			continue
+		}
+		TypedMemmove(ft, df, sf)
+	}
+}
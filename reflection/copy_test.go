@@ -0,0 +1,91 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type copyInner struct {
+	X int
+	y int
+}
+
+type copyOuter struct {
+	sync.Mutex
+	copyInner
+	Name   string
+	Tags   []string
+	Secret string `copy:"-"`
+	Nested copyInner
+	hidden int
+}
+
+func TestCopyExportedFields(t *testing.T) {
+	src := &copyOuter{
+		copyInner: copyInner{X: 1, y: 2},
+		Name:      "src",
+		Tags:      []string{"a"},
+		Secret:    "s",
+		Nested:    copyInner{X: 3, y: 4},
+		hidden:    5,
+	}
+	src.Lock()
+	defer src.Unlock()
+
+	dst := &copyOuter{copyInner: copyInner{y: -2}, hidden: -5}
+	if err := CopyExportedFields(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	want := copyOuter{
+		copyInner: copyInner{X: 1, y: -2},
+		Name:      "src",
+		Tags:      src.Tags,
+		Secret:    "s",
+		Nested:    copyInner{X: 3, y: 4}, // copied whole
+		hidden:    -5,
+	}
+	if dst.copyInner != want.copyInner || dst.Name != want.Name || dst.Secret != want.Secret ||
+		dst.Nested != want.Nested || dst.hidden != want.hidden || !reflect.DeepEqual(dst.Tags, want.Tags) {
+		t.Errorf("CopyExportedFields copied %+v, want %+v", dst, &want)
+	}
+	// The embedded mutex, locked in src, is left alone.
+	if !dst.TryLock() {
+		t.Error("the embedded mutex was copied")
+	}
+
+	dst = &copyOuter{Secret: "kept"}
+	o := CopyOptions{SkipTagged: true}
+	if err := o.CopyExportedFields(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Secret != "kept" || dst.Name != "src" {
+		t.Errorf("with SkipTagged: Secret, Name = %q, %q, want kept, src", dst.Secret, dst.Name)
+	}
+
+	// Copying a value onto itself is a no-op.
+	if err := CopyExportedFields(src, src); err != nil || src.Name != "src" {
+		t.Errorf("CopyExportedFields onto itself = %v, Name %q", err, src.Name)
+	}
+}
+
+func TestCopyExportedFieldsErrors(t *testing.T) {
+	var ke *KindError
+	if err := CopyExportedFields(copyOuter{}, copyOuter{}); !errors.As(err, &ke) {
+		t.Errorf("CopyExportedFields of struct values = %v, want a *KindError", err)
+	}
+	if err := CopyExportedFields(new(int), new(int)); !errors.As(err, &ke) {
+		t.Errorf("CopyExportedFields of *int = %v, want a *KindError", err)
+	}
+	if err := CopyExportedFields(&copyOuter{}, &copyInner{}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("CopyExportedFields of different types = %v, want ErrTypeMismatch", err)
+	}
+	if err := CopyExportedFields(&copyOuter{}, (*copyOuter)(nil)); err == nil {
+		t.Error("CopyExportedFields from a nil pointer succeeded")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"go/format"
+	"path"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// DeclOptions controls the output of GoDecl.
+type DeclOptions struct {
+	// Name is the name of the declared type.
+	// If empty, the name of the struct type is used.
+	Name string
+
+	// Package is the import path of the package the declaration is
+	// generated for. Types defined in it are not qualified.
+	Package string
+
+	// Imports maps the import paths of the packages referenced by the
+	// declaration to the names they are qualified with. Entries already
+	// present are honored, and GoDecl adds one for every other referenced
+	// package, so that the caller can generate the matching import block.
+	// If nil, the default package names are used and not reported.
+	Imports map[string]string
+}
+
+// GoDecl renders the Go declaration equivalent to the struct type st, as in
+//
+//	type X struct {
+//		A int `json:"a"`
+//		B *pkg.T
+//	}
+//
+// formatted by gofmt. Field types are written as type expressions resolving
+// through pointers, slices, arrays, maps, channels and functions, anonymous
+// struct and interface types are rendered inline, and defined types are
+// qualified by package name. Tags containing a backquote are written as
+// interpreted string literals.
+//
+// It returns an error when a referenced type cannot be named from the
+// generated package, such as an unexported type of another package.
+func GoDecl(st *StructType, opts DeclOptions) (string, error) {
+	name := opts.Name
+	if name == "" {
+		name = st.Name()
+	}
+	if name == "" {
+		return "", errors.New("reflection: GoDecl of unnamed struct type without DeclOptions.Name")
+	}
+	if opts.Imports == nil {
+		opts.Imports = make(map[string]string)
+	}
+	g := declWriter{opts: &opts}
+	g.buf.WriteString("type " + name + " ")
+	g.structExpr(st)
+	if g.err != nil {
+		return "", g.err
+	}
+	src, err := format.Source([]byte(g.buf.String()))
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+type declWriter struct {
+	opts *DeclOptions
+	buf  strings.Builder
+	err  error
+}
+
+// qualifier returns the name the package pkgPath is referred to by,
+// registering it in the imports if needed.
+func (g *declWriter) qualifier(pkgPath string) string {
+	if name, ok := g.opts.Imports[pkgPath]; ok {
+		return name
+	}
+	base := path.Base(pkgPath)
+	name := base
+	for n := 2; g.nameUsed(name); n++ {
+		name = base + strconv.Itoa(n)
+	}
+	g.opts.Imports[pkgPath] = name
+	return name
+}
+
+func (g *declWriter) nameUsed(name string) bool {
+	for _, used := range g.opts.Imports {
+		if used == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *declWriter) typeExpr(t *rtype) {
+	if t.HasName() {
+		pkg := t.PkgPath()
+		name := t.Name()
+		if pkg == "" || pkg == g.opts.Package {
+			g.buf.WriteString(name)
+			return
+		}
+		if !isExportedName(name) && g.err == nil {
+			g.err = errors.New("reflection: GoDecl cannot refer to unexported type " + t.String())
+		}
+		g.buf.WriteString(g.qualifier(pkg) + "." + name)
+		return
+	}
+
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		g.buf.WriteString("[" + strconv.FormatUint(uint64(at.Len), 10) + "]")
+		g.typeExpr(at.Elem)
+	case Chan:
+		ct := (*ChanType)(unsafe.Pointer(t))
+		switch ChanDir(ct.Dir) {
+		case RecvDir:
+			g.buf.WriteString("<-chan ")
+		case SendDir:
+			g.buf.WriteString("chan<- ")
+		default:
+			g.buf.WriteString("chan ")
+			// chan (<-chan T) needs parentheses to bind correctly.
+			if ct.Elem.Kind() == Chan && !ct.Elem.HasName() && ChanDir((*ChanType)(unsafe.Pointer(ct.Elem)).Dir) == RecvDir {
+				g.buf.WriteString("(")
+				g.typeExpr(ct.Elem)
+				g.buf.WriteString(")")
+				return
+			}
+		}
+		g.typeExpr(ct.Elem)
+	case Func:
+		g.buf.WriteString("func")
+		g.signature((*FuncType)(unsafe.Pointer(t)))
+	case Interface:
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		if len(it.Methods) == 0 {
+			g.buf.WriteString("interface{}")
+			return
+		}
+		g.buf.WriteString("interface {\n")
+		for _, m := range it.Methods {
+			name := t.NameOff(m.Name)
+			if !name.IsExported() && it.PkgPath.Name() != g.opts.Package && g.err == nil {
+				g.err = errors.New("reflection: GoDecl cannot declare unexported method " + name.Name() + " of " + t.String())
+			}
+			g.buf.WriteString(name.Name())
+			g.signature((*FuncType)(unsafe.Pointer(t.TypeOff(m.Typ))))
+			g.buf.WriteString("\n")
+		}
+		g.buf.WriteString("}")
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		g.buf.WriteString("map[")
+		g.typeExpr(mt.Key)
+		g.buf.WriteString("]")
+		g.typeExpr(mt.Elem)
+	case Ptr:
+		g.buf.WriteString("*")
+		g.typeExpr((*PtrType)(unsafe.Pointer(t)).Elem)
+	case Slice:
+		g.buf.WriteString("[]")
+		g.typeExpr((*SliceType)(unsafe.Pointer(t)).Elem)
+	case Struct:
+		g.structExpr((*StructType)(unsafe.Pointer(t)))
+	default:
+		// Unnamed basic types do not exist; fall back to the type string.
+		g.buf.WriteString(t.String())
+	}
+}
+
+func (g *declWriter) signature(ft *FuncType) {
+	g.buf.WriteString("(")
+	for i := 0; i < ft.NumIn(); i++ {
+		if i > 0 {
+			g.buf.WriteString(", ")
+		}
+		in := ft.In(i)
+		if i == ft.NumIn()-1 && ft.IsVariadic() {
+			g.buf.WriteString("...")
+			in = (*SliceType)(unsafe.Pointer(in)).Elem
+		}
+		g.typeExpr(in)
+	}
+	g.buf.WriteString(")")
+	switch n := ft.NumOut(); n {
+	case 0:
+	case 1:
+		g.buf.WriteString(" ")
+		g.typeExpr(ft.Out(0))
+	default:
+		g.buf.WriteString(" (")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				g.buf.WriteString(", ")
+			}
+			g.typeExpr(ft.Out(i))
+		}
+		g.buf.WriteString(")")
+	}
+}
+
+func (g *declWriter) structExpr(st *StructType) {
+	if len(st.Fields) == 0 {
+		g.buf.WriteString("struct{}")
+		return
+	}
+	g.buf.WriteString("struct {\n")
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if !f.Embedded() {
+			g.buf.WriteString(f.Name.Name() + " ")
+		}
+		g.typeExpr(f.typ)
+		if tag := f.Name.Tag(); tag != "" {
+			if strings.ContainsRune(tag, '`') {
+				g.buf.WriteString(" " + strconv.Quote(tag))
+			} else {
+				g.buf.WriteString(" `" + tag + "`")
+			}
+		}
+		g.buf.WriteString("\n")
+	}
+	g.buf.WriteString("}")
+}
+
+// isExportedName reports whether name starts with an upper-case letter.
+func isExportedName(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"go/format"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+type declFixture struct {
+	ID     int64  `json:"id,omitempty" db:"id"`
+	Quoted string "json:\"q\" re:\"a`b\\\"c\""
+	Point  struct {
+		X, Y float64 `csv:"coord"`
+		Meta struct {
+			Tags []string
+		}
+	}
+	Empty   struct{}
+	R       io.Reader
+	Local   interface{ Close() error }
+	Any     interface{}
+	At      *time.Time
+	Fn      func(string, ...int) (bool, error)
+	In      <-chan chan<- int
+	Nested  chan (<-chan int)
+	Grid    [2][3]uint8
+	ByName  map[string][]*declFixture
+	private uintptr
+	declEmbedded
+}
+
+type declEmbedded struct{ N int }
+
+// declPkg is the import path of this package, for the declarations of its
+// unexported types to be generated for it.
+const declPkg = "github.com/zchee/go-darkness/reflection"
+
+func TestGoDeclGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		st   *StructType
+		opts DeclOptions
+	}{
+		{"decl_fixture", (*StructType)(unsafe.Pointer(TypeFor[declFixture]())), DeclOptions{Package: declPkg}},
+		{"decl_fixture_renamed", (*StructType)(unsafe.Pointer(TypeFor[declFixture]())), DeclOptions{
+			Name:    "Fixture",
+			Package: declPkg,
+			Imports: map[string]string{"io": "stdio", "time": "stdtime"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GoDecl(tt.st, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, err := format.Source([]byte(got))
+			if err != nil {
+				t.Fatalf("output does not parse: %v\n%s", err, got)
+			}
+			if string(src) != got {
+				t.Errorf("output is not gofmt-formatted:\n%s", got)
+			}
+			checkGolden(t, tt.name, []byte(got))
+		})
+	}
+}
+
+func TestGoDeclImports(t *testing.T) {
+	imports := map[string]string{"io": "io"}
+	opts := DeclOptions{Package: declPkg, Imports: imports}
+	if _, err := GoDecl((*StructType)(unsafe.Pointer(TypeFor[declFixture]())), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(imports) != 2 || imports["io"] != "io" || imports["time"] != "time" {
+		t.Errorf("Imports = %v, want io and time", imports)
+	}
+}
+
+func TestGoDeclErrors(t *testing.T) {
+	if _, err := GoDecl((*StructType)(unsafe.Pointer(TypeFor[struct{ A int }]())), DeclOptions{}); err == nil {
+		t.Error("GoDecl of an unnamed struct without a name: no error")
+	}
+	type hidden struct{ t time.Time }
+	type refersHidden struct{ H hidden }
+	_, err := GoDecl((*StructType)(unsafe.Pointer(TypeFor[refersHidden]())), DeclOptions{Package: "other.example/p"})
+	if err == nil || !strings.Contains(err.Error(), "unexported type") {
+		t.Errorf("GoDecl referring to an unexported type of another package: %v", err)
+	}
+}
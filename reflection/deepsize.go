@@ -0,0 +1,171 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// DeepSize returns the number of bytes of the value held by v and of the
+// memory reachable from it: the values that pointers point to, the len
+// elements of slices, the bytes of strings, the memory of maps, with their
+// keys and elements, and the values held by interfaces, each counted with
+// what is reachable from it in turn. The values and fields of types without
+// pointers, as the garbage collector metadata of their types tells, are
+// skipped without being walked.
+//
+// Memory reachable along several paths, or through a cycle, is counted
+// once: a pointed-to value by its type and address, a slice by its element
+// type, address and length, a string by its address and length, and a map
+// by its address. A pointer into a value counted through another pointer,
+// or a slice sharing part of the backing array of another, is counted
+// again. Channels, functions and unsafe pointers only count as their own
+// word: the memory they reference is not followed. The capacity of slices
+// beyond their length, and the rounding of allocations to size classes, are
+// not counted.
+//
+// DeepSize returns an error wrapping ErrUnsupported, with the size of the
+// rest, if v holds a map and the layout of maps of the toolchain is not
+// mirrored by this package. The value must not be modified concurrently.
+func DeepSize(v interface{}) (uintptr, error) {
+	e := efaceOf(&v)
+	if e.Type == nil {
+		return 0, nil
+	}
+	var s sizer
+	s.size = e.Type.size
+	if ifaceIndir(e.Type) {
+		s.once(e.Type, e.Word, 1)
+		s.walk(e.Type, e.Word)
+	} else {
+		s.walk(e.Type, unsafe.Pointer(&e.Word))
+	}
+	runtime.KeepAlive(v)
+	return s.size, s.err
+}
+
+// sizeKey identifies memory counted by DeepSize: n values of type t at p.
+type sizeKey struct {
+	t *rtype
+	p unsafe.Pointer
+	n int
+}
+
+// sizer sums the memory reachable from a value for DeepSize.
+type sizer struct {
+	seen map[sizeKey]bool // memory counted
+	size uintptr
+	err  error
+}
+
+// once reports whether the n values of type t at p are not yet counted,
+// and marks them counted.
+func (s *sizer) once(t *rtype, p unsafe.Pointer, n int) bool {
+	k := sizeKey{t, p, n}
+	if s.seen[k] {
+		return false
+	}
+	if s.seen == nil {
+		s.seen = make(map[sizeKey]bool)
+	}
+	s.seen[k] = true
+	return true
+}
+
+// walk counts the memory reachable from the value of type t at p, but not
+// the value itself.
+func (s *sizer) walk(t *rtype, p unsafe.Pointer) {
+	if t.ptrdata == 0 {
+		return
+	}
+	switch t.Kind() {
+	case Ptr:
+		et := (*PtrType)(unsafe.Pointer(t)).Elem
+		if q := *(*unsafe.Pointer)(p); q != nil && s.once(et, q, 1) {
+			s.size += et.size
+			s.walk(et, q)
+		}
+	case String:
+		hdr := (*StringHeader)(p)
+		if hdr.Len > 0 && s.once(t, hdr.Data, hdr.Len) {
+			s.size += uintptr(hdr.Len)
+		}
+	case Slice:
+		et := (*SliceType)(unsafe.Pointer(t)).Elem
+		hdr := (*SliceHeader)(p)
+		if hdr.Len == 0 || !s.once(et, hdr.Data, hdr.Len) {
+			return
+		}
+		s.size += uintptr(hdr.Len) * et.size
+		if et.ptrdata != 0 {
+			for i := 0; i < hdr.Len; i++ {
+				s.walk(et, Add(hdr.Data, uintptr(i)*et.size, "i < len"))
+			}
+		}
+	case Interface:
+		var e InterfaceHeader
+		if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+			e = *(*InterfaceHeader)(p)
+		} else if tab := (*IfaceHeader)(p).Tab; tab != nil {
+			e = InterfaceHeader{Type: tab.Type, Word: (*IfaceHeader)(p).Word}
+		}
+		switch {
+		case e.Type == nil:
+		case !ifaceIndir(e.Type):
+			s.walk(e.Type, unsafe.Pointer(&e.Word))
+		case s.once(e.Type, e.Word, 1):
+			s.size += e.Type.size
+			s.walk(e.Type, e.Word)
+		}
+	case Map:
+		s.walkMap((*MapType)(unsafe.Pointer(t)), *(*unsafe.Pointer)(p))
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			s.walk(at.Elem, Add(p, i*at.Elem.size, "i < len"))
+		}
+	case Struct:
+		for _, f := range (*StructType)(unsafe.Pointer(t)).Fields {
+			if f.typ.ptrdata != 0 {
+				s.walk(f.typ, Add(p, f.Offset(), "the field lies within the struct"))
+			}
+		}
+	}
+}
+
+// walkMap counts the memory of the map h of type mt, and the memory
+// reachable from its keys and elements.
+func (s *sizer) walkMap(mt *MapType, h unsafe.Pointer) {
+	if h == nil || !s.once(&mt.rtype, h, 1) {
+		return
+	}
+	n, ok := mapMemory(mt, h)
+	if !ok {
+		if s.err == nil {
+			s.err = fmt.Errorf("%w: DeepSize of %s", ErrUnsupported, &mt.rtype)
+		}
+		return
+	}
+	s.size += n
+	// The runtime stores keys and elements larger than maxFastElem bytes
+	// out of the groups.
+	indirKey, indirElem := mt.Key.size > maxFastElem, mt.Elem.size > maxFastElem
+	if mt.Key.ptrdata == 0 && mt.Elem.ptrdata == 0 && !indirKey && !indirElem {
+		return
+	}
+	mapRange(mt, h, func(key, elem unsafe.Pointer) bool {
+		if indirKey {
+			s.size += mt.Key.size
+		}
+		if indirElem {
+			s.size += mt.Elem.size
+		}
+		s.walk(mt.Key, key)
+		s.walk(mt.Elem, elem)
+		return true
+	})
+}
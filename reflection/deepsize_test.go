@@ -0,0 +1,138 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+type sizeNode struct {
+	Next *sizeNode
+	V    int64
+}
+
+type sizeMixed struct {
+	S   string
+	B   []byte
+	P   *[4]int64
+	I   interface{}
+	E   error
+	C   chan int
+	F   func()
+	U   unsafe.Pointer
+	Arr [2]*int32
+}
+
+func TestDeepSize(t *testing.T) {
+	const (
+		word  = unsafe.Sizeof(uintptr(0))
+		str   = unsafe.Sizeof("")
+		slice = unsafe.Sizeof([]byte(nil))
+	)
+	cycle := &sizeNode{V: 1}
+	cycle.Next = &sizeNode{Next: cycle}
+	s := "abc"
+	arr := new([4]int64)
+	i32 := new(int32)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want uintptr
+	}{
+		{"nil", nil, 0},
+		{"int", 1, word},
+		{"string", "hello", str + 5},
+		{"empty string", "", str},
+		{"pointer", new(int64), word + 8},
+		{"nil pointer", (*int64)(nil), word},
+		{"slice", []int64{1, 2, 3}, slice + 24},
+		{"empty slice", []int64{}, slice},
+		{"shared string", []string{s, s}, slice + 2*str + 3},
+		{"substring", []string{s, s[:2]}, slice + 2*str + 3 + 2},
+		{"cycle", cycle, word + 2*unsafe.Sizeof(sizeNode{})},
+		{"no pointers", [3]sizeNode{}, 3 * unsafe.Sizeof(sizeNode{})},
+		{"array of shared pointers", [2]*int32{i32, i32}, 2*word + 4},
+		{"mixed", &sizeMixed{
+			S:   s,
+			B:   []byte("xy"),
+			P:   arr,
+			I:   *arr,
+			E:   errors.New("e"),
+			C:   make(chan int, 100),
+			F:   func() {},
+			U:   unsafe.Pointer(arr),
+			Arr: [2]*int32{i32, nil},
+		}, word + unsafe.Sizeof(sizeMixed{}) + 3 + 2 + 32 + 32 + str + 1 + 4},
+		{"interface holding a pointer", []interface{}{arr, arr}, slice + 2*unsafe.Sizeof(interface{}(nil)) + 32},
+	}
+	for _, tt := range tests {
+		got, err := DeepSize(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DeepSize = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestDeepSizeMap(t *testing.T) {
+	s := "0123456789"
+	empty, err := DeepSize(map[int64]string{1: ""})
+	if safeMode {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("DeepSize of a map = %v, want ErrUnsupported", err)
+		}
+		// The rest is still counted.
+		if n, _ := DeepSize(struct {
+			M map[int]int
+			S string
+		}{nil, s}); n != unsafe.Sizeof(map[int]int(nil))+unsafe.Sizeof("")+10 {
+			t.Errorf("DeepSize of a nil map and a string = %d", n)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := DeepSize(map[int64]string{1: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full-empty != uintptr(len(s)) {
+		t.Errorf("DeepSize of the string element = %d, want %d", full-empty, len(s))
+	}
+
+	m := make(map[int64]int64)
+	for i := int64(0); i < 100; i++ {
+		m[i] = i
+	}
+	size, err := DeepSize(m)
+	if err != nil || size < 100*16 {
+		t.Errorf("DeepSize of 100 entries = %d, %v", size, err)
+	}
+	twice, err := DeepSize([2]map[int64]int64{m, m})
+	if err != nil || twice != size+unsafe.Sizeof(m) {
+		t.Errorf("DeepSize of a map reached twice = %d, %v, want %d", twice, err, size+unsafe.Sizeof(m))
+	}
+
+	// Elements larger than maxFastElem are stored out of the groups.
+	small, _ := DeepSize(map[int64]mapBig{})
+	big, err := DeepSize(map[int64]mapBig{1: {}})
+	if err != nil || big-small < unsafe.Sizeof(mapBig{}) {
+		t.Errorf("DeepSize of a large element = %d, %v, want at least %d more than %d", big, err, unsafe.Sizeof(mapBig{}), small)
+	}
+}
+
+func BenchmarkDeepSize(b *testing.B) {
+	v := make([]*sizeNode, 100)
+	for i := range v {
+		v[i] = &sizeNode{V: int64(i)}
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DeepSize(v)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrTooDeep is returned by DerefAll for a chain of more than maxDerefDepth
+// pointers, such as a pointer of a recursive pointer type pointing to
+// itself.
+var ErrTooDeep = errors.New("reflection: pointer chain too deep")
+
+// maxDerefDepth is the number of pointers DerefAll follows at most.
+const maxDerefDepth = 64
+
+// Deref loads the pointer of type t, a pointer type, stored at p, and
+// returns the element type of t and the loaded pointer, to the value of that
+// type. A pointer to a value of size zero is not nil, and is returned as
+// loaded, though it may be shared with other such values.
+//
+// Deref returns a *KindError if t is not a pointer type, and, for a nil
+// pointer, the element type with an error wrapping ErrNilPointer, which
+// callers can tell from the others with errors.Is.
+func Deref(t *rtype, p unsafe.Pointer) (*rtype, unsafe.Pointer, error) {
+	if k := t.Kind(); k != Ptr {
+		return nil, nil, &KindError{Op: "Deref", Kind: k}
+	}
+	et := (*PtrType)(unsafe.Pointer(t)).Elem
+	q := *(*unsafe.Pointer)(p)
+	if q == nil {
+		return et, nil, fmt.Errorf("%w: Deref of %s", ErrNilPointer, t)
+	}
+	return et, q, nil
+}
+
+// DerefAll is like Deref, but follows the pointers of type t at p as long as
+// they point to pointers, as for a **T, and returns the first type that is
+// not a pointer type and the pointer to the value of that type. For t not a
+// pointer type, it returns t and p.
+//
+// For a nil pointer along the chain, DerefAll returns its element type and
+// an error wrapping ErrNilPointer that tells its depth, the number of
+// pointers followed before it. It returns an error wrapping ErrTooDeep
+// after 64 pointers, which only a recursive pointer type, such as
+// type P *P, allows.
+func DerefAll(t *rtype, p unsafe.Pointer) (*rtype, unsafe.Pointer, error) {
+	for depth := 0; t.Kind() == Ptr; depth++ {
+		if depth == maxDerefDepth {
+			return nil, nil, fmt.Errorf("%w: DerefAll of %s", ErrTooDeep, t)
+		}
+		et := (*PtrType)(unsafe.Pointer(t)).Elem
+		q := *(*unsafe.Pointer)(p)
+		if q == nil {
+			return et, nil, fmt.Errorf("%w: DerefAll of %s at depth %d", ErrNilPointer, t, depth)
+		}
+		t, p = et, q
+	}
+	return t, p, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type derefP *derefP
+
+func TestDeref(t *testing.T) {
+	x := 5
+	px := &x
+	et, q, err := Deref(TypeFor[*int](), unsafe.Pointer(&px))
+	if err != nil || et != TypeFor[int]() || q != unsafe.Pointer(&x) {
+		t.Errorf("Deref = %v, %p, %v, want int, %p", et, q, err, &x)
+	}
+
+	px = nil
+	et, q, err = Deref(TypeFor[*int](), unsafe.Pointer(&px))
+	if !errors.Is(err, ErrNilPointer) || et != TypeFor[int]() || q != nil {
+		t.Errorf("Deref of nil = %v, %p, %v, want int and ErrNilPointer", et, q, err)
+	}
+
+	z := new(struct{})
+	if _, q, err := Deref(TypeFor[*struct{}](), unsafe.Pointer(&z)); err != nil || q != unsafe.Pointer(z) {
+		t.Errorf("Deref of a pointer to a zero-size value = %p, %v", q, err)
+	}
+
+	var ke *KindError
+	if _, _, err := Deref(TypeFor[int](), unsafe.Pointer(&x)); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("Deref of an int = %v, want a *KindError", err)
+	}
+}
+
+func TestDerefAll(t *testing.T) {
+	x := 5
+	px := &x
+	ppx := &px
+	pppx := &ppx
+	root := unsafe.Pointer(&pppx)
+	tp := TypeFor[***int]()
+
+	et, q, err := DerefAll(tp, root)
+	if err != nil || et != TypeFor[int]() || q != unsafe.Pointer(&x) {
+		t.Errorf("DerefAll = %v, %p, %v, want int, %p", et, q, err, &x)
+	}
+
+	// A nil pointer at each depth.
+	nils := []struct {
+		clear func()
+		want  *rtype
+	}{
+		{func() { pppx = nil }, TypeFor[**int]()},
+		{func() { ppx = nil }, TypeFor[*int]()},
+		{func() { px = nil }, TypeFor[int]()},
+	}
+	for depth, tt := range nils {
+		pppx, ppx, px = &ppx, &px, &x
+		tt.clear()
+		et, q, err := DerefAll(tp, root)
+		if !errors.Is(err, ErrNilPointer) || et != tt.want || q != nil {
+			t.Errorf("DerefAll with nil at depth %d = %v, %p, %v, want %v and ErrNilPointer", depth, et, q, err, tt.want)
+		}
+		if want := "at depth " + strconv.Itoa(depth); err == nil || !strings.HasSuffix(err.Error(), want) {
+			t.Errorf("DerefAll with nil at depth %d: %v, want it to end in %q", depth, err, want)
+		}
+	}
+
+	if et, q, err := DerefAll(TypeFor[int](), unsafe.Pointer(&x)); err != nil || et != TypeFor[int]() || q != unsafe.Pointer(&x) {
+		t.Errorf("DerefAll of an int = %v, %p, %v", et, q, err)
+	}
+}
+
+func TestDerefAllRecursive(t *testing.T) {
+	tp := TypeFor[derefP]()
+
+	var self derefP
+	self = &self
+	if _, _, err := DerefAll(tp, unsafe.Pointer(&self)); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("DerefAll of a pointer to itself = %v, want ErrTooDeep", err)
+	}
+
+	// A chain ending in nil reports the depth of the nil pointer, unless
+	// DerefAll gives up first, after maxDerefDepth pointers.
+	for _, n := range []int{3, maxDerefDepth - 1, maxDerefDepth} {
+		var head derefP
+		for i := 0; i < n; i++ {
+			p := head
+			head = &p
+		}
+		_, _, err := DerefAll(tp, unsafe.Pointer(&head))
+		if n < maxDerefDepth {
+			if !errors.Is(err, ErrNilPointer) || !strings.HasSuffix(err.Error(), "at depth "+strconv.Itoa(n)) {
+				t.Errorf("DerefAll of %d pointers = %v, want ErrNilPointer at depth %d", n, err, n)
+			}
+		} else if !errors.Is(err, ErrTooDeep) {
+			t.Errorf("DerefAll of %d pointers = %v, want ErrTooDeep", n, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// DOTOptions controls the output of WriteDOT.
+type DOTOptions struct {
+	// MaxDepth limits how far from the roots types are expanded.
+	// Zero means unlimited.
+	MaxDepth int
+
+	// CollapseStdlib emits types defined in the standard library as
+	// nodes without following their fields and elements.
+	CollapseStdlib bool
+
+	// ClusterByPackage groups the nodes of defined types into one
+	// subgraph per package path.
+	ClusterByPackage bool
+
+	// InterfaceMethods follows the parameter and result types of the
+	// methods of interface types.
+	InterfaceMethods bool
+}
+
+// WriteDOT writes to w a Graphviz DOT digraph of the types reachable from
+// roots through struct fields, array, slice, pointer, channel and map
+// elements and keys, and optionally interface method signatures.
+//
+// Nodes are labeled by type string and each distinct type appears once,
+// so cycles terminate. Edges are labeled by field name, or by "elem", "key",
+// or the method name for other relationships. Types are walked breadth
+// first, so that MaxDepth counts the shortest path from a root to a type.
+func WriteDOT(w io.Writer, roots []*rtype, opts DOTOptions) error {
+	g := dotGraph{opts: &opts, ids: make(map[*rtype]int)}
+	for _, t := range roots {
+		g.node(t, 0)
+	}
+	for len(g.queue) > 0 {
+		q := g.queue[0]
+		g.queue = g.queue[1:]
+		g.expand(q.t, q.depth)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph types {\n\tnode [shape=box];\n")
+	if opts.ClusterByPackage {
+		g.writeClusters(bw)
+	} else {
+		for _, t := range g.nodes {
+			g.writeNode(bw, "\t", t)
+		}
+	}
+	for _, e := range g.edges {
+		bw.WriteString("\tt" + strconv.Itoa(e.from) + " -> t" + strconv.Itoa(e.to) +
+			" [label=" + strconv.Quote(e.label) + "];\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+type dotEdge struct {
+	from, to int
+	label    string
+}
+
+// dotPending is a node waiting in the queue of dotGraph to be expanded.
+type dotPending struct {
+	t     *rtype
+	depth int
+}
+
+type dotGraph struct {
+	opts  *DOTOptions
+	ids   map[*rtype]int
+	nodes []*rtype
+	edges []dotEdge
+	queue []dotPending
+}
+
+// node returns the node id of t, adding t to the graph, and queueing it for
+// expansion, the first time it is reached. As the queue is walked breadth
+// first, that is at the smallest depth t is reachable at.
+func (g *dotGraph) node(t *rtype, depth int) int {
+	if id, ok := g.ids[t]; ok {
+		return id
+	}
+	id := len(g.nodes)
+	g.ids[t] = id
+	g.nodes = append(g.nodes, t)
+	g.queue = append(g.queue, dotPending{t, depth})
+	return id
+}
+
+// expand adds the edges from t, at the given depth, to the types it refers to.
+func (g *dotGraph) expand(t *rtype, depth int) {
+	if g.opts.MaxDepth > 0 && depth >= g.opts.MaxDepth {
+		return
+	}
+	if g.opts.CollapseStdlib && isStdlib(t.PkgPath()) {
+		return
+	}
+	id := g.ids[t]
+	edge := func(label string, to *rtype) {
+		g.edges = append(g.edges, dotEdge{from: id, to: g.node(to, depth+1), label: label})
+	}
+	switch t.Kind() {
+	case Array:
+		edge("elem", (*ArrayType)(unsafe.Pointer(t)).Elem)
+	case Chan:
+		edge("elem", (*ChanType)(unsafe.Pointer(t)).Elem)
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		edge("key", mt.Key)
+		edge("elem", mt.Elem)
+	case Ptr:
+		edge("elem", (*PtrType)(unsafe.Pointer(t)).Elem)
+	case Slice:
+		edge("elem", (*SliceType)(unsafe.Pointer(t)).Elem)
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			edge(st.Fields[i].Name.Name(), st.Fields[i].typ)
+		}
+	case Interface:
+		if !g.opts.InterfaceMethods {
+			break
+		}
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		for _, m := range it.Methods {
+			name := t.NameOff(m.Name).Name()
+			for _, p := range (*FuncType)(unsafe.Pointer(t.TypeOff(m.Typ))).params() {
+				edge(name, p)
+			}
+		}
+	}
+}
+
+func (g *dotGraph) writeNode(bw *bufio.Writer, indent string, t *rtype) {
+	bw.WriteString(indent + "t" + strconv.Itoa(g.ids[t]) + " [label=" + strconv.Quote(t.String()) + "];\n")
+}
+
+func (g *dotGraph) writeClusters(bw *bufio.Writer) {
+	var pkgs []string
+	byPkg := make(map[string][]*rtype)
+	for _, t := range g.nodes {
+		pkg := t.PkgPath()
+		if _, ok := byPkg[pkg]; !ok {
+			pkgs = append(pkgs, pkg)
+		}
+		byPkg[pkg] = append(byPkg[pkg], t)
+	}
+	cluster := 0
+	for _, pkg := range pkgs {
+		if pkg == "" {
+			for _, t := range byPkg[pkg] {
+				g.writeNode(bw, "\t", t)
+			}
+			continue
+		}
+		bw.WriteString("\tsubgraph cluster_" + strconv.Itoa(cluster) + " {\n\t\tlabel=" + strconv.Quote(pkg) + ";\n")
+		for _, t := range byPkg[pkg] {
+			g.writeNode(bw, "\t\t", t)
+		}
+		bw.WriteString("\t}\n")
+		cluster++
+	}
+}
+
+// isStdlib reports whether the package path belongs to the standard library,
+// whose import paths have no dot in their first element. The main package,
+// whose types have the package path "main", is not part of it.
+func isStdlib(pkgPath string) bool {
+	if pkgPath == "" || pkgPath == "main" {
+		return false
+	}
+	first := pkgPath
+	if i := strings.IndexByte(first, '/'); i >= 0 {
+		first = first[:i]
+	}
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,194 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dotTree struct {
+	Root  *dotLeaf
+	Leafs map[string][]dotLeaf
+	At    time.Time
+}
+
+type dotLeaf struct {
+	Parent *dotTree
+	R      io.Reader
+}
+
+var (
+	dotNodeRE = regexp.MustCompile(`^\s*t(\d+) \[label=("(?:[^"\\]|\\.)*")\];$`)
+	dotEdgeRE = regexp.MustCompile(`^\s*t(\d+) -> t(\d+) \[label=("(?:[^"\\]|\\.)*")\];$`)
+)
+
+// parseDOT returns the labels of the nodes of the graph written by WriteDOT,
+// and its edges as "from -label-> to" with the node labels.
+func parseDOT(t *testing.T, out string) (nodes map[string]bool, edges map[string]bool) {
+	t.Helper()
+	if !strings.HasPrefix(out, "digraph types {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Fatalf("not a digraph:\n%s", out)
+	}
+	labels := make(map[string]string)
+	nodes, edges = make(map[string]bool), make(map[string]bool)
+	unquote := func(s string) string {
+		u, err := strconv.Unquote(s)
+		if err != nil {
+			t.Fatalf("label %s: %v", s, err)
+		}
+		return u
+	}
+	lines := strings.Split(out, "\n")
+	for _, line := range lines {
+		if m := dotNodeRE.FindStringSubmatch(line); m != nil {
+			if _, dup := labels[m[1]]; dup {
+				t.Errorf("node t%s written twice", m[1])
+			}
+			labels[m[1]] = unquote(m[2])
+			nodes[labels[m[1]]] = true
+		}
+	}
+	for _, line := range lines {
+		if m := dotEdgeRE.FindStringSubmatch(line); m != nil {
+			from, ok1 := labels[m[1]]
+			to, ok2 := labels[m[2]]
+			if !ok1 || !ok2 {
+				t.Errorf("edge %q between unknown nodes", line)
+			}
+			edges[from+" -"+unquote(m[3])+"-> "+to] = true
+		}
+	}
+	return nodes, edges
+}
+
+func TestWriteDOT(t *testing.T) {
+	tree := TypeFor[dotTree]()
+	var b strings.Builder
+	if err := WriteDOT(&b, []*rtype{tree}, DOTOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	nodes, edges := parseDOT(t, b.String())
+	for _, n := range []string{
+		"reflection.dotTree", "*reflection.dotLeaf", "reflection.dotLeaf",
+		"*reflection.dotTree", "map[string][]reflection.dotLeaf", "[]reflection.dotLeaf",
+		"string", "io.Reader", "time.Time",
+	} {
+		if !nodes[n] {
+			t.Errorf("missing node %s", n)
+		}
+	}
+	for _, e := range []string{
+		"reflection.dotTree -Root-> *reflection.dotLeaf",
+		"*reflection.dotLeaf -elem-> reflection.dotLeaf",
+		"reflection.dotLeaf -Parent-> *reflection.dotTree",
+		"*reflection.dotTree -elem-> reflection.dotTree", // the cycle closes
+		"reflection.dotTree -Leafs-> map[string][]reflection.dotLeaf",
+		"map[string][]reflection.dotLeaf -key-> string",
+		"map[string][]reflection.dotLeaf -elem-> []reflection.dotLeaf",
+		"[]reflection.dotLeaf -elem-> reflection.dotLeaf",
+		"reflection.dotLeaf -R-> io.Reader",
+		"reflection.dotTree -At-> time.Time",
+	} {
+		if !edges[e] {
+			t.Errorf("missing edge %s", e)
+		}
+	}
+	// Interface methods are not followed by default.
+	for e := range edges {
+		if strings.HasPrefix(e, "io.Reader ") {
+			t.Errorf("unexpected edge %s", e)
+		}
+	}
+}
+
+func TestWriteDOTOptions(t *testing.T) {
+	tree := TypeFor[dotTree]()
+	var b strings.Builder
+	if err := WriteDOT(&b, []*rtype{tree}, DOTOptions{InterfaceMethods: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, edges := parseDOT(t, b.String())
+	if !edges["io.Reader -Read-> []uint8"] {
+		t.Errorf("InterfaceMethods: missing edge io.Reader -Read-> []uint8 in\n%s", b.String())
+	}
+	if !edges["time.Time -loc-> *time.Location"] {
+		t.Errorf("missing edge time.Time -loc-> *time.Location in\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteDOT(&b, []*rtype{tree}, DOTOptions{CollapseStdlib: true, InterfaceMethods: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, edges = parseDOT(t, b.String())
+	for e := range edges {
+		if strings.HasPrefix(e, "time.Time ") || strings.HasPrefix(e, "io.Reader ") {
+			t.Errorf("CollapseStdlib: unexpected edge %s", e)
+		}
+	}
+
+	b.Reset()
+	if err := WriteDOT(&b, []*rtype{tree}, DOTOptions{MaxDepth: 1}); err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ := parseDOT(t, b.String())
+	if nodes["reflection.dotLeaf"] || !nodes["*reflection.dotLeaf"] {
+		t.Errorf("MaxDepth 1: nodes %v", nodes)
+	}
+
+	b.Reset()
+	if err := WriteDOT(&b, []*rtype{tree}, DOTOptions{ClusterByPackage: true, CollapseStdlib: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, pkg := range []string{"github.com/zchee/go-darkness/reflection", "time", "io"} {
+		if !strings.Contains(out, "label="+strconv.Quote(pkg)+";") {
+			t.Errorf("ClusterByPackage: no cluster for %s in\n%s", pkg, out)
+		}
+	}
+	if nodes, _ := parseDOT(t, out); !nodes["reflection.dotTree"] {
+		t.Errorf("ClusterByPackage: missing node reflection.dotTree")
+	}
+}
+
+type dotDepthRoot struct {
+	A *dotDepthA
+	B dotDepthB
+}
+
+type dotDepthA struct{ B dotDepthB }
+
+type dotDepthB struct{ C int }
+
+func TestWriteDOTMaxDepthShortestPath(t *testing.T) {
+	// dotDepthB is reached at depth 3 through A before depth 1 through B in
+	// field order, and must be expanded at depth 1.
+	var b strings.Builder
+	if err := WriteDOT(&b, []*rtype{TypeFor[dotDepthRoot]()}, DOTOptions{MaxDepth: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, edges := parseDOT(t, b.String()); !edges["reflection.dotDepthB -C-> int"] {
+		t.Errorf("MaxDepth 3: missing edge reflection.dotDepthB -C-> int in\n%s", b.String())
+	}
+}
+
+func TestIsStdlib(t *testing.T) {
+	for path, want := range map[string]bool{
+		"":                  false,
+		"main":              false,
+		"time":              true,
+		"encoding/json":     true,
+		"github.com/x/y":    false,
+		"golang.org/x/sync": false,
+	} {
+		if got := isStdlib(path); got != want {
+			t.Errorf("isStdlib(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
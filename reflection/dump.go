@@ -0,0 +1,209 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// DumpConfig controls the output of Dump.
+type DumpConfig struct {
+	// MaxDepth limits how deep element and field types are described.
+	// Zero means unlimited.
+	MaxDepth int
+
+	// MaxWidth truncates lines longer than MaxWidth bytes.
+	// Zero means unlimited.
+	MaxWidth int
+
+	// Methods includes the method set of each defined type.
+	Methods bool
+
+	// Unexported includes unexported methods in method sets.
+	Unexported bool
+
+	// PkgPaths resolves and prints the package path of defined types
+	// and of unexported struct fields.
+	PkgPaths bool
+}
+
+// DefaultDumpConfig is the configuration used by Dump.
+var DefaultDumpConfig = DumpConfig{
+	MaxDepth: 8,
+	MaxWidth: 160,
+	Methods:  true,
+}
+
+// Dump returns a readable multi-line description of the type t using
+// DefaultDumpConfig: its kind, size, alignment and tflag bits and, for
+// composite types, the description of their element, key and field types,
+// indented by nesting level.
+//
+// A type that is already being described higher up the graph is printed once
+// more with a "(cycle)" marker instead of being expanded again.
+func Dump(t *rtype) string {
+	return DefaultDumpConfig.Dump(t)
+}
+
+// Dump is like the top-level Dump function but uses the configuration c.
+func (c *DumpConfig) Dump(t *rtype) string {
+	d := dumper{cfg: c, stack: make(map[*rtype]bool)}
+	d.dump("", t, 0)
+	return d.buf.String()
+}
+
+type dumper struct {
+	cfg     *DumpConfig
+	buf     strings.Builder
+	stack   map[*rtype]bool
+	scratch []byte // line being built
+}
+
+func (d *dumper) line(depth int, s string) {
+	d.scratch = append(d.scratch[:0], s...)
+	d.flush(depth)
+}
+
+// flush writes the line in d.scratch, indented by depth.
+func (d *dumper) flush(depth int) {
+	indent, s := 2*depth, d.scratch
+	ellipsis := false
+	if w := d.cfg.MaxWidth; w > 0 && indent+len(s) > w {
+		if w > 3 {
+			w -= 3
+			ellipsis = true
+		}
+		if indent > w {
+			indent = w
+		}
+		s = s[:w-indent]
+	}
+	for i := 0; i < indent; i++ {
+		d.buf.WriteByte(' ')
+	}
+	d.buf.Write(s)
+	if ellipsis {
+		d.buf.WriteString("...")
+	}
+	d.buf.WriteByte('\n')
+}
+
+// summary returns a one-line description of t.
+func summary(t *rtype) string {
+	return t.String() + ": kind=" + t.Kind().String() +
+		" size=" + strconv.FormatUint(uint64(t.size), 10) +
+		" align=" + strconv.Itoa(int(t.align)) +
+		" tflag=" + t.tflag.String()
+}
+
+func (d *dumper) dump(label string, t *rtype, depth int) {
+	if t == nil {
+		d.line(depth, label+"<nil>")
+		return
+	}
+	s := label + summary(t)
+	if d.stack[t] {
+		d.line(depth, s+" (cycle)")
+		return
+	}
+	if d.cfg.MaxDepth > 0 && depth >= d.cfg.MaxDepth {
+		d.line(depth, s+" (max depth)")
+		return
+	}
+	d.line(depth, s)
+	d.stack[t] = true
+	defer delete(d.stack, t)
+
+	if d.cfg.PkgPaths {
+		if pkg := t.PkgPath(); pkg != "" {
+			d.line(depth+1, "pkgPath "+strconv.Quote(pkg))
+		}
+	}
+	if d.cfg.Methods {
+		d.methods(t, depth+1)
+	}
+
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		d.line(depth+1, "len "+strconv.FormatUint(uint64(at.Len), 10))
+		d.dump("elem ", at.Elem, depth+1)
+	case Chan:
+		d.dump("elem ", (*ChanType)(unsafe.Pointer(t)).Elem, depth+1)
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		d.dump("key ", mt.Key, depth+1)
+		d.dump("elem ", mt.Elem, depth+1)
+	case Ptr:
+		d.dump("elem ", (*PtrType)(unsafe.Pointer(t)).Elem, depth+1)
+	case Slice:
+		d.dump("elem ", (*SliceType)(unsafe.Pointer(t)).Elem, depth+1)
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			f := &st.Fields[i]
+			b := append(d.scratch[:0], "field "...)
+			b = strconv.AppendInt(b, int64(i), 10)
+			b = append(b, ' ')
+			b = f.Name.AppendTo(b)
+			b = append(b, " offset="...)
+			b = strconv.AppendUint(b, uint64(f.Offset()), 10)
+			if f.Embedded() {
+				b = append(b, " embedded"...)
+			}
+			if f.Name.TagLen() != 0 {
+				b = append(b, " tag="...)
+				b = strconv.AppendQuote(b, f.Name.Tag())
+			}
+			if d.cfg.PkgPaths && !f.Name.IsExported() {
+				b = append(b, " pkgPath="...)
+				b = strconv.AppendQuote(b, st.PkgPath.Name())
+			}
+			d.scratch = b
+			d.flush(depth + 1)
+			d.dump("", f.typ, depth+2)
+		}
+	}
+}
+
+func (d *dumper) methods(t *rtype, depth int) {
+	if t.Kind() == Interface {
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		for _, m := range it.Methods {
+			name := t.NameOff(m.Name)
+			if !name.IsExported() && !d.cfg.Unexported {
+				continue
+			}
+			d.method(depth, name, t.TypeOff(m.Typ).String())
+		}
+		return
+	}
+	u := t.Uncommon()
+	if u == nil {
+		return
+	}
+	for _, m := range u.Methods() {
+		name := t.NameOff(m.Name)
+		if !name.IsExported() && !d.cfg.Unexported {
+			continue
+		}
+		typ := "(unreachable)"
+		if m.Mtyp != -1 {
+			typ = t.TypeOff(m.Mtyp).String()
+		}
+		d.method(depth, name, typ)
+	}
+}
+
+// method writes the line of the method name of type typ.
+func (d *dumper) method(depth int, name Name, typ string) {
+	b := append(d.scratch[:0], "method "...)
+	b = name.AppendTo(b)
+	b = append(b, ' ')
+	d.scratch = append(b, typ...)
+	d.flush(depth)
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"unsafe"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the content of testdata/name.golden, or
+// rewrites the file with got when the -update flag is set.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s (run go test -update to accept it):\n got:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+// skipGolden skips the golden tests of type layouts on 32-bit platforms,
+// whose sizes and offsets differ from those of the golden files.
+func skipGolden(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("the golden files describe 64-bit layouts")
+	}
+}
+
+// dumpTflag matches the tflag bits of a dump line, left out of the golden
+// files as the compiler sets different bits from one release to the next.
+var dumpTflag = regexp.MustCompile(` tflag=[a-zA-Z|]+`)
+
+type dumpNode struct {
+	Name  string `json:"name"`
+	Next  *dumpNode
+	kids  []dumpNode
+	attrs map[string]int32
+	Out   chan<- [2]uint8
+	dumpEmbedded
+}
+
+type dumpEmbedded struct{ ID uint16 }
+
+// The methods match those of fmt.Stringer and io.Writer, for the linker to
+// keep them whatever the rest of the test binary calls.
+func (dumpNode) String() string               { return "" }
+func (*dumpNode) Write(p []byte) (int, error) { return len(p), nil }
+func (dumpEmbedded) Error() string            { return "" }
+
+// dumpTypes keeps the types described by the golden tests in the binary.
+var dumpTypes = []interface{}{dumpNode{}, &dumpNode{}, (*io.ReadWriter)(nil)}
+
+func TestDumpGolden(t *testing.T) {
+	skipGolden(t)
+	tests := []struct {
+		name string
+		typ  *rtype
+		cfg  DumpConfig
+	}{
+		{"dump_int", TypeFor[int](), DefaultDumpConfig},
+		{"dump_node", TypeFor[dumpNode](), DefaultDumpConfig},
+		{"dump_node_ptr", TypeFor[*dumpNode](), DefaultDumpConfig},
+		{"dump_node_pkgpaths", TypeFor[dumpNode](), DumpConfig{PkgPaths: true, Methods: true, Unexported: true}},
+		{"dump_node_depth", TypeFor[dumpNode](), DumpConfig{MaxDepth: 2}},
+		{"dump_node_width", TypeFor[dumpNode](), DumpConfig{MaxWidth: 40}},
+		{"dump_readwriter", TypeFor[io.ReadWriter](), DefaultDumpConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, dumpTflag.ReplaceAll([]byte(tt.cfg.Dump(tt.typ)), nil))
+		})
+	}
+}
+
+func TestDumpNil(t *testing.T) {
+	if got := Dump(nil); got != "<nil>\n" {
+		t.Errorf("Dump(nil) = %q, want %q", got, "<nil>\n")
+	}
+}
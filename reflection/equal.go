@@ -0,0 +1,204 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// EqualIgnoring reports whether a and b are deeply equal, as reflect.DeepEqual
+// reports, except for the struct fields for which skip returns true. If they
+// differ, it also returns the path to the first difference found, such as
+// "Items[2].Owner.Name" or `Labels["env"]`, which is empty when a and b have
+// different types.
+//
+// skip is called with the path of each field and the field itself, for the
+// fields of structs at any depth, including those of slice, array and map
+// elements and of the values pointed to. A nil skip skips no field.
+// Unexported fields are compared as well.
+func EqualIgnoring(a, b interface{}, skip func(path string, f *StructField) bool) (bool, string) {
+	ea, eb := efaceOf(&a), efaceOf(&b)
+	if ea.Type != eb.Type {
+		return false, ""
+	}
+	if ea.Type == nil {
+		return true, ""
+	}
+	c := equalWalker{skip: skip}
+	return c.equal(ea.Type, ea.data(), eb.data(), "")
+}
+
+// SkipTag returns a predicate for EqualIgnoring that skips the fields whose
+// key tag is value, such as SkipTag("cmp", "-") for fields tagged `cmp:"-"`.
+func SkipTag(key, value string) func(path string, f *StructField) bool {
+	return func(_ string, f *StructField) bool {
+		return reflect.StructTag(f.Name.Tag()).Get(key) == value
+	}
+}
+
+// equalWalker compares values as reflect.deepValueEqual does.
+type equalWalker struct {
+	skip func(path string, f *StructField) bool
+
+	// visited holds the pairs of pointers, maps and slices being compared
+	// by type, assumed equal when met again so that cycles terminate.
+	visited map[equalVisit]bool
+}
+
+type equalVisit struct {
+	a, b unsafe.Pointer
+	t    *rtype
+}
+
+// seen reports whether the pair of references a and b of type t is already
+// being compared, and records it otherwise.
+func (c *equalWalker) seen(t *rtype, a, b unsafe.Pointer) bool {
+	if uintptr(a) > uintptr(b) {
+		a, b = b, a
+	}
+	v := equalVisit{a, b, t}
+	if c.visited[v] {
+		return true
+	}
+	if c.visited == nil {
+		c.visited = make(map[equalVisit]bool)
+	}
+	c.visited[v] = true
+	return false
+}
+
+func (c *equalWalker) equal(t *rtype, a, b unsafe.Pointer, path string) (bool, string) {
+	switch t.Kind() {
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			f := &st.Fields[i]
+			fpath := f.Name.Name()
+			if path != "" {
+				fpath = path + "." + fpath
+			}
+			if c.skip != nil && c.skip(fpath, f) {
+				continue
+			}
+			fa := Add(a, f.Offset(), "field offset is within the struct")
+			fb := Add(b, f.Offset(), "field offset is within the struct")
+			if ok, diff := c.equal(f.typ, fa, fb, fpath); !ok {
+				return false, diff
+			}
+		}
+		return true, ""
+
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		return c.elems(at.Elem, a, b, int(at.Len), path)
+
+	case Slice:
+		sa, sb := (*SliceHeader)(a), (*SliceHeader)(b)
+		if (sa.Data == nil) != (sb.Data == nil) || sa.Len != sb.Len {
+			return false, path
+		}
+		if sa.Data == sb.Data || c.seen(t, sa.Data, sb.Data) {
+			return true, ""
+		}
+		return c.elems((*SliceType)(unsafe.Pointer(t)).Elem, sa.Data, sb.Data, sa.Len, path)
+
+	case Ptr:
+		pa, pb := *(*unsafe.Pointer)(a), *(*unsafe.Pointer)(b)
+		if pa == pb || pa != nil && pb != nil && c.seen(t, pa, pb) {
+			return true, ""
+		}
+		if pa == nil || pb == nil {
+			return false, path
+		}
+		return c.equal((*PtrType)(unsafe.Pointer(t)).Elem, pa, pb, path)
+
+	case Interface:
+		ta, tb := ifaceType(t, a), ifaceType(t, b)
+		if ta != tb {
+			return false, path
+		}
+		if ta == nil {
+			return true, ""
+		}
+		ha, hb := &InterfaceHeader{ta, (*InterfaceHeader)(a).Word}, &InterfaceHeader{tb, (*InterfaceHeader)(b).Word}
+		return c.equal(ta, ha.data(), hb.data(), path)
+
+	case Map:
+		return c.maps(t, a, b, path)
+
+	case Func:
+		// Funcs are equal only if both are nil.
+		if *(*unsafe.Pointer)(a) == nil && *(*unsafe.Pointer)(b) == nil {
+			return true, ""
+		}
+		return false, path
+	}
+
+	if eq := t.equalFunc(); eq != nil && !eq(a, b) {
+		return false, path
+	}
+	return true, ""
+}
+
+// ifaceType returns the dynamic type of the value of interface type t at p.
+func ifaceType(t *rtype, p unsafe.Pointer) *rtype {
+	if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+		return (*InterfaceHeader)(p).Type
+	}
+	if tab := (*IfaceHeader)(p).Tab; tab != nil {
+		return tab.Type
+	}
+	return nil
+}
+
+// elems compares the n elements of type elem of the arrays at a and b.
+func (c *equalWalker) elems(elem *rtype, a, b unsafe.Pointer, n int, path string) (bool, string) {
+	for i := 0; i < n; i++ {
+		off := uintptr(i) * elem.size
+		ea := Add(a, off, "i < len")
+		eb := Add(b, off, "i < len")
+		if ok, diff := c.equal(elem, ea, eb, path+"["+strconv.Itoa(i)+"]"); !ok {
+			return false, diff
+		}
+	}
+	return true, ""
+}
+
+// maps compares the maps of type t at a and b key by key.
+func (c *equalWalker) maps(t *rtype, a, b unsafe.Pointer, path string) (bool, string) {
+	ma, mb := *(*unsafe.Pointer)(a), *(*unsafe.Pointer)(b)
+	if ma == mb {
+		return true, ""
+	}
+	rt := ToReflect(t)
+	va, vb := reflect.NewAt(rt, a).Elem(), reflect.NewAt(rt, b).Elem()
+	if ma == nil || mb == nil || va.Len() != vb.Len() {
+		return false, path
+	}
+	if c.seen(t, ma, mb) {
+		return true, ""
+	}
+
+	elem := (*MapType)(unsafe.Pointer(t)).Elem
+	ea, eb := reflect.New(rt.Elem()).Elem(), reflect.New(rt.Elem()).Elem()
+	iter := va.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		kpath := fmt.Sprintf("%s[%#v]", path, k.Interface())
+		vbk := vb.MapIndex(k)
+		if !vbk.IsValid() {
+			return false, kpath
+		}
+		ea.Set(iter.Value())
+		eb.Set(vbk)
+		if ok, diff := c.equal(elem, unsafe.Pointer(ea.UnsafeAddr()), unsafe.Pointer(eb.UnsafeAddr()), kpath); !ok {
+			return false, diff
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type equalOwner struct {
+	Name    string
+	Updated int64 `cmp:"-"`
+}
+
+type equalItem struct {
+	ID     int
+	Owner  *equalOwner
+	Labels map[string]string
+	Any    interface{}
+	secret string
+}
+
+type equalNode struct {
+	V    int
+	Next *equalNode
+}
+
+func TestEqualIgnoringDeepEqual(t *testing.T) {
+	nan := math.NaN()
+	values := []interface{}{
+		nil,
+		1, 2, int64(1), "a", "b", nan, 1.5,
+		[]int(nil), []int{}, []int{1}, []int{1, 2}, []int{1, 3},
+		[2]string{"a", "b"}, [2]string{"a", "c"},
+		map[string]int(nil), map[string]int{}, map[string]int{"a": 1}, map[string]int{"a": 2}, map[string]int{"b": 1},
+		(*int)(nil), new(int),
+		equalItem{ID: 1}, equalItem{ID: 1, secret: "s"},
+		equalItem{Owner: &equalOwner{Name: "a"}}, equalItem{Owner: &equalOwner{Name: "b"}},
+		equalItem{Any: 1}, equalItem{Any: "1"}, equalItem{Any: []int{1}},
+		struct{ R io.Reader }{}, struct{ R io.Reader }{assignInt(1)}, struct{ R io.Reader }{assignInt(2)},
+		(func())(nil),
+	}
+	for _, a := range values {
+		for _, b := range values {
+			got, _ := EqualIgnoring(a, b, nil)
+			if want := reflect.DeepEqual(a, b); got != want {
+				t.Errorf("EqualIgnoring(%#v, %#v) = %v, want %v", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestEqualIgnoringPath(t *testing.T) {
+	a := []equalItem{
+		{ID: 1},
+		{ID: 2, Owner: &equalOwner{Name: "x"}, Labels: map[string]string{"env": "prod"}},
+	}
+	b := []equalItem{
+		{ID: 1},
+		{ID: 2, Owner: &equalOwner{Name: "y"}, Labels: map[string]string{"env": "prod"}},
+	}
+	if ok, path := EqualIgnoring(a, b, nil); ok || path != "[1].Owner.Name" {
+		t.Errorf("EqualIgnoring = %v, %q, want false, [1].Owner.Name", ok, path)
+	}
+	b[1].Owner.Name = "x"
+	b[1].Labels["env"] = "dev"
+	if ok, path := EqualIgnoring(a, b, nil); ok || path != `[1].Labels["env"]` {
+		t.Errorf(`EqualIgnoring = %v, %q, want false, [1].Labels["env"]`, ok, path)
+	}
+	if ok, path := EqualIgnoring(a, 1, nil); ok || path != "" {
+		t.Errorf("EqualIgnoring of different types = %v, %q, want false and no path", ok, path)
+	}
+}
+
+func TestEqualIgnoringSkip(t *testing.T) {
+	a := equalItem{ID: 1, Owner: &equalOwner{Name: "a", Updated: 1}, secret: "x"}
+	b := equalItem{ID: 1, Owner: &equalOwner{Name: "a", Updated: 2}, secret: "y"}
+	if ok, path := EqualIgnoring(a, b, nil); ok || path != "Owner.Updated" {
+		t.Errorf("EqualIgnoring = %v, %q, want false, Owner.Updated", ok, path)
+	}
+	if ok, path := EqualIgnoring(a, b, SkipTag("cmp", "-")); ok || path != "secret" {
+		t.Errorf("EqualIgnoring with SkipTag = %v, %q, want false, secret", ok, path)
+	}
+	var paths []string
+	skip := func(path string, f *StructField) bool {
+		paths = append(paths, path)
+		return path == "secret" || f.Name.Tag() == `cmp:"-"`
+	}
+	if ok, path := EqualIgnoring(a, b, skip); !ok {
+		t.Errorf("EqualIgnoring skipping both differences = false, %q", path)
+	}
+	want := []string{"ID", "Owner", "Owner.Name", "Owner.Updated", "Labels", "Any", "secret"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("skip called with %q, want %q", paths, want)
+	}
+}
+
+func TestEqualIgnoringCycle(t *testing.T) {
+	a := &equalNode{V: 1}
+	a.Next = &equalNode{V: 2, Next: a}
+	b := &equalNode{V: 1}
+	b.Next = &equalNode{V: 2, Next: b}
+	if ok, path := EqualIgnoring(a, b, nil); !ok {
+		t.Errorf("EqualIgnoring of equal cycles = false, %q", path)
+	}
+	b.Next.V = 3
+	if ok, path := EqualIgnoring(a, b, nil); ok || path != "Next.V" {
+		t.Errorf("EqualIgnoring of different cycles = %v, %q, want false, Next.V", ok, path)
+	}
+}
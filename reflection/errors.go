@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+)
+
+// ErrChanDir is returned when sending on a receive-only channel or
+// receiving from a send-only channel.
+var ErrChanDir = errors.New("reflection: channel direction does not permit the operation")
+
+// ErrFieldNotFound is returned when a struct type has no field of the requested name.
+var ErrFieldNotFound = errors.New("reflection: field not found")
+
+// ErrMisaligned is returned when a field accessed atomically is not aligned
+// for the atomic operation, which faults on some 32-bit platforms.
+var ErrMisaligned = errors.New("reflection: misaligned atomic field")
+
+// ErrNameTooLong is returned when a name or a tag is too long to be encoded.
+var ErrNameTooLong = errors.New("reflection: name too long")
+
+// ErrNegativeSize is returned when a negative size hint or buffer size is requested.
+var ErrNegativeSize = errors.New("reflection: negative size")
+
+// ErrNilPointer is returned when a nil pointer is passed where a value is
+// needed.
+var ErrNilPointer = errors.New("reflection: nil pointer")
+
+// ErrNotPOD is returned when raw memory operations are applied to a type
+// that is not plain old data, see IsPOD.
+var ErrNotPOD = errors.New("reflection: type is not plain old data")
+
+// ErrOverflow is returned when a value written to a number does not fit
+// its kind.
+var ErrOverflow = errors.New("reflection: value out of range")
+
+// ErrTypeMismatch is returned when values that must share a type do not.
+var ErrTypeMismatch = errors.New("reflection: type mismatch")
+
+// ErrUnsupported is returned by operations the toolchain that built the
+// program does not support, or that the darkness_safe build tag disables.
+var ErrUnsupported = errors.New("reflection: not supported by this toolchain")
+
+// A KindError occurs when an operation is applied to a type of the wrong kind.
+type KindError struct {
+	Op   string
+	Kind Kind
+}
+
+func (e *KindError) Error() string {
+	return "reflection: call of " + e.Op + " on " + e.Kind.String() + " type"
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+// FastRand returns a pseudo-random uint32 from the runtime's generator, meant
+// for seeding hash tables as EfaceHash takes. It does not lock and is much
+// cheaper than math/rand.
+//
+// The generator state is per-P and not cryptographically secure: values must
+// not be used where they need to be unpredictable.
+func FastRand() uint32 {
+	return fastrand()
+}
+
+// FastRand64 is like FastRand but returns a uint64.
+func FastRand64() uint64 {
+	return fastrand64()
+}
+
+// FastRandN returns a pseudo-random number in [0, n), as FastRand does.
+// It uses the multiply-shift reduction, which is slightly biased for n that
+// are not powers of two.
+func FastRandN(n uint32) uint32 {
+	return uint32(uint64(fastrand()) * uint64(n) >> 32)
+}
+
+//go:linkname fastrand runtime.fastrand
+
+// fastrand returns a pseudo-random uint32.
+// Implemented in the runtime package, which keeps the name for compatibility
+// since the generator was renamed cheaprand in Go 1.22.
+func fastrand() uint32
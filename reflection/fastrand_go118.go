@@ -0,0 +1,13 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.19
+
+package reflection
+
+// fastrand64 combines two values of fastrand, since the runtime has no
+// 64-bit generator before Go 1.19.
+func fastrand64() uint64 {
+	return uint64(fastrand())<<32 | uint64(fastrand())
+}
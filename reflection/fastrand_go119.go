@@ -0,0 +1,17 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.19
+
+package reflection
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+//go:linkname fastrand64 runtime.fastrand64
+
+// fastrand64 returns a pseudo-random uint64.
+// Implemented in the runtime package.
+func fastrand64() uint64
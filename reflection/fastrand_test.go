@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"testing"
+)
+
+func TestFastRand(t *testing.T) {
+	// A working generator does not repeat itself within a few draws, and
+	// sets every bit of its output at some point.
+	seen := make(map[uint64]bool)
+	var or32 uint32
+	var or64 uint64
+	for i := 0; i < 1000; i++ {
+		r := FastRand()
+		or32 |= r
+		seen[uint64(r)] = true
+		r64 := FastRand64()
+		or64 |= r64
+		seen[r64] = true
+	}
+	if len(seen) < 1990 {
+		t.Errorf("%d distinct values in 2000 draws", len(seen))
+	}
+	if or32 != ^uint32(0) || or64 != ^uint64(0) {
+		t.Errorf("bits never set: %#x, %#x", ^or32, ^or64)
+	}
+}
+
+func TestFastRandN(t *testing.T) {
+	for _, n := range []uint32{1, 2, 7, 100, 1 << 31} {
+		var counts [2]int
+		for i := 0; i < 1000; i++ {
+			r := FastRandN(n)
+			if r >= n {
+				t.Fatalf("FastRandN(%d) = %d", n, r)
+			}
+			counts[r*2/n]++
+		}
+		if n > 1 && (counts[0] < 300 || counts[1] < 300) {
+			t.Errorf("FastRandN(%d): %d draws in the lower half, %d in the upper", n, counts[0], counts[1])
+		}
+	}
+}
+
+var benchUint32 uint32
+
+func BenchmarkFastRand(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		benchUint32 += FastRand()
+	}
+}
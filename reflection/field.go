@@ -0,0 +1,89 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sort"
+)
+
+// OffsetOf returns the byte offset and the type of the field named by path
+// within the struct type t.
+//
+// The path is a dot-separated list of field names, each naming a field of the
+// struct type selected by the previous one, as in "Header.Len". Unexported
+// fields are accepted. Pointers are not followed, since the offset of a field
+// behind a pointer is not relative to t.
+func OffsetOf(t *rtype, path string) (uintptr, *rtype, error) {
+	off, ft, _, err := lookupField(t, "OffsetOf", path)
+	if err != nil {
+		return 0, nil, err
+	}
+	return off, ft, nil
+}
+
+// fieldIndex returns the index of the field named name in st, or -1.
+func fieldIndex(st *StructType, name string) int {
+	for i := range st.Fields {
+		if st.Fields[i].Name.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// FieldsInDeclOrder returns the indices into st.Fields of the fields of st in
+// the order they are declared in the source, blank and embedded fields
+// included.
+//
+// The compiler records the fields in declaration order, the order in which
+// reflect.Type.Field numbers them, and this is the order of st.Fields. Should
+// a compiler lay fields out in another order, st.Fields would remain in
+// declaration order while their offsets stop increasing; the returned
+// permutation is then still the declaration order, not the offset order.
+func FieldsInDeclOrder(st *StructType) []int {
+	order := make([]int, len(st.Fields))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// fieldOrder returns the indices into st.Fields sorted with less, keeping
+// ties in declaration order.
+func fieldOrder(st *StructType, less func(a, b *StructField) bool) []int {
+	order := FieldsInDeclOrder(st)
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(&st.Fields[order[i]], &st.Fields[order[j]])
+	})
+	return order
+}
+
+// SortFieldsByName returns the indices into st.Fields of the fields of st
+// sorted by name. Fields of the same name, such as blank fields, stay in
+// declaration order. st.Fields is left untouched.
+func SortFieldsByName(st *StructType) []int {
+	return fieldOrder(st, func(a, b *StructField) bool {
+		return a.Name.Name() < b.Name.Name()
+	})
+}
+
+// SortFieldsBySize returns the indices into st.Fields of the fields of st
+// sorted by decreasing size. Fields of the same size stay in declaration
+// order. st.Fields is left untouched.
+func SortFieldsBySize(st *StructType) []int {
+	return fieldOrder(st, func(a, b *StructField) bool {
+		return a.typ.size > b.typ.size
+	})
+}
+
+// SortFieldsByOffset returns the indices into st.Fields of the fields of st
+// sorted by offset. Fields at the same offset, which zero-sized fields may
+// share with the next one, stay in declaration order. st.Fields is left
+// untouched.
+func SortFieldsByOffset(st *StructType) []int {
+	return fieldOrder(st, func(a, b *StructField) bool {
+		return a.Offset() < b.Offset()
+	})
+}
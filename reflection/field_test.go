@@ -0,0 +1,209 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type fieldInner struct {
+	X int16
+	y string
+}
+
+type fieldT struct {
+	Name  string `json:"name"`
+	n     int32
+	Inner fieldInner
+	P     *fieldInner
+}
+
+func TestNewName(t *testing.T) {
+	long := strings.Repeat("x", 200) // a varint length of two bytes
+	tests := []struct {
+		name, tag string
+		exported  bool
+	}{
+		{"a", "", true},
+		{"b", `json:"b"`, false},
+		{long, "", true},
+		{"c", long, true},
+		{long, long, false},
+	}
+	for _, tt := range tests {
+		n := NewName(tt.name, tt.tag, tt.exported)
+		if got := n.Name(); got != tt.name {
+			t.Errorf("Name() = %q, want %q", got, tt.name)
+		}
+		if got := n.Tag(); got != tt.tag {
+			t.Errorf("Tag() = %q, want %q", got, tt.tag)
+		}
+		if got := n.IsExported(); got != tt.exported {
+			t.Errorf("IsExported() = %v, want %v", got, tt.exported)
+		}
+		if got := n.NameLen(); got != len(tt.name) {
+			t.Errorf("NameLen() = %d, want %d", got, len(tt.name))
+		}
+		if got := n.TagLen(); got != len(tt.tag) {
+			t.Errorf("TagLen() = %d, want %d", got, len(tt.tag))
+		}
+	}
+}
+
+func TestStructFieldNames(t *testing.T) {
+	rt := reflect.TypeOf(fieldT{})
+	st := (*StructType)(unsafe.Pointer(FromReflect(rt)))
+	if len(st.Fields) != rt.NumField() {
+		t.Fatalf("%d fields, want %d", len(st.Fields), rt.NumField())
+	}
+	for i := range st.Fields {
+		f, want := &st.Fields[i], rt.Field(i)
+		if got := f.Name.Name(); got != want.Name {
+			t.Errorf("field %d: Name = %q, want %q", i, got, want.Name)
+		}
+		if got := f.Name.Tag(); got != string(want.Tag) {
+			t.Errorf("field %s: Tag = %q, want %q", want.Name, got, want.Tag)
+		}
+		if got := f.Offset(); got != want.Offset {
+			t.Errorf("field %s: Offset = %d, want %d", want.Name, got, want.Offset)
+		}
+		if got := f.Embedded(); got != want.Anonymous {
+			t.Errorf("field %s: Embedded = %v, want %v", want.Name, got, want.Anonymous)
+		}
+		if got := ToReflect(f.Type()); got != want.Type {
+			t.Errorf("field %s: Type = %v, want %v", want.Name, got, want.Type)
+		}
+	}
+}
+
+func TestOffsetOf(t *testing.T) {
+	rt := reflect.TypeOf(fieldT{})
+	inner, _ := rt.FieldByName("Inner")
+	tests := []struct {
+		path    string
+		off     uintptr
+		typ     reflect.Type
+		wantErr error
+	}{
+		{"Name", 0, reflect.TypeOf(""), nil},
+		{"n", unsafe.Offsetof(fieldT{}.n), reflect.TypeOf(int32(0)), nil},
+		{"Inner.y", inner.Offset + unsafe.Offsetof(fieldInner{}.y), reflect.TypeOf(""), nil},
+		{"Missing", 0, nil, ErrFieldNotFound},
+		{"Inner.Missing", 0, nil, ErrFieldNotFound},
+		{"P.X", 0, nil, &KindError{}},
+		{"Name.Len", 0, nil, &KindError{}},
+	}
+	for _, tt := range tests {
+		off, typ, err := OffsetOf(FromReflect(rt), tt.path)
+		switch want := tt.wantErr.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("OffsetOf(%q): %v", tt.path, err)
+			} else if off != tt.off || ToReflect(typ) != tt.typ {
+				t.Errorf("OffsetOf(%q) = %d, %v, want %d, %v", tt.path, off, ToReflect(typ), tt.off, tt.typ)
+			}
+		case *KindError:
+			if !errors.As(err, &want) {
+				t.Errorf("OffsetOf(%q) error = %v, want a *KindError", tt.path, err)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("OffsetOf(%q) error = %v, want %v", tt.path, err, want)
+			}
+		}
+	}
+}
+
+func TestFieldOf(t *testing.T) {
+	v := &fieldT{Name: "v", n: -3, Inner: fieldInner{X: 9, y: "y"}}
+
+	name, err := StringFieldOf[fieldT]("Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := name.GetString(v); got != "v" {
+		t.Errorf("GetString = %q, want %q", got, "v")
+	}
+	if name.Index != 0 {
+		t.Errorf("Index = %d, want 0", name.Index)
+	}
+
+	n, err := IntFieldOf[fieldT]("n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.GetInt(v); got != -3 {
+		t.Errorf("GetInt = %d, want -3", got)
+	}
+
+	x, err := IntFieldOf[fieldT]("Inner.X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := x.GetInt(v); got != 9 {
+		t.Errorf("GetInt(Inner.X) = %d, want 9", got)
+	}
+	*(*int16)(x.Get(v)) = 10
+	if v.Inner.X != 10 {
+		t.Errorf("write through Get: Inner.X = %d, want 10", v.Inner.X)
+	}
+
+	if _, err := FieldOf[fieldT]("Missing"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("FieldOf(Missing) error = %v, want ErrFieldNotFound", err)
+	}
+	var ke *KindError
+	if _, err := StringFieldOf[fieldT]("n"); !errors.As(err, &ke) || ke.Kind != Int32 {
+		t.Errorf("StringFieldOf(n) error = %v, want a *KindError for int32", err)
+	}
+	if _, err := IntFieldOf[fieldT]("Name"); !errors.As(err, &ke) || ke.Kind != String {
+		t.Errorf("IntFieldOf(Name) error = %v, want a *KindError for string", err)
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	type sorted struct {
+		C  int8
+		_  int8
+		A  [3]int64
+		B  int8
+		_  int8
+		Z  struct{}
+		D  string
+		AA int16
+	}
+	st, _ := AsStructType(TypeFor[sorted]())
+	before := st.FieldsCopy()
+
+	tests := []struct {
+		name string
+		fn   func(*StructType) []int
+		want []int
+	}{
+		{"name", SortFieldsByName, []int{2, 7, 3, 0, 6, 5, 1, 4}},
+		{"size", SortFieldsBySize, []int{2, 6, 7, 0, 1, 3, 4, 5}},
+		{"offset", SortFieldsByOffset, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(st); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortFieldsBy %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	for i := range before {
+		if st.Fields[i] != before[i] {
+			t.Fatalf("sorting changed st.Fields[%d]", i)
+		}
+	}
+
+	// FieldsCopy does not alias the descriptor.
+	fields := st.FieldsCopy()
+	fields[0], fields[1] = fields[1], fields[0]
+	if st.Fields[0] != before[0] {
+		t.Error("FieldsCopy aliases st.Fields")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// minPerfectHashFields is the number of fields below which a FieldIndex is a
+// plain map, cheaper to build and as fast to query at that size.
+const minPerfectHashFields = 8
+
+// maxSeedTries bounds the search for the seed of a perfect hash bucket.
+const maxSeedTries = 1 << 16
+
+// FieldIndex maps the names of the fields of a struct type, as spelled in
+// their tags, to their index in the Fields of the type.
+//
+// For larger structs it is a perfect hash built by hash and displace: the
+// hash of a name selects a bucket, whose seed, chosen at construction so that
+// no two names share a slot, is mixed into the same hash to select the slot.
+// A lookup thus costs a single pass over the name and a single comparison.
+type FieldIndex struct {
+	seeds []uint32 // seed per bucket, len is a power of two
+	names []string // name per slot, len is a power of two
+	index []int    // field index per slot
+
+	m map[string]int // used instead for few fields
+}
+
+// CompileFieldIndex returns a FieldIndex over the exported fields of st,
+// named by the part before the first comma of their tagKey tag, or by their
+// field name when that is empty. Fields tagged "-" are left out. When several
+// fields have the same name, the first one is indexed.
+func CompileFieldIndex(st *StructType, tagKey string) *FieldIndex {
+	var names []string
+	var index []int
+	seen := make(map[string]bool)
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if !f.Name.IsExported() {
+			continue
+		}
+		name := reflect.StructTag(f.Name.Tag()).Get(tagKey)
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name = name[:i]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name.Name()
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		index = append(index, i)
+	}
+	return newFieldIndex(names, index)
+}
+
+// newFieldIndex returns a FieldIndex mapping each of names, which must be
+// distinct, to the corresponding index.
+func newFieldIndex(names []string, index []int) *FieldIndex {
+	if len(names) >= minPerfectHashFields {
+		if x := newPerfectHash(names, index); x != nil {
+			return x
+		}
+	}
+	x := &FieldIndex{m: make(map[string]int, len(names))}
+	for i, name := range names {
+		x.m[name] = index[i]
+	}
+	return x
+}
+
+// newPerfectHash builds the perfect hash of names, or returns nil if no seed
+// could be found for some bucket.
+func newPerfectHash(names []string, index []int) *FieldIndex {
+	size := 1
+	for size < len(names) {
+		size <<= 1
+	}
+	x := &FieldIndex{
+		seeds: make([]uint32, size),
+		names: make([]string, size),
+		index: make([]int, size),
+	}
+	mask := uint32(size - 1)
+
+	hashes := make([]uint64, len(names))
+	buckets := make([][]int, size)
+	for i, name := range names {
+		hashes[i] = fieldHash([]byte(name))
+		b := uint32(hashes[i]) & mask
+		buckets[b] = append(buckets[b], i)
+	}
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	// Place the largest buckets first, while most slots are free.
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(buckets[order[i]]) > len(buckets[order[j]])
+	})
+
+	used := make([]bool, size)
+	slots := make([]uint32, 0, len(names))
+	for _, b := range order {
+		bucket := buckets[b]
+		if len(bucket) == 0 {
+			break
+		}
+		seed := uint32(1)
+	search:
+		for ; seed < maxSeedTries; seed++ {
+			slots = slots[:0]
+			for _, i := range bucket {
+				s := fieldSlot(hashes[i], seed) & mask
+				if used[s] {
+					continue search
+				}
+				for _, t := range slots {
+					if t == s {
+						continue search
+					}
+				}
+				slots = append(slots, s)
+			}
+			break
+		}
+		if seed == maxSeedTries {
+			return nil
+		}
+		x.seeds[b] = seed
+		for k, i := range bucket {
+			used[slots[k]] = true
+			x.names[slots[k]] = names[i]
+			x.index[slots[k]] = index[i]
+		}
+	}
+	return x
+}
+
+// Lookup returns the index in the Fields of the struct type of the field
+// named name. It does not allocate.
+func (x *FieldIndex) Lookup(name []byte) (int, bool) {
+	if x.m != nil {
+		i, ok := x.m[string(name)]
+		return i, ok
+	}
+	mask := uint32(len(x.seeds) - 1)
+	h := fieldHash(name)
+	seed := x.seeds[uint32(h)&mask]
+	if seed == 0 {
+		return 0, false
+	}
+	s := fieldSlot(h, seed) & mask
+	// Free slots hold the empty name, which is never the name of a field.
+	if len(name) == 0 || x.names[s] != string(name) {
+		return 0, false
+	}
+	return x.index[s], true
+}
+
+// fieldHash returns a hash of s, consuming it eight bytes at a time.
+func fieldHash(s []byte) uint64 {
+	const m = 0x9e3779b97f4a7c15
+	h := uint64(len(s)) * m
+	for ; len(s) >= 8; s = s[8:] {
+		h = (h ^ binary.LittleEndian.Uint64(s)) * m
+		h ^= h >> 29
+	}
+	var w uint64
+	for i := len(s) - 1; i >= 0; i-- {
+		w = w<<8 | uint64(s[i])
+	}
+	h = (h ^ w) * m
+	return h ^ h>>32
+}
+
+// fieldSlot returns the slot hash of the name of hash h in a bucket of the
+// given seed.
+func fieldSlot(h uint64, seed uint32) uint32 {
+	h = (h ^ uint64(seed)) * 0xbf58476d1ce4e5b9
+	return uint32(h >> 32)
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type fieldIndexSmall struct {
+	A       int `db:"a"`
+	B       int `db:"b,omitempty"`
+	Skipped int `db:"-"`
+	C       int
+	hidden  int
+	Dup     int `db:"a"`
+}
+
+// fieldIndexWide returns a struct type of n fields named F0, F1 and so on,
+// tagged json:"fN".
+func fieldIndexWide(n int) *StructType {
+	fields := make([]reflect.StructField, n)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: "F" + strconv.Itoa(i),
+			Type: reflect.TypeOf(0),
+			Tag:  reflect.StructTag(`json:"f` + strconv.Itoa(i) + `"`),
+		}
+	}
+	return (*StructType)(unsafe.Pointer(FromReflect(reflect.StructOf(fields))))
+}
+
+func TestCompileFieldIndexSmall(t *testing.T) {
+	x := CompileFieldIndex((*StructType)(unsafe.Pointer(TypeFor[fieldIndexSmall]())), "db")
+	if x.m == nil {
+		t.Fatalf("index of %d fields is not a map", len(x.names))
+	}
+	for _, tt := range []struct {
+		name  string
+		index int
+		ok    bool
+	}{
+		{"a", 0, true}, // not Dup, which comes later
+		{"b", 1, true},
+		{"C", 3, true},
+		{"Skipped", 0, false},
+		{"-", 0, false},
+		{"hidden", 0, false},
+		{"A", 0, false},
+		{"", 0, false},
+	} {
+		if i, ok := x.Lookup([]byte(tt.name)); i != tt.index || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %d, %v, want %d, %v", tt.name, i, ok, tt.index, tt.ok)
+		}
+	}
+}
+
+func TestCompileFieldIndexPerfectHash(t *testing.T) {
+	for _, n := range []int{minPerfectHashFields, 9, 33, 150, 1000} {
+		st := fieldIndexWide(n)
+		x := CompileFieldIndex(st, "json")
+		if x.m != nil {
+			t.Fatalf("%d fields: no perfect hash", n)
+		}
+		displaced := false
+		for _, seed := range x.seeds {
+			if seed >= maxSeedTries {
+				t.Errorf("%d fields: seed %d out of range", n, seed)
+			}
+			displaced = displaced || seed > 1
+		}
+		if n >= 33 && !displaced {
+			t.Errorf("%d fields: every bucket placed with the first seed", n)
+		}
+		for i := 0; i < n; i++ {
+			name := "f" + strconv.Itoa(i)
+			if got, ok := x.Lookup([]byte(name)); !ok || got != i {
+				t.Errorf("%d fields: Lookup(%s) = %d, %v, want %d", n, name, got, ok, i)
+			}
+			if _, ok := x.Lookup([]byte("F" + strconv.Itoa(i))); ok {
+				t.Errorf("%d fields: Lookup of field name F%d found", n, i)
+			}
+		}
+		for _, name := range []string{"", "f", "f-1", "f" + strconv.Itoa(n), "longer than eight bytes"} {
+			if _, ok := x.Lookup([]byte(name)); ok {
+				t.Errorf("%d fields: Lookup(%q) found", n, name)
+			}
+		}
+	}
+}
+
+// TestFieldIndexFreeSlots checks that the empty name, which the free slots
+// of a perfect hash hold, is never found, over name sets of which some hash
+// it to a free slot.
+func TestFieldIndexFreeSlots(t *testing.T) {
+	for set := 0; set < 100; set++ {
+		names := make([]string, minPerfectHashFields+1)
+		index := make([]int, len(names))
+		for i := range names {
+			names[i] = "s" + strconv.Itoa(set) + "_" + strconv.Itoa(i)
+			index[i] = i
+		}
+		x := newFieldIndex(names, index)
+		if x.m != nil {
+			t.Fatalf("set %d: no perfect hash", set)
+		}
+		if i, ok := x.Lookup(nil); ok {
+			t.Errorf("set %d: Lookup of the empty name = %d, true", set, i)
+		}
+	}
+}
+
+// TestFieldIndexFallback checks that a bucket for which no seed separates
+// the names, as for equal names, makes the index fall back to a map once
+// maxSeedTries seeds are tried.
+func TestFieldIndexFallback(t *testing.T) {
+	names := make([]string, minPerfectHashFields)
+	index := make([]int, len(names))
+	for i := range names {
+		names[i] = "n" + strconv.Itoa(i)
+		index[i] = i
+	}
+	names[len(names)-1] = names[0]
+	if x := newPerfectHash(names, index); x != nil {
+		t.Fatal("newPerfectHash of equal names succeeded")
+	}
+	x := newFieldIndex(names, index)
+	if x.m == nil {
+		t.Fatal("newFieldIndex did not fall back to a map")
+	}
+	if i, ok := x.Lookup([]byte("n3")); !ok || i != 3 {
+		t.Errorf("Lookup(n3) = %d, %v, want 3", i, ok)
+	}
+}
+
+func TestFieldIndexLookupAllocs(t *testing.T) {
+	x := CompileFieldIndex(fieldIndexWide(150), "json")
+	name := []byte("f149")
+	if allocs := testing.AllocsPerRun(100, func() { x.Lookup(name) }); allocs != 0 {
+		t.Errorf("Lookup allocates %v times", allocs)
+	}
+}
+
+func BenchmarkFieldIndex(b *testing.B) {
+	const n = 150
+	st := fieldIndexWide(n)
+	names := make([][]byte, n)
+	m := make(map[string]int, n)
+	for i := range names {
+		names[i] = []byte("f" + strconv.Itoa(i))
+		m[string(names[i])] = i
+	}
+	b.Run("FieldIndex", func(b *testing.B) {
+		x := CompileFieldIndex(st, "json")
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			x.Lookup(names[i%n])
+		}
+	})
+	b.Run("Map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = m[string(names[i%n])]
+		}
+	})
+	b.Run("Compile", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			CompileFieldIndex(st, "json")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// FieldInterface returns an interface{} holding the value of the field f of
+// the struct at base, as reflect.Value.Interface would.
+//
+// A value of a pointer-shaped type, stored directly in interfaces, is put
+// in the data word of the interface, without allocating. Other values are
+// copied into a new heap cell, as converting them to an interface copies
+// them; use FieldInterfaceAlias to avoid the copy. For a field of interface
+// type, the value it holds is returned.
+func FieldInterface(base unsafe.Pointer, f *StructField) interface{} {
+	p := Add(base, f.Offset(), "the field lies within the struct")
+	if f.typ.Kind() == Interface {
+		return fieldIface(f.typ, p)
+	}
+	if !ifaceIndir(f.typ) {
+		return PackEface(f.typ, p)
+	}
+	c := AllocTyped(f.typ, false)
+	typedmemmove(f.typ, c, p)
+	return PackEface(f.typ, c)
+}
+
+// FieldInterfaceAlias is like FieldInterface, but never copies: for a value
+// not stored directly in interfaces, the data word of the returned interface
+// points into the struct at base.
+//
+// The interface aliases the field: it sees the writes to the field, and the
+// value it holds is only valid while the struct is alive and not moved,
+// which holds for heap and global structs but not for one on a stack, which
+// may move when the stack grows. Interfaces are assumed immutable, so the
+// field must not be written while the interface, or any copy of it, is in
+// use where immutability matters, such as in a map key or a cached hash.
+func FieldInterfaceAlias(base unsafe.Pointer, f *StructField) interface{} {
+	p := Add(base, f.Offset(), "the field lies within the struct")
+	if f.typ.Kind() == Interface {
+		return fieldIface(f.typ, p)
+	}
+	return PackEface(f.typ, p)
+}
+
+// fieldIface returns the value held by the interface of type t at p.
+func fieldIface(t *rtype, p unsafe.Pointer) interface{} {
+	var i interface{}
+	*efaceOf(&i) = ifaceOf(t, p)
+	return i
+}
+
+// SetFieldOptions controls SetFieldFromInterface.
+type SetFieldOptions struct {
+	// AllowConvert also accepts values of a type convertible to the type of
+	// the field, and converts them as Go does: between numeric types, from
+	// integers, byte slices and rune slices to strings, from strings to
+	// byte and rune slices, and between types of identical underlying
+	// types, such as a defined type and its underlying type.
+	AllowConvert bool
+}
+
+// SetFieldFromInterface stores the value held by v into the field f of the
+// struct at base, with TypedMemmove, and the write barriers it implies. The
+// dynamic type of v must be Identical to the type of the field, or, for a
+// field of interface type, implement it.
+//
+// A nil v zeroes a field of pointer, map, slice, channel, function,
+// interface or unsafe pointer kind; a typed nil pointer is stored as any
+// other value. SetFieldFromInterface returns an error wrapping
+// ErrTypeMismatch for a value of another type, or a nil v for a field of
+// another kind.
+func SetFieldFromInterface(base unsafe.Pointer, f *StructField, v interface{}) error {
+	var o SetFieldOptions
+	return o.SetFieldFromInterface(base, f, v)
+}
+
+// SetFieldFromInterface is like the top-level SetFieldFromInterface, with
+// the options of o.
+func (o *SetFieldOptions) SetFieldFromInterface(base unsafe.Pointer, f *StructField, v interface{}) error {
+	p := Add(base, f.Offset(), "the field lies within the struct")
+	ft := f.typ
+	e := efaceOf(&v)
+	if e.Type == nil {
+		if nilable(ft.Kind()) {
+			Zero(ft, p)
+			return nil
+		}
+		return fmt.Errorf("%w: nil to field %s of type %s", ErrTypeMismatch, f.Name.Name(), ft)
+	}
+
+	switch {
+	case Identical(e.Type, ft):
+		typedmemmove(ft, p, e.data())
+		return nil
+	case ft.Kind() == Interface && Implements(ft, e.Type):
+		if it := (*InterfaceType)(unsafe.Pointer(ft)); len(it.Methods) > 0 {
+			ConvertToInterfaceAt(p, v, it)
+		} else {
+			*(*interface{})(p) = v
+		}
+		return nil
+	case o.AllowConvert && ConvertibleTo(e.Type, ft) && convertValue(ft, p, e.Type, e.data()):
+		return nil
+	}
+	return fmt.Errorf("%w: %s to field %s of type %s", ErrTypeMismatch, e.Type, f.Name.Name(), ft)
+}
+
+// convertValue converts the value of type st at sp, which is convertible to
+// dt, to dt and stores it at dp. It reports false for the conversions it
+// does not perform: of slices to arrays and to pointers to arrays, and to
+// interfaces.
+func convertValue(dt *rtype, dp unsafe.Pointer, st *rtype, sp unsafe.Pointer) bool {
+	if convertNumber(dt, dp, st, sp) {
+		return true
+	}
+	sk, dk := st.Kind(), dt.Kind()
+	switch {
+	case sk == String && dk == Slice:
+		if elem(dt).Kind() == Uint8 {
+			*(*[]byte)(dp) = []byte(*(*string)(sp))
+		} else {
+			*(*[]rune)(dp) = []rune(*(*string)(sp))
+		}
+		return true
+	case sk == Slice && dk == String:
+		if elem(st).Kind() == Uint8 {
+			*(*string)(dp) = string(*(*[]byte)(sp))
+		} else {
+			*(*string)(dp) = string(*(*[]rune)(sp))
+		}
+		return true
+	case dk == Interface || dk == Array || sk == Slice && dk == Ptr:
+		return false
+	}
+	// Values of identical underlying types, channels differing in direction
+	// and pointers to identical underlying types share their representation.
+	typedmemmove(dt, dp, sp)
+	return true
+}
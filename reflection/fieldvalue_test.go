@@ -0,0 +1,235 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type fieldValueT struct {
+	I   int
+	P   *int
+	W   struct{ P *int }
+	A   [1]*int
+	S   string
+	B   []byte
+	Any interface{}
+	Err error
+	Nil interface{}
+	M   map[string]int
+	F   float32
+}
+
+func fieldValueFixture() *fieldValueT {
+	x := 3
+	return &fieldValueT{
+		I: 1, P: &x, W: struct{ P *int }{&x}, A: [1]*int{&x}, S: "s", B: []byte("b"),
+		Any: 2.5, Err: errors.New("e"), M: map[string]int{"k": 1}, F: 1.5,
+	}
+}
+
+func TestFieldInterface(t *testing.T) {
+	v := fieldValueFixture()
+	rv := reflect.ValueOf(v).Elem()
+	st := (*StructType)(unsafe.Pointer(TypeFor[fieldValueT]()))
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		want := rv.Field(i).Interface()
+		for name, get := range map[string]func(unsafe.Pointer, *StructField) interface{}{
+			"FieldInterface":      FieldInterface,
+			"FieldInterfaceAlias": FieldInterfaceAlias,
+		} {
+			if got := get(unsafe.Pointer(v), f); !reflect.DeepEqual(got, want) || reflect.TypeOf(got) != reflect.TypeOf(want) {
+				t.Errorf("%s(%s) = %#v, want %#v", name, f.Name.Name(), got, want)
+			}
+		}
+	}
+}
+
+func TestFieldInterfaceCopy(t *testing.T) {
+	v := fieldValueFixture()
+	fi, fs := columnField[fieldValueT]("I"), columnField[fieldValueT]("S")
+	copied, aliased := FieldInterface(unsafe.Pointer(v), fi), FieldInterfaceAlias(unsafe.Pointer(v), fi)
+	copiedS := FieldInterface(unsafe.Pointer(v), fs)
+	v.I, v.S = 7, "t"
+	if copied != 1 || copiedS != "s" {
+		t.Errorf("FieldInterface = %v, %v, want copies 1, s", copied, copiedS)
+	}
+	if aliased != 7 {
+		t.Errorf("FieldInterfaceAlias = %v, want 7 written through the field", aliased)
+	}
+}
+
+func TestFieldInterfaceAllocs(t *testing.T) {
+	v := fieldValueFixture()
+	for _, name := range []string{"P", "W", "A", "Any", "Err"} {
+		f := columnField[fieldValueT](name)
+		if allocs := testing.AllocsPerRun(100, func() { FieldInterface(unsafe.Pointer(v), f) }); allocs != 0 {
+			t.Errorf("FieldInterface(%s) allocates %v times", name, allocs)
+		}
+	}
+	f := columnField[fieldValueT]("S")
+	if allocs := testing.AllocsPerRun(100, func() { FieldInterfaceAlias(unsafe.Pointer(v), f) }); allocs != 0 {
+		t.Errorf("FieldInterfaceAlias(S) allocates %v times", allocs)
+	}
+}
+
+func BenchmarkFieldInterface(b *testing.B) {
+	v := fieldValueFixture()
+	f := columnField[fieldValueT]("I")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FieldInterface(unsafe.Pointer(v), f)
+	}
+}
+
+type fieldValueErr struct{ msg string }
+
+func (e *fieldValueErr) Error() string { return e.msg }
+
+type fieldValueInt int
+
+func TestSetFieldFromInterface(t *testing.T) {
+	x := 9
+	myErr := &fieldValueErr{"mine"}
+	tests := []struct {
+		field string
+		v     interface{}
+		want  interface{} // ErrTypeMismatch for a mismatch
+	}{
+		{"I", 5, 5},
+		{"I", int64(5), ErrTypeMismatch},
+		{"I", fieldValueInt(5), ErrTypeMismatch},
+		{"I", nil, ErrTypeMismatch},
+		{"P", &x, &x},
+		{"P", (*int)(nil), (*int)(nil)},
+		{"P", nil, (*int)(nil)},
+		{"W", struct{ P *int }{&x}, struct{ P *int }{&x}},
+		{"S", "t", "t"},
+		{"S", []byte("t"), ErrTypeMismatch},
+		{"B", []byte("new"), []byte("new")},
+		{"Any", 7, 7},
+		{"Any", nil, nil},
+		{"Err", myErr, error(myErr)},
+		{"Err", "not an error", ErrTypeMismatch},
+		{"Err", nil, error(nil)},
+		{"M", map[string]int(nil), map[string]int(nil)},
+		{"M", nil, map[string]int(nil)},
+		{"F", 1.0, ErrTypeMismatch},
+	}
+	for _, tt := range tests {
+		v := fieldValueFixture()
+		f := columnField[fieldValueT](tt.field)
+		err := SetFieldFromInterface(unsafe.Pointer(v), f, tt.v)
+		got := reflect.ValueOf(v).Elem().FieldByName(tt.field).Interface()
+		switch {
+		case tt.want == ErrTypeMismatch:
+			if !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("SetFieldFromInterface(%s, %#v) = %v, want ErrTypeMismatch", tt.field, tt.v, err)
+			}
+			if want := reflect.ValueOf(fieldValueFixture()).Elem().FieldByName(tt.field).Interface(); !reflect.DeepEqual(got, want) {
+				t.Errorf("SetFieldFromInterface(%s, %#v) stored %#v on error", tt.field, tt.v, got)
+			}
+		case err != nil:
+			t.Errorf("SetFieldFromInterface(%s, %#v) = %v", tt.field, tt.v, err)
+		case !reflect.DeepEqual(got, tt.want):
+			t.Errorf("SetFieldFromInterface(%s, %#v) stored %#v, want %#v", tt.field, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestSetFieldFromInterfaceConvert(t *testing.T) {
+	o := SetFieldOptions{AllowConvert: true}
+	tests := []struct {
+		field string
+		v     interface{}
+		want  interface{}
+	}{
+		{"I", int64(-5), -5},
+		{"I", fieldValueInt(5), 5},
+		{"I", float64(2.9), 2},
+		{"F", uint8(200), float32(200)},
+		{"F", 1.0, float32(1)},
+		{"S", []byte("b"), "b"},
+		{"S", []rune("é"), "é"},
+		{"S", 0x1F600, "\U0001F600"},
+		{"S", int64(-1), "�"},
+		{"B", "str", []byte("str")},
+	}
+	for _, tt := range tests {
+		v := fieldValueFixture()
+		f := columnField[fieldValueT](tt.field)
+		if err := o.SetFieldFromInterface(unsafe.Pointer(v), f, tt.v); err != nil {
+			t.Errorf("SetFieldFromInterface(%s, %#v) = %v", tt.field, tt.v, err)
+			continue
+		}
+		if got := reflect.ValueOf(v).Elem().FieldByName(tt.field).Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SetFieldFromInterface(%s, %#v) stored %#v, want %#v", tt.field, tt.v, got, tt.want)
+		}
+	}
+
+	v := fieldValueFixture()
+	if err := o.SetFieldFromInterface(unsafe.Pointer(v), columnField[fieldValueT]("I"), "1"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("SetFieldFromInterface of a string to an int = %v, want ErrTypeMismatch", err)
+	}
+}
+
+// TestConvertValue compares convertValue with the conversions of reflect.
+func TestConvertValue(t *testing.T) {
+	type myString string
+	type myBytes []byte
+	srcs := []interface{}{
+		int8(-1), int16(-300), int32(0x10FFFF + 1), int64(math.MinInt64), int(65),
+		uint8(255), uint16(65535), uint32(1 << 31), uint64(math.MaxUint64), uintptr(42),
+		float32(-2.5), float64(1e10), complex64(1 + 2i), complex128(-3i),
+		"héllo", myString("x"), []byte("ab"), myBytes("cd"), []rune("ζ"),
+	}
+	dsts := []reflect.Type{
+		reflect.TypeOf(int8(0)), reflect.TypeOf(int16(0)), reflect.TypeOf(int32(0)), reflect.TypeOf(int64(0)), reflect.TypeOf(0),
+		reflect.TypeOf(uint8(0)), reflect.TypeOf(uint16(0)), reflect.TypeOf(uint32(0)), reflect.TypeOf(uint64(0)), reflect.TypeOf(uintptr(0)),
+		reflect.TypeOf(float32(0)), reflect.TypeOf(float64(0)), reflect.TypeOf(complex64(0)), reflect.TypeOf(complex128(0)),
+		reflect.TypeOf(""), reflect.TypeOf(myString("")), reflect.TypeOf([]byte(nil)), reflect.TypeOf(myBytes(nil)), reflect.TypeOf([]rune(nil)),
+	}
+	for _, src := range srcs {
+		sv := reflect.ValueOf(src)
+		for _, dt := range dsts {
+			if !sv.Type().ConvertibleTo(dt) {
+				continue
+			}
+			if sv.CanFloat() && floatOutOfRange(sv.Float(), dt) {
+				// The result is implementation-defined.
+				continue
+			}
+			want := sv.Convert(dt)
+			got := reflect.New(dt)
+			sp := reflect.New(sv.Type())
+			sp.Elem().Set(sv)
+			if !convertValue(FromReflect(dt), got.UnsafePointer(), FromReflect(sv.Type()), sp.UnsafePointer()) {
+				t.Errorf("convertValue(%T to %s) reported false", src, dt)
+				continue
+			}
+			if !reflect.DeepEqual(got.Elem().Interface(), want.Interface()) {
+				t.Errorf("convertValue(%T(%v) to %s) = %#v, want %#v", src, src, dt, got.Elem(), want)
+			}
+		}
+	}
+}
+
+// floatOutOfRange reports whether converting f to dt, if an integer type,
+// is out of its range.
+func floatOutOfRange(f float64, dt reflect.Type) bool {
+	z := reflect.New(dt).Elem()
+	switch {
+	case z.CanInt():
+		return f < -1<<63 || f >= 1<<63 || z.OverflowInt(int64(f))
+	case z.CanUint():
+		return f < 0 || f >= 1<<64 || z.OverflowUint(uint64(f))
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// FieldStringView returns the string held by the variable of string kind at
+// p, such as a field, without copying its bytes: the string shares them
+// with the variable.
+func FieldStringView(p unsafe.Pointer) string {
+	return *(*string)(p)
+}
+
+// FieldBytesView returns the slice held by the variable of a byte slice
+// type at p, such as a field, without copying its elements: the slice
+// shares its backing array with the variable, and writes through either
+// are seen by the other.
+func FieldBytesView(p unsafe.Pointer) []byte {
+	return *(*[]byte)(p)
+}
+
+// SetFieldStringView stores to the variable of string kind at p a string
+// whose bytes are those of b, without copying them, as a zero-copy decoder
+// points the fields it decodes at the regions of its input.
+//
+// The string aliases b: b must not be modified while the string, or any
+// string sharing its bytes, is in use, as strings are assumed immutable,
+// such as in maps and by the hashes of the runtime. The bytes are kept
+// alive by the string, not by b, so that b may be dropped; but a small
+// string keeps the whole backing array of b alive.
+func SetFieldStringView(p unsafe.Pointer, b []byte) {
+	var s string
+	if len(b) > 0 {
+		s = unsafeString(&b[0], len(b))
+	}
+	*(*string)(p) = s
+	runtime.KeepAlive(b)
+}
+
+// SetFieldBytesView stores to the variable of a byte slice type at p the
+// slice b, without copying its elements, with its capacity cut to its
+// length, so that appending to the field reallocates rather than
+// overwrites the bytes following b in its backing array.
+//
+// The field aliases b: writes through either are seen by the other, and the
+// field keeps the whole backing array of b alive.
+func SetFieldBytesView(p unsafe.Pointer, b []byte) {
+	*(*[]byte)(p) = b[:len(b):len(b)]
+	runtime.KeepAlive(b)
+}
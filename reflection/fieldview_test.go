@@ -0,0 +1,96 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+type fieldViewT struct {
+	N int32
+	S string
+	B []byte
+}
+
+func TestFieldViews(t *testing.T) {
+	v := &fieldViewT{S: "hello", B: []byte("world")}
+	sp, bp := unsafe.Pointer(&v.S), unsafe.Pointer(&v.B)
+
+	if s := FieldStringView(sp); s != "hello" || unsafe.Pointer((*StringHeader)(unsafe.Pointer(&s)).Data) != unsafe.Pointer((*StringHeader)(sp).Data) {
+		t.Errorf("FieldStringView = %q, not sharing the bytes of the field", s)
+	}
+	b := FieldBytesView(bp)
+	b[0] = 'W'
+	if v.B[0] != 'W' {
+		t.Errorf("write through FieldBytesView lost: %q", v.B)
+	}
+}
+
+func TestSetFieldStringView(t *testing.T) {
+	v := new(fieldViewT)
+	buf := []byte("abcdef")
+	SetFieldStringView(unsafe.Pointer(&v.S), buf[1:4])
+	if v.S != "bcd" {
+		t.Fatalf("SetFieldStringView stored %q", v.S)
+	}
+	if unsafe.Pointer((*StringHeader)(unsafe.Pointer(&v.S)).Data) != unsafe.Pointer(&buf[1]) {
+		t.Error("SetFieldStringView copied the bytes")
+	}
+	// The string keeps the bytes alive once buf is dropped.
+	buf = nil
+	runtime.GC()
+	if v.S != "bcd" {
+		t.Errorf("string view after GC = %q", v.S)
+	}
+
+	v.S = "x"
+	SetFieldStringView(unsafe.Pointer(&v.S), nil)
+	if v.S != "" {
+		t.Errorf("SetFieldStringView(nil) stored %q", v.S)
+	}
+	SetFieldStringView(unsafe.Pointer(&v.S), make([]byte, 0, 4))
+	if v.S != "" {
+		t.Errorf("SetFieldStringView(empty) stored %q", v.S)
+	}
+}
+
+func TestSetFieldBytesView(t *testing.T) {
+	v := new(fieldViewT)
+	buf := []byte("abcdef")
+	SetFieldBytesView(unsafe.Pointer(&v.B), buf[1:3])
+	if string(v.B) != "bc" || cap(v.B) != 2 {
+		t.Fatalf("SetFieldBytesView stored %q with cap %d, want bc with cap 2", v.B, cap(v.B))
+	}
+	buf[1] = 'B'
+	if v.B[0] != 'B' {
+		t.Error("SetFieldBytesView copied the bytes")
+	}
+	// Appending reallocates rather than overwriting buf.
+	v.B = append(v.B, 'x')
+	if string(buf) != "aBcdef" {
+		t.Errorf("append to the field overwrote %q", buf)
+	}
+
+	SetFieldBytesView(unsafe.Pointer(&v.B), nil)
+	if v.B != nil {
+		t.Errorf("SetFieldBytesView(nil) stored %q", v.B)
+	}
+}
+
+func TestFieldViewAllocs(t *testing.T) {
+	v := &fieldViewT{S: "s", B: []byte("b")}
+	buf := []byte("bytes")
+	allocs := testing.AllocsPerRun(100, func() {
+		FieldStringView(unsafe.Pointer(&v.S))
+		FieldBytesView(unsafe.Pointer(&v.B))
+		SetFieldStringView(unsafe.Pointer(&v.S), buf)
+		SetFieldBytesView(unsafe.Pointer(&v.B), buf)
+	})
+	if allocs != 0 {
+		t.Errorf("field views allocate %v times", allocs)
+	}
+}
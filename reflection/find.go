@@ -0,0 +1,99 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// FindOptions controls FindStructsWithFieldType.
+type FindOptions struct {
+	// FollowPointers also matches the fields of type *target, and looks
+	// into the structs embedded by pointer.
+	FollowPointers bool
+}
+
+// StructMatch is a field of type target found in a struct type Owner.
+type StructMatch struct {
+	Owner *StructType
+
+	// Path is the dot-separated list of field names leading to the field,
+	// through the embedded structs, as accepted by OffsetOf when no
+	// pointer is crossed.
+	Path string
+}
+
+// FindStructsWithFieldType returns the fields of type target of the struct
+// types of Types, including the fields of the structs they embed at any
+// depth, such as the fields of a deprecated type to audit before removing
+// it. A struct with several such fields has a match for each.
+//
+// Like Types, it requires the runtime module layout, and returns the error
+// of Types, wrapping ErrUnsupported, otherwise.
+func FindStructsWithFieldType(target *rtype) ([]StructMatch, error) {
+	var o FindOptions
+	return o.FindStructsWithFieldType(target)
+}
+
+// FindStructsWithFieldType is like the top-level FindStructsWithFieldType,
+// with the options of o.
+func (o *FindOptions) FindStructsWithFieldType(target *rtype) ([]StructMatch, error) {
+	f := fieldFinder{FindOptions: *o, target: target, paths: make(map[*rtype][]string)}
+	var matches []StructMatch
+	err := Types(func(t *rtype) bool {
+		if t.Kind() == Struct {
+			for _, path := range f.find(t) {
+				matches = append(matches, StructMatch{(*StructType)(unsafe.Pointer(t)), path})
+			}
+		}
+		return true
+	})
+	return matches, err
+}
+
+// fieldFinder searches struct types for the fields of type target.
+type fieldFinder struct {
+	FindOptions
+	target *rtype
+
+	// paths holds the paths found in each struct type searched so far. A
+	// struct type being searched maps to nil, so that a struct embedding a
+	// pointer to itself is not searched again.
+	paths map[*rtype][]string
+}
+
+func (f *fieldFinder) find(t *rtype) []string {
+	if paths, ok := f.paths[t]; ok {
+		return paths
+	}
+	f.paths[t] = nil
+	var paths []string
+	st := (*StructType)(unsafe.Pointer(t))
+	for i := range st.Fields {
+		sf := &st.Fields[i]
+		name := sf.Name.Name()
+		ft := sf.typ
+		if f.FollowPointers && ft.Kind() == Ptr && elem(ft) == f.target {
+			ft = f.target
+		}
+		if ft == f.target {
+			paths = append(paths, name)
+			continue
+		}
+		if !sf.Embedded() {
+			continue
+		}
+		if f.FollowPointers && ft.Kind() == Ptr {
+			ft = elem(ft)
+		}
+		if ft.Kind() == Struct {
+			for _, p := range f.find(ft) {
+				paths = append(paths, name+"."+p)
+			}
+		}
+	}
+	f.paths[t] = paths
+	return paths
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type (
+	findTarget struct{ N int }
+	findEmbed  struct{ Deep findTarget }
+	findHolder struct {
+		T findTarget
+		P *findTarget
+		findEmbed
+	}
+	findSelf struct {
+		*findSelf
+		T findTarget
+	}
+)
+
+type findFixture interface{ fixture() }
+
+func (findHolder) fixture() {}
+func (findSelf) fixture()   {}
+
+// findFixtures lays out the itabs that have Types report the fixtures.
+var findFixtures = []findFixture{findHolder{}, findSelf{}}
+
+func findMatches(t *testing.T, o FindOptions) []string {
+	t.Helper()
+	for _, f := range findFixtures {
+		f.fixture()
+	}
+	matches, err := o.FindStructsWithFieldType(TypeFor[findTarget]())
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("FindStructsWithFieldType error = %v, want ErrUnsupported", err)
+		}
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := map[*rtype]bool{TypeFor[findEmbed](): true, TypeFor[findHolder](): true, TypeFor[findSelf](): true}
+	var got []string
+	for _, m := range matches {
+		if fixtures[&m.Owner.rtype] {
+			got = append(got, m.Owner.Name()+"."+m.Path)
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestFindStructsWithFieldType(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FindOptions
+		want []string
+	}{
+		{"direct", FindOptions{}, []string{"findEmbed.Deep", "findHolder.T", "findHolder.findEmbed.Deep", "findSelf.T"}},
+		{"pointers", FindOptions{FollowPointers: true}, []string{"findEmbed.Deep", "findHolder.P", "findHolder.T", "findHolder.findEmbed.Deep", "findSelf.T"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findMatches(t, tt.opts)
+			if !modulesSupported {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matches %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FindStructsWithFieldType(TypeFor[findTarget]()); (err == nil) != modulesSupported {
+		t.Errorf("FindStructsWithFieldType error = %v", err)
+	}
+}
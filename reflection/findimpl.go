@@ -0,0 +1,103 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ImplOptions controls FindImplementations.
+type ImplOptions struct {
+	// PkgPathPrefix restricts the search to the types defined in the
+	// packages whose import path starts with it, and to pointers to them.
+	PkgPathPrefix string
+
+	// Workers is the number of goroutines checking the candidates. Values
+	// below 2 check them on the calling goroutine.
+	Workers int
+}
+
+// implSets caches the results of Implements, by interface type then by type.
+var implSets TypeMap[*TypeMap[bool]]
+
+// FindImplementations returns the concrete types of Types that implement the
+// interface type iface, in the order of Types. Unlike ImplementationsOf, it
+// finds the types the binary never converts to iface, but not the named types
+// Types misses.
+//
+// The result of the method set check is cached for each type, so that later
+// searches for iface only walk the types.
+//
+// Like Types, it requires the runtime module layout, and returns the error
+// of Types, wrapping ErrUnsupported, otherwise.
+func FindImplementations(iface *InterfaceType) ([]*rtype, error) {
+	var o ImplOptions
+	return o.FindImplementations(iface)
+}
+
+// FindImplementations is like the top-level FindImplementations, with the
+// options of o.
+func (o *ImplOptions) FindImplementations(iface *InterfaceType) ([]*rtype, error) {
+	var candidates []*rtype
+	err := Types(func(t *rtype) bool {
+		if t.Kind() != Interface && hasPkgPathPrefix(t, o.PkgPathPrefix) {
+			candidates = append(candidates, t)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := implSets.GetOrCompute(&iface.rtype, func(*rtype) *TypeMap[bool] {
+		return new(TypeMap[bool])
+	})
+	check := func(t *rtype) bool {
+		return set.GetOrCompute(t, func(t *rtype) bool {
+			return Implements(&iface.rtype, t)
+		})
+	}
+
+	ok := make([]bool, len(candidates))
+	if o.Workers < 2 {
+		for i, t := range candidates {
+			ok[i] = check(t)
+		}
+	} else {
+		var next int64 = -1
+		var wg sync.WaitGroup
+		for w := 0; w < o.Workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					i := int(atomic.AddInt64(&next, 1))
+					if i >= len(candidates) {
+						return
+					}
+					ok[i] = check(candidates[i])
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	var impls []*rtype
+	for i, t := range candidates {
+		if ok[i] {
+			impls = append(impls, t)
+		}
+	}
+	return impls, nil
+}
+
+// hasPkgPathPrefix reports whether t, or its element if t is an unnamed
+// pointer, is defined in a package whose import path starts with prefix.
+// Every type has the empty prefix.
+func hasPkgPathPrefix(t *rtype, prefix string) bool {
+	return prefix == "" || strings.HasPrefix(definingPkgPath(t), prefix)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// implTriangle implements moduleShape but is never converted to it: Types
+// only reports it as the field of implHolder.
+type implTriangle struct{ b, h float64 }
+
+func (t implTriangle) Area() float64 { return t.b * t.h / 2 }
+
+type implHolder struct{ T implTriangle }
+
+type implFixture interface{ fixture() }
+
+func (implHolder) fixture() {}
+
+var implFixtures = []implFixture{implHolder{}}
+
+func TestFindImplementations(t *testing.T) {
+	if moduleArea() != 4 {
+		t.Fatal("wrong area")
+	}
+	for _, f := range implFixtures {
+		f.fixture()
+	}
+	iface := (*InterfaceType)(unsafe.Pointer(TypeFor[moduleShape]()))
+	impls, err := FindImplementations(iface)
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) || impls != nil {
+			t.Fatalf("FindImplementations = %v, %v, want ErrUnsupported", impls, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[*rtype]bool)
+	for _, typ := range impls {
+		found[typ] = true
+		if !Implements(&iface.rtype, typ) {
+			t.Errorf("%s does not implement moduleShape", typ)
+		}
+	}
+	for _, typ := range []*rtype{TypeFor[moduleSquare](), TypeFor[*moduleCircle](), TypeFor[implTriangle]()} {
+		if !found[typ] {
+			t.Errorf("FindImplementations did not find %s", typ)
+		}
+	}
+	if found[TypeFor[moduleCircle]()] {
+		t.Error("FindImplementations found moduleCircle, whose Area has a pointer receiver")
+	}
+
+	tests := []struct {
+		name string
+		opts ImplOptions
+	}{
+		{"workers", ImplOptions{Workers: 4}},
+		{"package", ImplOptions{PkgPathPrefix: TypeFor[moduleSquare]().PkgPath()}},
+		{"other package", ImplOptions{PkgPathPrefix: "example.com/none"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.FindImplementations(iface)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []*rtype
+			for _, typ := range impls {
+				if hasPkgPathPrefix(typ, tt.opts.PkgPathPrefix) {
+					want = append(want, typ)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("found %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestHasPkgPathPrefix(t *testing.T) {
+	pkg := TypeFor[moduleSquare]().PkgPath()
+	tests := []struct {
+		typ    *rtype
+		prefix string
+		want   bool
+	}{
+		{TypeFor[int](), "", true},
+		{TypeFor[int](), pkg, false},
+		{TypeFor[moduleSquare](), pkg, true},
+		{TypeFor[*moduleSquare](), pkg, true},
+		{TypeFor[[]moduleSquare](), pkg, false},
+		{TypeFor[moduleSquare](), pkg + "/sub", false},
+	}
+	for _, tt := range tests {
+		if got := hasPkgPathPrefix(tt.typ, tt.prefix); got != tt.want {
+			t.Errorf("hasPkgPathPrefix(%s, %q) = %v, want %v", tt.typ, tt.prefix, got, tt.want)
+		}
+	}
+}
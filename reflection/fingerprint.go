@@ -0,0 +1,154 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"unsafe"
+)
+
+// FingerprintOptions controls what the fingerprint of a type depends on.
+type FingerprintOptions struct {
+	// IgnoreTags excludes struct field tags.
+	IgnoreTags bool
+
+	// IgnoreFieldNames excludes struct field names, leaving the
+	// field order, offsets and types.
+	IgnoreFieldNames bool
+
+	// IgnoreTypeNames excludes the names and package paths of defined
+	// types and the method names of interface types.
+	IgnoreTypeNames bool
+}
+
+// LayoutOnly is the set of options that reduces a fingerprint to the memory
+// layout of a type.
+var LayoutOnly = FingerprintOptions{
+	IgnoreTags:       true,
+	IgnoreFieldNames: true,
+	IgnoreTypeNames:  true,
+}
+
+// Fingerprint returns a fingerprint of t that, unlike the hash computed by the
+// compiler, is stable across builds: two binaries compiled from the same
+// source produce the same fingerprint for the same type, while renaming,
+// reordering or retyping a field changes it.
+//
+// The fingerprint is the FNV-1a hash of a canonical serialization of the type
+// structure: kinds, sizes, defined type names, struct field names, with the
+// package path of unexported ones, tags, offsets and types, and element, key,
+// parameter and method types, recursively. Cycles are broken
+// deterministically by numbering types on first visit and serializing back
+// references by number.
+func Fingerprint(t *rtype) uint64 {
+	var o FingerprintOptions
+	return o.Fingerprint(t)
+}
+
+// Fingerprint is like the top-level Fingerprint function but honors o.
+func (o *FingerprintOptions) Fingerprint(t *rtype) uint64 {
+	f := fingerprinter{opts: o, h: fnv.New64a(), seen: make(map[*rtype]int)}
+	f.typ(t)
+	return f.h.Sum64()
+}
+
+type fingerprinter struct {
+	opts *FingerprintOptions
+	h    hash.Hash64
+	seen map[*rtype]int
+	buf  [binary.MaxVarintLen64]byte
+}
+
+func (f *fingerprinter) uint(v uint64) {
+	n := binary.PutUvarint(f.buf[:], v)
+	f.h.Write(f.buf[:n])
+}
+
+func (f *fingerprinter) string(s string) {
+	f.uint(uint64(len(s)))
+	f.h.Write([]byte(s))
+}
+
+func (f *fingerprinter) typ(t *rtype) {
+	if i, ok := f.seen[t]; ok {
+		// Back reference: kind 0 is never a valid kind.
+		f.uint(0)
+		f.uint(uint64(i))
+		return
+	}
+	f.seen[t] = len(f.seen)
+
+	f.uint(uint64(t.Kind()))
+	f.uint(uint64(t.size))
+	if !f.opts.IgnoreTypeNames {
+		f.string(t.PkgPath())
+		f.string(t.Name())
+	}
+
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		f.uint(uint64(at.Len))
+		f.typ(at.Elem)
+	case Chan:
+		ct := (*ChanType)(unsafe.Pointer(t))
+		f.uint(uint64(ct.Dir))
+		f.typ(ct.Elem)
+	case Func:
+		ft := (*FuncType)(unsafe.Pointer(t))
+		f.uint(uint64(ft.InCount))
+		f.uint(uint64(ft.OutCount))
+		for _, p := range ft.params() {
+			f.typ(p)
+		}
+	case Interface:
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		f.uint(uint64(len(it.Methods)))
+		for _, m := range it.Methods {
+			if !f.opts.IgnoreTypeNames {
+				name := t.NameOff(m.Name)
+				f.string(name.Name())
+				if !name.IsExported() {
+					f.string(it.PkgPath.Name())
+				}
+			}
+			f.typ(t.TypeOff(m.Typ))
+		}
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		f.typ(mt.Key)
+		f.typ(mt.Elem)
+	case Ptr:
+		f.typ((*PtrType)(unsafe.Pointer(t)).Elem)
+	case Slice:
+		f.typ((*SliceType)(unsafe.Pointer(t)).Elem)
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		f.uint(uint64(len(st.Fields)))
+		for i := range st.Fields {
+			sf := &st.Fields[i]
+			if !f.opts.IgnoreFieldNames {
+				f.string(sf.Name.Name())
+				// Unexported names are qualified by their package, so
+				// that same-shaped structs of different packages differ.
+				if !sf.Name.IsExported() {
+					f.string(st.PkgPath.Name())
+				}
+			}
+			if !f.opts.IgnoreTags {
+				f.string(sf.Name.Tag())
+			}
+			embedded := uint64(0)
+			if sf.Embedded() {
+				embedded = 1
+			}
+			f.uint(embedded)
+			f.uint(uint64(sf.Offset()))
+			f.typ(sf.typ)
+		}
+	}
+}
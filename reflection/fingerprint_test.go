@@ -0,0 +1,166 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fpGolden struct {
+	A int32 `json:"a"`
+	B [2]uint16
+	c int8
+}
+
+type fpRenamed struct {
+	A int32 `json:"a"`
+	B [2]uint16
+	c int8
+}
+
+// Mutually recursive pairs, of the same layout but for the type of X.
+type (
+	fpEven struct{ Odd *fpOdd }
+	fpOdd  struct {
+		Even *fpEven
+		X    int
+	}
+	fpEven2 struct{ Odd *fpOdd2 }
+	fpOdd2  struct {
+		Even *fpEven2
+		X    int
+	}
+	fpEven3 struct{ Odd *fpOdd3 }
+	fpOdd3  struct {
+		Even *fpEven3
+		X    uint
+	}
+)
+
+// The fixtures below are declared once in each of two functions, as the
+// descriptors of one type in two builds, or in a plugin and its host, would
+// be: distinct descriptors with the same names and structure.
+
+func fpTypesA() []*rtype {
+	type list struct {
+		Next *list
+		V    int
+	}
+	type tagged struct {
+		A int `json:"a"`
+	}
+	type renamed struct{ A int }
+	type reordered struct {
+		A int8
+		B int16
+	}
+	type retyped struct{ A int }
+	return []*rtype{TypeFor[list](), TypeFor[tagged](), TypeFor[renamed](), TypeFor[reordered](), TypeFor[retyped]()}
+}
+
+func fpTypesB() []*rtype {
+	type list struct {
+		Next *list
+		V    int
+	}
+	type tagged struct {
+		A int `json:"b"`
+	}
+	type renamed struct{ B int }
+	type reordered struct {
+		B int16
+		A int8
+	}
+	type retyped struct{ A uint }
+	return []*rtype{TypeFor[list](), TypeFor[tagged](), TypeFor[renamed](), TypeFor[reordered](), TypeFor[retyped]()}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := fpTypesA(), fpTypesB()
+	tests := []struct {
+		name string
+		opts FingerprintOptions
+		same []bool // per fixture, whether the two declarations agree
+	}{
+		{"default", FingerprintOptions{}, []bool{true, false, false, false, false}},
+		{"IgnoreTags", FingerprintOptions{IgnoreTags: true}, []bool{true, true, false, false, false}},
+		{"IgnoreFieldNames", FingerprintOptions{IgnoreFieldNames: true}, []bool{true, false, true, false, false}},
+		{"LayoutOnly", LayoutOnly, []bool{true, true, true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range a {
+				if a[i] == b[i] {
+					t.Fatalf("%s: the fixtures share a descriptor", a[i])
+				}
+				fa, fb := tt.opts.Fingerprint(a[i]), tt.opts.Fingerprint(b[i])
+				if got := fa == fb; got != tt.same[i] {
+					t.Errorf("%s: equal fingerprints = %v, want %v", a[i], got, tt.same[i])
+				}
+				if again := tt.opts.Fingerprint(a[i]); again != fa {
+					t.Errorf("%s: fingerprint changed from %#x to %#x", a[i], fa, again)
+				}
+			}
+		})
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	// The fingerprint of a type must not change across builds or
+	// toolchains; the fixture has the same layout on every architecture.
+	const want uint64 = 0x1a6a3ebffd962f86
+	if got := Fingerprint(TypeFor[fpGolden]()); got != want {
+		t.Errorf("Fingerprint(fpGolden) = %#x, want %#x", got, want)
+	}
+}
+
+func TestFingerprintNames(t *testing.T) {
+	golden, renamed := TypeFor[fpGolden](), TypeFor[fpRenamed]()
+	if Fingerprint(golden) == Fingerprint(renamed) {
+		t.Error("types of different names have the same fingerprint")
+	}
+	if LayoutOnly.Fingerprint(golden) != LayoutOnly.Fingerprint(renamed) {
+		t.Error("types of the same layout have different LayoutOnly fingerprints")
+	}
+}
+
+func TestFingerprintPkgPath(t *testing.T) {
+	// Same-shaped structs with unexported fields of different packages are
+	// different types.
+	local := TypeFor[struct{ x int }]()
+	other := FromReflect(reflect.StructOf([]reflect.StructField{
+		{Name: "x", PkgPath: "example.com/other", Type: reflect.TypeOf(0)},
+	}))
+	if Fingerprint(local) == Fingerprint(other) {
+		t.Error("unexported fields of different packages have the same fingerprint")
+	}
+	if LayoutOnly.Fingerprint(local) != LayoutOnly.Fingerprint(other) {
+		t.Error("LayoutOnly fingerprints differ by package path")
+	}
+
+	exported := FromReflect(reflect.StructOf([]reflect.StructField{
+		{Name: "X", Type: reflect.TypeOf(0)},
+	}))
+	if Fingerprint(TypeFor[struct{ X int }]()) != Fingerprint(exported) {
+		t.Error("struct { X int } has two fingerprints")
+	}
+}
+
+func TestFingerprintMutuallyRecursive(t *testing.T) {
+	even, even2, even3 := TypeFor[fpEven](), TypeFor[fpEven2](), TypeFor[fpEven3]()
+	if Fingerprint(even) == Fingerprint(even2) {
+		t.Error("types of different names have the same fingerprint")
+	}
+	if LayoutOnly.Fingerprint(even) != LayoutOnly.Fingerprint(even2) {
+		t.Error("mutually recursive types of the same layout have different LayoutOnly fingerprints")
+	}
+	if LayoutOnly.Fingerprint(even) == LayoutOnly.Fingerprint(even3) {
+		t.Error("mutually recursive types of different layouts have the same LayoutOnly fingerprint")
+	}
+	if Fingerprint(even) == Fingerprint(TypeFor[fpOdd]()) {
+		t.Error("the two types of a cycle have the same fingerprint")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldName appends to scratch the canonical folding of the name in and
+// returns the extended buffer. Two names fold to the same bytes exactly when
+// they are equal under bytes.EqualFold, so that case-insensitive matches of
+// names, such as encoding/json does of object keys, can be looked up in a map
+// of folded names.
+//
+// ASCII letters are upper-cased and other runes replaced by the smallest
+// rune of their Unicode fold set, as encoding/json folds names.
+func FoldName(in, scratch []byte) []byte {
+	for i := 0; i < len(in); {
+		if c := in[i]; c < utf8.RuneSelf {
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			scratch = append(scratch, c)
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(in[i:])
+		scratch = utf8.AppendRune(scratch, foldRune(r))
+		i += n
+	}
+	return scratch
+}
+
+// EqualFoldName reports whether FoldName folds a and b to the same bytes,
+// without folding them.
+func EqualFoldName(a, b []byte) bool {
+	return bytes.EqualFold(a, b)
+}
+
+// foldRune returns the smallest rune of the fold set of r.
+func foldRune(r rune) rune {
+	for {
+		r2 := unicode.SimpleFold(r)
+		if r2 <= r {
+			return r2
+		}
+		r = r2
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"testing"
+)
+
+// foldPairs are the seeds of the encoding/json fold tests.
+var foldPairs = [][2]string{
+	{"", ""},
+	{"123abc", "123ABC"},
+	{"αβδ", "ΑΒΔ"},
+	{"abc", "xyz"},
+	{"abc", "XYZ"},
+	{"1", "2"},
+	{"hello, world!", "hello, world!"},
+	{"hello, world!", "Hello, World!"},
+	{"hello, world!", "HELLO, WORLD!"},
+	{"hello, world!", "jello, world!"},
+	{"γειά, κόσμε!", "γειά, κόσμε!"},
+	{"γειά, κόσμε!", "Γειά, Κόσμε!"},
+	{"γειά, κόσμε!", "ΓΕΙΆ, ΚΌΣΜΕ!"},
+	{"γειά, κόσμε!", "ΛΕΙΆ, ΚΌΣΜΕ!"},
+	{"AESKey", "aesKey"},
+	{"AESKEY", "aes_key"},
+	{"aes_key", "AES_KEY"},
+	{"AES_KEY", "aes-key"},
+	{"aes-key", "AES-KEY"},
+	{"AES-KEY", "aesKey"},
+	{"aesKey", "AesKey"},
+	{"AesKey", "AESKey"},
+	{"AESKey", "aeskey"},
+	{"DESKey", "aeskey"},
+	{"AES Key", "aeskey"},
+	{"Kkelvin", "KKELVIN"}, // Kelvin sign
+	{"ſ", "S"},             // long s
+	{"\xff", "\xfe"},
+}
+
+func foldEqual(x, y []byte) bool {
+	return string(FoldName(x, nil)) == string(FoldName(y, nil))
+}
+
+func TestFoldName(t *testing.T) {
+	for _, p := range foldPairs {
+		x, y := []byte(p[0]), []byte(p[1])
+		want := bytes.EqualFold(x, y)
+		if got := foldEqual(x, y); got != want {
+			t.Errorf("FoldName(%q) == FoldName(%q) is %v, want %v", x, y, got, want)
+		}
+		if got := EqualFoldName(x, y); got != want {
+			t.Errorf("EqualFoldName(%q, %q) = %v, want %v", x, y, got, want)
+		}
+	}
+	if got := string(FoldName([]byte("aB"), []byte("x:"))); got != "x:AB" {
+		t.Errorf("FoldName appended %q, want %q", got, "x:AB")
+	}
+}
+
+func FuzzFoldName(f *testing.F) {
+	for _, p := range foldPairs {
+		f.Add([]byte(p[0]), []byte(p[1]))
+	}
+	f.Fuzz(func(t *testing.T, x, y []byte) {
+		if got, want := foldEqual(x, y), bytes.EqualFold(x, y); got != want {
+			t.Errorf("FoldName(%q) == FoldName(%q) is %v, want %v", x, y, got, want)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// ptrSize is the size of a pointer in bytes.
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+// GCDataBytes returns a copy of the pointer bitmap of t: bit i%8 of byte i/8
+// is set if the i'th word of a value of t may hold a pointer. The bitmap
+// covers the ptrdata prefix of t, in (ptrdata/ptrSize+7)/8 bytes.
+//
+// Since Go 1.24 the bitmap of a large type is only built by the runtime on
+// first use; GCDataBytes then builds it from the bitmaps of the element and
+// field types, as the runtime does. It reports false for a type described by
+// a GC program, as built by toolchains before Go 1.24.
+func GCDataBytes(t *rtype) ([]byte, bool) {
+	if t.kind&KindGCProg != 0 {
+		return nil, false
+	}
+	return gcMask(t), true
+}
+
+// gcMask returns the pointer bitmap of t. Unlike GCDataBytes, it also
+// handles types described by a GC program, by building their bitmap from the
+// bitmaps of their element and field types.
+func gcMask(t *rtype) []byte {
+	words := t.ptrdata / ptrSize
+	mask := make([]byte, (words+7)/8)
+	if words > 0 {
+		buildGCMask(t, mask, 0)
+	}
+	return mask
+}
+
+// buildGCMask writes the pointer bitmap of t to mask, starting at bit,
+// as runtime.buildGCMask does.
+func buildGCMask(t *rtype, mask []byte, bit uintptr) {
+	if t.ptrdata == 0 {
+		return
+	}
+	if t.tflag&TflagGCMaskOnDemand == 0 && t.kind&KindGCProg == 0 {
+		src := unsafe.Slice(t.gcdata, (t.ptrdata/ptrSize+7)/8)
+		for i := uintptr(0); i < t.ptrdata/ptrSize; i++ {
+			if src[i/8]&(1<<(i%8)) != 0 {
+				mask[(bit+i)/8] |= 1 << ((bit + i) % 8)
+			}
+		}
+		return
+	}
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			buildGCMask(at.Elem, mask, bit+i*at.Elem.size/ptrSize)
+		}
+	case Struct:
+		for _, f := range (*StructType)(unsafe.Pointer(t)).Fields {
+			buildGCMask(f.typ, mask, bit+f.Offset()/ptrSize)
+		}
+	}
+}
+
+// VisitOptions controls VisitPointers.
+type VisitOptions struct {
+	// SkipNil omits the pointer slots holding nil.
+	SkipNil bool
+}
+
+// VisitPointers calls fn, in increasing offset order, with the offset of each
+// word of the value of type t at p that the garbage collector scans as a
+// pointer, and the pointer currently stored there, until fn returns false.
+//
+// The pointers are only loaded, never dereferenced. Of an interface value,
+// only the data word is a pointer slot; the type word points to static data.
+func VisitPointers(t *rtype, p unsafe.Pointer, fn func(offset uintptr, ptr unsafe.Pointer) bool) {
+	var o VisitOptions
+	o.VisitPointers(t, p, fn)
+}
+
+// VisitPointers is like the top-level VisitPointers function but honors o.
+func (o *VisitOptions) VisitPointers(t *rtype, p unsafe.Pointer, fn func(offset uintptr, ptr unsafe.Pointer) bool) {
+	mask := gcMask(t)
+	for i := uintptr(0); i < t.ptrdata/ptrSize; i++ {
+		if mask[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		off := i * ptrSize
+		ptr := *(*unsafe.Pointer)(Add(p, off, "off < t.ptrdata"))
+		if ptr == nil && o.SkipNil {
+			continue
+		}
+		if !fn(off, ptr) {
+			return
+		}
+	}
+}
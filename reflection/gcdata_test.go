@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type gcdataMixed struct {
+	A int
+	P *int
+	S string
+	N [2]int
+	I interface{}
+	B []byte
+}
+
+func TestGCDataBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *rtype
+		want []byte
+	}{
+		{"int", TypeFor[int](), []byte{}},
+		{"pointer", TypeFor[*int](), []byte{0b1}},
+		{"string", TypeFor[string](), []byte{0b1}},
+		{"slice", TypeFor[[]int](), []byte{0b1}},
+		{"interface", TypeFor[interface{}](), []byte{0b10}},
+		// A, P, S.Data, S.Len, N[0], N[1], I.Type, I.Data, B.Data.
+		{"struct", TypeFor[gcdataMixed](), []byte{0b10000110, 0b1}},
+		{"array", TypeFor[[3]struct {
+			N int
+			P *int
+		}](), []byte{0b101010}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GCDataBytes(tt.typ)
+			if !ok {
+				t.Fatal("GCDataBytes reported a GC program")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GCDataBytes = %08b, want %08b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCDataBytesLarge(t *testing.T) {
+	// Large arrays have their bitmap built on demand, or described by a GC
+	// program by older toolchains.
+	typ := TypeFor[[1 << 12]*int]()
+	got, ok := GCDataBytes(typ)
+	if !ok {
+		t.Skip("type is described by a GC program")
+	}
+	if len(got) != 1<<12/8 {
+		t.Fatalf("len(GCDataBytes) = %d, want %d", len(got), 1<<12/8)
+	}
+	for i, b := range got {
+		if b != 0xff {
+			t.Fatalf("GCDataBytes[%d] = %08b, want all pointers", i, b)
+		}
+	}
+}
+
+func TestVisitPointers(t *testing.T) {
+	x, y := 1, 2
+	v := gcdataMixed{P: &x, S: "s", I: &y}
+	type visit struct {
+		off uintptr
+		ptr unsafe.Pointer
+	}
+	var got []visit
+	VisitPointers(TypeFor[gcdataMixed](), unsafe.Pointer(&v), func(off uintptr, ptr unsafe.Pointer) bool {
+		got = append(got, visit{off, ptr})
+		return true
+	})
+	want := []visit{
+		{unsafe.Offsetof(v.P), unsafe.Pointer(&x)},
+		{unsafe.Offsetof(v.S), *(*unsafe.Pointer)(unsafe.Pointer(&v.S))},
+		{unsafe.Offsetof(v.I) + ptrSize, unsafe.Pointer(&y)},
+		{unsafe.Offsetof(v.B), nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisitPointers = %v, want %v", got, want)
+	}
+
+	got = nil
+	o := VisitOptions{SkipNil: true}
+	o.VisitPointers(TypeFor[gcdataMixed](), unsafe.Pointer(&v), func(off uintptr, ptr unsafe.Pointer) bool {
+		got = append(got, visit{off, ptr})
+		return len(got) < 2
+	})
+	if !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("VisitPointers with SkipNil stopping after 2 = %v, want %v", got, want[:2])
+	}
+
+	VisitPointers(TypeFor[[4]int](), unsafe.Pointer(new([4]int)), func(uintptr, unsafe.Pointer) bool {
+		t.Error("VisitPointers called fn for a pointer-free type")
+		return true
+	})
+}
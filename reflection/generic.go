@@ -0,0 +1,133 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// KindFor returns the kind of T.
+func KindFor[T any]() Kind {
+	return TypeFor[T]().Kind()
+}
+
+// As returns a pointer to the value of type T held by i, without copying it.
+// The boolean reports whether the dynamic type of i is exactly T.
+//
+// When T is stored indirectly in an interface (any non-pointer-shaped type,
+// such as a large struct) the returned pointer aliases the interface's storage:
+// mutations through it are visible through every copy of that interface, which
+// breaks the usual assumption that interface values are immutable. Use it only
+// for read-mostly hot paths where the copy of a type assertion is measurable.
+//
+// The storage of an interface need not be writable: the compiler places the
+// value of an interface converted from a constant, or from a composite
+// literal of constants, in the read-only data of the binary, and a write
+// through the returned pointer then faults. Only write through it when i is
+// known to hold a value copied to the heap, and otherwise copy *p first.
+//
+// When T is pointer-shaped (pointers, maps, chans, funcs and single pointer
+// structs or arrays) the value is the interface data word itself, which has no
+// address; As then returns a pointer to a fresh copy and does not alias i.
+func As[T any](i interface{}) (*T, bool) {
+	e := efaceOf(&i)
+	t := TypeFor[T]()
+	if e.Type != t {
+		return nil, false
+	}
+	if ifaceIndir(t) {
+		return (*T)(e.Word), true
+	}
+	p := new(T)
+	*(*unsafe.Pointer)(unsafe.Pointer(p)) = e.Word
+	return p, true
+}
+
+// FieldDesc describes a field of the struct type T, resolved once by FieldOf
+// and typically kept in a package-level variable.
+type FieldDesc[T any] struct {
+	Offset uintptr // byte offset of the field within T
+	Type   *rtype  // type of the field
+	Index  int     // index of the field within its enclosing struct type
+}
+
+// FieldOf resolves the field of T named by name, which may be a dotted path
+// into nested struct fields as accepted by OffsetOf. Unexported fields are
+// accepted.
+//
+// It returns an error for unknown field names, so that the package-level
+// variable pattern fails fast, when the program starts:
+//
+//	var userName = func() reflection.FieldDesc[User] {
+//		f, err := reflection.FieldOf[User]("Name")
+//		if err != nil {
+//			panic(err)
+//		}
+//		return f
+//	}()
+func FieldOf[T any](name string) (FieldDesc[T], error) {
+	off, ft, i, err := lookupField(TypeFor[T](), "FieldOf", name)
+	if err != nil {
+		return FieldDesc[T]{}, err
+	}
+	return FieldDesc[T]{Offset: off, Type: ft, Index: i}, nil
+}
+
+// StringFieldOf is like FieldOf but additionally requires the field to be of
+// string kind, so that GetString may be used on the result.
+func StringFieldOf[T any](name string) (FieldDesc[T], error) {
+	f, err := FieldOf[T](name)
+	if err != nil {
+		return f, err
+	}
+	if k := f.Type.Kind(); k != String {
+		return FieldDesc[T]{}, &KindError{Op: "StringFieldOf", Kind: k}
+	}
+	return f, nil
+}
+
+// IntFieldOf is like FieldOf but additionally requires the field to be of a
+// signed integer kind, so that GetInt may be used on the result.
+func IntFieldOf[T any](name string) (FieldDesc[T], error) {
+	f, err := FieldOf[T](name)
+	if err != nil {
+		return f, err
+	}
+	switch k := f.Type.Kind(); k {
+	case Int, Int8, Int16, Int32, Int64:
+	default:
+		return FieldDesc[T]{}, &KindError{Op: "IntFieldOf", Kind: k}
+	}
+	return f, nil
+}
+
+// Get returns a pointer to the field within *p.
+func (f FieldDesc[T]) Get(p *T) unsafe.Pointer {
+	return Add(unsafe.Pointer(p), f.Offset, "field offset is within T")
+}
+
+// GetString returns the value of the field within *p.
+// f must have been resolved by StringFieldOf.
+func (f FieldDesc[T]) GetString(p *T) string {
+	return *(*string)(f.Get(p))
+}
+
+// GetInt returns the value of the field within *p, widened to int64.
+// f must have been resolved by IntFieldOf.
+func (f FieldDesc[T]) GetInt(p *T) int64 {
+	ptr := f.Get(p)
+	switch f.Type.Kind() {
+	case Int8:
+		return int64(*(*int8)(ptr))
+	case Int16:
+		return int64(*(*int16)(ptr))
+	case Int32:
+		return int64(*(*int32)(ptr))
+	case Int64:
+		return *(*int64)(ptr)
+	default:
+		return int64(*(*int)(ptr))
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type genericT struct {
+	A int
+	B string
+	C [8]int64
+}
+
+func TestTypeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		got  *rtype
+		want reflect.Type
+	}{
+		{"int", TypeFor[int](), reflect.TypeOf(0)},
+		{"string", TypeFor[string](), reflect.TypeOf("")},
+		{"struct", TypeFor[genericT](), reflect.TypeOf(genericT{})},
+		{"pointer", TypeFor[*genericT](), reflect.TypeOf((*genericT)(nil))},
+		{"slice", TypeFor[[]byte](), reflect.TypeOf([]byte(nil))},
+		{"map", TypeFor[map[string]int](), reflect.TypeOf(map[string]int(nil))},
+		{"interface", TypeFor[io.Reader](), reflect.TypeOf((*io.Reader)(nil)).Elem()},
+		{"empty interface", TypeFor[interface{}](), reflect.TypeOf((*interface{})(nil)).Elem()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got == nil {
+				t.Fatal("TypeFor returned nil")
+			}
+			if got := ToReflect(tt.got); got != tt.want {
+				t.Errorf("TypeFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKindFor(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Kind
+		want Kind
+	}{
+		{"int", KindFor[int](), Int},
+		{"struct", KindFor[genericT](), Struct},
+		{"pointer", KindFor[*int](), Ptr},
+		{"interface", KindFor[io.Reader](), Interface},
+		{"func", KindFor[func()](), Func},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("KindFor[%s] = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestTypeForAllocs(t *testing.T) {
+	if n := testing.AllocsPerRun(100, func() { _ = TypeFor[genericT]() }); n != 0 {
+		t.Errorf("TypeFor allocates %v times, want 0", n)
+	}
+}
+
+func BenchmarkTypeFor(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = TypeFor[genericT]()
+	}
+}
+
+func BenchmarkAs(b *testing.B) {
+	b.ReportAllocs()
+	var i interface{} = genericT{A: 1}
+	for n := 0; n < b.N; n++ {
+		if _, ok := As[genericT](i); !ok {
+			b.Fatal("As failed")
+		}
+	}
+}
+
+func TestAs(t *testing.T) {
+	large := genericT{A: 1, B: "b", C: [8]int64{7: 8}}
+	x := 42
+	tests := []struct {
+		name string
+		call func(interface{}) (interface{}, bool)
+		in   interface{}
+		want interface{}
+	}{
+		{"large struct", asOf[genericT], large, large},
+		{"pointer", asOf[*int], &x, &x},
+		{"map", asOf[map[string]int], map[string]int(nil), map[string]int(nil)},
+		{"string", asOf[string], "s", "s"},
+		{"mismatch", asOf[genericT], 1, nil},
+		{"mismatch pointer", asOf[*genericT], &x, nil},
+		{"nil", asOf[int], nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.call(tt.in)
+			if ok != (tt.want != nil) {
+				t.Fatalf("As ok = %v, want %v", ok, tt.want != nil)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("As = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// asOf returns the value As[T] points to, boxed.
+func asOf[T any](i interface{}) (interface{}, bool) {
+	p, ok := As[T](i)
+	if !ok {
+		return nil, false
+	}
+	return *p, true
+}
+
+func TestAsAliasing(t *testing.T) {
+	// A value of constants would be in read-only data, see the As docs.
+	v := genericT{A: len(t.Name())}
+	var i interface{} = v
+	j := i
+	p, ok := As[genericT](i)
+	if !ok {
+		t.Fatal("As failed")
+	}
+	p.A = 2
+	if got := j.(genericT).A; got != 2 {
+		t.Errorf("copy of the interface sees A = %d, want 2 through the aliasing pointer", got)
+	}
+
+	x := 1
+	i = &x
+	q, ok := As[*int](i)
+	if !ok {
+		t.Fatal("As failed")
+	}
+	*q = nil
+	if i.(*int) != &x {
+		t.Error("writing the result of As for a pointer type changed the interface")
+	}
+}
+
+// genericB is resolved once, when the package is initialized, and panics
+// there if genericT has no field B.
+var genericB = func() FieldDesc[genericT] {
+	f, err := StringFieldOf[genericT]("B")
+	if err != nil {
+		panic(err)
+	}
+	return f
+}()
+
+func ExampleFieldOf() {
+	v := genericT{A: 1, B: "b"}
+	fmt.Println(genericB.GetString(&v))
+	if _, err := FieldOf[genericT]("Missing"); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// b
+	// reflection: field not found: "Missing" in reflection.genericT
+}
@@ -0,0 +1,15 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 || arm64
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// getg returns the runtime.g of the calling goroutine.
+// Implemented in assembly.
+func getg() unsafe.Pointer
@@ -0,0 +1,60 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"unsafe"
+)
+
+// goidFast reports whether GoID reads the goid field of runtime.g, once
+// checked against the stack trace at init.
+var goidFast = func() bool {
+	g := getg()
+	if goidOffset == 0 || g == nil {
+		return false
+	}
+	return *(*int64)(unsafe.Add(g, goidOffset)) == goidSlow()
+}()
+
+// GetG returns the runtime.g of the calling goroutine, or nil on
+// architectures where it cannot be read. The layout of runtime.g depends
+// on the Go release.
+func GetG() unsafe.Pointer {
+	return getg()
+}
+
+// GoID returns the ID of the calling goroutine, as printed in stack traces.
+// IDs are unique among live goroutines, and are not reused by the runtime
+// before the 64-bit counter wraps.
+//
+// It is meant for debugging, such as checking which goroutine owns a
+// scratch buffer. It reads the ID from runtime.g where its offset is known
+// for the Go release and was checked at init; otherwise it parses the header
+// of the goroutine's stack trace, which is much slower.
+func GoID() int64 {
+	if goidFast {
+		return *(*int64)(unsafe.Add(getg(), goidOffset))
+	}
+	return goidSlow()
+}
+
+// goidSlow parses the ID of the calling goroutine from the header of its
+// stack trace, "goroutine 1 [running]:".
+func goidSlow() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return id
+}
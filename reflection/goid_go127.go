@@ -0,0 +1,17 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.27
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// goidOffset is the offset of the goid field in runtime.g, after the stack
+// bounds, stack guards, panic, defer and m pointers, the gobuf, the syscall
+// registers, stktopsp, param, atomicstatus and stackLock.
+const goidOffset = 2*unsafe.Sizeof(uintptr(0)) + 5*unsafe.Sizeof(uintptr(0)) +
+	6*unsafe.Sizeof(uintptr(0)) + 5*unsafe.Sizeof(uintptr(0)) + 8
@@ -0,0 +1,11 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.27
+
+package reflection
+
+// goidOffset is 0 on toolchains whose runtime.g layout is not mirrored by
+// this package, which makes GoID parse the stack trace.
+const goidOffset = 0
@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestGoID(t *testing.T) {
+	if !goidFast {
+		t.Log("GoID parses the stack trace")
+	}
+	id, g := GoID(), GetG()
+	if want := goidSlow(); id != want || id <= 0 {
+		t.Errorf("GoID = %d, want %d from the stack trace", id, want)
+	}
+	if GoID() != id || GetG() != g {
+		t.Error("GoID or GetG changed within a goroutine")
+	}
+
+	const n = 8
+	ids := make([]int64, n)
+	gs := make([]unsafe.Pointer, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], gs[i] = GoID(), GetG()
+			if want := goidSlow(); ids[i] != want {
+				t.Errorf("GoID = %d, want %d from the stack trace", ids[i], want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	seen := map[int64]bool{id: true}
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("GoID %d reported by two goroutines", id)
+		}
+		seen[id] = true
+	}
+	if g != nil {
+		for _, other := range gs {
+			if other == g || other == nil {
+				t.Errorf("GetG of another goroutine = %p, test goroutine %p", other, g)
+			}
+		}
+	}
+}
+
+func BenchmarkGoID(b *testing.B) {
+	b.Run("GoID", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchInt64 = GoID()
+		}
+	})
+	b.Run("stack", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchInt64 = goidSlow()
+		}
+	})
+}
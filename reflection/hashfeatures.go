@@ -0,0 +1,116 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"strconv"
+	"unsafe"
+)
+
+// HashBackend is an implementation of the runtime hash functions.
+type HashBackend uint8
+
+const (
+	// HashUnknown is reported when the backend cannot be told, on 32-bit
+	// platforms where the runtime flag selecting it is out of reach.
+	HashUnknown HashBackend = iota
+
+	// HashAES uses the AES instructions of the CPU.
+	HashAES
+
+	// HashWyhash uses the portable wyhash-based functions of 64-bit
+	// platforms.
+	HashWyhash
+
+	// HashFallback32 uses the portable functions of 32-bit platforms.
+	HashFallback32
+)
+
+var hashBackendNames = [...]string{
+	HashUnknown:    "unknown",
+	HashAES:        "aes",
+	HashWyhash:     "wyhash",
+	HashFallback32: "fallback32",
+}
+
+func (b HashBackend) String() string {
+	if int(b) < len(hashBackendNames) {
+		return hashBackendNames[b]
+	}
+	return "HashBackend(" + strconv.Itoa(int(b)) + ")"
+}
+
+// HashFeatureSet describes the implementation of the hash functions the
+// runtime uses for maps, and this package through MemHash, EfaceHash,
+// NilInterHash and InterHash. It is fixed at program start.
+type HashFeatureSet struct {
+	// Backend is the implementation in use.
+	Backend HashBackend
+
+	// AES is set when hashing uses the AES instructions of the CPU.
+	AES bool
+
+	// WyhashFallback is set when hashing uses the portable wyhash-based
+	// functions of 64-bit platforms. It is never set together with AES.
+	WyhashFallback bool
+}
+
+// hashFeatures is computed once, as the backend cannot change.
+var hashFeatures = loadHashFeatures()
+
+// HashFeatures reports which implementation the runtime hash functions use.
+func HashFeatures() HashFeatureSet {
+	return hashFeatures
+}
+
+// loadHashFeatures reads the flag the runtime selects the backend with at
+// startup, or probes the hash functions where it cannot be read.
+func loadHashFeatures() HashFeatureSet {
+	if aes, ok := runtimeUseAeshash(); ok {
+		return hashFeaturesOf(aes)
+	}
+	return probeHashFeatures()
+}
+
+// hashFeaturesOf returns the features of the platform when the AES backend
+// is in use, or not.
+func hashFeaturesOf(aes bool) HashFeatureSet {
+	switch {
+	case aes:
+		return HashFeatureSet{Backend: HashAES, AES: true}
+	case ptrSize == 8:
+		return HashFeatureSet{Backend: HashWyhash, WyhashFallback: true}
+	}
+	return HashFeatureSet{Backend: HashFallback32}
+}
+
+// probeHashFeatures tells the backends of 64-bit platforms apart by hashing
+// no bytes with two seeds: the wyhash fallback returns the seed mixed with
+// its key by xor only, so that the xor of the results is that of the seeds,
+// which the AES scrambling does not preserve.
+func probeHashFeatures() HashFeatureSet {
+	if ptrSize != 8 {
+		return HashFeatureSet{}
+	}
+	var b byte
+	const s1, s2 = 0x9e3779b9, 0x2545f491
+	h1 := memhash(unsafe.Pointer(&b), s1, 0)
+	h2 := memhash(unsafe.Pointer(&b), s2, 0)
+	return hashFeaturesOf(h1^h2 != s1^s2)
+}
+
+// MemHash returns the hash of the size bytes at p with the given seed, as
+// the runtime hashes regular memory, with the backend HashFeatures().Backend
+// reports.
+func MemHash(p unsafe.Pointer, seed, size uintptr) uintptr {
+	return memhash(p, seed, size)
+}
+
+//go:linkname memhash runtime.memhash
+
+// memhash computes the hash of the s bytes at p.
+// h is the seed.
+// Implemented in the runtime package.
+func memhash(p unsafe.Pointer, h, s uintptr) uintptr
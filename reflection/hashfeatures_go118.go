@@ -0,0 +1,23 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.27
+
+package reflection
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+//go:linkname useAeshash runtime.useAeshash
+
+// useAeshash is set at startup when the runtime hashes with the AES
+// instructions.
+// Implemented in the runtime package.
+var useAeshash bool
+
+// runtimeUseAeshash returns the flag selecting the AES hash backend.
+func runtimeUseAeshash() (aes, ok bool) {
+	return useAeshash, true
+}
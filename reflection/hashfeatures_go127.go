@@ -0,0 +1,14 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.27
+
+package reflection
+
+// runtimeUseAeshash reports that the flag selecting the AES hash backend
+// cannot be read: it moved to internal/runtime/maps.UseAeshash, which the
+// linker does not let other packages reference.
+func runtimeUseAeshash() (aes, ok bool) {
+	return false, false
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestHashFeatures(t *testing.T) {
+	f := HashFeatures()
+	if f.AES != (f.Backend == HashAES) || f.WyhashFallback != (f.Backend == HashWyhash) {
+		t.Errorf("HashFeatures = %+v: flags disagree with the backend", f)
+	}
+	if ptrSize == 8 && f.Backend != HashAES && f.Backend != HashWyhash {
+		t.Errorf("HashFeatures().Backend = %v on a 64-bit platform", f.Backend)
+	}
+	if aes, ok := runtimeUseAeshash(); ok {
+		if want := hashFeaturesOf(aes); f != want {
+			t.Errorf("HashFeatures = %+v, want %+v from the runtime flag", f, want)
+		}
+		if p := probeHashFeatures(); ptrSize == 8 && p != f {
+			t.Errorf("probeHashFeatures = %+v, want %+v", p, f)
+		}
+	}
+
+	// The wyhash fallback keeps the xor of the seeds for no bytes.
+	var b byte
+	h1, h2 := MemHash(unsafe.Pointer(&b), 1, 0), MemHash(unsafe.Pointer(&b), 2, 0)
+	if f.Backend == HashWyhash && h1^h2 != 3 {
+		t.Errorf("MemHash of no bytes does not behave as wyhash: %#x, %#x", h1, h2)
+	}
+}
+
+func TestHashBackendString(t *testing.T) {
+	tests := []struct {
+		b    HashBackend
+		want string
+	}{
+		{HashUnknown, "unknown"},
+		{HashAES, "aes"},
+		{HashWyhash, "wyhash"},
+		{HashFallback32, "fallback32"},
+		{HashBackend(9), "HashBackend(9)"},
+	}
+	for _, tt := range tests {
+		if got := tt.b.String(); got != tt.want {
+			t.Errorf("HashBackend(%d).String() = %q, want %q", tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// hchan mirrors the leading fields of runtime.hchan.
+type hchan struct {
+	qcount   uint           // total data in the queue
+	dataqsiz uint           // size of the circular queue
+	buf      unsafe.Pointer // points to an array of dataqsiz elements
+	elemsize uint16
+	closed   uint32
+	timer    unsafe.Pointer // timer feeding this chan
+	elemtype *rtype         // element type
+	sendx    uint           // send index
+	recvx    uint           // receive index
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// implCache is an immutable snapshot of the results of Implements, by
+// interface type then by type, valid for the set of loaded modules whose
+// last module is gen.
+type implCache struct {
+	gen unsafe.Pointer
+	m   map[*InterfaceType]map[*rtype]bool
+}
+
+var implements struct {
+	sync.Mutex              // serializes updates
+	cache      atomic.Value // *implCache
+}
+
+// ImplementsCached is like Implements(&iface.rtype, t), but memoizes the
+// results. Lookups of cached results take no lock; a miss copies the cache
+// to add the new result.
+//
+// The cache is tied to the set of loaded modules reported by Modules, and
+// starts afresh after a plugin is loaded, whose types unify with the ones of
+// the host only from then on.
+func ImplementsCached(t *rtype, iface *InterfaceType) bool {
+	gen := atomic.LoadPointer(&lastmoduledatap)
+	c, _ := implements.cache.Load().(*implCache)
+	if c != nil && c.gen == gen {
+		if ok, hit := c.m[iface][t]; hit {
+			return ok
+		}
+	}
+
+	ok := Implements(&iface.rtype, t)
+
+	implements.Lock()
+	defer implements.Unlock()
+	c, _ = implements.cache.Load().(*implCache)
+	nc := &implCache{gen: gen, m: make(map[*InterfaceType]map[*rtype]bool)}
+	if c != nil && c.gen == gen {
+		for k, v := range c.m {
+			nc.m[k] = v
+		}
+	}
+	types := make(map[*rtype]bool, len(nc.m[iface])+1)
+	for k, v := range nc.m[iface] {
+		types[k] = v
+	}
+	types[t] = ok
+	nc.m[iface] = types
+	implements.cache.Store(nc)
+	return ok
+}
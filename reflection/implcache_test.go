@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestImplementsCached(t *testing.T) {
+	ifaces := []*InterfaceType{
+		(*InterfaceType)(unsafe.Pointer(TypeFor[io.Reader]())),
+		(*InterfaceType)(unsafe.Pointer(TypeFor[io.ReadWriter]())),
+		(*InterfaceType)(unsafe.Pointer(TypeFor[error]())),
+	}
+	// Twice, the second time from the cache.
+	for i := 0; i < 2; i++ {
+		for _, iface := range ifaces {
+			for _, v := range assignTypes {
+				typ := FromReflect(v)
+				if got, want := ImplementsCached(typ, iface), Implements(&iface.rtype, typ); got != want {
+					t.Errorf("pass %d: ImplementsCached(%v, %v) = %v, want %v", i, v, ToReflect(&iface.rtype), got, want)
+				}
+			}
+		}
+	}
+
+	c := implements.cache.Load().(*implCache)
+	if ok, hit := c.m[ifaces[0]][TypeFor[assignInt]()]; !hit || !ok {
+		t.Errorf("cache entry of assignInt for io.Reader = %v, %v, want true, true", ok, hit)
+	}
+}
+
+func TestImplementsCachedConcurrent(t *testing.T) {
+	reader := (*InterfaceType)(unsafe.Pointer(TypeFor[io.Reader]()))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, v := range assignTypes {
+				typ := FromReflect(v)
+				if got, want := ImplementsCached(typ, reader), Implements(&reader.rtype, typ); got != want {
+					t.Errorf("ImplementsCached(%v, io.Reader) = %v, want %v", v, got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// implementsBench is the hit-heavy workload of the Implements benchmarks:
+// the same few pairs of types and interfaces queried over and over.
+func implementsBench(b *testing.B, implements func(t *rtype, iface *InterfaceType) bool) {
+	ifaces := []*InterfaceType{
+		(*InterfaceType)(unsafe.Pointer(TypeFor[io.Reader]())),
+		(*InterfaceType)(unsafe.Pointer(TypeFor[io.ReadWriter]())),
+		(*InterfaceType)(unsafe.Pointer(TypeFor[error]())),
+	}
+	types := make([]*rtype, len(assignTypes))
+	for i, v := range assignTypes {
+		types[i] = FromReflect(v)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		implements(types[i%len(types)], ifaces[i%len(ifaces)])
+	}
+}
+
+func BenchmarkImplements(b *testing.B) {
+	implementsBench(b, func(t *rtype, iface *InterfaceType) bool {
+		return Implements(&iface.rtype, t)
+	})
+}
+
+func BenchmarkImplementsCached(b *testing.B) {
+	implementsBench(b, ImplementsCached)
+}
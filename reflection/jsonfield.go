@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// jsonFields is the index of the fields of a struct type by JSON name.
+type jsonFields struct {
+	exact  *FieldIndex
+	folded *FieldIndex // by FoldName
+}
+
+var jsonFieldCache TypeMap[*jsonFields]
+
+// ResolveJSONField returns the index in the Fields of t of the field that
+// encoding/json decodes the object key key into: the field of that name,
+// else the first field whose name matches key case-insensitively.
+//
+// Fields are named as encoding/json names them: by the part before the first
+// comma of their json tag, or by their field name when that is empty or not
+// a valid name. Unexported fields and fields tagged "-" are left out, and so
+// are untagged embedded structs, whose fields encoding/json promotes; the
+// promoted fields are not resolved. When several fields have the same name,
+// a single tagged one is resolved, and none otherwise.
+//
+// The index is built once per type; lookups then do not allocate.
+func ResolveJSONField(t *StructType, key []byte) (int, bool) {
+	x := jsonFieldCache.GetOrCompute(&t.rtype, compileJSONFields)
+	if i, ok := x.exact.Lookup(key); ok {
+		return i, true
+	}
+	var arr [32]byte // large enough for most names
+	return x.folded.Lookup(FoldName(key, arr[:0]))
+}
+
+func compileJSONFields(t *rtype) *jsonFields {
+	st := (*StructType)(unsafe.Pointer(t))
+	type candidate struct {
+		index  int
+		tagged bool
+		count  int // of fields of that name tagged as index is
+	}
+	byName := make(map[string]*candidate)
+	var order []string
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if !f.Name.IsExported() {
+			continue
+		}
+		tag := reflect.StructTag(f.Name.Tag()).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if j := strings.IndexByte(name, ','); j >= 0 {
+			name = name[:j]
+		}
+		tagged := isValidJSONName(name)
+		if !tagged {
+			if f.Embedded() && f.typ.Kind() == Struct {
+				continue
+			}
+			name = f.Name.Name()
+		}
+		c := byName[name]
+		switch {
+		case c == nil:
+			byName[name] = &candidate{index: i, tagged: tagged, count: 1}
+			order = append(order, name)
+		case tagged && !c.tagged:
+			*c = candidate{index: i, tagged: true, count: 1}
+		case tagged == c.tagged:
+			c.count++
+		}
+	}
+
+	var names []string
+	var index []int
+	for _, name := range order {
+		if c := byName[name]; c.count == 1 {
+			names = append(names, name)
+			index = append(index, c.index)
+		}
+	}
+
+	// A name matched case-insensitively resolves to the first field in
+	// declaration order.
+	byIndex := make([]int, len(names))
+	for k := range byIndex {
+		byIndex[k] = k
+	}
+	sort.Slice(byIndex, func(i, j int) bool { return index[byIndex[i]] < index[byIndex[j]] })
+	var folded []string
+	var foldedIndex []int
+	seen := make(map[string]bool)
+	for _, k := range byIndex {
+		name := string(FoldName([]byte(names[k]), nil))
+		if !seen[name] {
+			seen[name] = true
+			folded = append(folded, name)
+			foldedIndex = append(foldedIndex, index[k])
+		}
+	}
+	return &jsonFields{
+		exact:  newFieldIndex(names, index),
+		folded: newFieldIndex(folded, foldedIndex),
+	}
+}
+
+// isValidJSONName reports whether s is a valid name for encoding/json tags.
+func isValidJSONName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
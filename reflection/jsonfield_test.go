@@ -0,0 +1,173 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type jsonFieldEmbedded struct {
+	Promoted int
+}
+
+type jsonFieldStruct struct {
+	A      int `json:"alpha"`
+	B      int
+	C      int `json:"-"`
+	D      int `json:",omitempty"`
+	b      int
+	G      int `json:"g"`
+	Gx     int `json:"G"`
+	H      int
+	Htag   int `json:"H"`
+	Κάππα  int
+	Last   int `json:"last,string"`
+	Itself int `json:"alpha2,omitempty"`
+	jsonFieldEmbedded
+}
+
+func TestResolveJSONField(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[jsonFieldStruct]()))
+	keys := []string{
+		"alpha", "ALPHA", "Alpha", "A", "B", "b", "C", "-", "D",
+		"g", "G", "Gx", "H", "h", "Htag", "Κάππα", "ΚΆΠΠΑ",
+		"last", "Last", "alpha2", "Promoted", "jsonFieldEmbedded", "", "missing",
+	}
+	for _, key := range keys {
+		// The field encoding/json decodes key into.
+		var v jsonFieldStruct
+		if err := json.Unmarshal([]byte(`{`+string(mustMarshal(t, key))+`:1}`), &v); err != nil {
+			// A ",string" field rejects the number; decode a string instead.
+			if err := json.Unmarshal([]byte(`{`+string(mustMarshal(t, key))+`:"1"}`), &v); err != nil {
+				t.Fatalf("%q: %v", key, err)
+			}
+		}
+		want, wantOK := -1, false
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.NumField(); i++ {
+			if f := rv.Field(i); f.Kind() == reflect.Int && f.Int() != 0 {
+				want, wantOK = i, true
+			}
+		}
+
+		got, ok := ResolveJSONField(st, []byte(key))
+		if !ok {
+			got = -1
+		}
+		if got != want || ok != wantOK {
+			t.Errorf("ResolveJSONField(%q) = %d, %v, want %d, %v", key, got, ok, want, wantOK)
+		}
+	}
+}
+
+func TestResolveJSONFieldDuplicates(t *testing.T) {
+	// Built at run time, as vet rejects duplicate tags in declarations.
+	typ := reflect.StructOf([]reflect.StructField{
+		{Name: "E", Type: reflect.TypeOf(0), Tag: `json:"dup"`},
+		{Name: "F", Type: reflect.TypeOf(0), Tag: `json:"dup"`},
+		{Name: "G", Type: reflect.TypeOf(0)},
+	})
+	st := (*StructType)(unsafe.Pointer(FromReflect(typ)))
+	for _, key := range []string{"dup", "DUP", "E", "F"} {
+		v := reflect.New(typ)
+		if err := json.Unmarshal([]byte(`{"`+key+`":1}`), v.Interface()); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if v.Elem().Field(i).Int() != 0 {
+				t.Fatalf("encoding/json decoded %q into %s", key, typ.Field(i).Name)
+			}
+		}
+		if i, ok := ResolveJSONField(st, []byte(key)); ok {
+			t.Errorf("ResolveJSONField(%q) = %d, true, want no field", key, i)
+		}
+	}
+}
+
+func TestResolveJSONFieldAllocs(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[jsonFieldStruct]()))
+	ResolveJSONField(st, []byte("alpha"))
+	key := []byte("ALPHA")
+	if n := testing.AllocsPerRun(100, func() { ResolveJSONField(st, key) }); n != 0 {
+		t.Errorf("ResolveJSONField allocates %v times", n)
+	}
+}
+
+// jsonFieldName returns the name encoding/json gives to a field of the given
+// name and tag, and whether the field is encoded at all.
+func jsonFieldName(name string, tag reflect.StructTag) (string, bool) {
+	v := tag.Get("json")
+	if v == "-" {
+		return "", false
+	}
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return name, true
+	}
+	return v, true
+}
+
+func BenchmarkResolveJSONField(b *testing.B) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[jsonFieldStruct]()))
+	rt := reflect.TypeOf(jsonFieldStruct{})
+	keys := [][]byte{[]byte("alpha"), []byte("LAST"), []byte("Htag"), []byte("missing")}
+
+	b.Run("ResolveJSONField", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ResolveJSONField(st, keys[i%len(keys)])
+		}
+	})
+	// reflect walks the fields of the reflect.Type on every lookup.
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			key := string(keys[i%len(keys)])
+			found := -1
+			for j := 0; j < rt.NumField() && found < 0; j++ {
+				f := rt.Field(j)
+				if name, ok := jsonFieldName(f.Name, f.Tag); ok && f.IsExported() && strings.EqualFold(name, key) {
+					found = j
+				}
+			}
+			benchInt64 = int64(found)
+		}
+	})
+	// parse reads the tags from the descriptor on every lookup.
+	b.Run("parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			found := -1
+			for j := range st.Fields {
+				f := &st.Fields[j]
+				if !f.Name.IsExported() {
+					continue
+				}
+				if name, ok := jsonFieldName(f.Name.Name(), reflect.StructTag(f.Name.Tag())); ok && bytes.EqualFold([]byte(name), key) {
+					found = j
+					break
+				}
+			}
+			benchInt64 = int64(found)
+		}
+	})
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+)
+
+// Type is the read-only subset of reflect.Type that can be answered from
+// type descriptors alone. Code written against that subset of reflect.Type
+// ports by replacing reflect.TypeOf with TypeOfLite.
+//
+// Like reflect.Type, Type values are comparable, and methods panic when
+// called on a type of the wrong kind.
+//
+// Method, MethodByName, Implements, AssignableTo, ConvertibleTo and the other
+// methods of reflect.Type that take or return reflect values or reflect.Type
+// arguments are intentionally absent; use ToReflect for them.
+type Type interface {
+	// Kind returns the specific kind of the type.
+	Kind() Kind
+
+	// Size returns the number of bytes needed to store a value of the type.
+	Size() uintptr
+
+	// Name returns the type's name within its package for a defined type.
+	Name() string
+
+	// PkgPath returns the package path of a defined type.
+	PkgPath() string
+
+	// String returns a string representation of the type.
+	String() string
+
+	// NumField returns a struct type's field count.
+	NumField() int
+
+	// Field returns a struct type's i'th field.
+	Field(i int) LiteStructField
+
+	// Elem returns the element type of an array, chan, map, pointer or slice type.
+	Elem() Type
+
+	// Key returns a map type's key type.
+	Key() Type
+
+	// NumMethod returns the number of exported methods in the type's method set.
+	NumMethod() int
+}
+
+// LiteStructField describes a single field in a struct, as reflect.StructField.
+type LiteStructField struct {
+	Name      string
+	PkgPath   string // empty for exported fields
+	Type      Type
+	Tag       reflect.StructTag
+	Offset    uintptr
+	Index     []int
+	Anonymous bool
+}
+
+// TypeOfLite returns the Type of the dynamic type of i, or nil if i is nil.
+func TypeOfLite(i interface{}) Type {
+	return toLite(efaceOf(&i).Type)
+}
+
+// toLite returns t as a Type, mapping a nil t to a nil Type.
+func toLite(t *rtype) Type {
+	if t == nil {
+		return nil
+	}
+	return liteType{t}
+}
+
+// liteType implements Type.
+type liteType struct {
+	*rtype
+}
+
+func (t liteType) Elem() Type {
+	e, err := t.rtype.Elem()
+	if err != nil {
+		panic(err)
+	}
+	return toLite(e)
+}
+
+func (t liteType) Key() Type {
+	k, err := t.rtype.Key()
+	if err != nil {
+		panic(err)
+	}
+	return toLite(k)
+}
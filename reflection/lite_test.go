@@ -0,0 +1,102 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type liteInner struct{ X float32 }
+
+type liteT struct {
+	Name  string `json:"name"`
+	count int
+	liteInner
+	M   map[liteInner][]*liteT
+	C   <-chan [2]byte
+	F   func(int) error
+	R   io.Reader
+	At  time.Time
+	Any interface{}
+}
+
+func (liteT) Exported()   {}
+func (liteT) unexported() {}
+
+// panics reports whether f panics.
+func panics(f func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	f()
+	return false
+}
+
+// checkLite compares every method of Type on lt with reflect.Type on rt,
+// recursing through elements, keys and fields up to depth.
+func checkLite(t *testing.T, lt Type, rt reflect.Type, depth int) {
+	t.Helper()
+	if Kind(rt.Kind()) != lt.Kind() || rt.Size() != lt.Size() || rt.Name() != lt.Name() ||
+		rt.PkgPath() != lt.PkgPath() || rt.String() != lt.String() || rt.NumMethod() != lt.NumMethod() {
+		t.Errorf("%v: Type = {%v %d %q %q %q %d}, want {%v %d %q %q %q %d}", rt,
+			lt.Kind(), lt.Size(), lt.Name(), lt.PkgPath(), lt.String(), lt.NumMethod(),
+			rt.Kind(), rt.Size(), rt.Name(), rt.PkgPath(), rt.String(), rt.NumMethod())
+	}
+	if depth == 0 {
+		return
+	}
+	switch rt.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Ptr, reflect.Slice:
+		checkLite(t, lt.Elem(), rt.Elem(), depth-1)
+	case reflect.Map:
+		checkLite(t, lt.Key(), rt.Key(), depth-1)
+		checkLite(t, lt.Elem(), rt.Elem(), depth-1)
+	case reflect.Struct:
+		if lt.NumField() != rt.NumField() {
+			t.Fatalf("%v: NumField = %d, want %d", rt, lt.NumField(), rt.NumField())
+		}
+		for i := 0; i < rt.NumField(); i++ {
+			lf, rf := lt.Field(i), rt.Field(i)
+			if lf.Name != rf.Name || lf.PkgPath != rf.PkgPath || lf.Tag != rf.Tag || lf.Offset != rf.Offset ||
+				!reflect.DeepEqual(lf.Index, rf.Index) || lf.Anonymous != rf.Anonymous {
+				t.Errorf("%v: Field(%d) = %+v, want %+v", rt, i, lf, rf)
+			}
+			checkLite(t, lf.Type, rf.Type, depth-1)
+		}
+	}
+	for _, m := range []struct {
+		name string
+		f    func()
+		ok   bool
+	}{
+		{"NumField", func() { lt.NumField() }, rt.Kind() == reflect.Struct},
+		{"Elem", func() { lt.Elem() }, !panics(func() { rt.Elem() })},
+		{"Key", func() { lt.Key() }, rt.Kind() == reflect.Map},
+	} {
+		if got := panics(m.f); got == m.ok {
+			t.Errorf("%v: %s panics = %v, want %v", rt, m.name, got, !m.ok)
+		}
+	}
+}
+
+func TestTypeOfLite(t *testing.T) {
+	for _, v := range []interface{}{
+		liteT{}, &liteT{}, []liteT{}, [3]*liteInner{}, map[string]int{}, 0, "", 1.5i,
+		make(chan<- int), func(...string) {}, struct{}{}, (*io.Reader)(nil),
+	} {
+		checkLite(t, TypeOfLite(v), reflect.TypeOf(v), 3)
+	}
+	if TypeOfLite(nil) != nil {
+		t.Errorf("TypeOfLite(nil) = %v, want nil", TypeOfLite(nil))
+	}
+	// Types compare equal as reflect.Type values do.
+	if TypeOfLite(liteT{}) != TypeOfLite(liteT{Name: "x"}) || TypeOfLite(liteT{}) == TypeOfLite(&liteT{}) {
+		t.Error("TypeOfLite values do not compare as their types")
+	}
+	if TypeOfLite(&liteT{}).Elem() != TypeOfLite(liteT{}) {
+		t.Error("Elem of *liteT is not TypeOfLite(liteT{})")
+	}
+}
@@ -2,6 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build ignore
 // +build ignore
 
 package main
@@ -29,11 +30,14 @@ func main() {
 	var iface interface{} = a
 
 	ifaceHeader := (*reflection.InterfaceHeader)(unsafe.Pointer(&iface))
-	st := (*reflection.StructType)(unsafe.Pointer(ifaceHeader.Type))
+	st, err := reflection.AsStructType(ifaceHeader.Type)
+	if err != nil {
+		panic(err)
+	}
 
 	// possible to iterate through the fields
-	for i := range st.Fields {
-		f := st.Fields[i]
+	st.Range(func(i int, f *reflection.StructField) bool {
 		fmt.Printf("Name: %s, Tag: %8s\n", f.Name.Name(), f.Name.Tag())
-	}
+		return true
+	})
 }
@@ -0,0 +1,97 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// PackEface returns an interface{} holding the value of type t pointed to by p.
+//
+// For types stored indirectly in interfaces the result aliases *p rather than
+// holding a copy, so *p must not be modified while the interface is in use.
+func PackEface(t *rtype, p unsafe.Pointer) interface{} {
+	var i interface{}
+	e := efaceOf(&i)
+	if ifaceIndir(t) {
+		e.Word = p
+	} else {
+		e.Word = *(*unsafe.Pointer)(p)
+	}
+	e.Type = t
+	return i
+}
+
+// packWord returns an interface{} of the pointer-shaped type t whose data word is w.
+func packWord(t *rtype, w unsafe.Pointer) interface{} {
+	return PackEface(t, unsafe.Pointer(&w))
+}
+
+// MakeMap creates a new map of the map type t with space for approximately
+// hint entries, and returns it as an interface{} holding a value of type t.
+func MakeMap(t *rtype, hint int) (interface{}, error) {
+	if k := t.Kind(); k != Map {
+		return nil, &KindError{Op: "MakeMap", Kind: k}
+	}
+	if hint < 0 {
+		return nil, ErrNegativeSize
+	}
+	mt := (*MapType)(unsafe.Pointer(t))
+	if !IsComparable(mt.Key) {
+		return nil, errors.New("reflection: invalid map key type " + mt.Key.String())
+	}
+	return packWord(t, makemap(mt, hint)), nil
+}
+
+// MakeChan creates a new channel of the bidirectional channel type t with the
+// given buffer size, and returns it as an interface{} holding a value of type t.
+func MakeChan(t *rtype, buffer int) (interface{}, error) {
+	if k := t.Kind(); k != Chan {
+		return nil, &KindError{Op: "MakeChan", Kind: k}
+	}
+	if buffer < 0 {
+		return nil, ErrNegativeSize
+	}
+	ct := (*ChanType)(unsafe.Pointer(t))
+	if ChanDir(ct.Dir) != BothDir {
+		return nil, ErrChanDir
+	}
+	if ct.Elem.size >= 1<<16 {
+		return nil, errors.New("reflection: channel element type too large")
+	}
+	return packWord(t, makechan(ct, buffer)), nil
+}
+
+//go:linkname makemap reflect.makemap
+
+// makemap creates a new map of type t with space for cap entries.
+// Implemented in the runtime package.
+func makemap(t *MapType, cap int) unsafe.Pointer
+
+//go:linkname makechan reflect.makechan
+
+// makechan creates a new channel of type t with the given buffer size.
+// Implemented in the runtime package.
+func makechan(t *ChanType, size int) unsafe.Pointer
+
+// BoxInto stores the value of type t pointed to by src into *dst, as
+// "*dst = v" would, but without allocating: the value is copied into scratch,
+// a heap cell for a value of type t allocated once with New(t), and *dst is
+// made to point at it. Pointer-shaped types are stored in the interface
+// directly and scratch is not used.
+//
+// After BoxInto returns, *dst aliases scratch. The caller must not pass the
+// same scratch to BoxInto again, nor otherwise modify it, while the interface
+// value stored in *dst, or any copy of it, is still in use.
+func BoxInto(dst *interface{}, t *rtype, src unsafe.Pointer, scratch unsafe.Pointer) {
+	e := efaceOf(dst)
+	if ifaceIndir(t) {
+		TypedMemmove(t, scratch, src)
+		e.Type, e.Word = t, scratch
+		return
+	}
+	e.Type, e.Word = t, *(*unsafe.Pointer)(src)
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+// makeTypes keeps the types looked up by name in the tests of this file in
+// the binary.
+var makeTypes = []interface{}{map[string]int(nil), chan string(nil)}
+
+func TestMakeMap(t *testing.T) {
+	mt := TypeByName("map[string]int")
+	if mt == nil {
+		t.Fatal(`TypeByName("map[string]int") = nil`)
+	}
+	m, err := MakeMap(mt, 4)
+	if err != nil {
+		t.Fatalf("MakeMap = %v", err)
+	}
+	for i, k := range []string{"a", "b", "c"} {
+		v := i + 1
+		if err := MapAssignBytesKey(m, []byte(k), unsafe.Pointer(&v)); err != nil {
+			t.Fatalf("MapAssignBytesKey(%s) = %v", k, err)
+		}
+	}
+	got, ok := m.(map[string]int)
+	if !ok {
+		t.Fatalf("MakeMap returned %T, want map[string]int", m)
+	}
+	if len(got) != 3 || got["a"] != 1 || got["b"] != 2 || got["c"] != 3 {
+		t.Errorf("map = %v, want map[a:1 b:2 c:3]", got)
+	}
+	got["d"] = 4
+	if p, ok, err := MapAccessBytesKey(m, []byte("d")); !ok || err != nil || *(*int)(p) != 4 {
+		t.Errorf("MapAccessBytesKey(d) = %v, %v, want 4", ok, err)
+	}
+}
+
+func TestMakeChan(t *testing.T) {
+	ct := TypeByName("chan string")
+	if ct == nil {
+		t.Fatal(`TypeByName("chan string") = nil`)
+	}
+	c, err := MakeChan(ct, 1)
+	if err != nil {
+		t.Fatalf("MakeChan = %v", err)
+	}
+	got, ok := c.(chan string)
+	if !ok {
+		t.Fatalf("MakeChan returned %T, want chan string", c)
+	}
+	if cap(got) != 1 {
+		t.Errorf("cap = %d, want 1", cap(got))
+	}
+	got <- "x"
+	if s := <-got; s != "x" {
+		t.Errorf("received %q, want x", s)
+	}
+}
+
+func TestMakeErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := MakeMap(TypeFor[[]int](), 0); !errors.As(err, &ke) || ke.Kind != Slice {
+		t.Errorf("MakeMap([]int) = %v, want *KindError for slice", err)
+	}
+	if _, err := MakeChan(TypeFor[map[int]int](), 0); !errors.As(err, &ke) || ke.Kind != Map {
+		t.Errorf("MakeChan(map[int]int) = %v, want *KindError for map", err)
+	}
+	if _, err := MakeMap(TypeFor[map[string]int](), -1); err != ErrNegativeSize {
+		t.Errorf("MakeMap(-1) = %v, want ErrNegativeSize", err)
+	}
+	if _, err := MakeChan(TypeFor[chan int](), -1); err != ErrNegativeSize {
+		t.Errorf("MakeChan(-1) = %v, want ErrNegativeSize", err)
+	}
+	if _, err := MakeChan(TypeFor[<-chan int](), 0); err != ErrChanDir {
+		t.Errorf("MakeChan(<-chan int) = %v, want ErrChanDir", err)
+	}
+	if _, err := MakeChan(TypeFor[chan<- int](), 0); err != ErrChanDir {
+		t.Errorf("MakeChan(chan<- int) = %v, want ErrChanDir", err)
+	}
+	if _, err := MakeMap(TypeFor[map[interface{}]int](), 0); err != nil {
+		t.Errorf("MakeMap(map[interface{}]int) = %v, want nil", err)
+	}
+}
+
+type boxValue struct{ A, B, C int64 }
+
+// boxSink keeps the interface values of the boxing benchmarks escaping.
+var boxSink interface{}
+
+func TestBoxInto(t *testing.T) {
+	bt := TypeFor[boxValue]()
+	scratch := New(bt)
+	v := boxValue{1, 2, 3}
+	var i interface{}
+	BoxInto(&i, bt, unsafe.Pointer(&v), scratch)
+	if got, ok := i.(boxValue); !ok || got != v {
+		t.Errorf("BoxInto = %v, want %v", i, v)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		BoxInto(&boxSink, bt, unsafe.Pointer(&v), scratch)
+	}); allocs != 0 {
+		t.Errorf("BoxInto allocates %v times", allocs)
+	}
+
+	// Pointer-shaped values are stored in the interface directly.
+	p := &v
+	pt := TypeFor[*boxValue]()
+	BoxInto(&i, pt, unsafe.Pointer(&p), nil)
+	if got, ok := i.(*boxValue); !ok || got != p {
+		t.Errorf("BoxInto(*boxValue) = %v, want %p", i, p)
+	}
+}
+
+func BenchmarkBoxInto(b *testing.B) {
+	bt := TypeFor[boxValue]()
+	scratch := New(bt)
+	v := boxValue{1, 2, 3}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.A = int64(i)
+		BoxInto(&boxSink, bt, unsafe.Pointer(&v), scratch)
+	}
+}
+
+func BenchmarkBoxConvert(b *testing.B) {
+	v := boxValue{1, 2, 3}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.A = int64(i)
+		var x interface{} = v
+		boxSink = x
+	}
+}
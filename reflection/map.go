@@ -0,0 +1,201 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"unsafe"
+)
+
+// MapClear deletes all entries of the map held by m, as the clear builtin does,
+// keeping its allocated storage for reuse. Clearing a nil map is a no-op.
+// If m does not hold a map, MapClear returns a *KindError.
+//
+// Before Go 1.21, MapClear deletes the entries one by one, which cannot
+// delete the entries of NaN keys, never equal to themselves. It deletes the
+// other entries, and returns an error wrapping ErrUnsupported if any entry
+// is left.
+//
+// MapClear does not synchronize: clearing a map while another goroutine
+// accesses it is detected and reported by the runtime exactly as it would be
+// for any other unsynchronized map write.
+func MapClear(m interface{}) error {
+	e := efaceOf(&m)
+	if k := kindOf(e.Type); k != Map {
+		return &KindError{Op: "MapClear", Kind: k}
+	}
+	if e.Word == nil {
+		return nil
+	}
+	return mapclear((*MapType)(unsafe.Pointer(e.Type)), e.Word, m)
+}
+
+// MapKeys copies the keys of the map held by m to the array of cap keys at
+// dst, such as &keys[0] for a []K of that length, and returns their number.
+// If the map has more than cap entries, MapKeys copies nothing and returns
+// the number of entries, for the caller to retry with a larger array.
+//
+// Keys are copied with TypedMemmove, in iteration order, without boxing them
+// in interfaces or reflect.Values. MapKeys returns a *KindError if m does not
+// hold a map, and ErrNegativeSize if cap is negative.
+func MapKeys(m interface{}, dst unsafe.Pointer, cap int) (n int, err error) {
+	return mapCopy("MapKeys", m, dst, cap, false)
+}
+
+// MapValues is like MapKeys, but copies the elements of the map. As the
+// iteration order of maps varies from one iteration to the next, it is not
+// the order in which MapKeys copies the keys.
+func MapValues(m interface{}, dst unsafe.Pointer, cap int) (n int, err error) {
+	return mapCopy("MapValues", m, dst, cap, true)
+}
+
+func mapCopy(op string, m interface{}, dst unsafe.Pointer, cap int, elems bool) (int, error) {
+	e := efaceOf(&m)
+	if k := kindOf(e.Type); k != Map {
+		return 0, &KindError{Op: op, Kind: k}
+	}
+	if cap < 0 {
+		return 0, fmt.Errorf("%w: %s with cap %d", ErrNegativeSize, op, cap)
+	}
+	if e.Word == nil {
+		return 0, nil
+	}
+	mt := (*MapType)(unsafe.Pointer(e.Type))
+	n := mapLen(mt, e.Word)
+	if n > cap {
+		return n, nil
+	}
+	t := mt.Key
+	if elems {
+		t = mt.Elem
+	}
+	i := 0
+	mapRange(mt, e.Word, func(key, elem unsafe.Pointer) bool {
+		src := key
+		if elems {
+			src = elem
+		}
+		TypedMemmove(t, Add(dst, uintptr(i)*t.size, "i < cap"), src)
+		i++
+		return i < cap
+	})
+	runtime.KeepAlive(m)
+	return i, nil
+}
+
+// mapEntry holds pointers to a key of a map and to its element.
+type mapEntry struct {
+	key, elem unsafe.Pointer
+}
+
+// mapEntries holds the slices of entries that SortedMapRange reuses.
+var mapEntries = sync.Pool{New: func() interface{} { return new([]mapEntry) }}
+
+// SortedMapRange calls fn with pointers to each key and element of the map
+// held by m, in the order of the keys given by less, until fn returns false.
+// The pointers must not be written through, and are only valid during the
+// call; the map must not be modified during the iteration.
+//
+// If less is nil, keys of string, integer and floating-point kinds are
+// ordered by value, NaNs first; other key kinds return a *KindError. The
+// sort is not stable: entries whose keys less does not order come in an
+// unspecified order.
+//
+// Unlike sorting the keys of reflect.Value.MapKeys, SortedMapRange boxes
+// no key, and reuses the slice holding the entries across calls.
+func SortedMapRange(m interface{}, less func(a, b unsafe.Pointer) bool, fn func(k, v unsafe.Pointer) bool) error {
+	e := efaceOf(&m)
+	if k := kindOf(e.Type); k != Map {
+		return &KindError{Op: "SortedMapRange", Kind: k}
+	}
+	mt := (*MapType)(unsafe.Pointer(e.Type))
+	if less == nil {
+		if less = keyLess(mt.Key); less == nil {
+			return &KindError{Op: "SortedMapRange", Kind: mt.Key.Kind()}
+		}
+	}
+	if e.Word == nil {
+		return nil
+	}
+
+	p := mapEntries.Get().(*[]mapEntry)
+	entries := (*p)[:0]
+	mapRange(mt, e.Word, func(key, elem unsafe.Pointer) bool {
+		entries = append(entries, mapEntry{key, elem})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i].key, entries[j].key) })
+	for _, ent := range entries {
+		if !fn(ent.key, ent.elem) {
+			break
+		}
+	}
+
+	for i := range entries {
+		entries[i] = mapEntry{}
+	}
+	*p = entries[:0]
+	mapEntries.Put(p)
+	runtime.KeepAlive(m)
+	return nil
+}
+
+// SortedStringMapRange is like SortedMapRange for a map with string keys,
+// passing the keys as strings. It returns a *KindError if the keys of the
+// map held by m are not of string kind.
+func SortedStringMapRange(m interface{}, fn func(k string, v unsafe.Pointer) bool) error {
+	if t := efaceOf(&m).Type; t != nil && t.Kind() == Map {
+		if k := (*MapType)(unsafe.Pointer(t)).Key.Kind(); k != String {
+			return &KindError{Op: "SortedStringMapRange", Kind: k}
+		}
+	}
+	return SortedMapRange(m, nil, func(k, v unsafe.Pointer) bool {
+		return fn(*(*string)(k), v)
+	})
+}
+
+// keyLess returns the function ordering keys of type t by value, or nil if
+// the kind of t has no natural order.
+func keyLess(t *rtype) func(a, b unsafe.Pointer) bool {
+	switch t.Kind() {
+	case String:
+		return func(a, b unsafe.Pointer) bool { return *(*string)(a) < *(*string)(b) }
+	case Int:
+		return func(a, b unsafe.Pointer) bool { return *(*int)(a) < *(*int)(b) }
+	case Int8:
+		return func(a, b unsafe.Pointer) bool { return *(*int8)(a) < *(*int8)(b) }
+	case Int16:
+		return func(a, b unsafe.Pointer) bool { return *(*int16)(a) < *(*int16)(b) }
+	case Int32:
+		return func(a, b unsafe.Pointer) bool { return *(*int32)(a) < *(*int32)(b) }
+	case Int64:
+		return func(a, b unsafe.Pointer) bool { return *(*int64)(a) < *(*int64)(b) }
+	case Uint:
+		return func(a, b unsafe.Pointer) bool { return *(*uint)(a) < *(*uint)(b) }
+	case Uint8:
+		return func(a, b unsafe.Pointer) bool { return *(*uint8)(a) < *(*uint8)(b) }
+	case Uint16:
+		return func(a, b unsafe.Pointer) bool { return *(*uint16)(a) < *(*uint16)(b) }
+	case Uint32:
+		return func(a, b unsafe.Pointer) bool { return *(*uint32)(a) < *(*uint32)(b) }
+	case Uint64:
+		return func(a, b unsafe.Pointer) bool { return *(*uint64)(a) < *(*uint64)(b) }
+	case Uintptr:
+		return func(a, b unsafe.Pointer) bool { return *(*uintptr)(a) < *(*uintptr)(b) }
+	case Float32:
+		return func(a, b unsafe.Pointer) bool { return floatLess(float64(*(*float32)(a)), float64(*(*float32)(b))) }
+	case Float64:
+		return func(a, b unsafe.Pointer) bool { return floatLess(*(*float64)(a), *(*float64)(b)) }
+	}
+	return nil
+}
+
+// floatLess orders a before b, with NaNs first.
+func floatLess(a, b float64) bool {
+	return a < b || a != a && b == b
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.21
+
+package reflection
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Before Go 1.21, MapClear deletes the keys one by one, which leaves the
+// entries of NaN keys, never equal to themselves, and reports them.
+func TestMapClearNaN(t *testing.T) {
+	nan := math.NaN()
+	m := map[float64]int{nan: 1, nan: 2, 0: 3}
+	if err := MapClear(m); !errors.Is(err, ErrUnsupported) || len(m) != 2 {
+		t.Fatalf("MapClear = %v, leaving %v, want ErrUnsupported and the two NaN keys", err, m)
+	}
+	if err := MapClear(map[float64]int{0: 1, 1: 2}); err != nil {
+		t.Errorf("MapClear without NaN keys = %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package reflection
+
+import (
+	"math"
+	"testing"
+)
+
+// NaN keys are never equal to themselves, so they cannot be deleted one by
+// one, as MapClear does before Go 1.21, and each insertion adds an entry.
+func TestMapClearNaN(t *testing.T) {
+	nan := math.NaN()
+	m := map[float64]int{nan: 1, nan: 2, 0: 3}
+	if err := MapClear(m); err != nil || len(m) != 0 {
+		t.Fatalf("MapClear = %v, leaving %v", err, m)
+	}
+	m[nan] = 4
+	m[nan] = 5
+	if len(m) != 2 {
+		t.Errorf("cleared map holds %v after two insertions", m)
+	}
+	if err := MapClear(m); err != nil || len(m) != 0 {
+		t.Errorf("MapClear of a reused map = %v, leaving %v", err, m)
+	}
+}
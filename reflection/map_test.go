@@ -0,0 +1,302 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type mapKey string
+
+type mapClearKey struct {
+	S string
+	N int
+}
+
+func TestMapClear(t *testing.T) {
+	tests := []struct {
+		name string
+		m    interface{}
+		add  func(m interface{}) // inserts a new key into the cleared map
+	}{
+		{"int", map[int]string{1: "a", 2: "b"}, func(m interface{}) { m.(map[int]string)[3] = "c" }},
+		{"string", map[string]int{"a": 1, "b": 2}, func(m interface{}) { m.(map[string]int)["c"] = 3 }},
+		{
+			"struct",
+			map[mapClearKey]*int{{"a", 1}: new(int), {"b", 2}: nil},
+			func(m interface{}) { m.(map[mapClearKey]*int)[mapClearKey{"c", 3}] = new(int) },
+		},
+		{
+			"interface",
+			map[interface{}]int{1: 1, "a": 2, mapClearKey{"b", 2}: 3, nil: 4},
+			func(m interface{}) { m.(map[interface{}]int)[2.5] = 5 },
+		},
+		{"float64", map[float64]int{0: 1, 0.5: 2, math.Inf(1): 3}, func(m interface{}) { m.(map[float64]int)[-1] = 4 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := MapClear(tt.m); err != nil {
+				t.Fatalf("MapClear = %v", err)
+			}
+			v := reflect.ValueOf(tt.m)
+			if v.Len() != 0 {
+				t.Fatalf("cleared map holds %v", tt.m)
+			}
+			tt.add(tt.m)
+			if v.Len() != 1 {
+				t.Errorf("cleared map holds %v after an insertion", tt.m)
+			}
+			if err := MapClear(tt.m); err != nil || v.Len() != 0 {
+				t.Errorf("MapClear of a reused map = %v, leaving %v", err, tt.m)
+			}
+		})
+	}
+
+	if err := MapClear(map[int]int(nil)); err != nil {
+		t.Errorf("MapClear of a nil map = %v", err)
+	}
+	var ke *KindError
+	if err := MapClear([]int{1}); !errors.As(err, &ke) || ke.Kind != Slice {
+		t.Errorf("MapClear of a slice = %v, want a *KindError", err)
+	}
+}
+
+func TestMapKeysValues(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	keys := make([]string, 2)
+	if n, err := MapKeys(m, unsafe.Pointer(&keys[0]), len(keys)); err != nil || n != 3 || keys[0] != "" {
+		t.Fatalf("MapKeys with a short array = %d, %v, %q", n, err, keys)
+	}
+	keys = make([]string, 4)
+	n, err := MapKeys(m, unsafe.Pointer(&keys[0]), len(keys))
+	if err != nil || n != 3 {
+		t.Fatalf("MapKeys = %d, %v", n, err)
+	}
+	sort.Strings(keys[:n])
+	if !reflect.DeepEqual(keys[:n], []string{"a", "b", "c"}) {
+		t.Errorf("MapKeys copied %q", keys[:n])
+	}
+	vals := make([]int, 3)
+	if n, err := MapValues(m, unsafe.Pointer(&vals[0]), len(vals)); err != nil || n != 3 {
+		t.Fatalf("MapValues = %d, %v", n, err)
+	}
+	sort.Ints(vals)
+	if !reflect.DeepEqual(vals, []int{1, 2, 3}) {
+		t.Errorf("MapValues copied %v", vals)
+	}
+
+	if n, err := MapKeys(map[int]int(nil), nil, 0); n != 0 || err != nil {
+		t.Errorf("MapKeys of a nil map = %d, %v", n, err)
+	}
+	if _, err := MapKeys(m, nil, -1); !errors.Is(err, ErrNegativeSize) {
+		t.Errorf("MapKeys with cap -1 = %v, want ErrNegativeSize", err)
+	}
+	var ke *KindError
+	if _, err := MapValues(1, nil, 0); !errors.As(err, &ke) || ke.Op != "MapValues" {
+		t.Errorf("MapValues of an int = %v, want a *KindError", err)
+	}
+}
+
+type mapStructKey struct {
+	Name string
+	P    *int
+	N    int64
+}
+
+func TestMapKeysStructKeys(t *testing.T) {
+	xs := []int{0, 1, 2, 3, 4}
+	m := make(map[mapStructKey]*int)
+	for i := range xs {
+		m[mapStructKey{Name: strconv.Itoa(i), P: &xs[i], N: int64(-i)}] = &xs[i]
+	}
+
+	short := make([]mapStructKey, len(m)-1)
+	if n, err := MapKeys(m, unsafe.Pointer(&short[0]), len(short)); err != nil || n != len(m) {
+		t.Fatalf("MapKeys with %d slots = %d, %v, want %d", len(short), n, err, len(m))
+	}
+	for _, k := range short {
+		if k != (mapStructKey{}) {
+			t.Fatalf("MapKeys with too few slots copied %v", k)
+		}
+	}
+
+	keys := make([]mapStructKey, len(m))
+	n, err := MapKeys(m, unsafe.Pointer(&keys[0]), len(keys))
+	if err != nil || n != len(m) {
+		t.Fatalf("MapKeys = %d, %v", n, err)
+	}
+	runtime.GC() // the copied pointers are seen by the collector
+	for _, k := range keys {
+		if p, ok := m[k]; !ok || k.P != p || *k.P != -int(k.N) {
+			t.Errorf("MapKeys copied %+v, not a key of the map", k)
+		}
+	}
+	vals := make([]*int, len(m))
+	if n, err := MapValues(m, unsafe.Pointer(&vals[0]), len(vals)); err != nil || n != len(m) {
+		t.Fatalf("MapValues = %d, %v", n, err)
+	}
+	sort.Slice(vals, func(i, j int) bool { return *vals[i] < *vals[j] })
+	for i, p := range vals {
+		if p != &xs[i] {
+			t.Errorf("MapValues[%d] = %p, want %p", i, p, &xs[i])
+		}
+	}
+}
+
+func TestSortedMapRange(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name string
+		m    interface{}
+		want []string
+	}{
+		{"int", map[int]string{3: "c", -1: "a", 2: "b"}, []string{"a", "b", "c"}},
+		{"uint8", map[uint8]string{200: "b", 7: "a"}, []string{"a", "b"}},
+		{"float64", map[float64]string{1.5: "c", nan: "a", -2: "b"}, []string{"a", "b", "c"}},
+		{"float32", map[float32]string{1: "b", -1: "a"}, []string{"a", "b"}},
+		{"named string", map[mapKey]string{"y": "b", "x": "a", "z": "c"}, []string{"a", "b", "c"}},
+		{"nil", map[int]string(nil), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := SortedMapRange(tt.m, nil, func(k, v unsafe.Pointer) bool {
+				got = append(got, *(*string)(v))
+				return true
+			})
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortedMapRange visited %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	m := map[[2]int]int{{2, 0}: 2, {1, 9}: 1, {3, 1}: 3}
+	var ke *KindError
+	if err := SortedMapRange(m, nil, nil); !errors.As(err, &ke) || ke.Kind != Array {
+		t.Errorf("SortedMapRange without less on array keys = %v, want a *KindError", err)
+	}
+	var got []int
+	less := func(a, b unsafe.Pointer) bool { return (*[2]int)(a)[0] < (*[2]int)(b)[0] }
+	err := SortedMapRange(m, less, func(k, v unsafe.Pointer) bool {
+		got = append(got, *(*int)(v))
+		return len(got) < 2
+	})
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("SortedMapRange with less visited %v, %v, want [1 2]", got, err)
+	}
+}
+
+func TestSortedMapRangeStable(t *testing.T) {
+	ints := make(map[int]int)
+	strs := make(map[string]int)
+	for i := 0; i < 500; i++ {
+		ints[i*7919%1000-500] = i
+		strs[strconv.Itoa(i*7919%1000)] = i
+	}
+	order := func(m interface{}) []int {
+		var got []int
+		if err := SortedMapRange(m, nil, func(k, v unsafe.Pointer) bool {
+			got = append(got, *(*int)(v))
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	for _, m := range []interface{}{ints, strs} {
+		first := order(m)
+		if len(first) != 500 {
+			t.Fatalf("SortedMapRange(%T) visited %d entries, want 500", m, len(first))
+		}
+		for i := 0; i < 50; i++ {
+			if got := order(m); !reflect.DeepEqual(got, first) {
+				t.Fatalf("SortedMapRange(%T) pass %d visited another order", m, i)
+			}
+		}
+	}
+
+	var keys []string
+	SortedStringMapRange(strs, func(k string, v unsafe.Pointer) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if !sort.StringsAreSorted(keys) {
+		t.Error("SortedStringMapRange keys are not sorted")
+	}
+}
+
+func TestSortedStringMapRange(t *testing.T) {
+	var keys []string
+	err := SortedStringMapRange(map[string]int{"b": 2, "a": 1}, func(k string, v unsafe.Pointer) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if err != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("SortedStringMapRange visited %q, %v", keys, err)
+	}
+	var ke *KindError
+	if err := SortedStringMapRange(map[int]int{}, nil); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("SortedStringMapRange of int keys = %v, want a *KindError", err)
+	}
+}
+
+func BenchmarkSortedMapRange(b *testing.B) {
+	m := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		m[strconv.Itoa(i)] = i
+	}
+	b.Run("SortedMapRange", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			SortedMapRange(m, nil, func(k, v unsafe.Pointer) bool {
+				sum += *(*int)(v)
+				return true
+			})
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		v := reflect.ValueOf(m)
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, k := range keys {
+				sum += int(v.MapIndex(k).Int())
+			}
+		}
+	})
+}
+
+func BenchmarkMapKeys(b *testing.B) {
+	m := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		m[strconv.Itoa(i)] = i
+	}
+	keys := make([]string, len(m))
+	b.Run("MapKeys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			MapKeys(m, unsafe.Pointer(&keys[0]), len(keys))
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		v := reflect.ValueOf(m)
+		for i := 0; i < b.N; i++ {
+			for j, k := range v.MapKeys() {
+				keys[j] = k.String()
+			}
+		}
+	})
+}
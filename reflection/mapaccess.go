@@ -0,0 +1,115 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// This file holds the map operations the rest of the package is built on,
+// implemented with the runtime's map functions. Under the darkness_safe
+// build tag, safe.go implements them with package reflect instead.
+
+// safeMode reports whether the darkness_safe build tag is set.
+const safeMode = false
+
+// mapLen returns the number of entries of the map h of type t.
+func mapLen(_ *MapType, h unsafe.Pointer) int {
+	return maplen(h)
+}
+
+// mapRange calls fn with pointers to each key and element of the map h of
+// type t, in iteration order, until fn returns false. The pointers are only
+// valid during the call.
+func mapRange(t *MapType, h unsafe.Pointer, fn func(key, elem unsafe.Pointer) bool) {
+	var it mapIter
+	for mapiterinit(t, h, &it); it.key != nil; mapiternext(&it) {
+		if !fn(it.key, it.elem) {
+			return
+		}
+	}
+}
+
+// mapAccessString returns a pointer to the element of the key k in the map
+// h of type t with string keys, and whether it is present.
+func mapAccessString(t *MapType, h unsafe.Pointer, k string) (unsafe.Pointer, bool) {
+	if t.Elem.size <= maxFastElem {
+		return mapaccess2_faststr(t, h, k)
+	}
+	return mapaccess2(t, h, unsafe.Pointer(&k))
+}
+
+// mapStore sets the element of the key at key in the map h of type t to the
+// element at val.
+func mapStore(t *MapType, h, key, val unsafe.Pointer) {
+	typedmemmove(t.Elem, mapassign(t, h, key), val)
+}
+
+// mapIter has the layout of the runtime's hiter, the iterator state that
+// mapiterinit and mapiternext keep up to date. Since Go 1.24 the runtime
+// only uses its first words, keeping the current key and elem in place.
+type mapIter struct {
+	key         unsafe.Pointer // nil at the end of the iteration
+	elem        unsafe.Pointer
+	t           unsafe.Pointer
+	h           unsafe.Pointer
+	buckets     unsafe.Pointer
+	bptr        unsafe.Pointer
+	overflow    unsafe.Pointer
+	oldoverflow unsafe.Pointer
+	startBucket uintptr
+	offset      uint8
+	wrapped     bool
+	b           uint8
+	i           uint8
+	bucket      uintptr
+	checkBucket uintptr
+}
+
+//go:linkname mapiterinit runtime.mapiterinit
+
+// mapiterinit starts an iteration over the map h of type t, positioning it
+// at the first entry.
+// Implemented in the runtime package.
+func mapiterinit(t *MapType, h unsafe.Pointer, it *mapIter)
+
+//go:linkname mapiternext runtime.mapiternext
+
+// mapiternext advances it to the next entry.
+// Implemented in the runtime package.
+func mapiternext(it *mapIter)
+
+//go:linkname maplen reflect.maplen
+
+// maplen returns the number of entries of the map h.
+// Implemented in the runtime package.
+func maplen(h unsafe.Pointer) int
+
+//go:linkname mapaccess2_faststr runtime.mapaccess2_faststr
+
+// mapaccess2_faststr returns a pointer to the element of key in the map h
+// with string keys, and whether it is present.
+// Implemented in the runtime package.
+//
+//go:noescape
+func mapaccess2_faststr(t *MapType, h unsafe.Pointer, key string) (unsafe.Pointer, bool)
+
+//go:linkname mapaccess2 runtime.mapaccess2
+
+// mapaccess2 returns a pointer to the element of the key at key in the map
+// h, and whether it is present.
+// Implemented in the runtime package.
+//
+//go:noescape
+func mapaccess2(t *MapType, h unsafe.Pointer, key unsafe.Pointer) (unsafe.Pointer, bool)
+
+//go:linkname mapassign runtime.mapassign
+
+// mapassign returns a pointer to the element of the key at key in the map
+// h, inserting the key if absent.
+// Implemented in the runtime package.
+func mapassign(t *MapType, h unsafe.Pointer, key unsafe.Pointer) unsafe.Pointer
@@ -0,0 +1,74 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// maxFastElem is the largest element size for which the runtime stores the
+// elements of a map inline, as the string-keyed fast paths of the runtime
+// assume.
+const maxFastElem = 128
+
+// MapAccessBytesKey looks up the string key with the bytes of key in the map
+// with string keys held by m, without converting key to a string, and
+// returns a pointer to the element, which stays valid until the next write
+// to the map, and whether the key is present. The bytes of key must not be
+// modified during the call.
+//
+// MapAccessBytesKey returns a *KindError if m does not hold a map with keys
+// of string kind. A nil map has no keys.
+func MapAccessBytesKey(m interface{}, key []byte) (unsafe.Pointer, bool, error) {
+	mt, h, err := stringKeyedMap("MapAccessBytesKey", m)
+	if err != nil || h == nil {
+		return nil, false, err
+	}
+	var k string
+	if len(key) > 0 {
+		k = unsafeString(&key[0], len(key))
+	}
+	p, ok := mapAccessString(mt, h, k)
+	if !ok {
+		return nil, false, nil
+	}
+	return p, true, nil
+}
+
+// MapAssignBytesKey sets the element of the string key with the bytes of key
+// in the map with string keys held by m to the value of the element type at
+// val. Unlike MapAccessBytesKey, it copies key to a new string, as the map
+// keeps the key it is given: a key sharing the bytes of a []byte would
+// change with them, and corrupt the map.
+//
+// MapAssignBytesKey returns a *KindError if m does not hold a map with keys
+// of string kind, and an error wrapping ErrNilPointer for a nil map.
+func MapAssignBytesKey(m interface{}, key []byte, val unsafe.Pointer) error {
+	mt, h, err := stringKeyedMap("MapAssignBytesKey", m)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		return fmt.Errorf("%w: MapAssignBytesKey to nil %s", ErrNilPointer, &mt.rtype)
+	}
+	k := string(key)
+	mapStore(mt, h, unsafe.Pointer(&k), val)
+	return nil
+}
+
+// stringKeyedMap returns the type and the map held by m, which must be a map
+// with keys of string kind.
+func stringKeyedMap(op string, m interface{}) (*MapType, unsafe.Pointer, error) {
+	e := efaceOf(&m)
+	if k := kindOf(e.Type); k != Map {
+		return nil, nil, &KindError{Op: op, Kind: k}
+	}
+	mt := (*MapType)(unsafe.Pointer(e.Type))
+	if k := mt.Key.Kind(); k != String {
+		return nil, nil, &KindError{Op: op, Kind: k}
+	}
+	return mt, e.Word, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+// mapBig is larger than maxFastElem, so that the maps holding it take the
+// generic paths of the runtime.
+type mapBig [maxFastElem/8 + 1]int64
+
+func TestMapBytesKey(t *testing.T) {
+	small := map[mapKey]int64{"k": 1}
+	big := map[string]mapBig{"k": {1}}
+	tests := []struct {
+		name string
+		m    interface{}
+		val  unsafe.Pointer
+		get  func() interface{}
+	}{
+		{"small", small, unsafe.Pointer(&[]int64{2}[0]), func() interface{} { return small["new"] }},
+		{"big", big, unsafe.Pointer(&mapBig{2}), func() interface{} { return big["new"][0] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok, err := MapAccessBytesKey(tt.m, []byte("k"))
+			if err != nil || !ok || *(*int64)(p) != 1 {
+				t.Fatalf("MapAccessBytesKey(k) = %p, %v, %v", p, ok, err)
+			}
+			key := []byte("new")
+			if p, ok, err := MapAccessBytesKey(tt.m, key); p != nil || ok || err != nil {
+				t.Errorf("MapAccessBytesKey(new) = %p, %v, %v", p, ok, err)
+			}
+			if err := MapAssignBytesKey(tt.m, key, tt.val); err != nil {
+				t.Fatal(err)
+			}
+			key[0] = 'x' // the map keeps a copy of the key
+			if got := reflect.ValueOf(tt.get()).Int(); got != 2 {
+				t.Errorf("element of the assigned key = %d, want 2", got)
+			}
+			if p, ok, _ := MapAccessBytesKey(tt.m, nil); p != nil || ok {
+				t.Errorf("MapAccessBytesKey of the empty key = %p, %v", p, ok)
+			}
+		})
+	}
+
+	if _, ok, err := MapAccessBytesKey(map[string]int(nil), []byte("k")); ok || err != nil {
+		t.Errorf("MapAccessBytesKey of a nil map = %v, %v", ok, err)
+	}
+	if err := MapAssignBytesKey(map[string]int(nil), []byte("k"), nil); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("MapAssignBytesKey of a nil map = %v, want ErrNilPointer", err)
+	}
+	var ke *KindError
+	if _, _, err := MapAccessBytesKey(map[int]int{}, nil); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("MapAccessBytesKey of int keys = %v, want a *KindError", err)
+	}
+	if err := MapAssignBytesKey("m", nil, nil); !errors.As(err, &ke) || ke.Kind != String {
+		t.Errorf("MapAssignBytesKey of a string = %v, want a *KindError", err)
+	}
+}
+
+func TestMapBytesKeyGrow(t *testing.T) {
+	m := make(map[string]*int)
+	key := make([]byte, 0, 16)
+	for i := 0; i < 2000; i++ {
+		v := i
+		key = strconv.AppendInt(key[:0], int64(i), 10)
+		p := &v
+		if err := MapAssignBytesKey(m, key, unsafe.Pointer(&p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runtime.GC()
+	if len(m) != 2000 {
+		t.Fatalf("len = %d, want 2000", len(m))
+	}
+	for i := 0; i < 2000; i++ {
+		key = strconv.AppendInt(key[:0], int64(i), 10)
+		p, ok, err := MapAccessBytesKey(m, key)
+		if err != nil || !ok || **(**int)(p) != i {
+			t.Fatalf("MapAccessBytesKey(%s) = %p, %v, %v", key, p, ok, err)
+		}
+		if m[string(key)] != *(**int)(p) {
+			t.Fatalf("element of %s differs from the map's", key)
+		}
+	}
+}
+
+func TestMapAccessBytesKeyAllocs(t *testing.T) {
+	if safeMode {
+		t.Skip("darkness_safe converts the key to a string")
+	}
+	m := map[string]int{"hello": 1}
+	key := []byte("hello")
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, ok, _ := MapAccessBytesKey(m, key); !ok {
+			t.Fatal("key not found")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("MapAccessBytesKey allocates %v times", allocs)
+	}
+}
+
+func BenchmarkMapAccessBytesKey(b *testing.B) {
+	m := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		m["key"+strconv.Itoa(i)] = i
+	}
+	key := []byte("key42")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		MapAccessBytesKey(m, key)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.21
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// mapclear falls back to deleting every key, since the runtime has no
+// map clearing entry point reachable from reflect before Go 1.21.
+// Unlike the clear builtin, NaN keys cannot be deleted this way: mapclear
+// returns an error wrapping ErrUnsupported if any entry is left.
+func mapclear(t *MapType, h unsafe.Pointer, m interface{}) error {
+	v := reflect.ValueOf(m)
+	for it := v.MapRange(); it.Next(); {
+		v.SetMapIndex(it.Key(), reflect.Value{})
+	}
+	if n := mapLen(t, h); n != 0 {
+		return fmt.Errorf("%w: MapClear cannot delete the %d NaN keys of a %s before Go 1.21", ErrUnsupported, n, &t.rtype)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21 && !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+func mapclear(t *MapType, h unsafe.Pointer, _ interface{}) error {
+	runtimeMapclear(t, h)
+	return nil
+}
+
+//go:linkname runtimeMapclear reflect.mapclear
+
+// runtimeMapclear deletes all entries of the map h of type t.
+// Implemented in the runtime package.
+func runtimeMapclear(t *MapType, h unsafe.Pointer)
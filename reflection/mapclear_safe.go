@@ -0,0 +1,17 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21 && darkness_safe
+
+package reflection
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+func mapclear(_ *MapType, _ unsafe.Pointer, m interface{}) error {
+	reflect.ValueOf(m).Clear()
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// MapStats describes the internal layout of a map, to diagnose key
+// distributions that degrade its performance.
+//
+// Maps are Swiss tables: a directory of tables, each an array of groups of
+// 8 slots probed in sequence, or a single group for maps that never held
+// more than 8 entries. Tables grow whole, so no growth is ever in progress.
+type MapStats struct {
+	Count        int // number of entries
+	Tables       int // distinct tables, 0 for a single-group map
+	DirectoryLen int // entries of the directory, which tables may share
+	Groups       int
+	Capacity     int // number of slots
+	Tombstones   int // deleted slots, which lengthen probe sequences
+
+	// LoadFactor is Count / Capacity, at most 7/8 but for small maps.
+	LoadFactor float64
+
+	// GroupFill counts the groups by number of full slots, and MinFill,
+	// MaxFill and AvgFill summarize it. Clusters of full groups suggest
+	// keys whose hashes collide.
+	GroupFill        [9]int
+	MinFill, MaxFill int
+	AvgFill          float64
+}
+
+// MapStatsOf returns the layout statistics of the map held by m. It returns
+// a *KindError if m does not hold a map, and ErrUnsupported if the layout of
+// maps of the toolchain is not mirrored by this package. A nil map has zero
+// statistics.
+//
+// The map is read without synchronization, as the runtime's own debugging
+// code does: concurrent readers are harmless, but statistics taken during a
+// concurrent write may be inconsistent, and may even fault if the map grows
+// meanwhile.
+func MapStatsOf(m interface{}) (MapStats, error) {
+	var s MapStats
+	e := efaceOf(&m)
+	if k := kindOf(e.Type); k != Map {
+		return s, &KindError{Op: "MapStatsOf", Kind: k}
+	}
+	if e.Word == nil {
+		return s, nil
+	}
+	if !mapStats((*MapType)(unsafe.Pointer(e.Type)), e.Word, &s) {
+		return MapStats{}, ErrUnsupported
+	}
+	if s.Capacity > 0 {
+		s.LoadFactor = float64(s.Count) / float64(s.Capacity)
+	}
+	s.MinFill = -1
+	full := 0
+	for n, groups := range s.GroupFill {
+		if groups == 0 {
+			continue
+		}
+		if s.MinFill < 0 {
+			s.MinFill = n
+		}
+		s.MaxFill = n
+		full += n * groups
+	}
+	if s.MinFill < 0 {
+		s.MinFill = 0
+	}
+	if s.Groups > 0 {
+		s.AvgFill = float64(full) / float64(s.Groups)
+	}
+	return s, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.27 && !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// swissMap mirrors internal/runtime/maps.Map.
+type swissMap struct {
+	used              uint64
+	seed              uintptr
+	dirPtr            unsafe.Pointer // *[dirLen]*swissTable, or the group of a small map
+	dirLen            int
+	globalDepth       uint8
+	globalShift       uint8
+	writing           uint8
+	tombstonePossible bool
+	clearSeq          uint64
+}
+
+// swissTable mirrors internal/runtime/maps.table.
+type swissTable struct {
+	used       uint16
+	capacity   uint16
+	growthLeft uint16
+	localDepth uint8
+	index      int
+	groups     unsafe.Pointer // *[lengthMask+1]group
+	lengthMask uint64
+}
+
+const (
+	groupSlots  = 8
+	ctrlEmpty   = 0b10000000
+	ctrlDeleted = 0b11111110
+)
+
+// mapStats fills s, but for the fields derived from the others, from the
+// map h of type t.
+func mapStats(t *MapType, h unsafe.Pointer, s *MapStats) bool {
+	m := (*swissMap)(h)
+	s.Count = int(m.used)
+	if m.dirLen == 0 {
+		if m.dirPtr != nil {
+			s.Groups = 1
+			s.Capacity = groupSlots
+			addGroup(m.dirPtr, s)
+		}
+		return true
+	}
+	s.DirectoryLen = m.dirLen
+	dir := unsafe.Slice((**swissTable)(m.dirPtr), m.dirLen)
+	for i, tab := range dir {
+		// A table of local depth d fills 1<<(globalDepth-d) consecutive
+		// entries of the directory; count it at the first one only.
+		if i > 0 && dir[i-1] == tab {
+			continue
+		}
+		s.Tables++
+		s.Capacity += int(tab.capacity)
+		groups := int(tab.lengthMask) + 1
+		s.Groups += groups
+		for g := 0; g < groups; g++ {
+			addGroup(unsafe.Add(tab.groups, uintptr(g)*t.GroupSize), s)
+		}
+	}
+	return true
+}
+
+// addGroup counts the slots of the group at g in s.
+func addGroup(g unsafe.Pointer, s *MapStats) {
+	ctrl := *(*[groupSlots]uint8)(g)
+	full := 0
+	for _, c := range ctrl {
+		switch {
+		case c == ctrlDeleted:
+			s.Tombstones++
+		case c&ctrlEmpty == 0:
+			full++
+		}
+	}
+	s.GroupFill[full]++
+}
+
+// mapMemory returns the number of bytes of the map h of type t: its header,
+// and its directory, tables and groups or its single group. The keys and
+// elements stored indirectly are not counted.
+func mapMemory(t *MapType, h unsafe.Pointer) (uintptr, bool) {
+	m := (*swissMap)(h)
+	n := unsafe.Sizeof(*m)
+	if m.dirLen == 0 {
+		if m.dirPtr != nil {
+			n += t.GroupSize
+		}
+		return n, true
+	}
+	n += uintptr(m.dirLen) * ptrSize
+	dir := unsafe.Slice((**swissTable)(m.dirPtr), m.dirLen)
+	for i, tab := range dir {
+		if i > 0 && dir[i-1] == tab {
+			continue
+		}
+		n += unsafe.Sizeof(*tab) + uintptr(tab.lengthMask+1)*t.GroupSize
+	}
+	return n, true
+}
@@ -0,0 +1,19 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.27 || darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+func mapStats(t *MapType, h unsafe.Pointer, s *MapStats) bool {
+	return false
+}
+
+func mapMemory(t *MapType, h unsafe.Pointer) (uintptr, bool) {
+	return 0, false
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"testing"
+)
+
+// checkMapStats checks the invariants relating the fields of s.
+func checkMapStats(t *testing.T, s MapStats, count int) {
+	t.Helper()
+	if s.Count != count {
+		t.Errorf("Count = %d, want %d", s.Count, count)
+	}
+	groups, full := 0, 0
+	for n, g := range s.GroupFill {
+		groups += g
+		full += n * g
+	}
+	if groups != s.Groups || full != s.Count {
+		t.Errorf("GroupFill %v sums to %d groups and %d entries, want %d and %d", s.GroupFill, groups, full, s.Groups, s.Count)
+	}
+	if s.Capacity != s.Groups*8 {
+		t.Errorf("Capacity = %d, want %d slots in %d groups", s.Capacity, s.Groups*8, s.Groups)
+	}
+	if s.MinFill > s.MaxFill || s.AvgFill < float64(s.MinFill) || s.AvgFill > float64(s.MaxFill) {
+		t.Errorf("MinFill, AvgFill, MaxFill = %d, %v, %d", s.MinFill, s.AvgFill, s.MaxFill)
+	}
+}
+
+func TestMapStatsOf(t *testing.T) {
+	if s, err := MapStatsOf(map[int]int(nil)); err != nil || s != (MapStats{}) {
+		t.Errorf("MapStatsOf(nil map) = %+v, %v", s, err)
+	}
+	var ke *KindError
+	if _, err := MapStatsOf([]int{}); !errors.As(err, &ke) {
+		t.Errorf("MapStatsOf([]int) = %v, want a *KindError", err)
+	}
+
+	small := map[int]string{1: "a", 2: "b", 3: "c"}
+	s, err := MapStatsOf(small)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMapStats(t, s, 3)
+	if s.Tables != 0 || s.Groups != 1 || s.GroupFill[3] != 1 || s.LoadFactor != 3.0/8 {
+		t.Errorf("MapStatsOf(small map) = %+v", s)
+	}
+
+	big := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		big[i] = i
+	}
+	s, err = MapStatsOf(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMapStats(t, s, 10000)
+	if s.Tables == 0 || s.DirectoryLen < s.Tables || s.LoadFactor > 7.0/8 {
+		t.Errorf("MapStatsOf(big map) = %+v", s)
+	}
+
+	for i := 0; i < 10000; i += 2 {
+		delete(big, i)
+	}
+	s, err = MapStatsOf(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMapStats(t, s, 5000)
+	if s.Tombstones == 0 {
+		t.Errorf("MapStatsOf after deletions from full groups: no tombstones in %+v", s)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.24
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// MapType represents a map type.
+type MapType struct {
+	rtype
+	Key    *rtype // map key type
+	Elem   *rtype // map element (value) type
+	Bucket *rtype // internal type representing a hash bucket
+	// function for hashing keys (ptr to key, seed) -> hash
+	Hasher     func(unsafe.Pointer, uintptr) uintptr
+	KeySize    uint8  // size of key slot
+	ValueSize  uint8  // size of value slot
+	BucketSize uint16 // size of bucket
+	Flags      uint32
+}
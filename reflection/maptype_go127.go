@@ -0,0 +1,28 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.27
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// MapType represents a map type.
+type MapType struct {
+	rtype
+	Key   *rtype // map key type
+	Elem  *rtype // map element (value) type
+	Group *rtype // internal type representing a slot group
+	// function for hashing keys (ptr to key, seed) -> hash
+	Hasher     func(unsafe.Pointer, uintptr) uintptr
+	GroupSize  uintptr // == Group.size
+	KeysOff    uintptr // offset of the first key within a group
+	KeyStride  uintptr // distance between two keys of a group
+	ElemsOff   uintptr // offset of the first elem within a group
+	ElemStride uintptr // distance between two elems of a group
+	ElemOff    uintptr // offset of elem in key/elem slot
+	Flags      uint32
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// Memequal reports whether the size bytes at a and b are equal, with the
+// runtime's vectorized comparison. For the values of a type with
+// TflagRegularMemory set, it is the same as comparing them with ==.
+func Memequal(a, b unsafe.Pointer, size uintptr) bool {
+	return memequal(a, b, size)
+}
+
+// EqualFixed is like Memequal, but compares sizes of up to 16 bytes inline
+// when a and b are aligned to their size, or to 8 bytes beyond, as values of
+// regular memory types of those sizes usually are. It is meant for keys of a
+// size fixed by their type, which a call to Memequal costs more to compare
+// than their loads.
+func EqualFixed(a, b unsafe.Pointer, size uintptr) bool {
+	if a == b {
+		return true
+	}
+	align := size
+	if align > 8 {
+		align = 8
+	}
+	if (uintptr(a)|uintptr(b))&(align-1) == 0 {
+		switch size {
+		case 0:
+			return true
+		case 1:
+			return *(*uint8)(a) == *(*uint8)(b)
+		case 2:
+			return *(*uint16)(a) == *(*uint16)(b)
+		case 4:
+			return *(*uint32)(a) == *(*uint32)(b)
+		case 8:
+			return *(*uint64)(a) == *(*uint64)(b)
+		case 16:
+			return *(*[2]uint64)(a) == *(*[2]uint64)(b)
+		}
+	}
+	return memequal(a, b, size)
+}
+
+//go:linkname memequal runtime.memequal
+
+// memequal reports whether the size bytes at a and b are equal.
+// Implemented in the runtime package.
+func memequal(a, b unsafe.Pointer, size uintptr) bool
@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+)
+
+func TestMemequal(t *testing.T) {
+	var a, b [72]byte
+	for i := range a {
+		a[i] = byte(i * 7)
+	}
+	fns := []struct {
+		name string
+		fn   func(a, b unsafe.Pointer, size uintptr) bool
+	}{
+		{"Memequal", Memequal},
+		{"EqualFixed", EqualFixed},
+	}
+	for _, f := range fns {
+		// Every size, at aligned and misaligned offsets, equal and then
+		// differing at each byte in turn.
+		for _, off := range []uintptr{0, 1, 2, 4, 8} {
+			for size := uintptr(0); size <= 64; size++ {
+				copy(b[:], a[:])
+				pa, pb := unsafe.Pointer(&a[off]), unsafe.Pointer(&b[off])
+				if !f.fn(pa, pb, size) {
+					t.Errorf("%s of %d equal bytes at offset %d = false", f.name, size, off)
+				}
+				for i := uintptr(0); i < size; i++ {
+					b[off+i]++
+					if f.fn(pa, pb, size) {
+						t.Errorf("%s of %d bytes at offset %d differing at %d = true", f.name, size, off, i)
+					}
+					if !f.fn(pa, pb, i) {
+						t.Errorf("%s of the %d bytes before the difference = false", f.name, i)
+					}
+					b[off+i]--
+				}
+			}
+		}
+		if !f.fn(unsafe.Pointer(&a), unsafe.Pointer(&a), 16) {
+			t.Errorf("%s of the same bytes = false", f.name)
+		}
+	}
+}
+
+var benchMemequalKeys = [2][2]uint64{{1, 2}, {1, 2}}
+
+func BenchmarkMemequal(b *testing.B) {
+	x, y := unsafe.Pointer(&benchMemequalKeys[0]), unsafe.Pointer(&benchMemequalKeys[1])
+	b.Run("Memequal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchBool = Memequal(x, y, 16)
+		}
+	})
+	b.Run("EqualFixed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchBool = EqualFixed(x, y, 16)
+		}
+	})
+	b.Run("bytes.Equal", func(b *testing.B) {
+		bx, by := unsafe.Slice((*byte)(x), 16), unsafe.Slice((*byte)(y), 16)
+		for i := 0; i < b.N; i++ {
+			benchBool = bytes.Equal(bx, by)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// MergeOptions controls Merge.
+type MergeOptions struct {
+	// Recurse merges the fields of nested struct fields one by one instead
+	// of copying a nested struct as a whole when it is zero in dst.
+	Recurse bool
+
+	// Override copies fields of src even when they are not zero in dst.
+	Override bool
+
+	// SkipTagged leaves alone the fields tagged `merge:"-"`.
+	SkipTagged bool
+}
+
+// Merge copies into the struct pointed to by dst the fields of the struct
+// pointed to by src that are zero in dst, as reported by IsZero. dst and src
+// must hold pointers to the same struct type.
+//
+// Fields are copied with TypedMemmove, unexported ones included. Slices, maps
+// and pointers are copied as they are, their contents are never merged.
+func Merge(dst, src interface{}, opts MergeOptions) error {
+	d, s := efaceOf(&dst), efaceOf(&src)
+	if k := kindOf(d.Type); k != Ptr {
+		return &KindError{Op: "Merge", Kind: k}
+	}
+	if d.Type != s.Type {
+		return fmt.Errorf("%w: Merge of %v into %s", ErrTypeMismatch, ToReflect(s.Type), d.Type)
+	}
+	st := (*PtrType)(unsafe.Pointer(d.Type)).Elem
+	if k := st.Kind(); k != Struct {
+		return &KindError{Op: "Merge", Kind: k}
+	}
+	if d.Word == nil || s.Word == nil {
+		return errors.New("reflection: Merge of nil pointer")
+	}
+	opts.merge((*StructType)(unsafe.Pointer(st)), d.Word, s.Word)
+	return nil
+}
+
+func (o *MergeOptions) merge(st *StructType, dst, src unsafe.Pointer) {
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		if o.SkipTagged && reflect.StructTag(f.Name.Tag()).Get("merge") == "-" {
+			continue
+		}
+		ft := f.Type()
+		df := Add(dst, f.Offset(), "field offset is within the struct")
+		sf := Add(src, f.Offset(), "field offset is within the struct")
+		if o.Recurse && ft.Kind() == Struct {
+			o.merge((*StructType)(unsafe.Pointer(ft)), df, sf)
+			continue
+		}
+		if o.Override || IsZero(ft, df) {
+			TypedMemmove(ft, df, sf)
+		}
+	}
+}
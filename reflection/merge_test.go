@@ -0,0 +1,149 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type mergeInner struct {
+	Host string
+	Port int
+}
+
+type mergeConfig struct {
+	Name    string
+	Retries int
+	Tags    []string
+	Inner   mergeInner
+	secret  string
+	Locked  string `merge:"-"`
+	Ptr     *int
+}
+
+func TestMerge(t *testing.T) {
+	n := 3
+	src := func() *mergeConfig {
+		return &mergeConfig{
+			Name: "src", Retries: 5, Tags: []string{"a", "b"},
+			Inner:  mergeInner{Host: "h", Port: 80},
+			secret: "s", Locked: "l", Ptr: &n,
+		}
+	}
+	tests := []struct {
+		name string
+		dst  mergeConfig
+		opts MergeOptions
+		want func(s *mergeConfig) mergeConfig
+	}{
+		{
+			"zero dst", mergeConfig{}, MergeOptions{},
+			func(s *mergeConfig) mergeConfig { return *s },
+		},
+		{
+			"keeps set fields", mergeConfig{Name: "dst", Inner: mergeInner{Port: 1}}, MergeOptions{},
+			func(s *mergeConfig) mergeConfig {
+				w := *s
+				w.Name, w.Inner = "dst", mergeInner{Port: 1}
+				return w
+			},
+		},
+		{
+			"recurse", mergeConfig{Inner: mergeInner{Port: 1}}, MergeOptions{Recurse: true},
+			func(s *mergeConfig) mergeConfig {
+				w := *s
+				w.Inner = mergeInner{Host: "h", Port: 1}
+				return w
+			},
+		},
+		{
+			"override", mergeConfig{Name: "dst", Retries: 1}, MergeOptions{Override: true},
+			func(s *mergeConfig) mergeConfig { return *s },
+		},
+		{
+			"skip tagged", mergeConfig{}, MergeOptions{SkipTagged: true},
+			func(s *mergeConfig) mergeConfig {
+				w := *s
+				w.Locked = ""
+				return w
+			},
+		},
+		{
+			"unexported", mergeConfig{secret: "mine"}, MergeOptions{},
+			func(s *mergeConfig) mergeConfig {
+				w := *s
+				w.secret = "mine"
+				return w
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := src()
+			dst := tt.dst
+			if err := Merge(&dst, s, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.want(s); !reflect.DeepEqual(dst, want) {
+				t.Errorf("Merge = %+v, want %+v", dst, want)
+			}
+		})
+	}
+}
+
+func TestMergeSharesReferences(t *testing.T) {
+	n := 1
+	src := &mergeConfig{Tags: []string{"a"}, Ptr: &n}
+	var dst mergeConfig
+	if err := Merge(&dst, src, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Slices are copied by header: both share the array.
+	dst.Tags[0] = "changed"
+	if src.Tags[0] != "changed" || dst.Ptr != src.Ptr {
+		t.Errorf("Merge copied the contents of the slice or pointer: src %v, dst %v", src, dst)
+	}
+
+	// A non-nil but empty dst slice is not zero and is kept.
+	dst = mergeConfig{Tags: []string{}}
+	if err := Merge(&dst, src, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Tags == nil || len(dst.Tags) != 0 {
+		t.Errorf("Merge replaced an empty slice: %v", dst.Tags)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	var ke *KindError
+	c := mergeConfig{}
+	tests := []struct {
+		name     string
+		dst, src interface{}
+		kind     Kind
+		mismatch bool
+	}{
+		{"non-pointer dst", c, c, Struct, false},
+		{"pointer to non-struct", new(int), new(int), Int, false},
+		{"type mismatch", &c, &mergeInner{}, 0, true},
+		{"value src", &c, c, 0, true},
+	}
+	for _, tt := range tests {
+		err := Merge(tt.dst, tt.src, MergeOptions{})
+		switch {
+		case tt.mismatch:
+			if !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("%s: Merge = %v, want ErrTypeMismatch", tt.name, err)
+			}
+		case !errors.As(err, &ke) || ke.Kind != tt.kind:
+			t.Errorf("%s: Merge = %v, want *KindError for %v", tt.name, err, tt.kind)
+		}
+	}
+	if err := Merge(&c, (*mergeConfig)(nil), MergeOptions{}); err == nil {
+		t.Error("Merge from a nil pointer: no error")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// ErrNotMethodValue is returned by InspectMethodValue for funcs that are not
+// method values.
+var ErrNotMethodValue = errors.New("reflection: func is not a method value")
+
+// InspectMethodValue returns what the method value f, such as obj.Method,
+// is bound to: the entry PC of the method, its receiver and the type of the
+// receiver. For a pointer receiver, receiver is the pointer bound; for other
+// receivers, it points to the copy of the receiver held by f, which must not
+// be modified. For a method value of an interface, recvType is the interface
+// type, and code is the method of the dynamic type, or 0 for a nil interface.
+//
+// The compiler implements a method value as a closure whose code is a
+// wrapper, named after the method with a "-fm" suffix, and which holds the
+// receiver after the code pointer. The wrapper is recognized by its symbol
+// name, and the receiver type is looked up by name as TypeByName does; when
+// the linker dropped the method from the method table of the receiver type,
+// code is the PC of the wrapper instead. InspectMethodValue returns
+// ErrNotMethodValue for other funcs, and an error if the receiver type is not
+// found, such as for methods of generic types.
+func InspectMethodValue(f interface{}) (code uintptr, receiver unsafe.Pointer, recvType *rtype, err error) {
+	e := efaceOf(&f)
+	if k := kindOf(e.Type); k != Func {
+		return 0, nil, nil, &KindError{Op: "InspectMethodValue", Kind: k}
+	}
+	if e.Word == nil {
+		return 0, nil, nil, fmt.Errorf("%w: nil func", ErrNotMethodValue)
+	}
+	wrapper := *(*uintptr)(e.Word)
+	fn := runtime.FuncForPC(wrapper)
+	if fn == nil || !strings.HasSuffix(fn.Name(), "-fm") {
+		return 0, nil, nil, ErrNotMethodValue
+	}
+	recvName, pkgPath, method, ok := parseMethodSymbol(strings.TrimSuffix(fn.Name(), "-fm"))
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("%w: unexpected wrapper name %s", ErrNotMethodValue, fn.Name())
+	}
+	recvType = TypeByName(recvName)
+	if recvType == nil || definingPkgPath(recvType) != pkgPath {
+		return 0, nil, nil, fmt.Errorf("reflection: receiver type %s of %s not found", recvName, fn.Name())
+	}
+
+	recvOff := alignUp(ptrSize, uintptr(recvType.align))
+	receiver = Add(e.Word, recvOff, "the closure holds the receiver")
+	if recvType.Kind() == Ptr {
+		receiver = *(*unsafe.Pointer)(receiver)
+	}
+
+	code = wrapper
+	if recvType.Kind() == Interface {
+		code = 0
+		if pc, ok := ifaceMethodPC(recvType, receiver, method); ok {
+			code = pc
+		}
+	} else if pc, ok := methodPC(recvType, method); ok {
+		code = pc
+	}
+	return code, receiver, recvType, nil
+}
+
+// parseMethodSymbol splits the symbol name of a method, such as
+// "example.com/pkg.(*T).M" or "example.com/pkg.T.M", into the string form of
+// the receiver type, "*pkg.T" or "pkg.T", the import path of its package and
+// the method name.
+func parseMethodSymbol(sym string) (recv, pkgPath, method string, ok bool) {
+	slash := strings.LastIndexByte(sym, '/') + 1
+	dot := strings.IndexByte(sym[slash:], '.')
+	if dot < 0 {
+		return "", "", "", false
+	}
+	pkgPath = sym[:slash+dot]
+	pkgName := sym[slash : slash+dot]
+	rest := sym[slash+dot+1:]
+	m := strings.LastIndexByte(rest, '.')
+	if m < 0 {
+		return "", "", "", false
+	}
+	recv, method = rest[:m], rest[m+1:]
+	if strings.HasPrefix(recv, "(*") && strings.HasSuffix(recv, ")") {
+		return "*" + pkgName + "." + recv[2:len(recv)-1], pkgPath, method, true
+	}
+	return pkgName + "." + recv, pkgPath, method, true
+}
+
+// definingPkgPath returns the import path of the package defining t, or of its
+// element if t is an unnamed pointer.
+func definingPkgPath(t *rtype) string {
+	if t.Kind() == Ptr && !t.HasName() {
+		t = elem(t)
+	}
+	return t.PkgPath()
+}
+
+// methodPC returns the entry PC of the method named name of t, if the
+// method table of t has it.
+func methodPC(t *rtype, name string) (uintptr, bool) {
+	u := t.Uncommon()
+	if u == nil {
+		return 0, false
+	}
+	for _, m := range u.Methods() {
+		if t.NameOff(m.Name).Name() == name {
+			if m.Tfn == 0 || m.Tfn == -1 {
+				return 0, false
+			}
+			return uintptr(t.TextOff(m.Tfn)), true
+		}
+	}
+	return 0, false
+}
+
+// ifaceMethodPC returns the entry PC of the method named name of the dynamic
+// type of the value of interface type t at p, if it is not nil.
+func ifaceMethodPC(t *rtype, p unsafe.Pointer, name string) (uintptr, bool) {
+	it := (*InterfaceType)(unsafe.Pointer(t))
+	tab := (*IfaceHeader)(p).Tab
+	if tab == nil {
+		return 0, false
+	}
+	for i := range it.Methods {
+		if IfaceMethodName(tab, i) == name {
+			return tab.Methods()[i], true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type methodValueT struct {
+	A, B int
+}
+
+func (v methodValueT) String() string { return fmt.Sprint(v.A, v.B) }
+
+func (v *methodValueT) Set(a int) { v.A = a }
+
+type methodValueSetter interface{ Set(int) }
+
+// The itabs converting methodValueT to interfaces make TypeByName find it.
+var (
+	methodValueStringer fmt.Stringer      = methodValueT{}
+	methodValueSet      methodValueSetter = &methodValueT{}
+)
+
+func methodValueFuncName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+func TestInspectMethodValue(t *testing.T) {
+	if !modulesSupported {
+		t.Skip("TypeByName only finds defined types through ITabs")
+	}
+	v := &methodValueT{A: 1, B: 2}
+
+	code, recv, typ, err := InspectMethodValue(v.String)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != TypeFor[methodValueT]() || *(*methodValueT)(recv) != *v || recv == unsafe.Pointer(v) {
+		t.Errorf("InspectMethodValue(v.String) bound to %v %v at %p", typ, *(*methodValueT)(recv), recv)
+	}
+	if name := methodValueFuncName(code); !strings.HasSuffix(name, ".methodValueT.String") {
+		t.Errorf("InspectMethodValue(v.String) code is %s", name)
+	}
+
+	code, recv, typ, err = InspectMethodValue(v.Set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != TypeFor[*methodValueT]() || recv != unsafe.Pointer(v) {
+		t.Errorf("InspectMethodValue(v.Set) bound to %v at %p, want %p", typ, recv, v)
+	}
+	if name := methodValueFuncName(code); !strings.HasSuffix(name, ".(*methodValueT).Set") {
+		t.Errorf("InspectMethodValue(v.Set) code is %s", name)
+	}
+
+	var s fmt.Stringer = *v
+	code, recv, typ, err = InspectMethodValue(s.String)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != TypeFor[fmt.Stringer]() || (*InterfaceHeader)(recv).Word == nil {
+		t.Errorf("InspectMethodValue(s.String) bound to %v", typ)
+	}
+	if name := methodValueFuncName(code); !strings.Contains(name, "methodValueT).String") && !strings.HasSuffix(name, ".methodValueT.String") {
+		t.Errorf("InspectMethodValue(s.String) code is %s", name)
+	}
+}
+
+func TestInspectMethodValueErrors(t *testing.T) {
+	x := 0
+	for _, f := range []interface{}{func() {}, func() { x++ }, strings.ToUpper, (func())(nil)} {
+		if _, _, _, err := InspectMethodValue(f); !errors.Is(err, ErrNotMethodValue) {
+			t.Errorf("InspectMethodValue(%T) = %v, want ErrNotMethodValue", f, err)
+		}
+	}
+	var ke *KindError
+	if _, _, _, err := InspectMethodValue(1); !errors.As(err, &ke) {
+		t.Errorf("InspectMethodValue(1) = %v, want a *KindError", err)
+	}
+}
+
+func TestParseMethodSymbol(t *testing.T) {
+	tests := []struct {
+		sym, recv, pkgPath, method string
+		ok                         bool
+	}{
+		{"example.com/pkg.(*T).M", "*pkg.T", "example.com/pkg", "M", true},
+		{"example.com/pkg.T.M", "pkg.T", "example.com/pkg", "M", true},
+		{"bytes.(*Buffer).Write", "*bytes.Buffer", "bytes", "Write", true},
+		{"a/b.c/pkg.T.M", "pkg.T", "a/b.c/pkg", "M", true},
+		{"pkg.F", "", "", "", false},
+		{"nodot", "", "", "", false},
+	}
+	for _, tt := range tests {
+		recv, pkgPath, method, ok := parseMethodSymbol(tt.sym)
+		if recv != tt.recv || pkgPath != tt.pkgPath || method != tt.method || ok != tt.ok {
+			t.Errorf("parseMethodSymbol(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", tt.sym, recv, pkgPath, method, ok, tt.recv, tt.pkgPath, tt.method, tt.ok)
+		}
+	}
+}
+
+func TestIfaceMethodPC(t *testing.T) {
+	type readStringer interface {
+		Set(int)
+		String() string
+	}
+	var rs readStringer = &methodValueT{}
+	tab := (*IfaceHeader)(unsafe.Pointer(&rs)).Tab
+	want := []struct{ method, sym string }{
+		{"Set", ".(*methodValueT).Set"},
+		{"String", ".(*methodValueT).String"},
+	}
+	for i, w := range want {
+		if name := IfaceMethodName(tab, i); name != w.method {
+			t.Errorf("IfaceMethodName(%d) = %q, want %q", i, name, w.method)
+		}
+		pc, err := IfaceMethodPC(unsafe.Pointer(&rs), i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := methodValueFuncName(pc); !strings.HasSuffix(name, w.sym) {
+			t.Errorf("IfaceMethodPC(%d) is %s, want a name ending in %s", i, name, w.sym)
+		}
+	}
+	for _, i := range []int{-1, 2} {
+		if name := IfaceMethodName(tab, i); name != "" {
+			t.Errorf("IfaceMethodName(%d) = %q, want empty", i, name)
+		}
+		if _, err := IfaceMethodPC(unsafe.Pointer(&rs), i); err == nil {
+			t.Errorf("IfaceMethodPC(%d) succeeded", i)
+		}
+	}
+	rs = nil
+	if _, err := IfaceMethodPC(unsafe.Pointer(&rs), 0); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("IfaceMethodPC of a nil interface = %v, want ErrNilPointer", err)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// modulehash mirrors runtime.modulehash.
+type modulehash struct {
+	modulename   string
+	linktimehash string
+	runtimehash  *string
+}
+
+//go:linkname lastmoduledatap runtime.lastmoduledatap
+
+// lastmoduledatap points to the most recently added module.
+// Implemented in the runtime package.
+var lastmoduledatap unsafe.Pointer
+
+// firstmoduledata is the head of the module chain.
+//
+// The runtime's firstmoduledata cannot be linked to, but until the first
+// plugin is opened, which cannot happen before this package is initialized,
+// the last module is the first one. With -linkshared the shared libraries
+// are registered before any package initialization, so the modules before
+// the last one are not reachable.
+var firstmoduledata = (*moduledata)(lastmoduledatap)
+
+// modulesSupported reports whether moduledata matches the runtime's layout:
+// it mirrors the layout of the release that built the program, and the
+// module of this package, read with it, checks out.
+var modulesSupported = moduleLayoutMirrored && moduleLayoutHolds(firstmoduledata)
+
+// moduleLayoutHolds reports whether md, read with the mirrored layout, is
+// the module holding this package: not marked bad, with the code of this
+// function in its text and a type descriptor of this package among its
+// types. It guards against a toolchain laying out the module data other
+// than the release this package mirrors for it.
+func moduleLayoutHolds(md *moduledata) bool {
+	if md == nil || md.bad {
+		return false
+	}
+	pc := reflect.ValueOf(moduleLayoutHolds).Pointer()
+	t := uintptr(unsafe.Pointer(TypeFor[Module]()))
+	return md.text <= pc && pc < md.etext && md.types <= t && t < md.etypes
+}
+
+// Module is a loaded Go module: the executable itself or a plugin.
+type Module struct {
+	md *moduledata
+}
+
+// Modules returns the loaded modules, starting with the executable itself,
+// in load order.
+//
+// The runtime module layout is mirrored for each release from Go 1.18 on,
+// and checked against the module of this package when the program starts.
+// In binaries built with the darkness_safe build tag, or by a toolchain
+// whose layout fails the check, Modules returns nil, and the functions built
+// on the modules, such as ITabs, Types and the functions searching them,
+// return an error wrapping ErrUnsupported.
+func Modules() []Module {
+	var mods []Module
+	rangeModules(func(md *moduledata) bool {
+		mods = append(mods, Module{md})
+		return true
+	})
+	return mods
+}
+
+// checkModules returns an error wrapping ErrUnsupported for op if the
+// runtime module layout of the toolchain is not supported by this package.
+func checkModules(op string) error {
+	if !modulesSupported {
+		return fmt.Errorf("%w: %s needs the runtime module layout", ErrUnsupported, op)
+	}
+	return nil
+}
+
+// rangeModules calls fn for each loaded module, skipping modules that
+// failed to load.
+func rangeModules(fn func(*moduledata) bool) {
+	if !modulesSupported {
+		return
+	}
+	for md := firstmoduledata; md != nil; md = md.next {
+		if md.bad {
+			continue
+		}
+		if !fn(md) {
+			return
+		}
+	}
+}
+
+// PluginPath returns the plugin path of m, or the empty string for the
+// executable itself.
+func (m Module) PluginPath() string {
+	return m.md.pluginpath
+}
+
+// HasMain reports whether m contains the main function.
+func (m Module) HasMain() bool {
+	return m.md.hasmain != 0
+}
+
+// ITab is the first word of a non-empty interface value: the method table
+// of the pairing of an interface type with a concrete type.
+type ITab struct {
+	Inter *InterfaceType
+	Type  *rtype
+	Hash  uint32     // copy of Type.hash. Used for type switches.
+	Fun   [1]uintptr // variable sized. Fun[0]==0 means Type does not implement Inter.
+}
+
+// Methods returns the method table of it, in the order of the methods of
+// it.Inter. It returns nil if it.Type does not implement it.Inter.
+func (it *ITab) Methods() []uintptr {
+	if it.Fun[0] == 0 {
+		return nil
+	}
+	return unsafe.Slice(&it.Fun[0], len(it.Inter.Methods))
+}
+
+// IfaceMethodPC returns the entry PC of the i'th method, in the order of the
+// methods of the interface type, that calls through the non-empty interface
+// value at iface dispatch to; runtime.FuncForPC names it. For a method with
+// a value receiver of a type not stored directly in interfaces, it is the
+// wrapper the compiler generates for the pointer type. IfaceMethodPC returns
+// an error if the interface is nil or i is out of range.
+func IfaceMethodPC(iface unsafe.Pointer, i int) (uintptr, error) {
+	tab := (*IfaceHeader)(iface).Tab
+	if tab == nil {
+		return 0, fmt.Errorf("%w: IfaceMethodPC of nil interface", ErrNilPointer)
+	}
+	fun := tab.Methods()
+	if i < 0 || i >= len(fun) {
+		return 0, fmt.Errorf("reflection: IfaceMethodPC index %d out of range for %s with %d methods", i, &tab.Inter.rtype, len(fun))
+	}
+	return fun[i], nil
+}
+
+// IfaceMethodName returns the name of the i'th method of it.Inter, whose
+// code is it.Methods()[i], or the empty string if i is out of range.
+func IfaceMethodName(it *ITab, i int) string {
+	if i < 0 || i >= len(it.Inter.Methods) {
+		return ""
+	}
+	return it.Inter.NameOff(it.Inter.Methods[i].Name).Name()
+}
+
+// size returns the size of the itab in memory.
+func (it *ITab) size() uintptr {
+	size := unsafe.Sizeof(ITab{})
+	if it.Fun[0] == 0 || len(it.Inter.Methods) == 0 {
+		return size
+	}
+	return size + uintptr(len(it.Inter.Methods)-1)*unsafe.Sizeof(uintptr(0))
+}
+
+// ITabs calls yield for each itab the compiler materialized in the loaded
+// modules, following the plugin module chain, until yield returns false.
+// Only pairings known at link time are reported; itabs the runtime builds on
+// demand for dynamic type assertions are not.
+//
+// ITabs requires the runtime module layout, as Modules explains. Without it,
+// ITabs returns an error wrapping ErrUnsupported without calling yield.
+func ITabs(yield func(*ITab) bool) error {
+	if err := checkModules("ITabs"); err != nil {
+		return err
+	}
+	rangeModules(func(md *moduledata) bool {
+		return md.rangeITabs(yield)
+	})
+	return nil
+}
+
+// Types calls yield once for each type descriptor the linker laid out in the
+// loaded modules, following the plugin module chain, until yield returns
+// false.
+//
+// The linker only lists the typelinks, the unnamed composite types reflect
+// searches, and the types of the itabs; Types reports those and the types
+// they are made of, such as their elements, fields and parameters, at any
+// depth. A named type referenced by none of them is not reported, nor are
+// the types built at run time, by reflect or this package. A type used by
+// several modules is reported once per module.
+//
+// Like ITabs, Types requires the runtime module layout, and returns an error
+// wrapping ErrUnsupported without calling yield otherwise.
+func Types(yield func(*rtype) bool) error {
+	if err := checkModules("Types"); err != nil {
+		return err
+	}
+	seen := make(map[*rtype]struct{})
+	var stack []*rtype
+	visit := func(t *rtype) bool {
+		stack = append(stack[:0], t)
+		for len(stack) > 0 {
+			t := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			if !yield(t) {
+				return false
+			}
+			stack = appendComponents(stack, t)
+		}
+		return true
+	}
+	rangeModules(func(md *moduledata) bool {
+		return md.rangeTypes(visit) && md.rangeITabs(func(it *ITab) bool {
+			return visit(&it.Inter.rtype) && visit(it.Type)
+		})
+	})
+	return nil
+}
+
+// appendComponents appends to dst the types t is directly made of.
+func appendComponents(dst []*rtype, t *rtype) []*rtype {
+	switch t.Kind() {
+	case Array, Chan, Ptr, Slice:
+		elem, _ := t.Elem()
+		dst = append(dst, elem)
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		dst = append(dst, mt.Key, mt.Elem)
+	case Func:
+		dst = append(dst, (*FuncType)(unsafe.Pointer(t)).params()...)
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			dst = append(dst, st.Fields[i].typ)
+		}
+	}
+	return dst
+}
+
+// ImplementationsOf returns the concrete types the binary stores in values of
+// the interface type iface, according to the itabs of ITabs, without duplicates
+// and in module order. Like ITabs, it requires the runtime module layout,
+// and returns an error wrapping ErrUnsupported otherwise.
+func ImplementationsOf(iface *InterfaceType) ([]*rtype, error) {
+	var impls []*rtype
+	seen := make(map[*rtype]bool)
+	err := ITabs(func(it *ITab) bool {
+		if it.Inter == iface && it.Fun[0] != 0 && !seen[it.Type] {
+			seen[it.Type] = true
+			impls = append(impls, it.Type)
+		}
+		return true
+	})
+	return impls, err
+}
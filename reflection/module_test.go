@@ -0,0 +1,161 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+type moduleShape interface {
+	Area() float64
+}
+
+type moduleSquare struct{ side float64 }
+
+func (s moduleSquare) Area() float64 { return s.side * s.side }
+
+type moduleCircle struct{ r float64 }
+
+func (c *moduleCircle) Area() float64 { return 3 * c.r * c.r }
+
+// moduleShapes converts the fixtures to moduleShape, so that the linker
+// lays out their itabs, provided it is used, as by moduleArea.
+var moduleShapes = []moduleShape{moduleSquare{1}, &moduleCircle{1}}
+
+func moduleArea() (a float64) {
+	for _, s := range moduleShapes {
+		a += s.Area()
+	}
+	return a
+}
+
+func TestITabs(t *testing.T) {
+	if moduleArea() != 4 {
+		t.Fatal("wrong area")
+	}
+	iface := (*InterfaceType)(unsafe.Pointer(TypeFor[moduleShape]()))
+	var found []*rtype
+	err := ITabs(func(it *ITab) bool {
+		if it.Inter == iface {
+			found = append(found, it.Type)
+			if len(it.Methods()) != 1 || IfaceMethodName(it, 0) != "Area" {
+				t.Errorf("itab of %s: methods %v", it.Type, it.Methods())
+			}
+		}
+		return true
+	})
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("ITabs error = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) < 2 {
+		t.Errorf("ITabs found %v for moduleShape, want the two fixtures", found)
+	}
+
+	n := 0
+	ITabs(func(*ITab) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("ITabs went on for %d itabs after yield returned false", n-3)
+	}
+}
+
+func TestImplementationsOf(t *testing.T) {
+	if moduleArea() != 4 {
+		t.Fatal("wrong area")
+	}
+	impls, err := ImplementationsOf((*InterfaceType)(unsafe.Pointer(TypeFor[moduleShape]())))
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("ImplementationsOf error = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[*rtype]bool{TypeFor[moduleSquare](): true, TypeFor[*moduleCircle](): true}
+	for _, typ := range impls {
+		if !want[typ] {
+			t.Errorf("unexpected implementation %s", typ)
+		}
+		delete(want, typ)
+	}
+	for typ := range want {
+		t.Errorf("implementation %s not found", typ)
+	}
+}
+
+func TestModules(t *testing.T) {
+	mods := Modules()
+	if !modulesSupported {
+		if mods != nil {
+			t.Errorf("Modules = %v, want nil", mods)
+		}
+		return
+	}
+	if len(mods) == 0 || !mods[0].HasMain() || mods[0].PluginPath() != "" {
+		t.Errorf("Modules()[0] is not the executable: %v", mods)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	if moduleArea() != 4 {
+		t.Fatal("wrong area")
+	}
+	seen := make(map[*rtype]bool)
+	err := Types(func(typ *rtype) bool {
+		if seen[typ] {
+			t.Errorf("%s reported twice", typ)
+		}
+		seen[typ] = true
+		return true
+	})
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) || len(seen) != 0 {
+			t.Fatalf("Types error = %v after %d types, want ErrUnsupported", err, len(seen))
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The itab types, and the types they are made of.
+	for _, typ := range []*rtype{TypeFor[moduleShape](), TypeFor[moduleSquare](), TypeFor[*moduleCircle](), TypeFor[moduleCircle](), TypeFor[float64]()} {
+		if !seen[typ] {
+			t.Errorf("Types did not report %s", typ)
+		}
+	}
+
+	n := 0
+	Types(func(*rtype) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("Types went on for %d types after yield returned false", n-3)
+	}
+}
+
+func TestModuleLayoutHolds(t *testing.T) {
+	// A mirror that does not hold for the release it was written for would
+	// otherwise only show as skipped tests.
+	if moduleLayoutMirrored && !modulesSupported {
+		t.Errorf("the module layout mirrored for %s does not hold", runtime.Version())
+	}
+	if moduleLayoutHolds(nil) || moduleLayoutHolds(&moduledata{}) {
+		t.Error("moduleLayoutHolds of a nil or zero moduledata = true")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21 && !go1.27 && !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// moduledata mirrors runtime.moduledata for Go 1.21 to 1.26.
+//
+// moduledata records information about the layout of the executable
+// image. It is written by the linker. Any changes here must be
+// matched changes to the code in cmd/link/internal/ld/symtab.go:symtab.
+// moduledata is stored in statically allocated non-pointer memory;
+// none of the pointers here are visible to the garbage collector.
+type moduledata struct {
+	pcHeader     unsafe.Pointer
+	funcnametab  []byte
+	cutab        []uint32
+	filetab      []byte
+	pctab        []byte
+	pclntable    []byte
+	ftab         []struct{ entryoff, funcoff uint32 }
+	findfunctab  uintptr
+	minpc, maxpc uintptr
+
+	text, etext           uintptr
+	noptrdata, enoptrdata uintptr
+	data, edata           uintptr
+	bss, ebss             uintptr
+	noptrbss, enoptrbss   uintptr
+	covctrs, ecovctrs     uintptr
+	end, gcdata, gcbss    uintptr
+	types, etypes         uintptr
+	rodata                uintptr
+	gofunc                uintptr // go.func.*
+
+	textsectmap []struct{ vaddr, end, baseaddr uintptr }
+	typelinks   []int32 // offsets from types
+	itablinks   []*ITab
+
+	ptab []struct{ name, typ int32 }
+
+	pluginpath string
+	pkghashes  []modulehash
+
+	inittasks []unsafe.Pointer
+
+	modulename   string
+	modulehashes []modulehash
+
+	hasmain uint8 // 1 if module contains the main function, 0 otherwise
+
+	gcdatamask, gcbssmask struct {
+		n        int32
+		bytedata *uint8
+	}
+
+	typemap map[int32]unsafe.Pointer // offset to *_rtype in previous module
+
+	bad bool // module failed to load and should be ignored
+
+	next *moduledata
+}
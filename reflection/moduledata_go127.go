@@ -0,0 +1,174 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.27 && !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// moduledata mirrors runtime.moduledata.
+//
+// moduledata records information about the layout of the executable
+// image. It is written by the linker. Any changes here must be
+// matched changes to the code in cmd/link/internal/ld/symtab.go:symtab.
+// moduledata is stored in statically allocated non-pointer memory;
+// none of the pointers here are visible to the garbage collector.
+type moduledata struct {
+	pcHeader     unsafe.Pointer
+	funcnametab  []byte
+	cutab        []uint32
+	filetab      []byte
+	pctab        []byte
+	pclntable    []byte
+	ftab         []struct{ entryoff, funcoff uint32 }
+	findfunctab  uintptr
+	minpc, maxpc uintptr
+
+	text, etext                uintptr
+	noptrdata, enoptrdata      uintptr
+	data, edata                uintptr
+	bss, ebss                  uintptr
+	noptrbss, enoptrbss        uintptr
+	covctrs, ecovctrs          uintptr
+	end, gcdata, gcbss         uintptr
+	types, typedesclen, etypes uintptr
+	itaboffset, itabsize       uintptr
+	rodata                     uintptr
+	gofunc                     uintptr // go.func.*
+	epclntab                   uintptr
+
+	textsectmap []struct{ vaddr, end, baseaddr uintptr }
+
+	ptab []struct{ name, typ int32 }
+
+	pluginpath string
+	pkghashes  []modulehash
+
+	inittasks []unsafe.Pointer
+
+	modulename   string
+	modulehashes []modulehash
+
+	hasmain uint8 // 1 if module contains the main function, 0 otherwise
+	bad     bool  // module failed to load and should be ignored
+
+	gcdatamask, gcbssmask struct {
+		n        int32
+		bytedata *uint8
+	}
+
+	typemap map[unsafe.Pointer]unsafe.Pointer
+
+	next *moduledata
+}
+
+// moduleLayoutMirrored reports whether moduledata mirrors the runtime's
+// layout.
+const moduleLayoutMirrored = true
+
+// holdsType reports whether p lies in the type descriptors of md.
+func (md *moduledata) holdsType(p uintptr) bool {
+	return md.types <= p && p < md.etypes
+}
+
+// typeOff returns the address at offset off from the type descriptors of md.
+func (md *moduledata) typeOff(off int32) unsafe.Pointer {
+	// md.types is the address of a linker symbol, never a heap pointer.
+	types := *(*unsafe.Pointer)(unsafe.Pointer(&md.types))
+	return unsafe.Add(types, off)
+}
+
+// sharedType returns the descriptor of a module loaded before md to use in
+// place of its descriptor t, or nil.
+func (md *moduledata) sharedType(t unsafe.Pointer) unsafe.Pointer {
+	return md.typemap[t]
+}
+
+// rangeITabs calls fn for each itab the linker laid out in md.
+func (md *moduledata) rangeITabs(fn func(*ITab) bool) bool {
+	// md.types is the address of a linker symbol, never a heap pointer.
+	types := *(*unsafe.Pointer)(unsafe.Pointer(&md.types))
+	for off := md.itaboffset; off < md.itaboffset+md.itabsize; {
+		it := (*ITab)(unsafe.Add(types, off))
+		if !fn(it) {
+			return false
+		}
+		off += it.size()
+	}
+	return true
+}
+
+// rangeTypes calls fn for each typelink of md, the unnamed composite types
+// the linker lays out first from the pointer-sized runtime.types symbol on,
+// up to typedesclen, each aligned to a pointer as runtime.moduleTypelinks
+// walks them. The named types follow among other read-only data, and cannot
+// be walked.
+func (md *moduledata) rangeTypes(fn func(*rtype) bool) bool {
+	// md.types is the address of a linker symbol, never a heap pointer.
+	types := *(*unsafe.Pointer)(unsafe.Pointer(&md.types))
+	for off := ptrSize; off < md.typedesclen; {
+		off = (off + ptrSize - 1) &^ (ptrSize - 1)
+		t := (*rtype)(unsafe.Add(types, off))
+		if !fn(t) {
+			return false
+		}
+		off += descriptorSize(t)
+	}
+	return true
+}
+
+// descriptorSize returns the size of the descriptor of t, with its uncommon
+// type and methods, as abi.Type.DescriptorSize does.
+func descriptorSize(t *rtype) uintptr {
+	var base, add uintptr
+	switch t.Kind() {
+	case Array:
+		base = unsafe.Sizeof(ArrayType{})
+	case Chan:
+		base = unsafe.Sizeof(ChanType{})
+	case Func:
+		ft := (*FuncType)(unsafe.Pointer(t))
+		base = unsafe.Sizeof(FuncType{})
+		add = uintptr(ft.NumIn()+ft.NumOut()) * ptrSize
+	case Interface:
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		base = unsafe.Sizeof(InterfaceType{})
+		add = uintptr(len(it.Methods)) * unsafe.Sizeof(Imethod{})
+	case Map:
+		base = unsafe.Sizeof(MapType{})
+	case Ptr:
+		base = unsafe.Sizeof(PtrType{})
+	case Slice:
+		base = unsafe.Sizeof(SliceType{})
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		base = unsafe.Sizeof(StructType{})
+		add = uintptr(len(st.Fields)) * unsafe.Sizeof(StructField{})
+	default:
+		base = unsafe.Sizeof(rtype{})
+	}
+	size := base + add
+	if u := t.Uncommon(); u != nil {
+		size += unsafe.Sizeof(UncommonType{}) + uintptr(u.Mcount)*unsafe.Sizeof(Method{})
+	}
+	return size
+}
+
+// readOnly reports whether p lies in the read-only data of md, which holds
+// string literals and the pclntab, or in its type descriptors and itabs.
+func (md *moduledata) readOnly(p uintptr) bool {
+	return md.rodata <= p && p < md.epclntab ||
+		md.types <= p && p < md.etypes
+}
+
+// writable reports whether p lies in the data or bss sections of md.
+func (md *moduledata) writable(p uintptr) bool {
+	return md.noptrdata <= p && p < md.enoptrdata ||
+		md.data <= p && p < md.edata ||
+		md.bss <= p && p < md.ebss ||
+		md.noptrbss <= p && p < md.enoptrbss
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// moduledata stands in for runtime.moduledata under the darkness_safe build
+// tag, which does not read the runtime's layout. No module is ever reported.
+type moduledata struct {
+	text, etext   uintptr
+	types, etypes uintptr
+	pluginpath    string
+	modulename    string
+	hasmain       uint8
+	bad           bool
+	next          *moduledata
+}
+
+// moduleLayoutMirrored reports whether moduledata mirrors the runtime's
+// layout.
+const moduleLayoutMirrored = false
+
+func (md *moduledata) holdsType(p uintptr) bool {
+	return false
+}
+
+func (md *moduledata) typeOff(off int32) unsafe.Pointer {
+	return nil
+}
+
+func (md *moduledata) sharedType(t unsafe.Pointer) unsafe.Pointer {
+	return nil
+}
+
+func (md *moduledata) rangeITabs(fn func(*ITab) bool) bool {
+	return true
+}
+
+func (md *moduledata) rangeTypes(fn func(*rtype) bool) bool {
+	return true
+}
+
+func (md *moduledata) readOnly(p uintptr) bool {
+	return false
+}
+
+func (md *moduledata) writable(p uintptr) bool {
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.27 && !darkness_safe
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// The methods below read the moduledata of the releases before Go 1.27,
+// which list the typelinks and the itabs of a module in slices of their own.
+
+// moduleLayoutMirrored reports whether moduledata mirrors the runtime's
+// layout.
+const moduleLayoutMirrored = true
+
+// holdsType reports whether p lies in the type descriptors of md.
+func (md *moduledata) holdsType(p uintptr) bool {
+	return md.types <= p && p < md.etypes
+}
+
+// typeOff returns the address at offset off from the type descriptors of md.
+func (md *moduledata) typeOff(off int32) unsafe.Pointer {
+	// md.types is the address of a linker symbol, never a heap pointer.
+	types := *(*unsafe.Pointer)(unsafe.Pointer(&md.types))
+	return unsafe.Add(types, off)
+}
+
+// sharedType returns the descriptor of a module loaded before md to use in
+// place of its descriptor t, or nil. The typemap of these releases is keyed
+// by the offset of t from the type descriptors of md.
+func (md *moduledata) sharedType(t unsafe.Pointer) unsafe.Pointer {
+	if md.typemap == nil {
+		return nil
+	}
+	return md.typemap[int32(uintptr(t)-md.types)]
+}
+
+// rangeITabs calls fn for each itab the linker laid out in md.
+func (md *moduledata) rangeITabs(fn func(*ITab) bool) bool {
+	for _, it := range md.itablinks {
+		if !fn(it) {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeTypes calls fn for each typelink of md, the unnamed composite types
+// reflect searches.
+func (md *moduledata) rangeTypes(fn func(*rtype) bool) bool {
+	for _, off := range md.typelinks {
+		if !fn((*rtype)(md.typeOff(off))) {
+			return false
+		}
+	}
+	return true
+}
+
+// readOnly reports whether p lies in the read-only data of md, which holds
+// string literals and the pclntab, or in its type descriptors and itabs.
+func (md *moduledata) readOnly(p uintptr) bool {
+	if md.types <= p && p < md.etypes {
+		return true
+	}
+	for _, it := range md.itablinks {
+		if start := uintptr(unsafe.Pointer(it)); start <= p && p < start+it.size() {
+			return true
+		}
+	}
+	// The pclntab follows the read-only data, and ends with pclntable.
+	if len(md.pclntable) == 0 {
+		return false
+	}
+	epclntab := uintptr(unsafe.Pointer(&md.pclntable[0])) + uintptr(len(md.pclntable))
+	return md.rodata <= p && p < epclntab
+}
+
+// writable reports whether p lies in the data or bss sections of md.
+func (md *moduledata) writable(p uintptr) bool {
+	return md.noptrdata <= p && p < md.enoptrdata ||
+		md.data <= p && p < md.edata ||
+		md.bss <= p && p < md.ebss ||
+		md.noptrbss <= p && p < md.enoptrbss
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidName is returned when a name or a tag fails the validation of
+// NameOptions.
+var ErrInvalidName = errors.New("reflection: invalid name")
+
+// NameOptions controls the validation of the names and tags encoded by
+// NameOptions.NewName.
+type NameOptions struct {
+	// Permissive accepts any bytes as a name, as NewName and NewNameErr do.
+	Permissive bool
+
+	// StrictTags rejects tags that do not follow the conventional
+	// key:"value" syntax that reflect.StructTag.Get parses.
+	StrictTags bool
+}
+
+// NewName is like NewNameErr but, unless o.Permissive is set, returns an
+// error wrapping ErrInvalidName if n is not a Go identifier as the compiler
+// accepts it: valid UTF-8, made of letters, digits and underscores, and not
+// starting with a digit. With o.StrictTags, malformed tags are rejected too.
+func (o *NameOptions) NewName(n, tag string, exported bool) (Name, error) {
+	if !o.Permissive && !isIdentifier(n) {
+		return Name{}, fmt.Errorf("%w: %q is not an identifier", ErrInvalidName, n)
+	}
+	if o.StrictTags && !isWellFormedTag(tag) {
+		return Name{}, fmt.Errorf("%w: malformed tag %q", ErrInvalidName, tag)
+	}
+	return NewNameErr(n, tag, exported)
+}
+
+// isIdentifier reports whether s is a Go identifier.
+func isIdentifier(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isWellFormedTag reports whether tag is a space-separated list of key:"value"
+// pairs, where value is a quoted Go string, as reflect.StructTag.Lookup and
+// go vet's structtag check expect.
+func isWellFormedTag(tag string) bool {
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return false
+		}
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return false
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return false
+		}
+		tag = tag[i+1:]
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestNameEqualCompare(t *testing.T) {
+	long := strings.Repeat("x", 200) // a length of two varint bytes
+	tests := []struct {
+		a, b Name
+	}{
+		{NewName("A", "", true), NewName("A", "", true)},
+		{NewName("A", "", true), NewName("A", "", false)},
+		{NewName("A", `json:"a"`, true), NewName("A", `json:"b"`, true)},
+		{NewName("A", "", true), NewName("B", "", true)},
+		{NewName("ab", "", true), NewName("a", "", true)},
+		{NewName(long, "", true), NewName(long, "", true)},
+		{NewName(long, "", true), NewName(long+"y", "", true)},
+		{NewName("A", "", true), Name{}},
+		{NewName("", "", false), Name{}},
+		{Name{}, NewName("", `json:"-"`, false)},
+		{Name{}, Name{}},
+	}
+	for _, tt := range tests {
+		wantEq := tt.a.Name() == tt.b.Name() && tt.a.Tag() == tt.b.Tag()
+		if got := tt.a.Equal(tt.b); got != wantEq {
+			t.Errorf("%q.Equal(%q) = %v, want %v", tt.a.Name(), tt.b.Name(), got, wantEq)
+		}
+		if got, want := tt.a.EqualString(tt.b.Name()), tt.a.Name() == tt.b.Name(); got != want {
+			t.Errorf("%q.EqualString(%q) = %v, want %v", tt.a.Name(), tt.b.Name(), got, want)
+		}
+		want := strings.Compare(tt.a.Name(), tt.b.Name())
+		if want == 0 {
+			want = strings.Compare(tt.a.Tag(), tt.b.Tag())
+		}
+		if got := tt.a.Compare(tt.b); got != want {
+			t.Errorf("%q.Compare(%q) = %d, want %d", tt.a.Name(), tt.b.Name(), got, want)
+		}
+	}
+}
+
+func TestNewNameErr(t *testing.T) {
+	n, err := NewNameErr("Field", `json:"field"`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name() != "Field" || n.Tag() != `json:"field"` || !n.IsExported() {
+		t.Errorf("NewNameErr = %q %q exported %v", n.Name(), n.Tag(), n.IsExported())
+	}
+
+	// A string of maxNameLen bytes whose header points at a short buffer:
+	// NewNameErr reads no more than the prefix it quotes in the error.
+	buf := []byte(strings.Repeat("x", 1024))
+	long := *(*string)(unsafe.Pointer(&StringHeader{Data: unsafe.Pointer(&buf[0]), Len: maxNameLen}))
+	if _, err := NewNameErr(long, "", true); !errors.Is(err, ErrNameTooLong) {
+		t.Errorf("NewNameErr of a long name = %v, want ErrNameTooLong", err)
+	}
+	if _, err := NewNameErr("F", long, true); !errors.Is(err, ErrNameTooLong) {
+		t.Errorf("NewNameErr of a long tag = %v, want ErrNameTooLong", err)
+	}
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrNameTooLong) {
+			t.Errorf("NewName of a long name panicked with %v, want ErrNameTooLong", err)
+		}
+	}()
+	NewName(long, "", true)
+}
+
+// benchNames are two distinct encodings of the same long field name, so that
+// Equal does not return early on identical pointers.
+var benchNames = [2]Name{
+	NewName("AVeryLongFieldNameForBenchmarking", `json:"a_very_long_field_name"`, true),
+	NewName("AVeryLongFieldNameForBenchmarking", `json:"a_very_long_field_name"`, true),
+}
+
+var benchBool bool
+
+func BenchmarkNameEqual(b *testing.B) {
+	n, other := benchNames[0], benchNames[1]
+	b.Run("Equal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = n.Equal(other)
+		}
+	})
+	b.Run("EqualString", func(b *testing.B) {
+		s := other.Name()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = n.EqualString(s)
+		}
+	})
+	b.Run("Compare", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = n.Compare(other) == 0
+		}
+	})
+	b.Run("NameStrings", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = n.Name() == other.Name() && n.Tag() == other.Tag()
+		}
+	})
+}
+
+func TestNameOptions(t *testing.T) {
+	tests := []struct {
+		name, tag  string
+		permissive bool
+		strictTags bool
+		wantErr    bool
+	}{
+		{"Field", "", false, false, false},
+		{"_x9", "", false, false, false},
+		{"Größe", "", false, false, false},
+		{"", "", false, false, true},
+		{"9x", "", false, false, true},
+		{"a b", "", false, false, true},
+		{"a-b", "", false, false, true},
+		{"\xff", "", false, false, true},
+		{"a b", "", true, false, false},
+		{"F", `json:"f,omitempty" xml:"f"`, false, true, false},
+		{"F", ` json:"f" `, false, true, false},
+		{"F", `json:f`, false, true, true},
+		{"F", `json: "f"`, false, true, true},
+		{"F", `json:"f`, false, true, true},
+		{"F", `:"f"`, false, true, true},
+		{"F", `json:"\q"`, false, true, true},
+		{"F", `json:f`, false, false, false},
+	}
+	for _, tt := range tests {
+		o := NameOptions{Permissive: tt.permissive, StrictTags: tt.strictTags}
+		n, err := o.NewName(tt.name, tt.tag, true)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidName) {
+				t.Errorf("%+v.NewName(%q, %q) = %v, want ErrInvalidName", o, tt.name, tt.tag, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v.NewName(%q, %q) = %v", o, tt.name, tt.tag, err)
+			continue
+		}
+		if n.Name() != tt.name || n.Tag() != tt.tag {
+			t.Errorf("%+v.NewName(%q, %q) encoded %q %q", o, tt.name, tt.tag, n.Name(), n.Tag())
+		}
+	}
+}
+
+func TestNameAppend(t *testing.T) {
+	long := strings.Repeat("y", 300)
+	names := []Name{
+		NewName("A", "", true),
+		NewName("field", `json:"f"`, false),
+		NewName(long, long, true),
+		NewName("", "", false),
+		Name{},
+	}
+	for _, n := range names {
+		if got := string(n.AppendTo([]byte("x."))); got != "x."+n.Name() {
+			t.Errorf("AppendTo = %q, want x.%s", got, n.Name())
+		}
+		if got := string(n.AppendTag([]byte("x."))); got != "x."+n.Tag() {
+			t.Errorf("AppendTag = %q, want x.%s", got, n.Tag())
+		}
+	}
+
+	n := names[2]
+	buf := make([]byte, 0, 1024)
+	if allocs := testing.AllocsPerRun(100, func() {
+		buf = n.AppendTag(n.AppendTo(buf[:0]))
+	}); allocs != 0 {
+		t.Errorf("AppendTo and AppendTag allocate %v times", allocs)
+	}
+}
+
+func TestNameBytes(t *testing.T) {
+	st, _ := AsStructType(TypeFor[struct {
+		Field int `json:"field"`
+		Other int
+	}]())
+	for _, n := range []Name{NewName("A", `x:"y"`, true), st.Fields[0].Name, st.Fields[1].Name, Name{}} {
+		nb, tb := n.NameBytes(), n.TagBytes()
+		if string(nb) != n.Name() || string(tb) != n.Tag() {
+			t.Errorf("NameBytes, TagBytes = %q, %q, want %q, %q", nb, tb, n.Name(), n.Tag())
+		}
+		if cap(nb) != len(nb) || cap(tb) != len(tb) {
+			t.Errorf("%q: capacities %d, %d exceed the lengths", n.Name(), cap(nb), cap(tb))
+		}
+		if n.Tag() == "" && tb != nil {
+			t.Errorf("%q: TagBytes of no tag = %q, want nil", n.Name(), tb)
+		}
+	}
+
+	// The views alias the encoded data, and appending to them copies it.
+	n := NewName("Name", `tag:"v"`, true)
+	nb := n.NameBytes()
+	name := n.Name()
+	if unsafe.Pointer(&nb[0]) != (*StringHeader)(unsafe.Pointer(&name)).Data {
+		t.Error("NameBytes does not alias the encoded name")
+	}
+	_ = append(nb, 'X')
+	if n.Tag() != `tag:"v"` {
+		t.Errorf("appending to NameBytes overwrote the tag: %q", n.Tag())
+	}
+	if allocs := testing.AllocsPerRun(100, func() { nb = n.NameBytes() }); allocs != 0 {
+		t.Errorf("NameBytes allocates %v times", allocs)
+	}
+}
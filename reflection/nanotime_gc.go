@@ -0,0 +1,24 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build gc
+
+package reflection
+
+import (
+	_ "unsafe" // for go:linkname
+)
+
+// Nanotime returns the current time of the runtime's monotonic clock in
+// nanoseconds, as time.Now reads it but without the cost of the wall clock.
+// Only differences between two values are meaningful.
+func Nanotime() int64 {
+	return nanotime()
+}
+
+//go:linkname nanotime runtime.nanotime
+
+// nanotime returns the monotonic time in nanoseconds.
+// Implemented in the runtime package.
+func nanotime() int64
@@ -0,0 +1,23 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !gc
+
+package reflection
+
+import (
+	"time"
+)
+
+// nanotimeBase is the origin of the values of Nanotime.
+var nanotimeBase = time.Now()
+
+// Nanotime returns the current time of a monotonic clock in nanoseconds.
+// Only differences between two values are meaningful.
+//
+// Without access to the runtime clock, it reads the monotonic clock reading
+// of time.Now.
+func Nanotime() int64 {
+	return int64(time.Since(nanotimeBase))
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNanotime(t *testing.T) {
+	start, wall := Nanotime(), time.Now()
+	prev := start
+	for i := 0; i < 1000; i++ {
+		now := Nanotime()
+		if now < prev {
+			t.Fatalf("Nanotime went backwards: %d after %d", now, prev)
+		}
+		prev = now
+	}
+	time.Sleep(10 * time.Millisecond)
+	elapsed, wallElapsed := time.Duration(Nanotime()-start), time.Since(wall)
+	if elapsed < 10*time.Millisecond || elapsed > wallElapsed+time.Millisecond {
+		t.Errorf("Nanotime measured %v over a sleep of 10ms that time.Since measured as %v", elapsed, wallElapsed)
+	}
+}
+
+var benchInt64 int64
+
+func BenchmarkNanotime(b *testing.B) {
+	b.Run("Nanotime", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchInt64 = Nanotime()
+		}
+	})
+	b.Run("time.Now", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchInt64 = time.Now().UnixNano()
+		}
+	})
+}
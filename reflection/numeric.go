@@ -0,0 +1,327 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ReadInt returns the signed integer of kind k at p, whatever its width.
+// It returns a *KindError if k is not a signed integer kind.
+func ReadInt(p unsafe.Pointer, k Kind) (int64, error) {
+	if !isSigned(k) {
+		return 0, &KindError{Op: "ReadInt", Kind: k}
+	}
+	return loadInt(p, k), nil
+}
+
+// ReadUint returns the unsigned integer of kind k at p, whatever its width.
+// It returns a *KindError if k is not an unsigned integer kind.
+func ReadUint(p unsafe.Pointer, k Kind) (uint64, error) {
+	if !isUnsigned(k) {
+		return 0, &KindError{Op: "ReadUint", Kind: k}
+	}
+	return loadUint(p, k), nil
+}
+
+// ReadFloat returns the floating-point number of kind k at p. It returns a
+// *KindError if k is not a floating-point kind.
+func ReadFloat(p unsafe.Pointer, k Kind) (float64, error) {
+	if !isFloat(k) {
+		return 0, &KindError{Op: "ReadFloat", Kind: k}
+	}
+	return loadFloat(p, k), nil
+}
+
+// ReadBool returns the boolean of kind k at p. It returns a *KindError if k
+// is not Bool.
+func ReadBool(p unsafe.Pointer, k Kind) (bool, error) {
+	if k != Bool {
+		return false, &KindError{Op: "ReadBool", Kind: k}
+	}
+	return *(*bool)(p), nil
+}
+
+// NumericOptions controls the Write functions.
+type NumericOptions struct {
+	// Truncate stores the values that do not fit the kind written as
+	// converting them to its type does, keeping the low bits of integers,
+	// and rounding floating-point numbers to infinity, rather than failing.
+	Truncate bool
+}
+
+// WriteInt stores v to the integer of kind k at p, signed or unsigned,
+// whatever its width. It returns an error wrapping ErrOverflow, storing
+// nothing, if v does not fit the kind, such as 300 for Int8 or -1 for
+// Uint8, and a *KindError if k is not an integer kind.
+func WriteInt(p unsafe.Pointer, k Kind, v int64) error {
+	var o NumericOptions
+	return o.WriteInt(p, k, v)
+}
+
+// WriteInt is like the top-level WriteInt, with the options of o.
+func (o *NumericOptions) WriteInt(p unsafe.Pointer, k Kind, v int64) error {
+	n := intBits(k)
+	switch {
+	case isSigned(k):
+		if !o.Truncate && n < 64 && (v < -1<<(n-1) || v > 1<<(n-1)-1) {
+			return fmt.Errorf("%w: %d to %s", ErrOverflow, v, k)
+		}
+	case isUnsigned(k):
+		if !o.Truncate && (v < 0 || n < 64 && v > 1<<n-1) {
+			return fmt.Errorf("%w: %d to %s", ErrOverflow, v, k)
+		}
+	default:
+		return &KindError{Op: "WriteInt", Kind: k}
+	}
+	storeBits(p, k, uint64(v))
+	return nil
+}
+
+// WriteUint is like WriteInt, for an unsigned value.
+func WriteUint(p unsafe.Pointer, k Kind, v uint64) error {
+	var o NumericOptions
+	return o.WriteUint(p, k, v)
+}
+
+// WriteUint is like the top-level WriteUint, with the options of o.
+func (o *NumericOptions) WriteUint(p unsafe.Pointer, k Kind, v uint64) error {
+	n := intBits(k)
+	switch {
+	case isSigned(k):
+		if !o.Truncate && v > 1<<(n-1)-1 {
+			return fmt.Errorf("%w: %d to %s", ErrOverflow, v, k)
+		}
+	case isUnsigned(k):
+		if !o.Truncate && n < 64 && v > 1<<n-1 {
+			return fmt.Errorf("%w: %d to %s", ErrOverflow, v, k)
+		}
+	default:
+		return &KindError{Op: "WriteUint", Kind: k}
+	}
+	storeBits(p, k, v)
+	return nil
+}
+
+// WriteFloat stores v to the floating-point number of kind k at p. It
+// returns an error wrapping ErrOverflow, storing nothing, if v is finite but
+// beyond the range of the kind, and a *KindError if k is not a
+// floating-point kind. Infinities and NaNs are stored as they are.
+func WriteFloat(p unsafe.Pointer, k Kind, v float64) error {
+	var o NumericOptions
+	return o.WriteFloat(p, k, v)
+}
+
+// WriteFloat is like the top-level WriteFloat, with the options of o.
+func (o *NumericOptions) WriteFloat(p unsafe.Pointer, k Kind, v float64) error {
+	switch k {
+	case Float32:
+		if !o.Truncate && !math.IsInf(v, 0) && math.IsInf(float64(float32(v)), 0) {
+			return fmt.Errorf("%w: %g to %s", ErrOverflow, v, k)
+		}
+	case Float64:
+	default:
+		return &KindError{Op: "WriteFloat", Kind: k}
+	}
+	storeFloat(p, k, v)
+	return nil
+}
+
+// WriteBool stores v to the boolean of kind k at p. It returns a *KindError
+// if k is not Bool.
+func WriteBool(p unsafe.Pointer, k Kind, v bool) error {
+	if k != Bool {
+		return &KindError{Op: "WriteBool", Kind: k}
+	}
+	*(*bool)(p) = v
+	return nil
+}
+
+// intBits returns the width in bits of the integer kind k.
+func intBits(k Kind) uint {
+	switch k {
+	case Int8, Uint8:
+		return 8
+	case Int16, Uint16:
+		return 16
+	case Int32, Uint32:
+		return 32
+	case Int, Uint, Uintptr:
+		return uint(ptrSize) * 8
+	}
+	return 64
+}
+
+// isSigned reports whether k is a signed integer kind.
+func isSigned(k Kind) bool {
+	switch k {
+	case Int, Int8, Int16, Int32, Int64:
+		return true
+	}
+	return false
+}
+
+// isUnsigned reports whether k is an unsigned integer kind.
+func isUnsigned(k Kind) bool {
+	switch k {
+	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		return true
+	}
+	return false
+}
+
+// isFloat reports whether k is a floating-point kind.
+func isFloat(k Kind) bool {
+	return k == Float32 || k == Float64
+}
+
+// isComplex reports whether k is a complex kind.
+func isComplex(k Kind) bool {
+	return k == Complex64 || k == Complex128
+}
+
+// loadInt returns the signed integer of kind k at p.
+func loadInt(p unsafe.Pointer, k Kind) int64 {
+	switch k {
+	case Int:
+		return int64(*(*int)(p))
+	case Int8:
+		return int64(*(*int8)(p))
+	case Int16:
+		return int64(*(*int16)(p))
+	case Int32:
+		return int64(*(*int32)(p))
+	}
+	return *(*int64)(p)
+}
+
+// loadUint returns the unsigned integer of kind k at p.
+func loadUint(p unsafe.Pointer, k Kind) uint64 {
+	switch k {
+	case Uint:
+		return uint64(*(*uint)(p))
+	case Uint8:
+		return uint64(*(*uint8)(p))
+	case Uint16:
+		return uint64(*(*uint16)(p))
+	case Uint32:
+		return uint64(*(*uint32)(p))
+	case Uintptr:
+		return uint64(*(*uintptr)(p))
+	}
+	return *(*uint64)(p)
+}
+
+// loadFloat returns the floating-point number of kind k at p.
+func loadFloat(p unsafe.Pointer, k Kind) float64 {
+	if k == Float32 {
+		return float64(*(*float32)(p))
+	}
+	return *(*float64)(p)
+}
+
+// storeBits stores the low bits of x to the integer of kind k at p, as
+// converting x to its type does.
+func storeBits(p unsafe.Pointer, k Kind, x uint64) {
+	switch k {
+	case Int:
+		*(*int)(p) = int(x)
+	case Int8:
+		*(*int8)(p) = int8(x)
+	case Int16:
+		*(*int16)(p) = int16(x)
+	case Int32:
+		*(*int32)(p) = int32(x)
+	case Int64:
+		*(*int64)(p) = int64(x)
+	case Uint:
+		*(*uint)(p) = uint(x)
+	case Uint8:
+		*(*uint8)(p) = uint8(x)
+	case Uint16:
+		*(*uint16)(p) = uint16(x)
+	case Uint32:
+		*(*uint32)(p) = uint32(x)
+	case Uint64:
+		*(*uint64)(p) = x
+	case Uintptr:
+		*(*uintptr)(p) = uintptr(x)
+	}
+}
+
+// storeFloat stores f to the floating-point number of kind k at p.
+func storeFloat(p unsafe.Pointer, k Kind, f float64) {
+	if k == Float32 {
+		*(*float32)(p) = float32(f)
+		return
+	}
+	*(*float64)(p) = f
+}
+
+// convertNumber converts the value of type st at sp to the type dt, and
+// stores it at dp, as the conversion of a numeric value to dt does in Go: an
+// integer to an integer, a floating-point number or a string, a
+// floating-point number to an integer or a floating-point number, and a
+// complex number to a complex number. It reports false for other kinds.
+func convertNumber(dt *rtype, dp unsafe.Pointer, st *rtype, sp unsafe.Pointer) bool {
+	sk, dk := st.Kind(), dt.Kind()
+	switch {
+	case isSigned(sk) || isUnsigned(sk):
+		var x uint64
+		var f float64
+		var r rune
+		if isSigned(sk) {
+			i := loadInt(sp, sk)
+			x, f, r = uint64(i), float64(i), rune(i)
+			if int64(r) != i {
+				r = utf8.RuneError
+			}
+		} else {
+			u := loadUint(sp, sk)
+			x, f, r = u, float64(u), rune(u)
+			if u > utf8.MaxRune {
+				r = utf8.RuneError
+			}
+		}
+		switch {
+		case isSigned(dk) || isUnsigned(dk):
+			storeBits(dp, dk, x)
+		case isFloat(dk):
+			storeFloat(dp, dk, f)
+		case dk == String:
+			*(*string)(dp) = string(r)
+		default:
+			return false
+		}
+	case isFloat(sk):
+		f := loadFloat(sp, sk)
+		switch {
+		case isSigned(dk):
+			storeBits(dp, dk, uint64(int64(f)))
+		case isUnsigned(dk):
+			storeBits(dp, dk, uint64(f))
+		case isFloat(dk):
+			storeFloat(dp, dk, f)
+		default:
+			return false
+		}
+	case isComplex(sk) && isComplex(dk):
+		c := complex128(*(*complex64)(sp))
+		if sk == Complex128 {
+			c = *(*complex128)(sp)
+		}
+		if dk == Complex64 {
+			*(*complex64)(dp) = complex64(c)
+		} else {
+			*(*complex128)(dp) = c
+		}
+	default:
+		return false
+	}
+	return true
+}
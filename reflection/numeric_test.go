@@ -0,0 +1,225 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"unsafe"
+)
+
+var (
+	signedKinds   = []Kind{Int, Int8, Int16, Int32, Int64}
+	unsignedKinds = []Kind{Uint, Uint8, Uint16, Uint32, Uint64, Uintptr}
+)
+
+func TestWriteIntBoundaries(t *testing.T) {
+	for _, k := range signedKinds {
+		n := intBits(k)
+		min, max := int64(-1)<<(n-1), int64(1)<<(n-1)-1
+		testWriteInt(t, k, min, true, min)
+		testWriteInt(t, k, max, true, max)
+		testWriteUint(t, k, uint64(max), true, max)
+		testWriteUint(t, k, uint64(max)+1, false, min)
+		if n < 64 {
+			testWriteInt(t, k, min-1, false, max)
+			testWriteInt(t, k, max+1, false, min)
+		}
+	}
+	for _, k := range unsignedKinds {
+		n := intBits(k)
+		max := uint64(math.MaxUint64) >> (64 - n)
+		testWriteInt(t, k, 0, true, 0)
+		testWriteInt(t, k, -1, false, int64(max))
+		testWriteUint(t, k, max, true, int64(max))
+		if n < 64 {
+			testWriteInt(t, k, int64(max), true, int64(max))
+			testWriteInt(t, k, int64(max)+1, false, 0)
+			testWriteUint(t, k, max+1, false, 0)
+		} else {
+			testWriteInt(t, k, math.MaxInt64, true, math.MaxInt64)
+		}
+	}
+}
+
+// testWriteInt checks that WriteInt of v to kind k succeeds if ok, and
+// otherwise fails with ErrOverflow and stores nothing, and that the Truncate
+// option stores the bits of want, as read back by readBits.
+func testWriteInt(t *testing.T, k Kind, v int64, ok bool, want int64) {
+	t.Helper()
+	testWrite(t, "WriteInt", k, v, ok, want, func(o *NumericOptions, p unsafe.Pointer) error {
+		return o.WriteInt(p, k, v)
+	})
+}
+
+func testWriteUint(t *testing.T, k Kind, v uint64, ok bool, want int64) {
+	t.Helper()
+	testWrite(t, "WriteUint", k, v, ok, want, func(o *NumericOptions, p unsafe.Pointer) error {
+		return o.WriteUint(p, k, v)
+	})
+}
+
+func testWrite(t *testing.T, op string, k Kind, v interface{}, ok bool, want int64, write func(*NumericOptions, unsafe.Pointer) error) {
+	t.Helper()
+	const guard = 0x5a5a5a5a5a5a5a5a
+	for _, truncate := range []bool{false, true} {
+		w := uint64(guard)
+		o := NumericOptions{Truncate: truncate}
+		err := write(&o, unsafe.Pointer(&w))
+		switch {
+		case !ok && !truncate:
+			if !errors.Is(err, ErrOverflow) || w != guard {
+				t.Errorf("%s(%s, %d) = %v, stored %#x, want ErrOverflow storing nothing", op, k, v, err, w)
+			}
+		case err != nil:
+			t.Errorf("%s(%s, %d, Truncate %v) = %v", op, k, v, truncate, err)
+		case readBits(unsafe.Pointer(&w), k) != want:
+			t.Errorf("%s(%s, %d, Truncate %v) stored %d, want %d", op, k, v, truncate, readBits(unsafe.Pointer(&w), k), want)
+		}
+	}
+}
+
+// readBits returns the integer of kind k at p, converted to int64.
+func readBits(p unsafe.Pointer, k Kind) int64 {
+	if isSigned(k) {
+		v, _ := ReadInt(p, k)
+		return v
+	}
+	v, _ := ReadUint(p, k)
+	return int64(v)
+}
+
+type numericT struct {
+	I   int
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U   uint
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	Up  uintptr
+	F32 float32
+	F64 float64
+	B   bool
+}
+
+func TestReadWrite(t *testing.T) {
+	var v numericT
+	st := (*StructType)(unsafe.Pointer(TypeFor[numericT]()))
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		p := Add(unsafe.Pointer(&v), f.Offset(), "the field lies within the struct")
+		k := f.typ.Kind()
+		switch {
+		case isSigned(k):
+			if err := WriteInt(p, k, -7); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ReadInt(p, k); got != -7 || err != nil {
+				t.Errorf("ReadInt(%s) = %d, %v", k, got, err)
+			}
+		case isUnsigned(k):
+			if err := WriteUint(p, k, 7); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ReadUint(p, k); got != 7 || err != nil {
+				t.Errorf("ReadUint(%s) = %d, %v", k, got, err)
+			}
+		case isFloat(k):
+			if err := WriteFloat(p, k, 0.5); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ReadFloat(p, k); got != 0.5 || err != nil {
+				t.Errorf("ReadFloat(%s) = %g, %v", k, got, err)
+			}
+		case k == Bool:
+			if err := WriteBool(p, k, true); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ReadBool(p, k); !got || err != nil {
+				t.Errorf("ReadBool = %v, %v", got, err)
+			}
+		}
+	}
+	if v.I != -7 || v.I8 != -7 || v.I64 != -7 || v.U32 != 7 || v.Up != 7 || v.F32 != 0.5 || !v.B {
+		t.Errorf("written struct = %+v", v)
+	}
+}
+
+func TestWriteFloatBoundaries(t *testing.T) {
+	tests := []struct {
+		k    Kind
+		v    float64
+		ok   bool
+		want float64
+	}{
+		{Float32, math.MaxFloat32, true, math.MaxFloat32},
+		{Float32, -math.MaxFloat32, true, -math.MaxFloat32},
+		{Float32, math.SmallestNonzeroFloat32, true, math.SmallestNonzeroFloat32},
+		{Float32, 1e39, false, math.Inf(1)},
+		{Float32, -1e39, false, math.Inf(-1)},
+		{Float32, 1e-50, true, 0},
+		{Float32, math.Inf(-1), true, math.Inf(-1)},
+		{Float64, math.MaxFloat64, true, math.MaxFloat64},
+		{Float64, math.Inf(1), true, math.Inf(1)},
+	}
+	for _, tt := range tests {
+		for _, truncate := range []bool{false, true} {
+			w := uint64(0)
+			o := NumericOptions{Truncate: truncate}
+			err := o.WriteFloat(unsafe.Pointer(&w), tt.k, tt.v)
+			if !tt.ok && !truncate {
+				if !errors.Is(err, ErrOverflow) || w != 0 {
+					t.Errorf("WriteFloat(%s, %g) = %v, stored %#x, want ErrOverflow", tt.k, tt.v, err, w)
+				}
+				continue
+			}
+			if got, _ := ReadFloat(unsafe.Pointer(&w), tt.k); err != nil || got != tt.want {
+				t.Errorf("WriteFloat(%s, %g, Truncate %v) = %v, stored %g, want %g", tt.k, tt.v, truncate, err, got, tt.want)
+			}
+		}
+	}
+	for _, k := range []Kind{Float32, Float64} {
+		w := uint64(0)
+		if err := WriteFloat(unsafe.Pointer(&w), k, math.NaN()); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := ReadFloat(unsafe.Pointer(&w), k); !math.IsNaN(got) {
+			t.Errorf("WriteFloat(%s, NaN) stored %g", k, got)
+		}
+	}
+}
+
+func TestNumericKindErrors(t *testing.T) {
+	var w uint64
+	p := unsafe.Pointer(&w)
+	tests := []struct {
+		op   string
+		kind Kind
+		err  error
+	}{
+		{"ReadInt", Uint8, func() error { _, err := ReadInt(p, Uint8); return err }()},
+		{"ReadUint", Int, func() error { _, err := ReadUint(p, Int); return err }()},
+		{"ReadFloat", Int64, func() error { _, err := ReadFloat(p, Int64); return err }()},
+		{"ReadBool", Uint8, func() error { _, err := ReadBool(p, Uint8); return err }()},
+		{"WriteInt", Float64, WriteInt(p, Float64, 1)},
+		{"WriteUint", String, WriteUint(p, String, 1)},
+		{"WriteFloat", Complex64, WriteFloat(p, Complex64, 1)},
+		{"WriteBool", Int8, WriteBool(p, Int8, true)},
+	}
+	for _, tt := range tests {
+		var ke *KindError
+		if !errors.As(tt.err, &ke) || ke.Op != tt.op || ke.Kind != tt.kind {
+			t.Errorf("%s of %s = %v, want a *KindError", tt.op, tt.kind, tt.err)
+		}
+	}
+	if w != 0 {
+		t.Errorf("failed writes stored %#x", w)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+)
+
+// ErrSealed is returned when writing to an OwnedString that was sealed.
+var ErrSealed = errors.New("reflection: write to sealed string")
+
+// poisonByte fills the buffers released by an OwnedString in poison mode.
+const poisonByte = 0xdb
+
+// OwnedString builds a string in a caller-owned buffer and converts it to a
+// string without copying.
+//
+// Strings are immutable, so once Seal has returned a string sharing the
+// buffer, the buffer must not change for as long as the string is in use.
+// OwnedString enforces this for writes through itself, which fail with
+// ErrSealed until Reset hands it a buffer again; the caller must not keep
+// writing through slices it obtained from Bytes, nor reuse the buffer while
+// the string is live.
+//
+// The zero value is an empty OwnedString ready to use.
+type OwnedString struct {
+	// Poison makes Reset overwrite the previous buffer, so that strings
+	// still in use past the reuse of their buffer show up as garbage
+	// instead of silently changing. It is meant for tests.
+	Poison bool
+
+	buf    []byte
+	sealed bool
+}
+
+// NewOwnedString returns an OwnedString writing into buf, from its start
+// and up to its capacity before it grows.
+func NewOwnedString(buf []byte) *OwnedString {
+	return &OwnedString{buf: buf[:0]}
+}
+
+// Len returns the number of bytes written.
+func (s *OwnedString) Len() int {
+	return len(s.buf)
+}
+
+// Sealed reports whether Seal was called since the last Reset.
+func (s *OwnedString) Sealed() bool {
+	return s.sealed
+}
+
+// Bytes returns the bytes written, for in-place modification. It returns nil
+// once s is sealed.
+func (s *OwnedString) Bytes() []byte {
+	if s.sealed {
+		return nil
+	}
+	return s.buf
+}
+
+// Write appends p to the buffer.
+func (s *OwnedString) Write(p []byte) (int, error) {
+	if s.sealed {
+		return 0, ErrSealed
+	}
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends str to the buffer.
+func (s *OwnedString) WriteString(str string) (int, error) {
+	if s.sealed {
+		return 0, ErrSealed
+	}
+	s.buf = append(s.buf, str...)
+	return len(str), nil
+}
+
+// WriteByte appends c to the buffer.
+func (s *OwnedString) WriteByte(c byte) error {
+	if s.sealed {
+		return ErrSealed
+	}
+	s.buf = append(s.buf, c)
+	return nil
+}
+
+// Seal returns the bytes written as a string sharing the buffer and refuses
+// further writes. Sealing again returns the same string.
+func (s *OwnedString) Seal() string {
+	s.sealed = true
+	if len(s.buf) == 0 {
+		return ""
+	}
+	return unsafeString(&s.buf[0], len(s.buf))
+}
+
+// Reset discards the bytes written and makes s write into buf, which may be
+// the previous buffer once the strings sealed from it are no longer in use.
+// In poison mode the previous buffer is overwritten first.
+func (s *OwnedString) Reset(buf []byte) {
+	if s.Poison {
+		old := s.buf[:cap(s.buf)]
+		for i := range old {
+			old[i] = poisonByte
+		}
+	}
+	s.buf = buf[:0]
+	s.sealed = false
+}
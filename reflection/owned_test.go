@@ -0,0 +1,90 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestOwnedString(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	s := NewOwnedString(buf)
+	fmt.Fprintf(s, "%s-%d", "a", 1)
+	s.WriteByte('/')
+	s.WriteString("b")
+	s.Bytes()[0] = 'A'
+	if s.Len() != 5 || s.Sealed() {
+		t.Fatalf("Len, Sealed = %d, %v, want 5, false", s.Len(), s.Sealed())
+	}
+
+	str := s.Seal()
+	if str != "A-1/b" {
+		t.Errorf("Seal = %q, want A-1/b", str)
+	}
+	if *(*unsafe.Pointer)(unsafe.Pointer(&str)) != unsafe.Pointer(&buf[:1][0]) {
+		t.Error("sealed string does not share the buffer")
+	}
+	if again := s.Seal(); again != str {
+		t.Errorf("second Seal = %q, want %q", again, str)
+	}
+
+	if !s.Sealed() || s.Bytes() != nil {
+		t.Errorf("after Seal: Sealed, Bytes = %v, %q, want true, nil", s.Sealed(), s.Bytes())
+	}
+	if _, err := s.Write([]byte("x")); !errors.Is(err, ErrSealed) {
+		t.Errorf("Write after Seal = %v, want ErrSealed", err)
+	}
+	if _, err := s.WriteString("x"); !errors.Is(err, ErrSealed) {
+		t.Errorf("WriteString after Seal = %v, want ErrSealed", err)
+	}
+	if err := s.WriteByte('x'); !errors.Is(err, ErrSealed) {
+		t.Errorf("WriteByte after Seal = %v, want ErrSealed", err)
+	}
+	if str != "A-1/b" {
+		t.Errorf("sealed string changed to %q", str)
+	}
+
+	s.Reset(buf)
+	if s.Sealed() || s.Len() != 0 {
+		t.Errorf("after Reset: Sealed, Len = %v, %d, want false, 0", s.Sealed(), s.Len())
+	}
+	s.WriteString("reused")
+	if got := s.Seal(); got != "reused" {
+		t.Errorf("Seal after Reset = %q, want reused", got)
+	}
+}
+
+func TestOwnedStringZero(t *testing.T) {
+	var s OwnedString
+	if got := s.Seal(); got != "" {
+		t.Errorf("Seal of the zero OwnedString = %q", got)
+	}
+	s.Reset(nil)
+	s.WriteString(strings.Repeat("x", 100))
+	if got := s.Seal(); got != strings.Repeat("x", 100) {
+		t.Errorf("Seal after growing = %q", got)
+	}
+}
+
+func TestOwnedStringPoison(t *testing.T) {
+	buf := make([]byte, 0, 8)
+	s := NewOwnedString(buf)
+	s.Poison = true
+	s.WriteString("live")
+	str := s.Seal()
+	s.Reset(buf)
+	if want := "\xdb\xdb\xdb\xdb"; str != want {
+		t.Errorf("string used past Reset = %q, want poisoned %q", str, want)
+	}
+	for i, b := range buf[:cap(buf)] {
+		if b != poisonByte {
+			t.Fatalf("buf[%d] = %#x, want %#x", i, b, poisonByte)
+		}
+	}
+}
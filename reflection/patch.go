@@ -0,0 +1,112 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch
+
+package reflection
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// PatchITab replaces the code of the methodIndex'th method of it, in the
+// order of the methods of it.Inter, with the function replacement, so that
+// every call of the method through an interface value holding it, that is
+// through the interface type it.Inter with the dynamic type it.Type, calls
+// replacement instead, until restore is called. restore returns the error of
+// writing the original code back, which leaves the patch in place. It is
+// meant for fault injection in tests, and is only built with the
+// darkness_patch build tag.
+//
+// replacement must be a func taking the receiver, of type it.Type, which
+// must be pointer-shaped, such as a pointer type, followed by the parameters of
+// the method, and returning its results: for a method M(int) error of *T,
+// a func(*T, int) error. It must be a top-level func or a func literal that
+// captures no variable, since calls through an itab do not pass a closure
+// context. Calls the compiler devirtualized or inlined are not redirected.
+//
+// Itabs laid out by the linker are in read-only memory: PatchITab makes the
+// page of the slot writable for the duration of each write, and returns
+// ErrUnsupported where that is impossible, or where the permissions of the
+// slot cannot be told, as for the itabs the runtime builds for dynamic type
+// assertions, and where the runtime module layout is not available, as
+// Modules explains. Patching is not synchronized with other patches of the
+// same itab, but the slot is written atomically, so that concurrent calls see
+// either function.
+func PatchITab(it *ITab, methodIndex int, replacement interface{}) (restore func() error, err error) {
+	fun := it.Methods()
+	if methodIndex < 0 || methodIndex >= len(fun) {
+		return nil, fmt.Errorf("reflection: PatchITab index %d out of range for %s with %d methods", methodIndex, &it.Inter.rtype, len(fun))
+	}
+	r := efaceOf(&replacement)
+	if k := kindOf(r.Type); k != Func {
+		return nil, &KindError{Op: "PatchITab", Kind: k}
+	}
+	if r.Word == nil {
+		return nil, fmt.Errorf("%w: PatchITab with nil replacement", ErrNilPointer)
+	}
+	mt := (*FuncType)(unsafe.Pointer(it.Inter.TypeOff(it.Inter.Methods[methodIndex].Typ)))
+	if !isMethodFunc((*FuncType)(unsafe.Pointer(r.Type)), it.Type, mt) {
+		return nil, fmt.Errorf("%w: PatchITab of %s.%s with %s", ErrTypeMismatch, &it.Inter.rtype, IfaceMethodName(it, methodIndex), r.Type)
+	}
+
+	slot := &fun[methodIndex]
+	pc := *(*uintptr)(r.Word)
+	old := atomic.LoadUintptr(slot)
+	if err := writeSlot(slot, pc); err != nil {
+		return nil, err
+	}
+	return func() error { return writeSlot(slot, old) }, nil
+}
+
+// isMethodFunc reports whether f takes a receiver of the pointer-shaped type
+// recv followed by the parameters of the method type mt, and returns its
+// results.
+func isMethodFunc(f *FuncType, recv *rtype, mt *FuncType) bool {
+	if f.NumIn() != mt.NumIn()+1 || f.NumOut() != mt.NumOut() || f.IsVariadic() != mt.IsVariadic() {
+		return false
+	}
+	if f.In(0) != recv || ifaceIndir(recv) {
+		return false
+	}
+	for i := 0; i < mt.NumIn(); i++ {
+		if f.In(i+1) != mt.In(i) {
+			return false
+		}
+	}
+	for i := 0; i < mt.NumOut(); i++ {
+		if f.Out(i) != mt.Out(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeSlot stores pc in the itab slot, making its page writable meanwhile
+// if it lies in the read-only data of a module. It returns ErrUnsupported
+// for slots elsewhere, such as in the itabs the runtime builds off the heap,
+// whose permissions cannot be told.
+func writeSlot(slot *uintptr, pc uintptr) error {
+	p := unsafe.Pointer(slot)
+	if IsWritable(p) {
+		atomic.StoreUintptr(slot, pc)
+		return nil
+	}
+	if err := checkModules("PatchITab"); err != nil {
+		return err
+	}
+	readOnly := false
+	rangeModules(func(md *moduledata) bool {
+		readOnly = md.readOnly(uintptr(p))
+		return !readOnly
+	})
+	if !readOnly {
+		return fmt.Errorf("%w: PatchITab cannot tell the permissions of %p", ErrUnsupported, p)
+	}
+	return withWritablePage(p, func() {
+		atomic.StoreUintptr(slot, pc)
+	})
+}
@@ -0,0 +1,18 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch && (darwin || freebsd || netbsd || openbsd)
+
+package reflection
+
+import (
+	"syscall"
+)
+
+// pageProt returns the protection of the page at addr, which holds the
+// read-only data of a module. These systems have no /proc/self/maps to
+// read it from, and map that data read-only, without execute permission.
+func pageProt(addr uintptr) (int, error) {
+	return syscall.PROT_READ, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch && linux
+
+package reflection
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// pageProt returns the protection of the page at addr, as listed in
+// /proc/self/maps.
+func pageProt(addr uintptr) (int, error) {
+	maps, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		return 0, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(maps))
+	for sc.Scan() {
+		// Lines are of the form "start-end perms offset dev inode path",
+		// with hexadecimal addresses.
+		f := bytes.Fields(sc.Bytes())
+		if len(f) < 2 {
+			continue
+		}
+		i := bytes.IndexByte(f[0], '-')
+		if i < 0 {
+			continue
+		}
+		lo, err1 := strconv.ParseUint(string(f[0][:i]), 16, 64)
+		hi, err2 := strconv.ParseUint(string(f[0][i+1:]), 16, 64)
+		if err1 != nil || err2 != nil || uint64(addr) < lo || uint64(addr) >= hi {
+			continue
+		}
+		prot := syscall.PROT_NONE
+		for _, c := range f[1] {
+			switch c {
+			case 'r':
+				prot |= syscall.PROT_READ
+			case 'w':
+				prot |= syscall.PROT_WRITE
+			case 'x':
+				prot |= syscall.PROT_EXEC
+			}
+		}
+		return prot, nil
+	}
+	return 0, fmt.Errorf("%w: PatchITab cannot find the mapping of %#x", ErrUnsupported, addr)
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch && linux
+
+package reflection
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestPatchITabRestoresProtection(t *testing.T) {
+	it := patchITab(patchGreeters[0])
+	slot := uintptr(unsafe.Pointer(&it.Methods()[0]))
+	before, err := pageProt(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before&syscall.PROT_WRITE != 0 {
+		t.Skip("the itab is in writable memory")
+	}
+	restore, err := PatchITab(it, 0, func(p *patchPtr, n int) string { return "" })
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := pageProt(slot); err != nil || after != before {
+		t.Errorf("protection after restore = %#x, %v, want %#x", after, err, before)
+	}
+}
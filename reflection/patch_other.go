@@ -0,0 +1,18 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch && !(darwin || freebsd || linux || netbsd || openbsd)
+
+package reflection
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// withWritablePage returns ErrUnsupported: page permissions cannot be
+// changed on this platform.
+func withWritablePage(p unsafe.Pointer, fn func()) error {
+	return fmt.Errorf("%w: PatchITab cannot change page permissions on this platform", ErrUnsupported)
+}
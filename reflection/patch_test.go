@@ -0,0 +1,136 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch
+
+package reflection
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type patchGreeter interface {
+	Greet(n int) string
+}
+
+type patchPtr struct{ name string }
+
+func (p *patchPtr) Greet(n int) string { return p.name + strconv.Itoa(n) }
+
+type patchValue struct{ a, b int }
+
+func (v patchValue) Greet(n int) string { return strconv.Itoa(v.a + v.b + n) }
+
+// patchGreeters lays out the itabs of the fixtures in read-only data.
+var patchGreeters = []patchGreeter{&patchPtr{"p"}, patchValue{1, 2}}
+
+func patchITab(g patchGreeter) *ITab {
+	return (*IfaceHeader)(unsafe.Pointer(&g)).Tab
+}
+
+//go:noinline
+func patchGreet(g patchGreeter, n int) string {
+	return g.Greet(n)
+}
+
+func TestPatchITab(t *testing.T) {
+	g := patchGreeters[0]
+	restore, err := PatchITab(patchITab(g), 0, func(p *patchPtr, n int) string {
+		return "patched " + p.name + strconv.Itoa(n)
+	})
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("PatchITab = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := patchGreet(g, 1); got != "patched p1" {
+		t.Errorf("patched Greet = %q", got)
+	}
+	if got := patchGreet(patchGreeters[1], 1); got != "4" {
+		t.Errorf("Greet of another itab = %q", got)
+	}
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := patchGreet(g, 1); got != "p1" {
+		t.Errorf("restored Greet = %q", got)
+	}
+}
+
+func TestPatchITabErrors(t *testing.T) {
+	ptr, val := patchITab(patchGreeters[0]), patchITab(patchGreeters[1])
+	var ke *KindError
+	tests := []struct {
+		name        string
+		it          *ITab
+		index       int
+		replacement interface{}
+		is          func(error) bool
+	}{
+		{"index", ptr, 1, func(*patchPtr, int) string { return "" }, func(err error) bool { return err != nil }},
+		{"negative index", ptr, -1, func(*patchPtr, int) string { return "" }, func(err error) bool { return err != nil }},
+		{"not a func", ptr, 0, 1, func(err error) bool { return errors.As(err, &ke) && ke.Kind == Int }},
+		{"nil func", ptr, 0, (func(*patchPtr, int) string)(nil), func(err error) bool { return errors.Is(err, ErrNilPointer) }},
+		{"no receiver", ptr, 0, func(int) string { return "" }, func(err error) bool { return errors.Is(err, ErrTypeMismatch) }},
+		{"receiver", ptr, 0, func(*patchValue, int) string { return "" }, func(err error) bool { return errors.Is(err, ErrTypeMismatch) }},
+		{"parameter", ptr, 0, func(*patchPtr, string) string { return "" }, func(err error) bool { return errors.Is(err, ErrTypeMismatch) }},
+		{"result", ptr, 0, func(*patchPtr, int) error { return nil }, func(err error) bool { return errors.Is(err, ErrTypeMismatch) }},
+		// A two-word struct is not stored in the interface word.
+		{"indirect receiver", val, 0, func(patchValue, int) string { return "" }, func(err error) bool { return errors.Is(err, ErrTypeMismatch) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore, err := PatchITab(tt.it, tt.index, tt.replacement)
+			if !tt.is(err) || restore != nil {
+				t.Errorf("PatchITab = %v", err)
+			}
+		})
+	}
+}
+
+func TestIsMethodFunc(t *testing.T) {
+	mt := (*FuncType)(unsafe.Pointer(TypeFor[func(int, ...string) (bool, error)]()))
+	tests := []struct {
+		f, recv *rtype
+		want    bool
+	}{
+		{TypeFor[func(*int, int, ...string) (bool, error)](), TypeFor[*int](), true},
+		{TypeFor[func(map[int]int, int, ...string) (bool, error)](), TypeFor[map[int]int](), true},
+		{TypeFor[func(*int, int, ...string) (bool, error)](), TypeFor[*string](), false},
+		{TypeFor[func(*int, int, []string) (bool, error)](), TypeFor[*int](), false},
+		{TypeFor[func(*int, int, ...string) bool](), TypeFor[*int](), false},
+		{TypeFor[func([2]*int, int, ...string) (bool, error)](), TypeFor[[2]*int](), false},
+	}
+	for _, tt := range tests {
+		if got := isMethodFunc((*FuncType)(unsafe.Pointer(tt.f)), tt.recv, mt); got != tt.want {
+			t.Errorf("isMethodFunc(%s, %s) = %v, want %v", tt.f, tt.recv, got, tt.want)
+		}
+	}
+}
+
+type patchDynamic struct{}
+
+func (*patchDynamic) Greet(n int) string { return "" }
+
+//go:noinline
+func patchBox() interface{} { return &patchDynamic{} }
+
+func TestPatchITabRuntime(t *testing.T) {
+	// The runtime builds the itab of the assertion off the heap.
+	g := patchBox().(patchGreeter)
+	_, err := PatchITab(patchITab(g), 0, func(*patchDynamic, int) string { return "" })
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("PatchITab of a run-time itab = %v, want ErrUnsupported", err)
+	}
+}
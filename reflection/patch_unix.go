@@ -0,0 +1,36 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_patch && (darwin || freebsd || linux || netbsd || openbsd)
+
+package reflection
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// pageMu serializes the changes of page permissions, so that a patch does
+// not make a page read-only while another patch of the same page writes it.
+var pageMu sync.Mutex
+
+// withWritablePage calls fn with the read-only page holding p made
+// writable, and restores the protection of the page afterwards.
+func withWritablePage(p unsafe.Pointer, fn func()) error {
+	size := syscall.Getpagesize()
+	start := unsafe.Add(p, -int(uintptr(p)&uintptr(size-1)))
+	page := unsafe.Slice((*byte)(start), size)
+	pageMu.Lock()
+	defer pageMu.Unlock()
+	prot, err := pageProt(uintptr(start))
+	if err != nil {
+		return err
+	}
+	if err := syscall.Mprotect(page, prot|syscall.PROT_WRITE); err != nil {
+		return err
+	}
+	fn()
+	return syscall.Mprotect(page, prot)
+}
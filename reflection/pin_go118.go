@@ -0,0 +1,27 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.21
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// Pinner stands in for runtime.Pinner, which requires Go 1.21.
+type Pinner struct{}
+
+// Unpin does nothing.
+func (p *Pinner) Unpin() {}
+
+// PinField returns ErrUnsupported: pinning requires Go 1.21.
+func PinField(p *Pinner, v interface{}, path string) (unsafe.Pointer, error) {
+	return nil, ErrUnsupported
+}
+
+// PinValue returns ErrUnsupported: pinning requires Go 1.21.
+func PinValue(p *Pinner, v interface{}) (unsafe.Pointer, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package reflection
+
+import (
+	"errors"
+	"runtime"
+	"unsafe"
+)
+
+// Pinner is runtime.Pinner.
+type Pinner = runtime.Pinner
+
+// PinField pins the struct pointed to by v and returns the address of its
+// field named by path, as accepted by OffsetOf, which may then be passed to C
+// until p is unpinned. Go pointers stored in the field must be pinned
+// separately if C code dereferences them.
+func PinField(p *Pinner, v interface{}, path string) (unsafe.Pointer, error) {
+	e := efaceOf(&v)
+	if k := kindOf(e.Type); k != Ptr {
+		return nil, &KindError{Op: "PinField", Kind: k}
+	}
+	off, _, err := OffsetOf((*PtrType)(unsafe.Pointer(e.Type)).Elem, path)
+	if err != nil {
+		return nil, err
+	}
+	if e.Word == nil {
+		return nil, errors.New("reflection: PinField of nil pointer")
+	}
+	p.Pin(e.Word)
+	return Add(e.Word, off, "field offset is within the struct"), nil
+}
+
+// PinValue pins the value held by v and returns its address. Values stored
+// directly in the interface, such as pointers, maps and channels, are first
+// copied to a new cell, which is pinned along with the object the value
+// points to.
+func PinValue(p *Pinner, v interface{}) (unsafe.Pointer, error) {
+	e := efaceOf(&v)
+	if e.Type == nil {
+		return nil, errors.New("reflection: PinValue of nil interface")
+	}
+	if ifaceIndir(e.Type) {
+		p.Pin(e.Word)
+		return e.Word, nil
+	}
+	cell := new(unsafe.Pointer)
+	*cell = e.Word
+	p.Pin(cell)
+	if e.Word != nil {
+		p.Pin(e.Word)
+	}
+	return unsafe.Pointer(cell), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package reflection
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+type pinT struct {
+	A int32
+	B struct {
+		C [4]byte
+		D *int
+	}
+}
+
+func TestPinField(t *testing.T) {
+	var p Pinner
+	defer p.Unpin()
+	v := &pinT{}
+	ptr, err := PinField(&p, v, "B.C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ptr != unsafe.Pointer(&v.B.C) {
+		t.Errorf("PinField(B.C) = %p, want %p", ptr, &v.B.C)
+	}
+	(*[4]byte)(ptr)[2] = 9
+	runtime.GC()
+	if v.B.C[2] != 9 {
+		t.Errorf("write through the pinned field lost: %v", v.B.C)
+	}
+
+	var ke *KindError
+	if _, err := PinField(&p, *v, "A"); !errors.As(err, &ke) {
+		t.Errorf("PinField of a non-pointer = %v, want a *KindError", err)
+	}
+	if _, err := PinField(&p, v, "Z"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("PinField of a missing field = %v, want ErrFieldNotFound", err)
+	}
+	if _, err := PinField(&p, (*pinT)(nil), "A"); err == nil {
+		t.Error("PinField of a nil pointer: no error")
+	}
+}
+
+func TestPinValue(t *testing.T) {
+	var p Pinner
+	defer p.Unpin()
+	x := 5
+	for _, v := range []interface{}{pinT{A: 1}, &x, map[int]int{1: 2}, 3} {
+		ptr, err := PinValue(&p, v)
+		if err != nil {
+			t.Fatalf("PinValue(%T) = %v", v, err)
+		}
+		if got := PackEface(efaceOf(&v).Type, ptr); !equalPinned(got, v) {
+			t.Errorf("PinValue(%T) points to %v", v, got)
+		}
+	}
+	if _, err := PinValue(&p, nil); err == nil {
+		t.Error("PinValue(nil): no error")
+	}
+}
+
+// equalPinned reports whether a and b hold the same value, comparing maps by
+// identity.
+func equalPinned(a, b interface{}) bool {
+	if m, ok := a.(map[int]int); ok {
+		return m[1] == b.(map[int]int)[1]
+	}
+	return a == b
+}
+
+// TestUnpin checks that Unpin releases the pinned objects: a Pinner that
+// still holds pins when it is collected makes the runtime panic, and objects
+// may be pinned again by another Pinner once released.
+func TestUnpin(t *testing.T) {
+	v := &pinT{}
+	func() {
+		var p Pinner
+		if _, err := PinField(&p, v, "B"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := PinValue(&p, v); err != nil {
+			t.Fatal(err)
+		}
+		p.Unpin()
+		p.Unpin() // a no-op once unpinned
+	}()
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+	}
+
+	var q Pinner
+	if _, err := PinField(&q, v, "A"); err != nil {
+		t.Fatal(err)
+	}
+	q.Unpin()
+	runtime.GC()
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+)
+
+// FieldOrder is the order in which a Plan visits the fields of a struct.
+type FieldOrder int
+
+const (
+	// DeclOrder visits the fields in the order they are declared in the
+	// source, as FieldsInDeclOrder returns them, which is the order a
+	// schema matching fields by declaration index expects.
+	DeclOrder FieldOrder = iota
+
+	// OffsetOrder visits the fields by increasing offset, as
+	// SortFieldsByOffset returns them.
+	OffsetOrder
+
+	// NameOrder visits the fields by name, as SortFieldsByName returns
+	// them.
+	NameOrder
+
+	// SizeOrder visits the fields by decreasing size, as SortFieldsBySize
+	// returns them.
+	SizeOrder
+)
+
+// PlanField is a field of a struct, as visited by a Plan.
+type PlanField struct {
+	Index  int     // index of the field into the fields of the struct
+	Name   string  // name of the field, "_" for blank fields
+	Offset uintptr // byte offset of the field within the struct
+	Type   *rtype  // type of the field
+}
+
+// Plan is the sequence of the fields of a struct type a codec visits to
+// encode or decode its values, resolved once for the type.
+type Plan struct {
+	Type   *StructType
+	Fields []PlanField
+}
+
+// PlanOptions controls BuildPlan.
+type PlanOptions struct {
+	// Order is the order in which the plan visits the fields.
+	Order FieldOrder
+
+	// SkipBlank leaves the blank fields, such as _ padding, out of the
+	// plan. They keep their index, so that the indices of the other
+	// fields do not depend on it.
+	SkipBlank bool
+}
+
+// BuildPlan returns the plan visiting every field of st in declaration
+// order, blank and embedded fields included.
+func BuildPlan(st *StructType) (*Plan, error) {
+	var o PlanOptions
+	return o.BuildPlan(st)
+}
+
+// BuildPlan is like the top-level BuildPlan, with the options of o. It
+// returns an error for an unknown o.Order.
+func (o *PlanOptions) BuildPlan(st *StructType) (*Plan, error) {
+	var order []int
+	switch o.Order {
+	case DeclOrder:
+		order = FieldsInDeclOrder(st)
+	case OffsetOrder:
+		order = SortFieldsByOffset(st)
+	case NameOrder:
+		order = SortFieldsByName(st)
+	case SizeOrder:
+		order = SortFieldsBySize(st)
+	default:
+		return nil, fmt.Errorf("reflection: BuildPlan of %s with unknown field order %d", &st.rtype, o.Order)
+	}
+	p := &Plan{Type: st, Fields: make([]PlanField, 0, len(order))}
+	for _, i := range order {
+		f := &st.Fields[i]
+		name := f.Name.Name()
+		if o.SkipBlank && name == "_" {
+			continue
+		}
+		p.Fields = append(p.Fields, PlanField{Index: i, Name: name, Offset: f.Offset(), Type: f.typ})
+	}
+	return p, nil
+}
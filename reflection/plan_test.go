@@ -0,0 +1,84 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type planT struct {
+	Z int8
+	_ [3]byte
+	fieldInner
+	A int64
+	_ int32
+	M [32]byte
+}
+
+func planFields(p *Plan) []int {
+	var idx []int
+	for _, f := range p.Fields {
+		idx = append(idx, f.Index)
+	}
+	return idx
+}
+
+func TestFieldsInDeclOrder(t *testing.T) {
+	rt := reflect.TypeOf(planT{})
+	st := (*StructType)(unsafe.Pointer(FromReflect(rt)))
+	order := FieldsInDeclOrder(st)
+	if len(order) != rt.NumField() {
+		t.Fatalf("%d fields, want %d", len(order), rt.NumField())
+	}
+	for i, j := range order {
+		if got, want := st.Fields[j].Name.Name(), rt.Field(i).Name; got != want {
+			t.Errorf("field %d is %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[planT]()))
+	tests := []struct {
+		name string
+		opts PlanOptions
+		want []int
+	}{
+		{"decl", PlanOptions{}, []int{0, 1, 2, 3, 4, 5}},
+		{"decl skip blank", PlanOptions{SkipBlank: true}, []int{0, 2, 3, 5}},
+		{"offset", PlanOptions{Order: OffsetOrder}, []int{0, 1, 2, 3, 4, 5}},
+		// Blank fields keep their declaration order among themselves.
+		{"name", PlanOptions{Order: NameOrder}, []int{3, 5, 0, 1, 4, 2}},
+		{"size", PlanOptions{Order: SizeOrder}, []int{5, 2, 3, 4, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.opts.BuildPlan(st)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := planFields(p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields %v, want %v", got, tt.want)
+			}
+			for _, f := range p.Fields {
+				sf := &st.Fields[f.Index]
+				if f.Name != sf.Name.Name() || f.Offset != sf.Offset() || f.Type != sf.typ {
+					t.Errorf("field %d: %+v does not describe %s", f.Index, f, sf.Name.Name())
+				}
+			}
+		})
+	}
+
+	p, err := BuildPlan(st)
+	if err != nil || !reflect.DeepEqual(planFields(p), tests[0].want) {
+		t.Errorf("BuildPlan = %v, %v, want the declaration order", planFields(p), err)
+	}
+	bad := PlanOptions{Order: FieldOrder(-1)}
+	if _, err := bad.BuildPlan(st); err == nil {
+		t.Error("BuildPlan with an unknown order succeeded")
+	}
+}
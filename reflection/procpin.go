@@ -0,0 +1,145 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+//go:linkname procPin runtime.procPin
+
+// procPin disables preemption of the calling goroutine and returns the id
+// of its P.
+// Implemented in the runtime package.
+func procPin() int
+
+//go:linkname procUnpin runtime.procUnpin
+
+// procUnpin enables preemption again after procPin.
+// Implemented in the runtime package.
+func procUnpin()
+
+// ProcPin pins the calling goroutine to its P, the processor running it,
+// by disabling preemption, and returns the id of the P, in
+// [0, runtime.GOMAXPROCS(0)). Until ProcUnpin is called, no other goroutine
+// runs on that P, so data indexed by the id can be used without locking.
+//
+// The pinned goroutine must not block, allocate heavily or run for long:
+// the garbage collector and the scheduler wait for it. It must not panic
+// either: the runtime treats a panic while pinned as a fatal error, which
+// cannot be recovered. Pins nest, each must be matched by a call to
+// ProcUnpin.
+func ProcPin() int {
+	return procPin()
+}
+
+// ProcUnpin undoes a call to ProcPin.
+func ProcUnpin() {
+	procUnpin()
+}
+
+// WithPinned calls fn with the id of the P the calling goroutine is pinned
+// to during the call, as ProcPin returns it, and unpins it afterwards,
+// however fn returns: a runtime.Goexit in fn, such as by t.FailNow, unpins
+// the goroutine before it exits, and a panic in fn is recovered, the
+// goroutine unpinned, and the panic raised again. The other restrictions of
+// ProcPin apply to fn; in particular, the runtime may crash on the panic
+// before WithPinned gets to recover it.
+func WithPinned(fn func(pid int)) {
+	pid := procPin()
+	defer unpinRepanic()
+	fn(pid)
+}
+
+// unpinRepanic, deferred after procPin, unpins the goroutine and, if it is
+// panicking, raises the recovered panic again once unpinned.
+func unpinRepanic() {
+	r := recover()
+	procUnpin()
+	if r != nil {
+		panic(r)
+	}
+}
+
+// PerP holds a value of type T per P, such as a scratch buffer, to be used
+// without locking by the goroutine running on that P while it is pinned.
+// Values are created as needed, with New if not nil, and follow increases of
+// GOMAXPROCS; they are kept when it decreases.
+//
+// The zero value is ready to use.
+type PerP[T any] struct {
+	// New returns the initial value for a P. If nil, values start zero.
+	New func() T
+
+	mu     sync.Mutex   // serializes growth
+	slots  atomic.Value // []*T, indexed by P id
+	raceMu sync.Mutex   // serializes With under the race detector
+}
+
+// With calls fn, pinned as by WithPinned, with the value of the P running
+// the calling goroutine. fn has exclusive access to the value during the
+// call and must not keep it past the call; the restrictions of WithPinned
+// apply to it.
+func (p *PerP[T]) With(fn func(v *T)) {
+	if raceEnabled {
+		// The race detector does not know a P runs one goroutine at a
+		// time. Lock before pinning, as blocking while pinned throws.
+		p.raceMu.Lock()
+		defer p.raceMu.Unlock()
+	}
+	for {
+		pid := procPin()
+		if slots, _ := p.slots.Load().([]*T); pid < len(slots) {
+			p.call(fn, slots[pid])
+			return
+		}
+		// Growing allocates and takes a lock, which must not happen
+		// while pinned. The goroutine may then resume on another P.
+		procUnpin()
+		p.grow()
+	}
+}
+
+// call calls fn with v, unpinning as WithPinned does once it returns.
+func (p *PerP[T]) call(fn func(v *T), v *T) {
+	defer unpinRepanic()
+	fn(v)
+}
+
+// grow extends the slots to GOMAXPROCS.
+func (p *PerP[T]) grow() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old, _ := p.slots.Load().([]*T)
+	n := runtime.GOMAXPROCS(0)
+	if n <= len(old) {
+		return
+	}
+	slots := make([]*T, n)
+	copy(slots, old)
+	for i := len(old); i < n; i++ {
+		v := new(T)
+		if p.New != nil {
+			*v = p.New()
+		}
+		slots[i] = v
+	}
+	p.slots.Store(slots)
+}
+
+// Range calls fn for each value created so far, in order of P id, for
+// example to aggregate per-P counters. The values are not pinned: fn must
+// synchronize with concurrent users of With, or run when there are none.
+func (p *PerP[T]) Range(fn func(pid int, v *T) bool) {
+	slots, _ := p.slots.Load().([]*T)
+	for i, v := range slots {
+		if !fn(i, v) {
+			return
+		}
+	}
+}
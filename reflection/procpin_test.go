@@ -0,0 +1,113 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestWithPinned(t *testing.T) {
+	n := runtime.GOMAXPROCS(0)
+	called := false
+	WithPinned(func(pid int) {
+		called = true
+		if pid < 0 || pid >= n {
+			t.Errorf("pid %d out of [0, %d)", pid, n)
+		}
+		if got := ProcPin(); got != pid {
+			t.Errorf("nested ProcPin = %d, want %d", got, pid)
+		}
+		ProcUnpin()
+	})
+	if !called {
+		t.Fatal("fn not called")
+	}
+}
+
+func TestWithPinnedGoexit(t *testing.T) {
+	ready, done := make(chan bool), make(chan bool)
+	go func() {
+		defer close(done)
+		defer func() {
+			// Blocking while still pinned throws.
+			<-ready
+		}()
+		WithPinned(func(int) { runtime.Goexit() })
+		t.Error("Goexit returned")
+	}()
+	ready <- true
+	<-done
+}
+
+func TestPerP(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+	p := PerP[[]int]{New: func() []int { return make([]int, 0, 8) }}
+	count := func(goroutines, each int) {
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < each; i++ {
+					p.With(func(v *[]int) { *v = append((*v)[:0], len(*v)+1) })
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	count(4, 100)
+
+	// Values follow increases of GOMAXPROCS.
+	runtime.GOMAXPROCS(4)
+	count(8, 100)
+	slots := 0
+	p.Range(func(pid int, v *[]int) bool {
+		if pid != slots {
+			t.Errorf("Range visited P %d, want %d", pid, slots)
+		}
+		if *v == nil {
+			t.Errorf("P %d value not created with New", pid)
+		}
+		slots++
+		return true
+	})
+	if slots > 4 {
+		t.Errorf("%d values, want at most GOMAXPROCS 4", slots)
+	}
+
+	visited := 0
+	p.Range(func(int, *[]int) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Range went on for %d values after fn returned false", visited-1)
+	}
+}
+
+func TestPerPCounters(t *testing.T) {
+	var p PerP[int]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				p.With(func(v *int) { *v++ })
+			}
+		}()
+	}
+	wg.Wait()
+	sum := 0
+	p.Range(func(_ int, v *int) bool {
+		sum += *v
+		return true
+	})
+	if sum != 8000 {
+		t.Errorf("sum of the per-P counters = %d, want 8000", sum)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Project returns a view of the struct type st that only has the fields for
+// which keep returns true. The kept fields stay at their original offsets and
+// the size of the view is that of st, so the memory of a value of st also
+// backs a value of the view; the hidden fields are merely absent from Fields.
+//
+// The view hides fields only from code that walks the fields of a type, as
+// encoding/json and other marshalers do, not from code copying or comparing
+// values by size. The view has no methods, so that a marshaler method of st
+// cannot bypass the mask, and it is therefore an unnamed type, even though its
+// String is that of st.
+//
+// Under the darkness_safe build tag, Project returns an error wrapping
+// ErrUnsupported.
+func Project(st *StructType, keep func(f *StructField) bool) (*StructType, error) {
+	if k := st.Kind(); k != Struct {
+		return nil, &KindError{Op: "Project", Kind: k}
+	}
+	if keep == nil {
+		return nil, errors.New("reflection: Project with nil keep func")
+	}
+	if safeMode {
+		return nil, fmt.Errorf("%w: Project builds a type descriptor, not done under darkness_safe", ErrUnsupported)
+	}
+
+	var fields []StructField
+	for i := range st.Fields {
+		if keep(&st.Fields[i]) {
+			fields = append(fields, st.Fields[i])
+		}
+	}
+
+	nt := new(StructType)
+	*nt = *st
+	nt.tflag &^= TflagUncommon | TflagNamed
+	nt.Fields = fields
+	nt.str = reflectNameOff(st.NameOff(st.str))
+	nt.ptrToThis = 0
+
+	keepDerived(nt, st)
+	return nt, nil
+}
+
+// PackAs returns an interface{} holding the value held by v reinterpreted as
+// a value of the type projected, as returned by Project or Retag for the
+// dynamic type of v. The result shares the data pointer of v.
+//
+// Types derived from one another by Project and Retag, at any depth, share
+// their layout, so v may also hold a value of another such type. PackAs
+// panics if the dynamic type of v and projected were not derived from the
+// same type; see PackAsErr.
+func PackAs(v interface{}, projected *StructType) interface{} {
+	i, err := PackAsErr(v, projected)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// PackAsErr is like PackAs but returns an error wrapping ErrTypeMismatch,
+// instead of panicking, if the dynamic type of v and projected were not
+// derived from the same type.
+func PackAsErr(v interface{}, projected *StructType) (interface{}, error) {
+	e := efaceOf(&v)
+	if e.Type == nil || originOf(e.Type) != originOf(&projected.rtype) {
+		return nil, fmt.Errorf("%w: PackAs of %v as %s, which is not derived from it by Project or Retag", ErrTypeMismatch, ToReflect(e.Type), projected)
+	}
+	return PackEface(&projected.rtype, e.data()), nil
+}
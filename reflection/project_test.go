@@ -0,0 +1,163 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type projectUser struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Token    *string
+	Email    string `json:"email" private:"true"`
+}
+
+// projectSameSize has the size and fields of projectUser, but no pointer
+// in place of Token.
+type projectSameSize struct {
+	ID       int64
+	Name     string
+	Password string
+	Token    uintptr
+	Email    string
+}
+
+// projectPublic keeps the fields that are neither secrets nor private.
+func projectPublic(f *StructField) bool {
+	name := f.Name.Name()
+	return name != "Password" && name != "Token" && reflect.StructTag(f.Name.Tag()).Get("private") == ""
+}
+
+func TestProjectJSON(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[projectUser]()))
+	view, err := Project(st, projectPublic)
+	if safeMode {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("Project under darkness_safe = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Fields) != 2 || view.size != st.size || view.ptrdata != st.ptrdata {
+		t.Fatalf("view has %d fields, size %d and ptrdata %d, want 2, %d and %d", len(view.Fields), view.size, view.ptrdata, st.size, st.ptrdata)
+	}
+	tok := "secret"
+	u := &projectUser{ID: 7, Name: "gopher", Password: "hunter2", Token: &tok, Email: "g@example.com"}
+	b, err := json.Marshal(PackAs(*u, view))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":7,"name":"gopher"}`; string(b) != want {
+		t.Errorf("Marshal of the view = %s, want %s", b, want)
+	}
+	full, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(full, &m); err != nil || len(m) != 5 {
+		t.Errorf("Marshal of the value = %s, want all 5 fields", full)
+	}
+
+	// The kept fields are those of st, at their offsets.
+	rt := ToReflect(&view.rtype)
+	for i, name := range []string{"ID", "Name"} {
+		f := rt.Field(i)
+		want, _ := reflect.TypeOf(*u).FieldByName(name)
+		if f.Name != name || f.Offset != want.Offset || f.Tag != want.Tag {
+			t.Errorf("field %d = %+v, want %+v", i, f, want)
+		}
+	}
+	if rt.NumMethod() != 0 || rt.Name() != "" {
+		t.Errorf("view is named %q with %d methods, want unnamed without methods", rt.Name(), rt.NumMethod())
+	}
+}
+
+func TestPackAsDerived(t *testing.T) {
+	if safeMode {
+		t.Skip("Retag is not supported under darkness_safe")
+	}
+	st := (*StructType)(unsafe.Pointer(TypeFor[projectUser]()))
+	tagged, err := Retag(st, func(field, tag string) string {
+		if v, ok := reflect.StructTag(tag).Lookup("private"); ok {
+			return `json:"` + field + `" private:"` + v + `"`
+		}
+		return `json:"` + field + `"`
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := Project(tagged, projectPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := projectUser{ID: 1, Name: "n", Password: "p"}
+	b, err := json.Marshal(PackAs(u, view))
+	if err != nil || string(b) != `{"ID":1,"Name":"n"}` {
+		t.Errorf("Marshal of a projection of a retagged type = %s, %v", b, err)
+	}
+
+	// A value already packed as a derived type can be packed as another.
+	asRetagged := PackAs(u, tagged)
+	b, err = json.Marshal(PackAs(asRetagged, view))
+	if err != nil || string(b) != `{"ID":1,"Name":"n"}` {
+		t.Errorf("Marshal of a view of a retagged value = %s, %v", b, err)
+	}
+	if _, err := PackAsErr(PackAs(u, view), st); err != nil {
+		t.Errorf("PackAsErr back to the original type = %v", err)
+	}
+}
+
+func TestPackAsMismatch(t *testing.T) {
+	if safeMode {
+		t.Skip("Project is not supported under darkness_safe")
+	}
+	st := (*StructType)(unsafe.Pointer(TypeFor[projectUser]()))
+	view, err := Project(st, projectPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsafe.Sizeof(projectSameSize{}) != unsafe.Sizeof(projectUser{}) {
+		t.Fatalf("projectSameSize has size %d, want %d", unsafe.Sizeof(projectSameSize{}), unsafe.Sizeof(projectUser{}))
+	}
+	for _, v := range []interface{}{projectSameSize{}, &projectUser{}, 0, nil} {
+		if _, err := PackAsErr(v, view); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("PackAsErr(%T) = %v, want ErrTypeMismatch", v, err)
+		}
+	}
+	other, err := Project((*StructType)(unsafe.Pointer(TypeFor[projectSameSize]())), func(*StructField) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PackAsErr(projectUser{}, other); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("PackAsErr as a view of another type = %v, want ErrTypeMismatch", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("PackAs of a mismatched type did not panic")
+			}
+		}()
+		PackAs(projectSameSize{}, view)
+	}()
+}
+
+func TestProjectErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := Project((*StructType)(unsafe.Pointer(TypeFor[[]int]())), projectPublic); !errors.As(err, &ke) {
+		t.Errorf("Project([]int) = %v, want a *KindError", err)
+	}
+	if _, err := Project((*StructType)(unsafe.Pointer(TypeFor[projectUser]())), nil); err == nil {
+		t.Error("Project with a nil func: no error")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// redacted replaces the strings redacted with RedactOptions.Placeholder.
+const redacted = "[REDACTED]"
+
+// RedactOptions controls Redact.
+type RedactOptions struct {
+	// Placeholder sets redacted strings that are not empty to "[REDACTED]"
+	// rather than to the empty string. Fields of other kinds are still
+	// zeroed.
+	Placeholder bool
+
+	// FollowPointers also walks the values that pointers, and interfaces
+	// holding pointers, nested in the value point to. Redaction mutates
+	// them, and they may be shared with other values.
+	FollowPointers bool
+}
+
+// Redact zeroes, in place, the fields of the value held by v whose tag of
+// key tagKey lists token, separated by commas, such as `log:"redact"` for
+// the tag key "log" and the token "redact". It returns a *KindError if v
+// does not hold a pointer, a slice or a map, whose values can be written.
+//
+// It walks the structs nested in v, through arrays, the elements of slices
+// and maps, and the pointer held by v, checking unexported fields too. Map
+// elements, which cannot be written in place, are copied, redacted and
+// stored back. Nested pointers and interfaces are not followed unless
+// allowed by the options; the values of other types that interfaces hold
+// are never walked, as they cannot be written. Each pointer is followed
+// once, so that cyclic values are walked once.
+func Redact(v interface{}, tagKey, token string) error {
+	var o RedactOptions
+	return o.Redact(v, tagKey, token)
+}
+
+// Redact is like the top-level Redact, with the options of o.
+func (o *RedactOptions) Redact(v interface{}, tagKey, token string) error {
+	e := efaceOf(&v)
+	r := redactor{opts: o, tagKey: tagKey, token: token}
+	switch k := kindOf(e.Type); k {
+	case Ptr:
+		r.walkPointer(e.Type, unsafe.Pointer(&e.Word))
+	case Slice, Map:
+		r.walk(e.Type, e.data())
+	default:
+		return &KindError{Op: "Redact", Kind: k}
+	}
+	runtime.KeepAlive(v)
+	return nil
+}
+
+// redactor walks a value for Redact.
+type redactor struct {
+	opts          *RedactOptions
+	tagKey, token string
+	seen          map[typedPointer]bool // pointers followed
+}
+
+// walk redacts the value of type t at p.
+func (r *redactor) walk(t *rtype, p unsafe.Pointer) {
+	if !mayRedact(t) {
+		return
+	}
+	switch t.Kind() {
+	case Struct:
+		r.walkStruct((*StructType)(unsafe.Pointer(t)), p)
+	case Ptr:
+		if r.opts.FollowPointers {
+			r.walkPointer(t, p)
+		}
+	case Interface:
+		if !r.opts.FollowPointers {
+			return
+		}
+		var e InterfaceHeader
+		if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+			e = *(*InterfaceHeader)(p)
+		} else if tab := (*IfaceHeader)(p).Tab; tab != nil {
+			e = InterfaceHeader{Type: tab.Type, Word: (*IfaceHeader)(p).Word}
+		}
+		if e.Type != nil && e.Type.Kind() == Ptr {
+			r.walkPointer(e.Type, unsafe.Pointer(&e.Word))
+		}
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			r.walk(at.Elem, Add(p, i*at.Elem.size, "i < len"))
+		}
+	case Slice:
+		et := (*SliceType)(unsafe.Pointer(t)).Elem
+		hdr := (*SliceHeader)(p)
+		for i := 0; i < hdr.Len; i++ {
+			r.walk(et, Add(hdr.Data, uintptr(i)*et.size, "i < len"))
+		}
+	case Map:
+		r.walkMap((*MapType)(unsafe.Pointer(t)), *(*unsafe.Pointer)(p))
+	}
+}
+
+// walkPointer redacts the value the pointer of type t at p points to.
+func (r *redactor) walkPointer(t *rtype, p unsafe.Pointer) {
+	q := typedPointer{(*PtrType)(unsafe.Pointer(t)).Elem, *(*unsafe.Pointer)(p)}
+	if q.p == nil || r.seen[q] {
+		return
+	}
+	if r.seen == nil {
+		r.seen = make(map[typedPointer]bool)
+	}
+	r.seen[q] = true
+	r.walk(q.t, q.p)
+}
+
+// walkMap redacts the elements of the map h, through a copy of each element
+// stored back to its key.
+func (r *redactor) walkMap(mt *MapType, h unsafe.Pointer) {
+	if h == nil {
+		return
+	}
+	tmp := New(mt.Elem)
+	mapRange(mt, h, func(key, elem unsafe.Pointer) bool {
+		typedmemmove(mt.Elem, tmp, elem)
+		r.walk(mt.Elem, tmp)
+		mapStore(mt, h, key, tmp)
+		return true
+	})
+}
+
+func (r *redactor) walkStruct(st *StructType, p unsafe.Pointer) {
+	for _, f := range redactionPlan(st, r.tagKey, r.token) {
+		fp := Add(p, f.offset, "the field lies within the struct")
+		switch {
+		case !f.redact:
+			r.walk(f.typ, fp)
+		case r.opts.Placeholder && f.typ.Kind() == String && *(*string)(fp) != "":
+			*(*string)(fp) = redacted
+		default:
+			Zero(f.typ, fp)
+		}
+	}
+}
+
+// redactedField is a field redacted or walked by Redact.
+type redactedField struct {
+	offset uintptr
+	typ    *rtype
+	redact bool // whether its tag lists the token
+}
+
+// redactionPlanKey identifies the fields of a struct type that Redact
+// redacts or walks into.
+type redactionPlanKey struct {
+	t             *rtype
+	tagKey, token string
+}
+
+// redactionPlans caches redactionPlan by redactionPlanKey.
+var redactionPlans sync.Map
+
+// redactionPlan returns the fields of st to redact or holding structs.
+func redactionPlan(st *StructType, tagKey, token string) []redactedField {
+	key := redactionPlanKey{&st.rtype, tagKey, token}
+	if plan, ok := redactionPlans.Load(key); ok {
+		return plan.([]redactedField)
+	}
+	var plan []redactedField
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		redact := false
+		if tag, ok := reflect.StructTag(f.Name.Tag()).Lookup(tagKey); ok {
+			for _, tok := range strings.Split(tag, ",") {
+				if tok == token {
+					redact = true
+					break
+				}
+			}
+		}
+		if redact || mayRedact(f.typ) {
+			plan = append(plan, redactedField{f.Offset(), f.typ, redact})
+		}
+	}
+	redactionPlans.Store(key, plan)
+	return plan
+}
+
+// mayRedact reports whether a value of type t may hold a struct that Redact
+// walks: whether t is a struct, an interface, or a pointer, slice, array or
+// map leading to one.
+func mayRedact(t *rtype) bool {
+	for {
+		switch t.Kind() {
+		case Struct, Interface:
+			return true
+		case Ptr, Slice, Array:
+			t = elem(t)
+		case Map:
+			t = (*MapType)(unsafe.Pointer(t)).Elem
+		default:
+			return false
+		}
+	}
+}
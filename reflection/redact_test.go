@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+type redactCreds struct {
+	User     string
+	Password string            `log:"json,redact"`
+	Token    []byte            `log:"redact"`
+	Pin      int               `log:"redact"`
+	Meta     map[string]string `log:"redact"`
+	secret   string            `log:"redact"`
+	Note     string            `log:"redacted"`
+}
+
+type redactRoot struct {
+	Creds  redactCreds
+	List   []redactCreds
+	Arr    [2]redactCreds
+	ByName map[string]redactCreds
+	Ptr    *redactCreds
+	Any    interface{}
+	Self   *redactRoot
+}
+
+func redactFixture() redactCreds {
+	return redactCreds{
+		User: "u", Password: "p", Token: []byte("t"), Pin: 1234,
+		Meta: map[string]string{"k": "v"}, secret: "s", Note: "n",
+	}
+}
+
+func TestRedact(t *testing.T) {
+	shared := redactFixture()
+	v := &redactRoot{
+		Creds:  redactFixture(),
+		List:   []redactCreds{redactFixture(), redactFixture()},
+		Arr:    [2]redactCreds{redactFixture()},
+		ByName: map[string]redactCreds{"a": redactFixture()},
+		Ptr:    &shared,
+		Any:    &shared,
+	}
+	v.Self = v
+	if err := Redact(v, "log", "redact"); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	want := redactCreds{User: "u", Note: "n"}
+	for name, got := range map[string]redactCreds{
+		"Creds": v.Creds, "List[0]": v.List[0], "List[1]": v.List[1], "Arr[0]": v.Arr[0], "ByName[a]": v.ByName["a"],
+	} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+	if !reflect.DeepEqual(v.Arr[1], redactCreds{}) {
+		t.Errorf("Arr[1] = %+v", v.Arr[1])
+	}
+	// Nested pointers and interfaces are not followed by default.
+	if !reflect.DeepEqual(shared, redactFixture()) {
+		t.Errorf("shared = %+v, want it untouched", shared)
+	}
+
+	o := RedactOptions{Placeholder: true, FollowPointers: true}
+	if err := o.Redact(v, "log", "redact"); err != nil {
+		t.Fatal(err)
+	}
+	want = redactCreds{User: "u", Password: redacted, secret: redacted, Note: "n"}
+	if !reflect.DeepEqual(shared, want) {
+		t.Errorf("shared with FollowPointers = %+v, want %+v", shared, want)
+	}
+	// Empty strings stay empty with a placeholder.
+	if v.Creds.Password != "" {
+		t.Errorf("redacted empty string = %q", v.Creds.Password)
+	}
+}
+
+func TestRedactTopLevel(t *testing.T) {
+	s := []redactCreds{redactFixture()}
+	if err := Redact(s, "log", "redact"); err != nil {
+		t.Fatal(err)
+	}
+	if s[0].Password != "" || s[0].User != "u" {
+		t.Errorf("Redact of a slice = %+v", s[0])
+	}
+
+	m := map[int]*redactCreds{1: {Password: "p"}}
+	if err := Redact(m, "log", "redact"); err != nil {
+		t.Fatal(err)
+	}
+	if m[1].Password != "p" {
+		t.Errorf("Redact followed a pointer in a map: %+v", m[1])
+	}
+	o := RedactOptions{FollowPointers: true}
+	if err := o.Redact(m, "log", "redact"); err != nil {
+		t.Fatal(err)
+	}
+	if m[1].Password != "" {
+		t.Errorf("Redact with FollowPointers of a map = %+v", m[1])
+	}
+
+	if err := Redact((*redactCreds)(nil), "log", "redact"); err != nil {
+		t.Errorf("Redact of a nil pointer = %v", err)
+	}
+	if err := Redact(map[string]redactCreds(nil), "log", "redact"); err != nil {
+		t.Errorf("Redact of a nil map = %v", err)
+	}
+
+	var ke *KindError
+	for _, v := range []interface{}{redactFixture(), [1]redactCreds{}, nil} {
+		if err := Redact(v, "log", "redact"); !errors.As(err, &ke) {
+			t.Errorf("Redact(%T) = %v, want a *KindError", v, err)
+		}
+	}
+}
+
+func BenchmarkRedact(b *testing.B) {
+	s := make([]redactCreds, 100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range s {
+			s[j].Password = "p"
+		}
+		Redact(s, "log", "redact")
+	}
+}
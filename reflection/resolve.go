@@ -0,0 +1,108 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ResolverMode selects how ResolveNameOff and ResolveTypeOff, and the
+// NameOff and TypeOff methods built on them, resolve offsets.
+type ResolverMode int
+
+const (
+	// ResolveRuntime calls the resolvers of the runtime, reached through
+	// go:linkname.
+	ResolveRuntime ResolverMode = iota
+
+	// ResolveModules adds the offset to the start of the type descriptors
+	// of the module holding the base pointer, found among the loaded
+	// modules, as the runtime does. Offsets from descriptors built at run
+	// time, which the runtime records in a table of its own, are still
+	// resolved by the runtime. ResolveModules requires the runtime module
+	// layout, see SetResolver.
+	ResolveModules
+)
+
+// resolver holds the ResolverMode in use, read and written atomically, as
+// SetResolver may be called while other goroutines resolve offsets.
+var resolver int32 = int32(ResolveRuntime)
+
+// resolverMode returns the ResolverMode in use.
+func resolverMode() ResolverMode {
+	return ResolverMode(atomic.LoadInt32(&resolver))
+}
+
+// SetResolver selects the ResolverMode of ResolveNameOff and ResolveTypeOff.
+// It is meant to be called once, before types are inspected, by programs
+// built in configurations where the runtime resolvers are unreliable; the
+// result is the same otherwise.
+//
+// SetResolver returns an error wrapping ErrUnsupported, leaving the mode
+// unchanged, for ResolveModules where the runtime module layout is not
+// available, as Modules explains, and every offset would still be resolved
+// by the runtime.
+func SetResolver(mode ResolverMode) error {
+	switch mode {
+	case ResolveRuntime:
+	case ResolveModules:
+		if err := checkModules("ResolveModules"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("reflection: unknown ResolverMode %d", mode)
+	}
+	atomic.StoreInt32(&resolver, int32(mode))
+	return nil
+}
+
+// moduleResolveNameOff resolves a name offset against the module holding
+// ptrInModule, as runtime.resolveNameOff does. It reports false if no
+// loaded module holds ptrInModule.
+func moduleResolveNameOff(ptrInModule unsafe.Pointer, off int32) (unsafe.Pointer, bool) {
+	if off == 0 {
+		return nil, true
+	}
+	md := moduleOfType(ptrInModule)
+	if md == nil {
+		return nil, false
+	}
+	return md.typeOff(off), true
+}
+
+// moduleResolveTypeOff resolves a type offset against the module holding
+// rtype, as runtime.resolveTypeOff does, including the redirection of
+// the types a plugin shares with the modules loaded before it. It reports
+// false if no loaded module holds rtype.
+func moduleResolveTypeOff(rtype unsafe.Pointer, off int32) (unsafe.Pointer, bool) {
+	if off == 0 || off == -1 {
+		// -1 is the sentinel value for unreachable code.
+		return nil, true
+	}
+	md := moduleOfType(rtype)
+	if md == nil {
+		return nil, false
+	}
+	t := md.typeOff(off)
+	if u := md.sharedType(t); u != nil {
+		return u, true
+	}
+	return t, true
+}
+
+// moduleOfType returns the loaded module whose type descriptors hold p, or
+// nil.
+func moduleOfType(p unsafe.Pointer) *moduledata {
+	var found *moduledata
+	rangeModules(func(md *moduledata) bool {
+		if md.holdsType(uintptr(p)) {
+			found = md
+		}
+		return found == nil
+	})
+	return found
+}
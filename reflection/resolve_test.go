@@ -0,0 +1,112 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestSetResolver(t *testing.T) {
+	defer SetResolver(resolverMode())
+
+	err := SetResolver(ResolveModules)
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) || resolverMode() != ResolveRuntime {
+			t.Errorf("SetResolver(ResolveModules) = %v with mode %d, want ErrUnsupported", err, resolverMode())
+		}
+	} else if err != nil || resolverMode() != ResolveModules {
+		t.Errorf("SetResolver(ResolveModules) = %v with mode %d", err, resolverMode())
+	}
+	if err := SetResolver(ResolveRuntime); err != nil || resolverMode() != ResolveRuntime {
+		t.Errorf("SetResolver(ResolveRuntime) = %v with mode %d", err, resolverMode())
+	}
+	if err := SetResolver(ResolverMode(7)); err == nil || resolverMode() != ResolveRuntime {
+		t.Errorf("SetResolver(7) = %v with mode %d", err, resolverMode())
+	}
+}
+
+func TestSetResolverConcurrent(t *testing.T) {
+	if !modulesSupported {
+		t.Skip("module layout not supported")
+	}
+	defer SetResolver(resolverMode())
+	typ := TypeFor[*resolveT]()
+	want := typ.String()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetResolver(ResolverMode(i % 2))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if got := typ.String(); got != want {
+				t.Errorf("String = %q, want %q", got, want)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+type resolveT struct{ N int }
+
+// TestModuleResolver checks that the module resolver agrees with the runtime
+// on the offsets of the method tables and pointer types of every type.
+func TestModuleResolver(t *testing.T) {
+	if !modulesSupported {
+		t.Skip("module layout not supported")
+	}
+	checked := 0
+	check := func(what string, base unsafe.Pointer, off int32, got unsafe.Pointer, ok bool, want unsafe.Pointer) {
+		checked++
+		if !ok || got != want {
+			t.Errorf("%s offset %d from %p resolved to %p, %v, want %p", what, off, base, got, ok, want)
+		}
+	}
+	err := Types(func(typ *rtype) bool {
+		base := unsafe.Pointer(typ)
+		if off := int32(typ.ptrToThis); off != 0 {
+			got, ok := moduleResolveTypeOff(base, off)
+			check(typ.String()+" ptrToThis", base, off, got, ok, runtimeResolveTypeOff(base, off))
+		}
+		if u := typ.Uncommon(); u != nil {
+			for _, m := range u.Methods() {
+				got, ok := moduleResolveNameOff(base, int32(m.Name))
+				check(typ.String()+" method name", base, int32(m.Name), got, ok, runtimeResolveNameOff(base, int32(m.Name)))
+				if m.Mtyp != -1 {
+					got, ok = moduleResolveTypeOff(base, int32(m.Mtyp))
+					check(typ.String()+" method type", base, int32(m.Mtyp), got, ok, runtimeResolveTypeOff(base, int32(m.Mtyp)))
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked == 0 {
+		t.Error("no offsets checked")
+	}
+}
+
+func TestModuleResolverRuntimeType(t *testing.T) {
+	// Descriptors built at run time are in no module, and left to the
+	// runtime.
+	built := FromReflect(reflect.StructOf([]reflect.StructField{{Name: "Resolved", Type: reflect.TypeOf(0)}}))
+	if _, ok := moduleResolveTypeOff(unsafe.Pointer(built), 1); ok {
+		t.Error("module resolver resolved an offset from a run-time descriptor")
+	}
+	if _, ok := moduleResolveNameOff(unsafe.Pointer(built), 0); !ok {
+		t.Error("the zero name offset is not resolved to nil")
+	}
+}
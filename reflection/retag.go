@@ -0,0 +1,176 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// retagged keeps the types built by Retag and Project, and through them
+// their field names, reachable for the lifetime of the program, as the type
+// descriptors of the executable are. It maps each of them to the type it was
+// ultimately derived from, for PackAs to check.
+var retagged struct {
+	sync.RWMutex
+	origin map[*StructType]*rtype
+}
+
+// keepDerived records nt as derived from st by Retag or Project.
+func keepDerived(nt, st *StructType) {
+	retagged.Lock()
+	defer retagged.Unlock()
+	if retagged.origin == nil {
+		retagged.origin = make(map[*StructType]*rtype)
+	}
+	origin, ok := retagged.origin[st]
+	if !ok {
+		origin = &st.rtype
+	}
+	retagged.origin[nt] = origin
+}
+
+// originOf returns the type t was derived from by Retag or Project, or t
+// itself if it was not built by either.
+func originOf(t *rtype) *rtype {
+	if t.Kind() != Struct {
+		return t
+	}
+	retagged.RLock()
+	origin, ok := retagged.origin[(*StructType)(unsafe.Pointer(t))]
+	retagged.RUnlock()
+	if !ok {
+		return t
+	}
+	return origin
+}
+
+// Retag returns a copy of the struct type st whose exported fields carry the
+// tags returned by rewrite, called with the name and the current tag of each
+// exported field. Unexported fields keep their original names and tags.
+//
+// The new type has the same size, field offsets, equality and garbage
+// collection data as st, so a value of st can be reinterpreted as a value of
+// the new type, for example with PackEface, to be consumed by packages that
+// read tags such as encoding/json. The methods of st are preserved, but the
+// pointer type to the new type, as built by reflect.PointerTo, has none.
+//
+// Under the darkness_safe build tag, Retag returns an error wrapping
+// ErrUnsupported.
+func Retag(st *StructType, rewrite func(field string, tag string) string) (*StructType, error) {
+	if k := st.Kind(); k != Struct {
+		return nil, &KindError{Op: "Retag", Kind: k}
+	}
+	if rewrite == nil {
+		return nil, errors.New("reflection: Retag with nil rewrite func")
+	}
+	if safeMode {
+		return nil, fmt.Errorf("%w: Retag builds a type descriptor, not done under darkness_safe", ErrUnsupported)
+	}
+
+	fields := make([]StructField, len(st.Fields))
+	for i, f := range st.Fields {
+		fields[i] = f
+		if !f.Name.IsExported() {
+			continue
+		}
+		n, err := NewNameErr(f.Name.Name(), rewrite(f.Name.Name(), f.Name.Tag()), true)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.IsEmbedded() {
+			*n.bytes |= 1 << 3
+		}
+		fields[i].Name = n
+	}
+
+	var nt *StructType
+	if u := st.Uncommon(); u != nil {
+		nt = newUncommonStruct(st, u)
+	} else {
+		nt = new(StructType)
+		*nt = *st
+	}
+	nt.Fields = fields
+	nt.str = reflectNameOff(st.NameOff(st.str))
+	nt.ptrToThis = 0
+
+	keepDerived(nt, st)
+	return nt, nil
+}
+
+// newUncommonStruct returns a heap copy of the struct type st followed by its
+// uncommon data u and its methods, with every offset rebased so that it
+// resolves from the new location.
+func newUncommonStruct(st *StructType, u *UncommonType) *StructType {
+	methods := u.Methods()
+	block := reflect.New(reflect.StructOf([]reflect.StructField{
+		{Name: "T", Type: reflect.TypeOf(StructType{})},
+		{Name: "U", Type: reflect.TypeOf(UncommonType{})},
+		{Name: "M", Type: reflect.ArrayOf(len(methods), reflect.TypeOf(Method{}))},
+	})).UnsafePointer()
+
+	type uncommonStruct struct {
+		StructType
+		u UncommonType
+	}
+	nu := (*uncommonStruct)(block)
+	nu.StructType = *st
+	nu.u = *u
+	nu.u.Moff = uint32(unsafe.Sizeof(UncommonType{}))
+	if u.PkgPath != 0 {
+		nu.u.PkgPath = reflectNameOff(st.NameOff(u.PkgPath))
+	}
+
+	nms := nu.u.Methods()
+	for i, m := range methods {
+		nms[i].Name = reflectNameOff(st.NameOff(m.Name))
+		nms[i].Mtyp = reflectTypeOff(st, m.Mtyp)
+		nms[i].Ifn = reflectTextOff(st, m.Ifn)
+		nms[i].Tfn = reflectTextOff(st, m.Tfn)
+	}
+	return &nu.StructType
+}
+
+// reflectNameOff returns the offset resolving to n from a type built at run time.
+func reflectNameOff(n Name) NameOff {
+	return NameOff(addReflectOff(unsafe.Pointer(n.bytes)))
+}
+
+// reflectTypeOff rebases the type offset off of t so that it resolves from a
+// type built at run time. The offsets of unreachable methods are kept as is.
+func reflectTypeOff(t *StructType, off TypeOff) TypeOff {
+	if off == 0 || off == -1 {
+		return off
+	}
+	return TypeOff(addReflectOff(unsafe.Pointer(t.TypeOff(off))))
+}
+
+// reflectTextOff rebases the text offset off of t so that it resolves from a
+// type built at run time. The offsets of unreachable methods are kept as is.
+func reflectTextOff(t *StructType, off TextOff) TextOff {
+	if off == -1 {
+		return off
+	}
+	return TextOff(addReflectOff(t.TextOff(off)))
+}
+
+// reflectOffSentinel takes the first reflection offset, -1, should it still be
+// free, since the runtime also reads -1 as the offset of an unreachable method.
+var reflectOffSentinel byte
+
+func init() {
+	addReflectOff(unsafe.Pointer(&reflectOffSentinel))
+}
+
+//go:linkname addReflectOff reflect.addReflectOff
+
+// addReflectOff adds a pointer to the reflection lookup map in the runtime.
+// It returns a new ID that can be used as a NameOff, TypeOff or TextOff, and
+// will be resolved correctly. Implemented in the runtime package.
+func addReflectOff(ptr unsafe.Pointer) int32
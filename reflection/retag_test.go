@@ -0,0 +1,130 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type retagT struct {
+	Name   string `json:"name"`
+	Age    int    `json:"age,omitempty"`
+	Plain  bool
+	hidden int
+	retagEmbedded
+}
+
+type retagEmbedded struct {
+	City string `json:"city"`
+}
+
+func (v retagT) String() string { return v.Name + "/" + v.City }
+
+// retagTypes keeps the methods of the types retagged by the tests reachable.
+var retagTypes = []interface{}{retagT{}}
+
+// retagUpper rewrites the json key of every field to upper case, using the
+// field name for fields without one.
+func retagUpper(field, tag string) string {
+	key, opts := field, ""
+	if v, ok := reflect.StructTag(tag).Lookup("json"); ok {
+		if i := strings.IndexByte(v, ','); i >= 0 {
+			v, opts = v[:i], v[i:]
+		}
+		if v != "" {
+			key = v
+		}
+	}
+	return `json:"` + strings.ToUpper(key) + opts + `"`
+}
+
+func TestRetagJSON(t *testing.T) {
+	st := (*StructType)(unsafe.Pointer(TypeFor[retagT]()))
+	nt, err := Retag(st, retagUpper)
+	if safeMode {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("Retag under darkness_safe = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := retagT{Name: "n", Plain: true, hidden: 1, retagEmbedded: retagEmbedded{City: "c"}}
+	orig, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"n","Plain":true,"city":"c"}`; string(orig) != want {
+		t.Errorf("original: %s, want %s", orig, want)
+	}
+	got, err := json.Marshal(PackEface(&nt.rtype, unsafe.Pointer(&v)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The embedded struct keeps its own tags, its fields are promoted.
+	if want := `{"NAME":"n","PLAIN":true,"city":"c"}`; string(got) != want {
+		t.Errorf("retagged: %s, want %s", got, want)
+	}
+
+	// Unmarshaling into the retagged type writes the fields of v.
+	var w retagT
+	rv := reflect.NewAt(ToReflect(&nt.rtype), unsafe.Pointer(&w))
+	if err := json.Unmarshal([]byte(`{"NAME":"m","AGE":3}`), rv.Interface()); err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "m" || w.Age != 3 {
+		t.Errorf("unmarshaled %+v, want Name m and Age 3", w)
+	}
+}
+
+// TestRetagMethods checks that the name, package path and methods of a
+// retagged type, whose offsets Retag rebases with addReflectOff, resolve.
+func TestRetagMethods(t *testing.T) {
+	if safeMode {
+		t.Skip("Retag is not supported under darkness_safe")
+	}
+	st := (*StructType)(unsafe.Pointer(TypeFor[retagT]()))
+	nt, err := Retag(st, func(field, tag string) string { return `x:"1"` })
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := ToReflect(&nt.rtype)
+	if rt.Name() != "retagT" || rt.PkgPath() != reflect.TypeOf(retagT{}).PkgPath() {
+		t.Errorf("retagged type %s.%s, want the name and package of retagT", rt.PkgPath(), rt.Name())
+	}
+	if rt.NumMethod() != 1 || rt.Method(0).Name != "String" {
+		t.Fatalf("retagged type has %d methods, want String", rt.NumMethod())
+	}
+	v := retagT{Name: "n", retagEmbedded: retagEmbedded{City: "c"}}
+	out := reflect.NewAt(rt, unsafe.Pointer(&v)).Elem().Method(0).Call(nil)
+	if s := out[0].String(); s != "n/c" {
+		t.Errorf("String through the retagged type = %q, want n/c", s)
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if want := reflect.TypeOf(v).Field(i); f.Offset != want.Offset || f.Type != want.Type || f.Anonymous != want.Anonymous {
+			t.Errorf("field %s: %v, want %v", f.Name, f, want)
+		}
+		if f.IsExported() != (f.Tag == `x:"1"`) {
+			t.Errorf("field %s: tag %q", f.Name, f.Tag)
+		}
+	}
+}
+
+func TestRetagErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := Retag((*StructType)(unsafe.Pointer(TypeFor[int]())), retagUpper); !errors.As(err, &ke) {
+		t.Errorf("Retag(int) = %v, want a *KindError", err)
+	}
+	if _, err := Retag((*StructType)(unsafe.Pointer(TypeFor[retagT]())), nil); err == nil {
+		t.Error("Retag with a nil func: no error")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// New allocates a zeroed value of type t on the heap and returns a pointer to it.
+func New(t *rtype) unsafe.Pointer {
+	return unsafe_New(t)
+}
+
+// AllocNoScan allocates size bytes aligned to align, a power of two, on the
+// heap, in memory the garbage collector does not scan. Unless zero is set,
+// the memory is not cleared and holds arbitrary bytes.
+//
+// The memory must never hold pointers to Go memory: the collector does not
+// see them and frees what they point to while still in use. It is meant for
+// large buffers of plain data, which then cost nothing to mark. The block is
+// kept alive by pointers to any of its bytes.
+func AllocNoScan(size, align uintptr, zero bool) unsafe.Pointer {
+	if align == 0 || align&(align-1) != 0 {
+		panic("reflection: AllocNoScan alignment must be a power of two")
+	}
+	// Blocks are aligned to 8 bytes, and the tiny allocator aligns smaller
+	// blocks to their size, when it is a multiple of the alignment.
+	if align <= 8 && size%align == 0 {
+		return mallocgc(size, nil, zero)
+	}
+	// A zero-sized block is given a byte, so that the aligned pointer
+	// stays inside the allocation rather than one past its end.
+	n := size
+	if n == 0 {
+		n = 1
+	}
+	p := mallocgc(n+align-1, nil, zero)
+	return Add(p, -uintptr(p)&(align-1), "the block is align-1 bytes larger")
+}
+
+// AllocTyped allocates a value of type t on the heap, as New does. Unless
+// zero is set, the value is not cleared when t has no pointers, and holds
+// arbitrary bytes; values with pointers are always cleared.
+func AllocTyped(t *rtype, zero bool) unsafe.Pointer {
+	return mallocgc(t.size, t, zero || t.ptrdata != 0)
+}
+
+// TypedMemmove copies a value of type t to dst from src,
+// with the write barriers the garbage collector requires.
+func TypedMemmove(t *rtype, dst, src unsafe.Pointer) {
+	typedmemmove(t, dst, src)
+}
+
+// IsZero reports whether the value of type t pointed to by p is the zero
+// value of t, as reflect.Value.IsZero would. A floating-point negative zero
+// is not the zero value.
+func IsZero(t *rtype, p unsafe.Pointer) bool {
+	for _, b := range unsafe.Slice((*byte)(p), t.size) {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNilAt reports whether the value of type t pointed to by p is nil, as
+// reflect.Value.IsNil would: a nil pointer, unsafe pointer, map, channel or
+// function, a slice with a nil array, or an interface holding no value. An
+// interface holding a nil pointer, whose data word is nil, is not nil. It
+// returns a *KindError for the kinds that cannot be nil.
+func IsNilAt(t *rtype, p unsafe.Pointer) (bool, error) {
+	switch k := t.Kind(); k {
+	case Ptr, UnsafePointer, Map, Chan, Func:
+		return *(*unsafe.Pointer)(p) == nil, nil
+	case Slice:
+		return (*SliceHeader)(p).Data == nil, nil
+	case Interface:
+		// The type word, or the itab word, is nil only for a nil
+		// interface, whatever its data word holds.
+		return (*InterfaceHeader)(p).Type == nil, nil
+	default:
+		return false, &KindError{Op: "IsNilAt", Kind: k}
+	}
+}
+
+// nilable reports whether values of kind k can be nil.
+func nilable(k Kind) bool {
+	switch k {
+	case Ptr, UnsafePointer, Map, Chan, Func, Slice, Interface:
+		return true
+	}
+	return false
+}
+
+// Zero sets the value of type t pointed to by p to the zero value of t,
+// with the write barriers the garbage collector requires.
+func Zero(t *rtype, p unsafe.Pointer) {
+	typedmemclr(t, p)
+}
+
+// HasPointers reports whether values of type t contain pointers the garbage
+// collector has to scan.
+func HasPointers(t *rtype) bool {
+	return t.ptrdata != 0
+}
+
+// IsPOD reports whether t is plain old data: a type made only of booleans and
+// numbers, possibly in arrays and structs, whose values can be copied as raw
+// bytes and remain meaningful in another process. Uintptr is excluded, as its
+// values are usually addresses.
+func IsPOD(t *rtype) bool {
+	switch t.Kind() {
+	case Bool, Int, Int8, Int16, Int32, Int64,
+		Uint, Uint8, Uint16, Uint32, Uint64,
+		Float32, Float64, Complex64, Complex128:
+		return true
+	case Array:
+		return IsPOD((*ArrayType)(unsafe.Pointer(t)).Elem)
+	case Struct:
+		for i := range (*StructType)(unsafe.Pointer(t)).Fields {
+			if !IsPOD((*StructType)(unsafe.Pointer(t)).Fields[i].Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+//go:linkname unsafe_New reflect.unsafe_New
+
+// unsafe_New allocates a zeroed value of type t.
+// Implemented in the runtime package.
+func unsafe_New(t *rtype) unsafe.Pointer
+
+//go:linkname typedmemmove reflect.typedmemmove
+
+// typedmemmove copies a value of type t to dst from src.
+// Implemented in the runtime package.
+func typedmemmove(t *rtype, dst, src unsafe.Pointer)
+
+//go:linkname typedmemclr reflect.typedmemclr
+
+// typedmemclr clears the value of type t at p.
+// Implemented in the runtime package.
+func typedmemclr(t *rtype, p unsafe.Pointer)
+
+//go:linkname mallocgc runtime.mallocgc
+
+// mallocgc allocates size bytes for a value of type typ, or of no pointers if
+// typ is nil, cleared if needzero is set.
+// Implemented in the runtime package.
+func mallocgc(size uintptr, typ *rtype, needzero bool) unsafe.Pointer
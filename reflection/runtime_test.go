@@ -0,0 +1,120 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestIsNilAt(t *testing.T) {
+	var (
+		nilPtr  *int
+		nilErr  error
+		x       int
+		nilFunc func()
+	)
+	values := []interface{}{
+		nilPtr, &x,
+		unsafe.Pointer(nil), unsafe.Pointer(&x),
+		map[int]int(nil), map[int]int{},
+		(chan int)(nil), make(chan int),
+		nilFunc, TestIsNilAt,
+		[]int(nil), []int{}, make([]int, 0, 0),
+	}
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		got, err := IsNilAt(FromReflect(rv.Type()), p.UnsafePointer())
+		if err != nil || got != rv.IsNil() {
+			t.Errorf("IsNilAt(%T) = %v, %v, want %v", v, got, err, rv.IsNil())
+		}
+	}
+
+	// Interfaces, read from their variables.
+	ifaces := []struct {
+		name string
+		p    interface{}
+		want bool
+	}{
+		{"nil empty interface", new(interface{}), true},
+		{"empty interface holding a nil pointer", &[]interface{}{nilPtr}[0], false},
+		{"nil error", &nilErr, true},
+		{"error holding a nil pointer", &[]error{(*KindError)(nil)}[0], false},
+		{"error", &[]error{errors.New("e")}[0], false},
+	}
+	for _, tt := range ifaces {
+		rv := reflect.ValueOf(tt.p)
+		got, err := IsNilAt(FromReflect(rv.Type().Elem()), rv.UnsafePointer())
+		if err != nil || got != tt.want || got != rv.Elem().IsNil() {
+			t.Errorf("IsNilAt(%s) = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsNilAtKindError(t *testing.T) {
+	var buf [4]uintptr
+	for _, v := range []interface{}{0, "", [1]*int{}, struct{ P *int }{}, uintptr(0), false} {
+		_, err := IsNilAt(efaceOf(&v).Type, unsafe.Pointer(&buf))
+		var ke *KindError
+		if !errors.As(err, &ke) || ke.Op != "IsNilAt" || ke.Kind != efaceOf(&v).Type.Kind() {
+			t.Errorf("IsNilAt(%T) = %v, want a *KindError", v, err)
+		}
+	}
+}
+
+func TestAllocNoScan(t *testing.T) {
+	for _, size := range []uintptr{0, 1, 3, 12, 16, 100, 4097} {
+		for _, align := range []uintptr{1, 2, 4, 8, 16, 32} {
+			p := AllocNoScan(size, align, true)
+			if p == nil || uintptr(p)%align != 0 {
+				t.Errorf("AllocNoScan(%d, %d) = %p", size, align, p)
+			}
+			for i, b := range unsafe.Slice((*byte)(p), size) {
+				if b != 0 {
+					t.Fatalf("AllocNoScan(%d, %d): byte %d = %d, want zeroed", size, align, i, b)
+				}
+			}
+		}
+	}
+	for _, align := range []uintptr{0, 3, 24} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("AllocNoScan with alignment %d did not panic", align)
+				}
+			}()
+			AllocNoScan(8, align, false)
+		}()
+	}
+}
+
+func TestAllocTypedClearsPointers(t *testing.T) {
+	type withPtr struct {
+		N [64]int
+		P *int
+	}
+	// Dirty memory freed to the allocator, which a non-zeroing
+	// allocation of a pointer type must not see.
+	for i := 0; i < 100; i++ {
+		p := AllocNoScan(unsafe.Sizeof(withPtr{}), 8, false)
+		b := unsafe.Slice((*byte)(p), unsafe.Sizeof(withPtr{}))
+		for j := range b {
+			b[j] = 0xff
+		}
+	}
+	for i := 0; i < 100; i++ {
+		v := (*withPtr)(AllocTyped(TypeFor[withPtr](), false))
+		if v.P != nil || v.N != [64]int{} {
+			t.Fatal("AllocTyped of a type with pointers returned uncleared memory")
+		}
+	}
+	if v := (*[4]int)(AllocTyped(TypeFor[[4]int](), true)); *v != [4]int{} {
+		t.Errorf("AllocTyped with zero = %v", *v)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_safe
+
+package reflection
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// The darkness_safe build tag implements the map operations of this package
+// with package reflect instead of the runtime's map functions and iterator
+// layout, which change between Go releases more than any other layout the
+// package mirrors, and so are the accessors of type descriptors in
+// typeaccess_safe.go. A program whose operations fail, or misbehave, with
+// a new release can set the tag until the package catches up.
+//
+// The exported API is the same in both modes, with these differences:
+//
+//   - The key and element pointers SortedMapRange passes to fn, and those
+//     Traverse visits, point to copies of the entries of the map,
+//     allocated for each entry. Redact stores the redacted copies back.
+//   - MapAccessBytesKey converts the key to a string, and returns a pointer
+//     to a copy of the element: writes through it do not reach the map.
+//   - MapStatsOf, and DeepSize of a value reaching a map, return an error
+//     wrapping ErrUnsupported, as they read the storage of maps.
+//   - Retag and Project, which build type descriptors, ChanPeek, which reads
+//     the channel buffer, and the functions walking the module data, such
+//     as Modules, ITabs, TypeByName and FindImplementations, return an
+//     error wrapping ErrUnsupported.
+//
+// The field arrays of StructType, names, itabs and GC bitmaps are still read
+// directly, as the API exposes them, and the linknamed runtime functions
+// besides those on maps are still called.
+
+// safeMode reports whether the darkness_safe build tag is set.
+const safeMode = true
+
+// mapValue returns the map h of type t as a reflect.Value.
+func mapValue(t *MapType, h unsafe.Pointer) reflect.Value {
+	return reflect.NewAt(ToReflect(&t.rtype), unsafe.Pointer(&h)).Elem()
+}
+
+func mapLen(t *MapType, h unsafe.Pointer) int {
+	return mapValue(t, h).Len()
+}
+
+func mapRange(t *MapType, h unsafe.Pointer, fn func(key, elem unsafe.Pointer) bool) {
+	v := mapValue(t, h)
+	kt, et := v.Type().Key(), v.Type().Elem()
+	for it := v.MapRange(); it.Next(); {
+		k, e := reflect.New(kt), reflect.New(et)
+		k.Elem().Set(it.Key())
+		e.Elem().Set(it.Value())
+		if !fn(k.UnsafePointer(), e.UnsafePointer()) {
+			return
+		}
+	}
+}
+
+func mapAccessString(t *MapType, h unsafe.Pointer, k string) (unsafe.Pointer, bool) {
+	v := mapValue(t, h)
+	e := v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key()))
+	if !e.IsValid() {
+		return nil, false
+	}
+	p := reflect.New(e.Type())
+	p.Elem().Set(e)
+	return p.UnsafePointer(), true
+}
+
+func mapStore(t *MapType, h, key, val unsafe.Pointer) {
+	v := mapValue(t, h)
+	v.SetMapIndex(reflect.NewAt(v.Type().Key(), key).Elem(), reflect.NewAt(v.Type().Elem(), val).Elem())
+}
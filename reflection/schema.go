@@ -0,0 +1,218 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/json"
+	"strconv"
+	"unsafe"
+)
+
+// SchemaOptions controls ExportSchema.
+type SchemaOptions struct {
+	// Fingerprint is the options of the fingerprints identifying the
+	// types of the schema.
+	Fingerprint FingerprintOptions
+
+	// Indent, if not empty, indents the JSON document with it, as
+	// json.MarshalIndent does.
+	Indent string
+}
+
+// schemaVersion is the version of the schema document format.
+const schemaVersion = 1
+
+// schemaDoc is the JSON document of ExportSchema.
+type schemaDoc struct {
+	Version int           `json:"version"`
+	Roots   []schemaRoot  `json:"roots"`
+	Types   []*schemaType `json:"types"`
+}
+
+// schemaRoot names a type passed to ExportSchema.
+type schemaRoot struct {
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// schemaType describes a type. The types it refers to are referred to by
+// fingerprint.
+type schemaType struct {
+	Fingerprint string         `json:"fingerprint"`
+	Name        string         `json:"name"`
+	Kind        string         `json:"kind"`
+	Size        uintptr        `json:"size"`
+	Align       uint8          `json:"align"`
+	Len         uintptr        `json:"len,omitempty"`
+	Dir         ChanDir        `json:"dir,omitempty"`
+	Key         string         `json:"key,omitempty"`
+	Elem        string         `json:"elem,omitempty"`
+	Fields      []schemaField  `json:"fields,omitempty"`
+	In          []string       `json:"in,omitempty"`
+	Out         []string       `json:"out,omitempty"`
+	Variadic    bool           `json:"variadic,omitempty"`
+	Methods     []schemaMethod `json:"methods,omitempty"`
+}
+
+type schemaField struct {
+	Name     string  `json:"name"`
+	Offset   uintptr `json:"offset"`
+	Tag      string  `json:"tag,omitempty"`
+	Embedded bool    `json:"embedded,omitempty"`
+	Type     string  `json:"type"`
+}
+
+// schemaMethod is a method of an interface type, or an exported method of
+// another type. Type is the fingerprint of the method type, without the
+// receiver, and is only set for interface methods.
+type schemaMethod struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// ExportSchema returns a JSON document describing the layout of the types
+// ts and of the types they refer to, so that a process can check, with
+// VerifySchema, that its types match those of the process that exported
+// the schema before sharing raw memory with it.
+//
+// Each type is described once, identified by its fingerprint as computed
+// with opts.Fingerprint, with its kind, size and alignment, the fields of a
+// struct with their names, offsets and tags, the method names, and the
+// types it refers to by their fingerprints, which represents recursive
+// types. The types are listed so that a type comes after the types it
+// refers to, except along cycles, in an order that only depends on ts. The
+// types of ts are also listed under their string forms as the roots of the
+// document.
+func ExportSchema(ts []*rtype, opts SchemaOptions) ([]byte, error) {
+	e := schemaExporter{
+		opts:         &opts,
+		fingerprints: make(map[*rtype]string),
+		done:         make(map[string]bool),
+	}
+	doc := schemaDoc{Version: schemaVersion, Roots: []schemaRoot{}, Types: []*schemaType{}}
+	for _, t := range ts {
+		if t == nil {
+			return nil, &KindError{Op: "ExportSchema", Kind: Invalid}
+		}
+		doc.Roots = append(doc.Roots, schemaRoot{Name: t.String(), Fingerprint: e.ref(t)})
+		e.visit(t)
+	}
+	doc.Types = append(doc.Types, e.types...)
+	if opts.Indent != "" {
+		return json.MarshalIndent(&doc, "", opts.Indent)
+	}
+	return json.Marshal(&doc)
+}
+
+// schemaExporter lists the types of a schema in post-order.
+type schemaExporter struct {
+	opts         *SchemaOptions
+	fingerprints map[*rtype]string
+
+	// done holds the fingerprints of the types visited, which are listed
+	// in types once their components are.
+	done  map[string]bool
+	types []*schemaType
+}
+
+// ref returns the fingerprint of t as it appears in the document.
+func (e *schemaExporter) ref(t *rtype) string {
+	fp, ok := e.fingerprints[t]
+	if !ok {
+		fp = fingerprintString(e.opts.Fingerprint.Fingerprint(t))
+		e.fingerprints[t] = fp
+	}
+	return fp
+}
+
+// fingerprintString formats a fingerprint as 16 hexadecimal digits.
+func fingerprintString(fp uint64) string {
+	s := strconv.FormatUint(fp, 16)
+	for len(s) < 16 {
+		s = "0" + s
+	}
+	return s
+}
+
+func (e *schemaExporter) visit(t *rtype) {
+	fp := e.ref(t)
+	if e.done[fp] {
+		return
+	}
+	e.done[fp] = true
+
+	st := &schemaType{
+		Fingerprint: fp,
+		Name:        t.String(),
+		Kind:        t.Kind().String(),
+		Size:        t.size,
+		Align:       t.align,
+	}
+	var refs []*rtype
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		st.Len, st.Elem = at.Len, e.ref(at.Elem)
+		refs = append(refs, at.Elem)
+	case Chan:
+		ct := (*ChanType)(unsafe.Pointer(t))
+		st.Dir, st.Elem = ChanDir(ct.Dir), e.ref(ct.Elem)
+		refs = append(refs, ct.Elem)
+	case Func:
+		ft := (*FuncType)(unsafe.Pointer(t))
+		for i := 0; i < ft.NumIn(); i++ {
+			st.In = append(st.In, e.ref(ft.In(i)))
+		}
+		for i := 0; i < ft.NumOut(); i++ {
+			st.Out = append(st.Out, e.ref(ft.Out(i)))
+		}
+		st.Variadic = ft.IsVariadic()
+		refs = append(refs, ft.params()...)
+	case Interface:
+		it := (*InterfaceType)(unsafe.Pointer(t))
+		for _, m := range it.Methods {
+			mt := t.TypeOff(m.Typ)
+			st.Methods = append(st.Methods, schemaMethod{Name: t.NameOff(m.Name).Name(), Type: e.ref(mt)})
+			refs = append(refs, mt)
+		}
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		st.Key, st.Elem = e.ref(mt.Key), e.ref(mt.Elem)
+		refs = append(refs, mt.Key, mt.Elem)
+	case Ptr:
+		pe := (*PtrType)(unsafe.Pointer(t)).Elem
+		st.Elem = e.ref(pe)
+		refs = append(refs, pe)
+	case Slice:
+		se := (*SliceType)(unsafe.Pointer(t)).Elem
+		st.Elem = e.ref(se)
+		refs = append(refs, se)
+	case Struct:
+		stt := (*StructType)(unsafe.Pointer(t))
+		for i := range stt.Fields {
+			f := &stt.Fields[i]
+			st.Fields = append(st.Fields, schemaField{
+				Name:     f.Name.Name(),
+				Offset:   f.Offset(),
+				Tag:      f.Name.Tag(),
+				Embedded: f.Embedded(),
+				Type:     e.ref(f.typ),
+			})
+			refs = append(refs, f.typ)
+		}
+	}
+	if t.Kind() != Interface {
+		if u := t.Uncommon(); u != nil {
+			for _, m := range u.Methods()[:u.Xcount] {
+				st.Methods = append(st.Methods, schemaMethod{Name: t.NameOff(m.Name).Name()})
+			}
+		}
+	}
+
+	for _, r := range refs {
+		e.visit(r)
+	}
+	e.types = append(e.types, st)
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type schemaEmbed struct{ E int16 }
+
+type schemaT struct {
+	A    int32 `json:"a"`
+	Next *schemaT
+	M    map[string][]byte
+	F    func(int, ...string) error
+	C    chan<- int
+	Arr  [2]uint8
+	R    interface{ Read([]byte) (int, error) }
+	schemaEmbed
+}
+
+func (schemaT) Exported()   {}
+func (schemaT) unexported() {}
+
+func exportSchema(t *testing.T, opts SchemaOptions, ts ...*rtype) *schemaDoc {
+	t.Helper()
+	b, err := ExportSchema(ts, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc schemaDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("%v in %s", err, b)
+	}
+	return &doc
+}
+
+func TestExportSchema(t *testing.T) {
+	root := TypeFor[schemaT]()
+	doc := exportSchema(t, SchemaOptions{}, root)
+	if doc.Version != schemaVersion || len(doc.Roots) != 1 || doc.Roots[0].Name != root.String() {
+		t.Fatalf("version %d, roots %+v", doc.Version, doc.Roots)
+	}
+
+	// Every type is listed once, after the types it refers to but along
+	// the cycle through Next.
+	byFP := make(map[string]*schemaType)
+	pos := make(map[string]int)
+	for i, st := range doc.Types {
+		if byFP[st.Fingerprint] != nil {
+			t.Errorf("%s listed twice", st.Name)
+		}
+		byFP[st.Fingerprint], pos[st.Fingerprint] = st, i
+	}
+	for _, st := range doc.Types {
+		refs := append(append([]string{st.Key, st.Elem}, st.In...), st.Out...)
+		for _, f := range st.Fields {
+			refs = append(refs, f.Type)
+		}
+		for _, m := range st.Methods {
+			refs = append(refs, m.Type)
+		}
+		for _, r := range refs {
+			if r == "" {
+				continue
+			}
+			if byFP[r] == nil {
+				t.Errorf("%s refers to the unlisted %s", st.Name, r)
+			} else if pos[r] > pos[st.Fingerprint] && st.Name != "*reflection.schemaT" {
+				t.Errorf("%s is listed before %s, which it refers to", st.Name, byFP[r].Name)
+			}
+		}
+	}
+
+	st := byFP[doc.Roots[0].Fingerprint]
+	if st == nil || st.Kind != "struct" || st.Size != root.size || st.Align != root.align {
+		t.Fatalf("root described as %+v", st)
+	}
+	rt := reflect.TypeOf(schemaT{})
+	if len(st.Fields) != rt.NumField() {
+		t.Fatalf("%d fields, want %d", len(st.Fields), rt.NumField())
+	}
+	for i, f := range st.Fields {
+		sf := rt.Field(i)
+		if f.Name != sf.Name || f.Offset != sf.Offset || f.Tag != string(sf.Tag) || f.Embedded != sf.Anonymous {
+			t.Errorf("field %d described as %+v, want %s at %d", i, f, sf.Name, sf.Offset)
+		}
+		if ft := byFP[f.Type]; ft == nil || ft.Name != FromReflect(sf.Type).String() {
+			t.Errorf("field %s of type %+v", f.Name, ft)
+		}
+	}
+	if !reflect.DeepEqual(st.Methods, []schemaMethod{{Name: "Exported"}}) {
+		t.Errorf("methods %+v, want the exported method", st.Methods)
+	}
+
+	checks := map[string]func(*schemaType) bool{
+		"*reflection.schemaT":        func(s *schemaType) bool { return s.Elem == doc.Roots[0].Fingerprint },
+		"[2]uint8":                   func(s *schemaType) bool { return s.Len == 2 && s.Kind == "array" },
+		"chan<- int":                 func(s *schemaType) bool { return s.Dir == SendDir },
+		"func(int, ...string) error": func(s *schemaType) bool { return s.Variadic && len(s.In) == 2 && len(s.Out) == 1 },
+		"map[string][]uint8":         func(s *schemaType) bool { return s.Key != "" && byFP[s.Elem].Name == "[]uint8" },
+		"interface { Read([]uint8) (int, error) }": func(s *schemaType) bool {
+			return len(s.Methods) == 1 && s.Methods[0].Name == "Read" && byFP[s.Methods[0].Type].Kind == "func"
+		},
+	}
+	for _, s := range doc.Types {
+		if check, ok := checks[s.Name]; ok {
+			if !check(s) {
+				t.Errorf("%s described as %+v", s.Name, s)
+			}
+			delete(checks, s.Name)
+		}
+	}
+	for name := range checks {
+		t.Errorf("%s not listed", name)
+	}
+}
+
+func TestExportSchemaDeterministic(t *testing.T) {
+	ts := []*rtype{TypeFor[schemaT](), TypeFor[map[int]schemaEmbed](), TypeFor[schemaT]()}
+	a, err := ExportSchema(ts, SchemaOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ExportSchema(ts, SchemaOptions{})
+	if !bytes.Equal(a, b) {
+		t.Error("two exports of the same types differ")
+	}
+	doc := exportSchema(t, SchemaOptions{}, ts...)
+	if len(doc.Roots) != 3 || doc.Roots[0] != doc.Roots[2] {
+		t.Errorf("roots %+v", doc.Roots)
+	}
+
+	indented, err := ExportSchema(ts, SchemaOptions{Indent: "\t"})
+	if err != nil || !strings.Contains(string(indented), "\n\t\"roots\"") {
+		t.Errorf("indented schema %.40q, %v", indented, err)
+	}
+	if empty := exportSchema(t, SchemaOptions{}); empty.Roots == nil || empty.Types == nil {
+		t.Error("the schema of no types has null lists")
+	}
+}
+
+func TestExportSchemaNil(t *testing.T) {
+	var ke *KindError
+	if _, err := ExportSchema([]*rtype{nil}, SchemaOptions{}); !errors.As(err, &ke) || ke.Kind != Invalid {
+		t.Errorf("ExportSchema of nil = %v, want a *KindError", err)
+	}
+}
+
+func TestFingerprintString(t *testing.T) {
+	for fp, want := range map[uint64]string{0: "0000000000000000", 0xabc: "0000000000000abc", 1<<64 - 1: "ffffffffffffffff"} {
+		if got := fingerprintString(fp); got != want {
+			t.Errorf("fingerprintString(%#x) = %q, want %q", fp, got, want)
+		}
+	}
+}
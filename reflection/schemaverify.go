@@ -0,0 +1,373 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// ErrBadSchema is returned by VerifySchema for a document that ExportSchema
+// did not produce.
+var ErrBadSchema = errors.New("reflection: malformed schema")
+
+// MismatchCategory classifies the differences found by VerifySchema.
+type MismatchCategory uint8
+
+const (
+	// MissingRoot is a bound name that is not a root of the schema.
+	MissingRoot MismatchCategory = iota
+	// KindChanged is a type of another kind.
+	KindChanged
+	// SizeChanged is a type that grew or shrank.
+	SizeChanged
+	// AlignChanged is a type of another alignment.
+	AlignChanged
+	// LenChanged is an array type of another length.
+	LenChanged
+	// FieldMoved is a struct field at another offset.
+	FieldMoved
+	// FieldAdded is a struct field the schema does not have.
+	FieldAdded
+	// FieldRemoved is a struct field of the schema the type does not have.
+	FieldRemoved
+	// EmbeddingChanged is a struct field embedded in only one of the types.
+	EmbeddingChanged
+	// TagChanged is a struct field with another tag.
+	TagChanged
+	// TypeChanged is a type whose fingerprint differs for other reasons
+	// than the above, such as a renamed type, or another method set or
+	// func signature.
+	TypeChanged
+)
+
+var mismatchCategoryNames = []string{
+	MissingRoot:      "missing root",
+	KindChanged:      "kind changed",
+	SizeChanged:      "size changed",
+	AlignChanged:     "alignment changed",
+	LenChanged:       "length changed",
+	FieldMoved:       "field moved",
+	FieldAdded:       "field added",
+	FieldRemoved:     "field removed",
+	EmbeddingChanged: "embedding changed",
+	TagChanged:       "tag changed",
+	TypeChanged:      "type changed",
+}
+
+// String returns the name of c.
+func (c MismatchCategory) String() string {
+	if int(c) < len(mismatchCategoryNames) {
+		return mismatchCategoryNames[c]
+	}
+	return fmt.Sprintf("MismatchCategory(%d)", uint8(c))
+}
+
+// SchemaMismatch is a difference between a type of a schema and the type
+// bound to it.
+type SchemaMismatch struct {
+	Category MismatchCategory
+
+	// Root is the name the type is bound to, and Path locates the
+	// difference in it, with a dot and the name for a field, ".*" for the
+	// element of a pointer and "[]" for the element of an array or a slice,
+	// such as ".Next.*.Tags[]"; it is empty for the type itself.
+	Root, Path string
+
+	// Want and Got describe the schema and the bound sides, such as sizes,
+	// offsets or tags.
+	Want, Got string
+
+	// Fatal reports whether the difference changes the memory layout, or
+	// is made fatal by the VerifyOptions.
+	Fatal bool
+}
+
+// String returns a description of m, such as
+// "pkg.T.X: field moved from 8 to 16".
+func (m SchemaMismatch) String() string {
+	s := m.Root + m.Path + ": " + m.Category.String()
+	if m.Want != "" || m.Got != "" {
+		s += " from " + m.Want + " to " + m.Got
+	}
+	return s
+}
+
+// SchemaReport is the result of VerifySchema.
+type SchemaReport struct {
+	// Mismatches lists the differences, by root in the order of the
+	// schema then by path.
+	Mismatches []SchemaMismatch
+}
+
+// Fatal reports whether r has a fatal mismatch.
+func (r *SchemaReport) Fatal() bool {
+	for _, m := range r.Mismatches {
+		if m.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the mismatches of r, one per line.
+func (r *SchemaReport) String() string {
+	var b strings.Builder
+	for _, m := range r.Mismatches {
+		b.WriteString(m.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// VerifyOptions controls VerifySchema.
+type VerifyOptions struct {
+	// Fingerprint must be the options of the fingerprints of the schema,
+	// the SchemaOptions.Fingerprint it was exported with.
+	Fingerprint FingerprintOptions
+
+	// StrictTags makes TagChanged mismatches fatal.
+	StrictTags bool
+
+	// StrictTypes makes TypeChanged mismatches fatal.
+	StrictTypes bool
+}
+
+// VerifySchema checks the types bound by name in bind against the roots of
+// the same names of the schema doc, as exported by ExportSchema, and reports
+// how they differ, recursively through the types they refer to. Differences
+// of memory layout are fatal, while changed tags and other fingerprint
+// differences, such as of type or method names, are not. Struct fields are
+// matched by name, or by index if the fingerprints ignore field names, and
+// the types that maps, channels and funcs refer to are only compared by
+// fingerprint, as they are not part of the layout. A bound name that is not
+// a root of the schema is a fatal MissingRoot mismatch; the roots left
+// unbound are not checked.
+//
+// VerifySchema returns an error wrapping ErrBadSchema if doc is not a schema
+// of a supported version.
+func VerifySchema(doc []byte, bind map[string]*rtype) (*SchemaReport, error) {
+	var o VerifyOptions
+	return o.VerifySchema(doc, bind)
+}
+
+// VerifySchema is like the top-level VerifySchema, with the options of o.
+func (o *VerifyOptions) VerifySchema(doc []byte, bind map[string]*rtype) (*SchemaReport, error) {
+	var d schemaDoc
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSchema, err)
+	}
+	if d.Version != schemaVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrBadSchema, d.Version)
+	}
+	v := schemaVerifier{
+		VerifyOptions: *o,
+		types:         make(map[string]*schemaType, len(d.Types)),
+		fingerprints:  make(map[*rtype]string),
+	}
+	for _, st := range d.Types {
+		v.types[st.Fingerprint] = st
+	}
+
+	report := new(SchemaReport)
+	roots := make(map[string]bool, len(d.Roots))
+	for _, r := range d.Roots {
+		if roots[r.Name] {
+			continue
+		}
+		roots[r.Name] = true
+		t, ok := bind[r.Name]
+		if !ok {
+			continue
+		}
+		if t == nil {
+			return nil, &KindError{Op: "VerifySchema", Kind: Invalid}
+		}
+		v.root = r.Name
+		v.mismatches = nil
+		v.seen = make(map[schemaPair]bool)
+		if err := v.compare(r.Fingerprint, t, ""); err != nil {
+			return nil, err
+		}
+		sort.SliceStable(v.mismatches, func(i, j int) bool {
+			return v.mismatches[i].Path < v.mismatches[j].Path
+		})
+		report.Mismatches = append(report.Mismatches, v.mismatches...)
+	}
+
+	var missing []string
+	for name := range bind {
+		if !roots[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		report.Mismatches = append(report.Mismatches, SchemaMismatch{Category: MissingRoot, Root: name, Fatal: true})
+	}
+	return report, nil
+}
+
+// schemaPair is a type of a schema, by fingerprint, compared to a type.
+type schemaPair struct {
+	fp string
+	t  *rtype
+}
+
+// schemaVerifier compares the types of a schema to live types.
+type schemaVerifier struct {
+	VerifyOptions
+	types        map[string]*schemaType
+	fingerprints map[*rtype]string
+
+	// seen holds the pairs compared so far for the root, so that
+	// recursive types are compared once.
+	seen map[schemaPair]bool
+
+	root       string
+	mismatches []SchemaMismatch
+}
+
+func (v *schemaVerifier) fingerprint(t *rtype) string {
+	fp, ok := v.fingerprints[t]
+	if !ok {
+		fp = fingerprintString(v.Fingerprint.Fingerprint(t))
+		v.fingerprints[t] = fp
+	}
+	return fp
+}
+
+func (v *schemaVerifier) report(c MismatchCategory, path string, want, got interface{}, fatal bool) {
+	m := SchemaMismatch{Category: c, Root: v.root, Path: path, Fatal: fatal}
+	if want != nil {
+		m.Want, m.Got = fmt.Sprint(want), fmt.Sprint(got)
+	}
+	v.mismatches = append(v.mismatches, m)
+}
+
+// compare compares the type of fingerprint fp of the schema to t, at path.
+func (v *schemaVerifier) compare(fp string, t *rtype, path string) error {
+	if fp == v.fingerprint(t) {
+		return nil
+	}
+	p := schemaPair{fp, t}
+	if v.seen[p] {
+		return nil
+	}
+	v.seen[p] = true
+	st, ok := v.types[fp]
+	if !ok {
+		return fmt.Errorf("%w: type %s not found", ErrBadSchema, fp)
+	}
+
+	if k := t.Kind().String(); st.Kind != k {
+		v.report(KindChanged, path, st.Kind, k, true)
+		return nil
+	}
+	if st.Size != t.size {
+		v.report(SizeChanged, path, st.Size, t.size, true)
+	}
+	if st.Align != t.align {
+		v.report(AlignChanged, path, st.Align, t.align, true)
+	}
+	if !v.Fingerprint.IgnoreTypeNames && (st.Name != t.String() || !sameMethodNames(st, t)) {
+		v.report(TypeChanged, path, st.Name, t.String(), v.StrictTypes)
+	}
+
+	switch t.Kind() {
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		if st.Len != at.Len {
+			v.report(LenChanged, path, st.Len, at.Len, true)
+		}
+		return v.compare(st.Elem, at.Elem, path+"[]")
+	case Ptr:
+		return v.compare(st.Elem, (*PtrType)(unsafe.Pointer(t)).Elem, path+".*")
+	case Slice:
+		return v.compare(st.Elem, (*SliceType)(unsafe.Pointer(t)).Elem, path+"[]")
+	case Struct:
+		return v.compareFields(st, (*StructType)(unsafe.Pointer(t)), path)
+	case Chan, Func, Interface, Map:
+		// The types these refer to are not part of their layout: report
+		// the difference, unless reported above.
+		if n := len(v.mismatches); n == 0 || v.mismatches[n-1].Path != path {
+			v.report(TypeChanged, path, st.Name, t.String(), v.StrictTypes)
+		}
+	}
+	return nil
+}
+
+// sameMethodNames reports whether the schema type st lists the names of the
+// methods of t.
+func sameMethodNames(st *schemaType, t *rtype) bool {
+	var names []string
+	if t.Kind() == Interface {
+		for _, m := range (*InterfaceType)(unsafe.Pointer(t)).Methods {
+			names = append(names, t.NameOff(m.Name).Name())
+		}
+	} else if u := t.Uncommon(); u != nil {
+		for _, m := range u.Methods()[:u.Xcount] {
+			names = append(names, t.NameOff(m.Name).Name())
+		}
+	}
+	if len(names) != len(st.Methods) {
+		return false
+	}
+	for i, name := range names {
+		if st.Methods[i].Name != name {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *schemaVerifier) compareFields(st *schemaType, t *StructType, path string) error {
+	// Fields are matched by name, or by index if the fingerprints ignore
+	// the names.
+	key := func(i int, name string) string {
+		if v.Fingerprint.IgnoreFieldNames {
+			return strconv.Itoa(i)
+		}
+		return name
+	}
+	fields := make(map[string]*StructField, len(t.Fields))
+	for i := range t.Fields {
+		fields[key(i, t.Fields[i].Name.Name())] = &t.Fields[i]
+	}
+	for i, sf := range st.Fields {
+		fpath := path + "." + sf.Name
+		k := key(i, sf.Name)
+		f, ok := fields[k]
+		if !ok {
+			v.report(FieldRemoved, fpath, nil, nil, true)
+			continue
+		}
+		delete(fields, k)
+		if sf.Offset != f.Offset() {
+			v.report(FieldMoved, fpath, sf.Offset, f.Offset(), true)
+		}
+		if sf.Embedded != f.Embedded() {
+			v.report(EmbeddingChanged, fpath, sf.Embedded, f.Embedded(), true)
+		}
+		if tag := f.Name.Tag(); sf.Tag != tag && !v.Fingerprint.IgnoreTags {
+			v.report(TagChanged, fpath, strconv.Quote(sf.Tag), strconv.Quote(tag), v.StrictTags)
+		}
+		if err := v.compare(sf.Type, f.typ, fpath); err != nil {
+			return err
+		}
+	}
+	for i := range t.Fields {
+		name := t.Fields[i].Name.Name()
+		if fields[key(i, name)] != nil {
+			v.report(FieldAdded, path+"."+name, nil, nil, true)
+		}
+	}
+	return nil
+}
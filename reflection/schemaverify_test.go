@@ -0,0 +1,201 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fixtures below are versions of a type verifyT, each declared in a
+// function of its own, as the type would be in two builds of a program.
+
+func verifyBase() *rtype {
+	type verifyT struct {
+		A    int32 `json:"a"`
+		B    [2]int32
+		Next *verifyT
+		E    struct{ X int }
+		M    map[string]int
+	}
+	return TypeFor[verifyT]()
+}
+
+func verifySame() *rtype {
+	type verifyT struct {
+		A    int32 `json:"a"`
+		B    [2]int32
+		Next *verifyT
+		E    struct{ X int }
+		M    map[string]int
+	}
+	return TypeFor[verifyT]()
+}
+
+func verifyTag() *rtype {
+	type verifyT struct {
+		A    int32 `json:"aa"`
+		B    [2]int32
+		Next *verifyT
+		E    struct{ X int }
+		M    map[string]int
+	}
+	return TypeFor[verifyT]()
+}
+
+func verifyLayout() *rtype {
+	type verifyT struct {
+		Z    int64
+		A    int32 `json:"a"`
+		B    [3]int32
+		Next *verifyT
+		M    map[int]int
+	}
+	return TypeFor[verifyT]()
+}
+
+// verifyRenamed has the layout of verifyT under another name.
+type verifyRenamed struct {
+	A    int32 `json:"a"`
+	B    [2]int32
+	Next *verifyRenamed
+	E    struct{ X int }
+	M    map[string]int
+}
+
+func verify(t *testing.T, o VerifyOptions, schema []*rtype, bind map[string]*rtype) *SchemaReport {
+	t.Helper()
+	doc, err := ExportSchema(schema, SchemaOptions{Fingerprint: o.Fingerprint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := o.VerifySchema(doc, bind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestVerifySchema(t *testing.T) {
+	base := verifyBase()
+	name := base.String()
+	offset := func(t *rtype, field string) uintptr {
+		f, _ := ToReflect(t).FieldByName(field)
+		return f.Offset
+	}
+	layout := verifyLayout()
+	// The offsets past the pointer fields depend on the platform.
+	var lines []string
+	add := func(format string, want, got uintptr) {
+		if want != got {
+			lines = append(lines, fmt.Sprintf(format, want, got))
+		}
+	}
+	add(": size changed from %d to %d", base.size, layout.size)
+	lines = append(lines,
+		".A: field moved from 0 to 8",
+		".B: field moved from 4 to 12",
+		".B: size changed from 8 to 12",
+		".B: type changed from [2]int32 to [3]int32",
+		".B: length changed from 2 to 3",
+		".E: field removed",
+	)
+	add(".M: field moved from %d to %d", offset(base, "M"), offset(layout, "M"))
+	lines = append(lines, ".M: type changed from map[string]int to map[int]int")
+	add(".Next: field moved from %d to %d", offset(base, "Next"), offset(layout, "Next"))
+	lines = append(lines, ".Z: field added")
+	tests := []struct {
+		name  string
+		opts  VerifyOptions
+		bind  *rtype
+		want  []string
+		fatal bool
+	}{
+		{"same", VerifyOptions{}, verifySame(), nil, false},
+		{"self", VerifyOptions{}, base, nil, false},
+		{"tag", VerifyOptions{}, verifyTag(), []string{`.A: tag changed from "json:\"a\"" to "json:\"aa\""`}, false},
+		{"strict tag", VerifyOptions{StrictTags: true}, verifyTag(), []string{`.A: tag changed from "json:\"a\"" to "json:\"aa\""`}, true},
+		{"ignored tag", VerifyOptions{Fingerprint: FingerprintOptions{IgnoreTags: true}}, verifyTag(), nil, false},
+		{"layout", VerifyOptions{}, layout, lines, true},
+		{"kind", VerifyOptions{}, TypeFor[int](), []string{": kind changed from struct to int"}, true},
+		{"renamed", VerifyOptions{}, TypeFor[verifyRenamed](), []string{
+			": type changed from reflection.verifyT to reflection.verifyRenamed",
+			".Next: type changed from *reflection.verifyT to *reflection.verifyRenamed",
+		}, false},
+		{"strict renamed", VerifyOptions{StrictTypes: true}, TypeFor[verifyRenamed](), []string{
+			": type changed from reflection.verifyT to reflection.verifyRenamed",
+			".Next: type changed from *reflection.verifyT to *reflection.verifyRenamed",
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := verify(t, tt.opts, []*rtype{base}, map[string]*rtype{name: tt.bind})
+			var got []string
+			for _, m := range r.Mismatches {
+				if m.Root != name {
+					t.Errorf("mismatch of root %q", m.Root)
+				}
+				got = append(got, strings.TrimPrefix(m.String(), name))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mismatches:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if r.Fatal() != tt.fatal {
+				t.Errorf("Fatal() = %v, want %v", r.Fatal(), tt.fatal)
+			}
+		})
+	}
+}
+
+func TestVerifySchemaRoots(t *testing.T) {
+	base := verifyBase()
+	r := verify(t, VerifyOptions{}, []*rtype{base, TypeFor[int]()}, map[string]*rtype{
+		"int":    TypeFor[int](),
+		"absent": TypeFor[string](),
+	})
+	want := []SchemaMismatch{{Category: MissingRoot, Root: "absent", Fatal: true}}
+	if !reflect.DeepEqual(r.Mismatches, want) {
+		t.Errorf("mismatches %+v, want %+v", r.Mismatches, want)
+	}
+	if got := r.String(); got != "absent: missing root\n" {
+		t.Errorf("String() = %q", got)
+	}
+
+	doc, _ := ExportSchema([]*rtype{base}, SchemaOptions{})
+	var ke *KindError
+	if _, err := VerifySchema(doc, map[string]*rtype{base.String(): nil}); !errors.As(err, &ke) {
+		t.Errorf("VerifySchema of a nil type = %v, want a *KindError", err)
+	}
+}
+
+func TestVerifySchemaBad(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"json", `{"version":`},
+		{"version", `{"version":2,"roots":[],"types":[]}`},
+		{"dangling", `{"version":1,"roots":[{"name":"int","fingerprint":"00"}],"types":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifySchema([]byte(tt.doc), map[string]*rtype{"int": TypeFor[int]()}); !errors.Is(err, ErrBadSchema) {
+				t.Errorf("VerifySchema = %v, want ErrBadSchema", err)
+			}
+		})
+	}
+}
+
+func TestMismatchCategoryString(t *testing.T) {
+	if got := FieldMoved.String(); got != "field moved" {
+		t.Errorf("FieldMoved.String() = %q", got)
+	}
+	if got := MismatchCategory(200).String(); got != "MismatchCategory(200)" {
+		t.Errorf("MismatchCategory(200).String() = %q", got)
+	}
+}
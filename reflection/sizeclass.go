@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sort"
+)
+
+// Size class constants of the runtime memory allocator, from
+// runtime/sizeclasses.go.
+const (
+	maxSmallSize   = 32768
+	numSizeClasses = 68
+	allocPageSize  = 8192
+)
+
+// classToSize is the object size of each size class, from
+// runtime/sizeclasses.go. Class 0 is used for large objects.
+var classToSize = [numSizeClasses]uint16{
+	0, 8, 16, 24, 32, 48, 64, 80, 96, 112, 128, 144, 160, 176, 192, 208, 224,
+	240, 256, 288, 320, 352, 384, 416, 448, 480, 512, 576, 640, 704, 768, 896,
+	1024, 1152, 1280, 1408, 1536, 1792, 2048, 2304, 2688, 3072, 3200, 3456,
+	4096, 4864, 5376, 6144, 6528, 6784, 6912, 8192, 9472, 9728, 10240, 10880,
+	12288, 13568, 14336, 16384, 18432, 19072, 20480, 21760, 24576, 27264, 28672,
+	32768,
+}
+
+// SizeClassOf returns the size class of the runtime allocator serving
+// objects of the given size, and the size of its objects. ok is false for
+// sizes not served by a size class: zero, and sizes over 32 KiB, which are
+// allocated in whole pages.
+//
+// It does not account for the malloc header that objects with pointers may
+// need in front of them; see RoundUpAllocSize.
+func SizeClassOf(size uintptr) (class int, objectSize uintptr, ok bool) {
+	if size == 0 || size > maxSmallSize {
+		return 0, 0, false
+	}
+	class = sort.Search(numSizeClasses, func(i int) bool {
+		return uintptr(classToSize[i]) >= size
+	})
+	return class, uintptr(classToSize[class]), true
+}
+
+// RoundUpAllocSize returns the size of the memory the runtime allocates for
+// an object of the given size, which is where the tail space past size
+// comes from. noscan tells that the object holds no pointers.
+//
+// Small objects are rounded up to the size of their size class, after
+// adding the header that objects with pointers larger than 512 bytes carry
+// since Go 1.22, which is not part of the returned size. Large objects are
+// rounded up to whole pages. Sizes below 16 bytes without pointers may be
+// packed together by the tiny allocator, and use less.
+func RoundUpAllocSize(size uintptr, noscan bool) uintptr {
+	if size <= maxSmallSize-mallocHeaderSize {
+		req := size
+		if !noscan && req > minSizeForMallocHeader {
+			req += mallocHeaderSize
+		}
+		if req == 0 {
+			return 0
+		}
+		_, n, _ := SizeClassOf(req)
+		return n - (req - size)
+	}
+	req := size + allocPageSize - 1
+	if req < size {
+		return size
+	}
+	return req &^ (allocPageSize - 1)
+}
@@ -0,0 +1,13 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.22
+
+package reflection
+
+// Objects have no malloc header before Go 1.22.
+const (
+	mallocHeaderSize       = 0
+	minSizeForMallocHeader = ^uintptr(0)
+)
@@ -0,0 +1,14 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.22
+
+package reflection
+
+// Small objects with pointers of more than minSizeForMallocHeader bytes are
+// preceded by a header pointing to their type, of mallocHeaderSize bytes.
+const (
+	mallocHeaderSize       = 8
+	minSizeForMallocHeader = ptrSize * ptrSize * 8
+)
@@ -0,0 +1,54 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"testing"
+)
+
+func TestSizeClassOf(t *testing.T) {
+	for _, size := range []uintptr{0, maxSmallSize + 1, 1 << 20} {
+		if class, n, ok := SizeClassOf(size); ok {
+			t.Errorf("SizeClassOf(%d) = %d, %d, true, want false", size, class, n)
+		}
+	}
+	prev := 0
+	for size := uintptr(1); size <= maxSmallSize; size++ {
+		class, n, ok := SizeClassOf(size)
+		if !ok || class < prev || class >= numSizeClasses || n < size {
+			t.Fatalf("SizeClassOf(%d) = %d, %d, %v", size, class, n, ok)
+		}
+		if class > 1 && uintptr(classToSize[class-1]) >= size {
+			t.Fatalf("SizeClassOf(%d) = %d, but class %d of size %d fits", size, class, class-1, classToSize[class-1])
+		}
+		prev = class
+	}
+	if class, n, _ := SizeClassOf(maxSmallSize); class != numSizeClasses-1 || n != maxSmallSize {
+		t.Errorf("SizeClassOf(%d) = %d, %d, want the last class", maxSmallSize, class, n)
+	}
+}
+
+// TestRoundUpAllocSize checks RoundUpAllocSize against the capacity append
+// gives a new slice, which the runtime rounds up to the allocated size.
+func TestRoundUpAllocSize(t *testing.T) {
+	sizes := []uintptr{0, 1, 7, 8, 15, 16, 17, 100, 500, 511, 512, 513, 520, 1000, 4000, 8190, 9000}
+	for size := uintptr(maxSmallSize - 32); size <= maxSmallSize+16; size += 8 {
+		sizes = append(sizes, size)
+	}
+	sizes = append(sizes, 100000, 1<<20+1)
+	for _, size := range sizes {
+		b := append([]byte(nil), make([]byte, size)...)
+		if got, want := RoundUpAllocSize(size, true), uintptr(cap(b)); got != want {
+			t.Errorf("RoundUpAllocSize(%d, true) = %d, want %d", size, got, want)
+		}
+		if size%ptrSize != 0 {
+			continue
+		}
+		p := append([]*int(nil), make([]*int, size/ptrSize)...)
+		if got, want := RoundUpAllocSize(size, false), uintptr(cap(p))*ptrSize; got != want {
+			t.Errorf("RoundUpAllocSize(%d, false) = %d, want %d", size, got, want)
+		}
+	}
+}
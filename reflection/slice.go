@@ -0,0 +1,60 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// RangeSlice calls fn for each element of the slice held by s, in order,
+// with the element index and a pointer to the element in the backing array.
+// Writes through the pointer are visible to the caller. If fn returns false,
+// RangeSlice stops the iteration.
+//
+// Unlike reflect.Value.Index, no allocation happens per element.
+// Nil and empty slices call fn zero times. If s does not hold a slice,
+// RangeSlice returns a *KindError.
+func RangeSlice(s interface{}, fn func(i int, elemPtr unsafe.Pointer) bool) error {
+	e := efaceOf(&s)
+	if k := kindOf(e.Type); k != Slice {
+		return &KindError{Op: "RangeSlice", Kind: k}
+	}
+	elem := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	hdr := (*SliceHeader)(e.Word)
+	for i := 0; i < hdr.Len; i++ {
+		p := Add(hdr.Data, uintptr(i)*elem.size, "i < len")
+		if !fn(i, p) {
+			break
+		}
+	}
+	return nil
+}
+
+// TypedSliceCopy copies elements of type elem from src to dst, as the
+// built-in copy does, including when the slices overlap, and returns the
+// number of elements copied, the minimum of their lengths.
+//
+// Elements without pointers are moved as plain memory; otherwise the write
+// barriers the garbage collector requires are applied.
+func TypedSliceCopy(elem *rtype, dst, src SliceHeader) int {
+	if !HasPointers(elem) {
+		n := dst.Len
+		if src.Len < n {
+			n = src.Len
+		}
+		if n > 0 && elem.size > 0 {
+			copy(unsafe.Slice((*byte)(dst.Data), uintptr(n)*elem.size), unsafe.Slice((*byte)(src.Data), uintptr(n)*elem.size))
+		}
+		return n
+	}
+	return typedslicecopy(elem, dst, src)
+}
+
+//go:linkname typedslicecopy reflect.typedslicecopy
+
+// typedslicecopy copies a slice of elemType values from src to dst,
+// returning the number of elements copied.
+// Implemented in the runtime package.
+func typedslicecopy(elemType *rtype, dst, src SliceHeader) int
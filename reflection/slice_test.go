@@ -0,0 +1,173 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type sliceElem struct {
+	A int64
+	B string
+	C [3]byte
+}
+
+func TestRangeSlice(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      interface{}
+		stop    int // stop after this many elements, or -1
+		want    []int
+		wantErr bool
+	}{
+		{"elements", []sliceElem{{A: 0}, {A: 1}, {A: 2}}, -1, []int{0, 1, 2}, false},
+		{"stop", []sliceElem{{A: 0}, {A: 1}, {A: 2}}, 2, []int{0, 1}, false},
+		{"empty", []sliceElem{}, -1, nil, false},
+		{"nil slice", []sliceElem(nil), -1, nil, false},
+		{"zero-sized", make([]struct{}, 2), -1, []int{0, 1}, false},
+		{"array", [2]sliceElem{}, -1, nil, true},
+		{"pointer", &[]sliceElem{{}}, -1, nil, true},
+		{"nil", nil, -1, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			err := RangeSlice(tt.in, func(i int, p unsafe.Pointer) bool {
+				got = append(got, i)
+				if e, ok := tt.in.([]sliceElem); ok && p != unsafe.Pointer(&e[i]) {
+					t.Errorf("element %d at %p, want %p", i, p, &e[i])
+				}
+				return len(got) != tt.stop
+			})
+			var ke *KindError
+			if tt.wantErr != errors.As(err, &ke) {
+				t.Fatalf("RangeSlice error = %v, want a *KindError: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RangeSlice visited %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeSliceWrites(t *testing.T) {
+	s := []sliceElem{{A: 1}, {A: 2}}
+	err := RangeSlice(s, func(i int, p unsafe.Pointer) bool {
+		(*sliceElem)(p).A *= 10
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s[0].A != 10 || s[1].A != 20 {
+		t.Errorf("writes through the element pointers not seen: %v", s)
+	}
+}
+
+func BenchmarkRangeSlice(b *testing.B) {
+	var s interface{} = make([]sliceElem, 1024)
+	b.Run("RangeSlice", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			var sum int64
+			RangeSlice(s, func(i int, p unsafe.Pointer) bool {
+				sum += (*sliceElem)(p).A
+				return true
+			})
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			var sum int64
+			v := reflect.ValueOf(s)
+			for i := 0; i < v.Len(); i++ {
+				sum += v.Index(i).Interface().(sliceElem).A
+			}
+		}
+	})
+}
+
+func TestTypedSliceCopy(t *testing.T) {
+	hdr := func(p unsafe.Pointer) SliceHeader { return *(*SliceHeader)(p) }
+
+	t.Run("pointers", func(t *testing.T) {
+		src := []sliceElem{{A: 1, B: "one"}, {A: 2, B: "two"}, {A: 3, B: "three"}}
+		dst := make([]sliceElem, 2)
+		if n := TypedSliceCopy(TypeFor[sliceElem](), hdr(unsafe.Pointer(&dst)), hdr(unsafe.Pointer(&src))); n != 2 {
+			t.Errorf("TypedSliceCopy = %d, want 2", n)
+		}
+		if !reflect.DeepEqual(dst, src[:2]) {
+			t.Errorf("dst = %v, want %v", dst, src[:2])
+		}
+	})
+
+	t.Run("no pointers", func(t *testing.T) {
+		src := []int64{1, 2}
+		dst := make([]int64, 3)
+		if n := TypedSliceCopy(TypeFor[int64](), hdr(unsafe.Pointer(&dst)), hdr(unsafe.Pointer(&src))); n != 2 {
+			t.Errorf("TypedSliceCopy = %d, want 2", n)
+		}
+		if want := []int64{1, 2, 0}; !reflect.DeepEqual(dst, want) {
+			t.Errorf("dst = %v, want %v", dst, want)
+		}
+	})
+
+	// Overlapping slices are copied as the built-in copy does.
+	for _, shift := range []int{1, -1} {
+		s := []string{"a", "b", "c", "d"}
+		want := append([]string(nil), s...)
+		dst, src := s[1:], s[:3]
+		wd, ws := want[1:], want[:3]
+		if shift < 0 {
+			dst, src = src, dst
+			wd, ws = ws, wd
+		}
+		copy(wd, ws)
+		if n := TypedSliceCopy(TypeFor[string](), hdr(unsafe.Pointer(&dst)), hdr(unsafe.Pointer(&src))); n != 3 {
+			t.Errorf("overlap %d: TypedSliceCopy = %d, want 3", shift, n)
+		}
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("overlap %d: slice = %v, want %v", shift, s, want)
+		}
+	}
+
+	// Zero-sized elements and empty slices copy nothing but report the count.
+	zs := make([]struct{}, 2)
+	if n := TypedSliceCopy(TypeFor[struct{}](), hdr(unsafe.Pointer(&zs)), hdr(unsafe.Pointer(&zs))); n != 2 {
+		t.Errorf("zero-sized: TypedSliceCopy = %d, want 2", n)
+	}
+	var empty []string
+	if n := TypedSliceCopy(TypeFor[string](), SliceHeader{}, hdr(unsafe.Pointer(&empty))); n != 0 {
+		t.Errorf("empty: TypedSliceCopy = %d, want 0", n)
+	}
+}
+
+func BenchmarkTypedSliceCopy(b *testing.B) {
+	src := make([]string, 1024)
+	for i := range src {
+		src[i] = "element"
+	}
+	dst := make([]string, len(src))
+	elem := TypeFor[string]()
+	d, s := *(*SliceHeader)(unsafe.Pointer(&dst)), *(*SliceHeader)(unsafe.Pointer(&src))
+	b.Run("TypedSliceCopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			TypedSliceCopy(elem, d, s)
+		}
+	})
+	b.Run("TypedMemmove", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			for i := range src {
+				TypedMemmove(elem, unsafe.Pointer(&dst[i]), unsafe.Pointer(&src[i]))
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// EqualSlice reports whether the slices held by a and b, which must be of the
+// same type, have the same length and equal elements, as comparing their
+// elements with == would.
+//
+// The elements of a type with TflagRegularMemory, which has no padding and
+// no float, string or interface parts, are compared by a single Memequal of
+// the backing arrays; other elements one at a time with the equal function of
+// their type.
+//
+// EqualSlice returns a *KindError if a or b does not hold a slice, an error
+// wrapping ErrTypeMismatch if their types differ, and ErrUncomparable if the
+// element type, or the dynamic type of an interface in an element, is not
+// comparable.
+func EqualSlice(a, b interface{}) (eq bool, err error) {
+	x, y := efaceOf(&a), efaceOf(&b)
+	if k := kindOf(x.Type); k != Slice {
+		return false, &KindError{Op: "EqualSlice", Kind: k}
+	}
+	if x.Type != y.Type {
+		return false, fmt.Errorf("%w: EqualSlice of %s and %s", ErrTypeMismatch, x.Type, y.Type)
+	}
+	et := (*SliceType)(unsafe.Pointer(x.Type)).Elem
+	equal := EqualFunc(et)
+	if equal == nil {
+		return false, ErrUncomparable
+	}
+	sa, sb := (*SliceHeader)(x.Word), (*SliceHeader)(y.Word)
+	if sa.Len != sb.Len {
+		return false, nil
+	}
+	if sa.Len == 0 || et.size == 0 {
+		return true, nil
+	}
+	if et.tflag&TflagRegularMemory != 0 {
+		return sa.Data == sb.Data || memequal(sa.Data, sb.Data, uintptr(sa.Len)*et.size), nil
+	}
+	defer recoverRuntimeError(&err, ErrUncomparable)
+	for i := 0; i < sa.Len; i++ {
+		off := uintptr(i) * et.size
+		if !equal(Add(sa.Data, off, "i < len"), Add(sb.Data, off, "i < len")) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HashSlice returns a hash of the length and the elements of the slice held by
+// s with the given seed, consistent with EqualSlice: slices that EqualSlice
+// reports equal have the same hash, whatever their capacities.
+//
+// The elements of a type with TflagRegularMemory are hashed by a single
+// MemHash of the backing array; other elements one at a time as the runtime
+// hashes map keys. HashSlice returns a *KindError if s does not hold a slice,
+// and ErrUnhashable if the element type, or the dynamic type of an interface
+// in an element, is not hashable.
+func HashSlice(s interface{}, seed uintptr) (h uintptr, err error) {
+	e := efaceOf(&s)
+	if k := kindOf(e.Type); k != Slice {
+		return 0, &KindError{Op: "HashSlice", Kind: k}
+	}
+	et := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	if !IsComparable(et) {
+		return 0, ErrUnhashable
+	}
+	hdr := (*SliceHeader)(e.Word)
+	x := memhash(unsafe.Pointer(&hdr.Len), seed, unsafe.Sizeof(hdr.Len))
+	if hdr.Len == 0 || et.size == 0 {
+		return x, nil
+	}
+	if et.tflag&TflagRegularMemory != 0 {
+		return memhash(hdr.Data, x, uintptr(hdr.Len)*et.size), nil
+	}
+	defer recoverRuntimeError(&err, ErrUnhashable)
+	for i := 0; i < hdr.Len; i++ {
+		x = typehash(et, Add(hdr.Data, uintptr(i)*et.size, "i < len"), x)
+	}
+	return x, nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package reflection
+
+import (
+	"slices"
+	"testing"
+)
+
+func BenchmarkEqualSliceStd(b *testing.B) {
+	b.Run("int64/slices.Equal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = slices.Equal(benchInt64sA, benchInt64sB)
+		}
+	})
+	b.Run("string/slices.Equal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = slices.Equal(benchStringsA, benchStringsB)
+		}
+	})
+}
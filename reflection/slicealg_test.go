@@ -0,0 +1,173 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type slicePadded struct {
+	A int8
+	B int64
+}
+
+func TestEqualSlice(t *testing.T) {
+	nan := math.NaN()
+	ints := []int{1, 2, 3}
+	tests := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"ints", []int{1, 2, 3}, []int{1, 2, 3}, true},
+		{"ints differ", []int{1, 2, 3}, []int{1, 2, 4}, false},
+		{"lengths", []int{1, 2}, []int{1, 2, 3}, false},
+		{"same array", ints, ints[:3:3], true},
+		{"nil and empty", []int(nil), []int{}, true},
+		{"floats", []float64{0, 1}, []float64{math.Copysign(0, -1), 1}, true},
+		{"nan", []float64{nan}, []float64{nan}, false},
+		{"strings", []string{"a", "bc"}, []string{"a", "b" + "c"}, true},
+		{"strings differ", []string{"a", "bc"}, []string{"a", "bd"}, false},
+		{"padded", []slicePadded{{1, 2}}, []slicePadded{{1, 2}}, true},
+		{"interfaces", []interface{}{1, "a", nil}, []interface{}{1, "a", nil}, true},
+		{"interface types", []interface{}{1}, []interface{}{int64(1)}, false},
+		{"zero size", []struct{}{{}, {}}, []struct{}{{}, {}}, true},
+		{"arrays", [][2]int{{1, 2}}, [][2]int{{1, 2}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := EqualSlice(tt.a, tt.b); err != nil || got != tt.want {
+				t.Errorf("EqualSlice = %v, %v, want %v", got, err, tt.want)
+			}
+			if got, err := EqualSlice(tt.b, tt.a); err != nil || got != tt.want {
+				t.Errorf("EqualSlice swapped = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualSliceErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := EqualSlice(1, 1); !errors.As(err, &ke) || ke.Kind != Int {
+		t.Errorf("EqualSlice of ints = %v, want a *KindError", err)
+	}
+	if _, err := EqualSlice([]int{}, []int64{}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("EqualSlice of []int and []int64 = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := EqualSlice([]func(){}, []func(){}); !errors.Is(err, ErrUncomparable) {
+		t.Errorf("EqualSlice of funcs = %v, want ErrUncomparable", err)
+	}
+	a, b := []interface{}{[]int{1}}, []interface{}{[]int{1}}
+	if _, err := EqualSlice(a, b); !errors.Is(err, ErrUncomparable) {
+		t.Errorf("EqualSlice of interfaces holding slices = %v, want ErrUncomparable", err)
+	}
+}
+
+func TestHashSlice(t *testing.T) {
+	const seed = 0x1234
+	hash := func(s interface{}) uintptr {
+		t.Helper()
+		h, err := HashSlice(s, seed)
+		if err != nil {
+			t.Fatalf("HashSlice(%v) = %v", s, err)
+		}
+		return h
+	}
+	big := make([]int, 3, 100)
+	copy(big, []int{1, 2, 3})
+	equal := [][2]interface{}{
+		{[]int{1, 2, 3}, big},
+		{[]int(nil), []int{}},
+		{[]float64{0}, []float64{math.Copysign(0, -1)}},
+		{[]string{"ab", "c"}, []string{"a" + "b", "c"}},
+		{[]slicePadded{{1, 2}}, []slicePadded{{1, 2}}},
+		{[]interface{}{1, "a"}, []interface{}{1, "a"}},
+		{[]struct{}{{}, {}}, make([]struct{}, 2)},
+	}
+	for _, p := range equal {
+		if hash(p[0]) != hash(p[1]) {
+			t.Errorf("HashSlice(%v) != HashSlice(%v)", p[0], p[1])
+		}
+	}
+	// Distinct values are expected to hash differently.
+	distinct := []interface{}{[]int{1, 2, 3}, []int{1, 2}, []int{3, 2, 1}, []int{}, []struct{}{{}}}
+	seen := make(map[uintptr]interface{})
+	for _, s := range distinct {
+		h := hash(s)
+		if prev, ok := seen[h]; ok {
+			t.Errorf("HashSlice(%v) == HashSlice(%v)", s, prev)
+		}
+		seen[h] = s
+	}
+	if h1, _ := HashSlice([]int{1}, 1); h1 == hash([]int{1}) {
+		t.Error("HashSlice ignores the seed")
+	}
+}
+
+func TestHashSliceErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := HashSlice("s", 0); !errors.As(err, &ke) || ke.Kind != String {
+		t.Errorf("HashSlice of a string = %v, want a *KindError", err)
+	}
+	if _, err := HashSlice([]map[int]int{}, 0); !errors.Is(err, ErrUnhashable) {
+		t.Errorf("HashSlice of maps = %v, want ErrUnhashable", err)
+	}
+	if _, err := HashSlice([]interface{}{[]int{}}, 0); !errors.Is(err, ErrUnhashable) {
+		t.Errorf("HashSlice of an interface holding a slice = %v, want ErrUnhashable", err)
+	}
+}
+
+// Equal but distinct slices compared by the EqualSlice benchmarks.
+var (
+	benchInt64sA, benchInt64sB   = benchInt64s(), benchInt64s()
+	benchStringsA, benchStringsB = benchStrings(), benchStrings()
+)
+
+func benchInt64s() []int64 {
+	s := make([]int64, 1024)
+	for i := range s {
+		s[i] = int64(i) * 7919
+	}
+	return s
+}
+
+func benchStrings() []string {
+	s := make([]string, 1024)
+	for i := range s {
+		s[i] = "element-" + strconv.Itoa(i)
+	}
+	return s
+}
+
+func BenchmarkEqualSlice(b *testing.B) {
+	b.Run("int64/EqualSlice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool, _ = EqualSlice(benchInt64sA, benchInt64sB)
+		}
+	})
+	b.Run("int64/reflect.DeepEqual", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = reflect.DeepEqual(benchInt64sA, benchInt64sB)
+		}
+	})
+	b.Run("string/EqualSlice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool, _ = EqualSlice(benchStringsA, benchStringsB)
+		}
+	})
+	b.Run("string/reflect.DeepEqual", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchBool = reflect.DeepEqual(benchStringsA, benchStringsB)
+		}
+	})
+}
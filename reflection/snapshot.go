@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// snapshotHeaderLen is the length of the header of a snapshot: the
+// Fingerprint of the type and its size, both as little-endian uint64.
+const snapshotHeaderLen = 16
+
+// Snapshot returns the raw memory of the value held by v, preceded by a
+// header recording the Fingerprint and the size of its dynamic type, for
+// Restore to check against. The dynamic type of v must be plain old data.
+func Snapshot(v interface{}) ([]byte, error) {
+	e := efaceOf(&v)
+	if e.Type == nil || HasPointers(e.Type) || !IsPOD(e.Type) {
+		return nil, fmt.Errorf("%w: %v", ErrNotPOD, ToReflect(e.Type))
+	}
+	data := make([]byte, snapshotHeaderLen+e.Type.size)
+	binary.LittleEndian.PutUint64(data, Fingerprint(e.Type))
+	binary.LittleEndian.PutUint64(data[8:], uint64(e.Type.size))
+	copy(data[snapshotHeaderLen:], unsafe.Slice((*byte)(e.data()), e.Type.size))
+	return data, nil
+}
+
+// Restore copies a snapshot taken by Snapshot into the value pointed to by
+// dst. It fails with ErrTypeMismatch if the fingerprint or the size recorded
+// in the snapshot differs from that of the type dst points to, which is the
+// case for a snapshot of another type or of a type whose layout changed since,
+// or if the length of data does not match the recorded size.
+func Restore(dst interface{}, data []byte) error {
+	e := efaceOf(&dst)
+	if k := kindOf(e.Type); k != Ptr {
+		return &KindError{Op: "Restore", Kind: k}
+	}
+	if e.Word == nil {
+		return errors.New("reflection: Restore into nil pointer")
+	}
+	t := (*PtrType)(unsafe.Pointer(e.Type)).Elem
+	if HasPointers(t) || !IsPOD(t) {
+		return fmt.Errorf("%w: %s", ErrNotPOD, t)
+	}
+	if len(data) < snapshotHeaderLen {
+		return errors.New("reflection: short snapshot")
+	}
+	if fp := binary.LittleEndian.Uint64(data); fp != Fingerprint(t) {
+		return fmt.Errorf("%w: snapshot fingerprint %#x, %s has %#x", ErrTypeMismatch, fp, t, Fingerprint(t))
+	}
+	if size := binary.LittleEndian.Uint64(data[8:]); size != uint64(t.size) {
+		return fmt.Errorf("%w: snapshot of %d bytes, %s has %d", ErrTypeMismatch, size, t, t.size)
+	}
+	if n := snapshotHeaderLen + t.size; uintptr(len(data)) != n {
+		return fmt.Errorf("%w: snapshot of %d bytes, %s needs %d", ErrTypeMismatch, len(data), t, n)
+	}
+	copy(unsafe.Slice((*byte)(e.Word), t.size), data[snapshotHeaderLen:])
+	return nil
+}
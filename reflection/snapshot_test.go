@@ -0,0 +1,128 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+type snapshotPOD struct {
+	A int32
+	B [3]float64
+	C bool
+	D struct {
+		X, Y uint16
+	}
+}
+
+type snapshotOther struct {
+	A int32
+	B [3]float64
+	C bool
+	D struct {
+		X, Y int16
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	v := snapshotPOD{A: -1, B: [3]float64{1.5, 2, -3}, C: true}
+	v.D.X, v.D.Y = 7, 65535
+	data, err := Snapshot(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got snapshotPOD
+	if err := Restore(&got, data); err != nil {
+		t.Fatal(err)
+	}
+	if got != v {
+		t.Errorf("Restore = %+v, want %+v", got, v)
+	}
+
+	var n int64
+	data, err = Snapshot(int64(-42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(&n, data); err != nil || n != -42 {
+		t.Errorf("Restore(int64) = %d, %v, want -42", n, err)
+	}
+}
+
+func TestSnapshotNotPOD(t *testing.T) {
+	type withString struct {
+		N int
+		S string
+	}
+	for _, v := range []interface{}{withString{}, "s", []int{1}, new(int), nil} {
+		if _, err := Snapshot(v); !errors.Is(err, ErrNotPOD) {
+			t.Errorf("Snapshot(%T) = %v, want ErrNotPOD", v, err)
+		}
+	}
+	data, err := Snapshot(snapshotPOD{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ws withString
+	if err := Restore(&ws, data); !errors.Is(err, ErrNotPOD) {
+		t.Errorf("Restore into a struct with a string = %v, want ErrNotPOD", err)
+	}
+}
+
+func TestRestoreHeader(t *testing.T) {
+	v := snapshotPOD{A: 1}
+	data, err := Snapshot(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), data...))
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		mismatch bool
+	}{
+		{"fingerprint", corrupt(func(b []byte) []byte { b[0] ^= 1; return b }), true},
+		{"size", corrupt(func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[8:], binary.LittleEndian.Uint64(b[8:])+1)
+			return b
+		}), true},
+		{"truncated data", data[:len(data)-1], true},
+		{"trailing data", append(append([]byte(nil), data...), 0), true},
+		{"truncated header", data[:snapshotHeaderLen-1], false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		var got snapshotPOD
+		err := Restore(&got, tt.data)
+		if err == nil {
+			t.Errorf("%s: Restore succeeded", tt.name)
+			continue
+		}
+		if errors.Is(err, ErrTypeMismatch) != tt.mismatch {
+			t.Errorf("%s: Restore = %v, ErrTypeMismatch %v", tt.name, err, tt.mismatch)
+		}
+		if got != (snapshotPOD{}) {
+			t.Errorf("%s: Restore wrote %+v despite the error", tt.name, got)
+		}
+	}
+
+	// A type of the same size but another layout has another fingerprint.
+	var other snapshotOther
+	if err := Restore(&other, data); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Restore into another type = %v, want ErrTypeMismatch", err)
+	}
+
+	var ke *KindError
+	if err := Restore(snapshotPOD{}, data); !errors.As(err, &ke) {
+		t.Errorf("Restore into a non-pointer = %v, want a *KindError", err)
+	}
+	if err := Restore((*snapshotPOD)(nil), data); err == nil {
+		t.Error("Restore into a nil pointer: no error")
+	}
+}
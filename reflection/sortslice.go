@@ -0,0 +1,325 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// SortSlice sorts the slice held by s in place in the order of less, which
+// is called with pointers to two elements and reports whether the first
+// sorts before the second. The sort is not stable: equal elements may be
+// reordered; use SortSliceStable to keep their order.
+//
+// Unlike sort.Slice and reflect.Swapper, SortSlice works on the elements in
+// place without a reflect.Value, and swaps them as Swapper does, without
+// allocating. It is an introsort: a quicksort that falls back to heapsort
+// when its recursion gets too deep, so that it runs in O(n log n) time. It
+// returns a *KindError if s does not hold a slice.
+func SortSlice(s interface{}, less func(a, b unsafe.Pointer) bool) error {
+	r, ok, err := newRawSorter("SortSlice", s, less)
+	if !ok {
+		return err
+	}
+	r.introSort(0, r.n, 2*bits.Len(uint(r.n)))
+	return nil
+}
+
+// SortSliceStable is like SortSlice, but keeps the order of equal elements.
+// It is an in-place merge sort, which calls less O(n log n) and swaps
+// elements O(n log n log n) times, and does not allocate either.
+func SortSliceStable(s interface{}, less func(a, b unsafe.Pointer) bool) error {
+	r, ok, err := newRawSorter("SortSliceStable", s, less)
+	if !ok {
+		return err
+	}
+	r.stable()
+	return nil
+}
+
+// ByIntField returns a comparator for SortSlice ordering structs by their
+// field f, of an integer kind, in increasing order. It returns a *KindError
+// for fields of other kinds.
+func ByIntField(f *StructField) (func(a, b unsafe.Pointer) bool, error) {
+	off := f.Offset()
+	switch k := f.typ.Kind(); k {
+	case Int, Int64:
+		if k == Int && f.typ.size != 8 {
+			return byField[int32](off), nil
+		}
+		return byField[int64](off), nil
+	case Int32:
+		return byField[int32](off), nil
+	case Int16:
+		return byField[int16](off), nil
+	case Int8:
+		return byField[int8](off), nil
+	case Uint, Uint64, Uintptr:
+		if k != Uint64 && f.typ.size != 8 {
+			return byField[uint32](off), nil
+		}
+		return byField[uint64](off), nil
+	case Uint32:
+		return byField[uint32](off), nil
+	case Uint16:
+		return byField[uint16](off), nil
+	case Uint8:
+		return byField[uint8](off), nil
+	default:
+		return nil, &KindError{Op: "ByIntField", Kind: k}
+	}
+}
+
+// ByStringField returns a comparator for SortSlice ordering structs by their
+// field f, of string kind, in increasing byte-wise order. It returns a
+// *KindError for fields of other kinds.
+func ByStringField(f *StructField) (func(a, b unsafe.Pointer) bool, error) {
+	if k := f.typ.Kind(); k != String {
+		return nil, &KindError{Op: "ByStringField", Kind: k}
+	}
+	return byField[string](f.Offset()), nil
+}
+
+func byField[F int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64 | string](off uintptr) func(a, b unsafe.Pointer) bool {
+	return func(a, b unsafe.Pointer) bool {
+		return *(*F)(Add(a, off, "the field lies within the struct")) < *(*F)(Add(b, off, "the field lies within the struct"))
+	}
+}
+
+// rawSorter sorts the n elements of type et at data.
+type rawSorter struct {
+	data unsafe.Pointer
+	n    int
+	et   *rtype
+	mask []byte // pointer bitmap of et
+	less func(a, b unsafe.Pointer) bool
+}
+
+// newRawSorter returns a sorter for the slice held by s, and whether it has
+// elements to sort.
+func newRawSorter(op string, s interface{}, less func(a, b unsafe.Pointer) bool) (rawSorter, bool, error) {
+	e := efaceOf(&s)
+	if k := kindOf(e.Type); k != Slice {
+		return rawSorter{}, false, &KindError{Op: op, Kind: k}
+	}
+	et := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	hdr := (*SliceHeader)(e.Word)
+	if hdr.Len < 2 || et.size == 0 {
+		return rawSorter{}, false, nil
+	}
+	return rawSorter{data: hdr.Data, n: hdr.Len, et: et, mask: swapMask(et), less: less}, true, nil
+}
+
+func (r *rawSorter) at(i int) unsafe.Pointer {
+	return Add(r.data, uintptr(i)*r.et.size, "i < n")
+}
+
+func (r *rawSorter) lessAt(i, j int) bool {
+	return r.less(r.at(i), r.at(j))
+}
+
+func (r *rawSorter) swap(i, j int) {
+	swapValues(r.et, r.mask, r.at(i), r.at(j))
+}
+
+// introSort sorts the elements in [lo, hi), switching to heapsort after
+// depth partitions.
+func (r *rawSorter) introSort(lo, hi, depth int) {
+	for hi-lo > 12 {
+		if depth == 0 {
+			r.heapSort(lo, hi)
+			return
+		}
+		depth--
+		p := r.partition(lo, hi)
+		// Recurse into the smaller side, so that the stack stays shallow.
+		if p-lo < hi-p {
+			r.introSort(lo, p, depth)
+			lo = p + 1
+		} else {
+			r.introSort(p+1, hi, depth)
+			hi = p
+		}
+	}
+	r.insertionSort(lo, hi)
+}
+
+// partition partitions [lo, hi) around the median of its first, middle and
+// last elements, and returns the index of the pivot.
+func (r *rawSorter) partition(lo, hi int) int {
+	m := int(uint(lo+hi) >> 1)
+	if r.lessAt(m, lo) {
+		r.swap(lo, m)
+	}
+	if r.lessAt(hi-1, m) {
+		r.swap(m, hi-1)
+		if r.lessAt(m, lo) {
+			r.swap(lo, m)
+		}
+	}
+	r.swap(lo, m)
+
+	i, j := lo, hi
+	for {
+		for i++; i < hi && r.lessAt(i, lo); i++ {
+		}
+		for j--; r.lessAt(lo, j); j-- {
+		}
+		if i >= j {
+			break
+		}
+		r.swap(i, j)
+	}
+	r.swap(lo, j)
+	return j
+}
+
+func (r *rawSorter) insertionSort(lo, hi int) {
+	for i := lo + 1; i < hi; i++ {
+		for j := i; j > lo && r.lessAt(j, j-1); j-- {
+			r.swap(j, j-1)
+		}
+	}
+}
+
+func (r *rawSorter) heapSort(lo, hi int) {
+	n := hi - lo
+	for i := (n - 1) / 2; i >= 0; i-- {
+		r.siftDown(i, n, lo)
+	}
+	for i := n - 1; i >= 0; i-- {
+		r.swap(lo, lo+i)
+		r.siftDown(0, i, lo)
+	}
+}
+
+// siftDown restores the heap property of the heap of n elements from lo,
+// from its element i down.
+func (r *rawSorter) siftDown(i, n, lo int) {
+	for {
+		child := 2*i + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && r.lessAt(lo+child, lo+child+1) {
+			child++
+		}
+		if !r.lessAt(lo+i, lo+child) {
+			return
+		}
+		r.swap(lo+i, lo+child)
+		i = child
+	}
+}
+
+// stable sorts the elements stably, as sort.Stable does: insertion sorts of
+// blocks, then in-place merges of blocks of doubling sizes.
+func (r *rawSorter) stable() {
+	const blockSize = 20
+	a, b := 0, blockSize
+	for b <= r.n {
+		r.insertionSort(a, b)
+		a, b = b, b+blockSize
+	}
+	r.insertionSort(a, r.n)
+	for size := blockSize; size < r.n; size *= 2 {
+		a, b = 0, 2*size
+		for b <= r.n {
+			r.symMerge(a, a+size, b)
+			a, b = b, b+2*size
+		}
+		if m := a + size; m < r.n {
+			r.symMerge(a, m, r.n)
+		}
+	}
+}
+
+// symMerge merges the sorted ranges [a, m) and [m, b) with the SymMerge
+// algorithm of Kim and Kutzner, as sort.Stable does.
+func (r *rawSorter) symMerge(a, m, b int) {
+	if m-a == 1 {
+		// Insert the single element of [a, m) into [m, b).
+		i, j := m, b
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if r.lessAt(h, a) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := a; k < i-1; k++ {
+			r.swap(k, k+1)
+		}
+		return
+	}
+	if b-m == 1 {
+		// Insert the single element of [m, b) into [a, m).
+		i, j := a, m
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if !r.lessAt(m, h) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := m; k > i; k-- {
+			r.swap(k, k-1)
+		}
+		return
+	}
+
+	mid := int(uint(a+b) >> 1)
+	n := mid + m
+	var start, end int
+	if m > mid {
+		start, end = n-b, mid
+	} else {
+		start, end = a, m
+	}
+	p := n - 1
+	for start < end {
+		c := int(uint(start+end) >> 1)
+		if !r.lessAt(p-c, c) {
+			start = c + 1
+		} else {
+			end = c
+		}
+	}
+	end = n - start
+	if start < m && m < end {
+		r.rotate(start, m, end)
+	}
+	if a < start && start < mid {
+		r.symMerge(a, start, mid)
+	}
+	if mid < end && end < b {
+		r.symMerge(mid, end, b)
+	}
+}
+
+// rotate exchanges the ranges [a, m) and [m, b).
+func (r *rawSorter) rotate(a, m, b int) {
+	i, j := m-a, b-m
+	for i != j {
+		if i > j {
+			r.swapRange(m-i, m, j)
+			i -= j
+		} else {
+			r.swapRange(m-i, m+j-i, i)
+			j -= i
+		}
+	}
+	r.swapRange(m-i, m, i)
+}
+
+// swapRange swaps the n elements from a with the n elements from b.
+func (r *rawSorter) swapRange(a, b, n int) {
+	for i := 0; i < n; i++ {
+		r.swap(a+i, b+i)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+type sortT struct {
+	I8   int8
+	I16  int16
+	I32  int32
+	I64  int64
+	I    int
+	U8   uint8
+	U16  uint16
+	U32  uint32
+	U64  uint64
+	U    uint
+	Up   uintptr
+	S    string
+	P    *int
+	Seq  int
+	Fill [3]byte
+}
+
+func sortFixtures(n int, r *rand.Rand) map[string][]sortT {
+	gen := func(key func(i int) int) []sortT {
+		s := make([]sortT, n)
+		for i := range s {
+			k := key(i)
+			s[i] = sortT{
+				I8: int8(k), I16: int16(k), I32: int32(k), I64: int64(k), I: k,
+				U8: uint8(k), U16: uint16(k), U32: uint32(k), U64: uint64(k), U: uint(k), Up: uintptr(k),
+				S: strconv.Itoa(k), P: new(int), Seq: i,
+			}
+			*s[i].P = k
+		}
+		return s
+	}
+	return map[string][]sortT{
+		"random":   gen(func(int) int { return r.Intn(50) }),
+		"sorted":   gen(func(i int) int { return i }),
+		"reversed": gen(func(i int) int { return n - i }),
+		"equal":    gen(func(int) int { return 7 }),
+		"sawtooth": gen(func(i int) int { return i % 5 }),
+	}
+}
+
+func sortByP(a, b unsafe.Pointer) bool {
+	return *(*sortT)(a).P < *(*sortT)(b).P
+}
+
+func TestSortSlice(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 5, 13, 100, 1000} {
+		for name, s := range sortFixtures(n, r) {
+			want := append([]sortT(nil), s...)
+			sort.SliceStable(want, func(i, j int) bool { return *want[i].P < *want[j].P })
+
+			unstable := append([]sortT(nil), s...)
+			if err := SortSlice(unstable, sortByP); err != nil {
+				t.Fatal(err)
+			}
+			seen := make([]bool, n)
+			for i, v := range unstable {
+				if *v.P != *want[i].P || v.I != *v.P {
+					t.Fatalf("%s/%d: SortSlice element %d = %+v, want key %d", name, n, i, v, *want[i].P)
+				}
+				seen[v.Seq] = true
+			}
+			for i, ok := range seen {
+				if !ok {
+					t.Fatalf("%s/%d: SortSlice lost element %d", name, n, i)
+				}
+			}
+
+			stable := append([]sortT(nil), s...)
+			if err := SortSliceStable(stable, sortByP); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stable, want) {
+				t.Fatalf("%s/%d: SortSliceStable differs from sort.SliceStable", name, n)
+			}
+		}
+	}
+}
+
+func TestSortSliceHeapSort(t *testing.T) {
+	// A depth of zero sorts with heapsort alone.
+	s := make([]int, 200)
+	for i := range s {
+		s[i] = (i * 7919) % 200
+	}
+	rs, ok, err := newRawSorter("test", s, func(a, b unsafe.Pointer) bool { return *(*int)(a) < *(*int)(b) })
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	rs.introSort(0, rs.n, 0)
+	if !sort.IntsAreSorted(s) {
+		t.Errorf("heapsort left %v", s)
+	}
+}
+
+func TestSortSliceErrors(t *testing.T) {
+	var ke *KindError
+	if err := SortSlice([3]int{}, nil); !errors.As(err, &ke) || ke.Kind != Array {
+		t.Errorf("SortSlice of an array = %v, want a *KindError", err)
+	}
+	if err := SortSliceStable(nil, nil); !errors.As(err, &ke) || ke.Kind != Invalid {
+		t.Errorf("SortSliceStable of nil = %v, want a *KindError", err)
+	}
+	// Nothing to sort: less is not called.
+	for _, s := range []interface{}{[]int(nil), []int{1}, make([]struct{}, 5)} {
+		if err := SortSlice(s, nil); err != nil {
+			t.Errorf("SortSlice(%v) = %v", s, err)
+		}
+	}
+}
+
+func TestSortSliceAllocs(t *testing.T) {
+	s := make([]sortT, 100)
+	for i := range s {
+		s[i].P = new(int)
+		*s[i].P = -i
+	}
+	var x interface{} = s
+	allocs := testing.AllocsPerRun(10, func() {
+		SortSliceStable(x, sortByP)
+		SortSlice(x, sortByP)
+	})
+	if allocs != 0 {
+		t.Errorf("SortSlice allocates %v times", allocs)
+	}
+}
+
+func TestByField(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	s := sortFixtures(300, r)["random"]
+	fields := []string{"I8", "I16", "I32", "I64", "I", "U8", "U16", "U32", "U64", "U", "Up"}
+	for _, name := range fields {
+		t.Run(name, func(t *testing.T) {
+			less, err := ByIntField(columnField[sortT](name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := append([]sortT(nil), s...)
+			if err := SortSliceStable(got, less); err != nil {
+				t.Fatal(err)
+			}
+			if !sort.SliceIsSorted(got, func(i, j int) bool { return got[i].I < got[j].I }) {
+				t.Errorf("not sorted by %s", name)
+			}
+		})
+	}
+
+	less, err := ByStringField(columnField[sortT]("S"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := append([]sortT(nil), s...)
+	SortSlice(got, less)
+	if !sort.SliceIsSorted(got, func(i, j int) bool { return got[i].S < got[j].S }) {
+		t.Error("not sorted by S")
+	}
+
+	var ke *KindError
+	if _, err := ByIntField(columnField[sortT]("S")); !errors.As(err, &ke) || ke.Kind != String {
+		t.Errorf("ByIntField(S) = %v, want a *KindError", err)
+	}
+	if _, err := ByStringField(columnField[sortT]("P")); !errors.As(err, &ke) || ke.Kind != Ptr {
+		t.Errorf("ByStringField(P) = %v, want a *KindError", err)
+	}
+}
+
+func BenchmarkSortSlice(b *testing.B) {
+	s := make([]sortT, 1000)
+	r := rand.New(rand.NewSource(3))
+	keys := make([]int, len(s))
+	for i := range keys {
+		keys[i] = r.Int()
+		s[i].P = &keys[i]
+	}
+	work := make([]sortT, len(s))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		copy(work, s)
+		SortSlice(work, sortByP)
+	}
+}
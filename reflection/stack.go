@@ -0,0 +1,51 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// gstack mirrors runtime.stack, the first field of runtime.g in every
+// release.
+type gstack struct {
+	lo, hi uintptr
+}
+
+// stackBoundsOK reports whether the stack bounds read from runtime.g were
+// checked at init to hold the address of a local variable.
+var stackBoundsOK = func() bool {
+	g := getg()
+	if g == nil {
+		return false
+	}
+	var local int
+	s := (*gstack)(g)
+	p := uintptr(unsafe.Pointer(&local))
+	return s.lo <= p && p < s.hi
+}()
+
+// StackBounds returns the bounds [lo, hi) of the stack of the calling
+// goroutine, or zeros on architectures where they cannot be read.
+//
+// The runtime moves a goroutine stack when growing or shrinking it, so the
+// bounds are only valid until the goroutine calls a function that may grow
+// its stack.
+func StackBounds() (lo, hi uintptr) {
+	if !stackBoundsOK {
+		return 0, 0
+	}
+	s := (*gstack)(getg())
+	return s.lo, s.hi
+}
+
+// OnStack reports whether p points into the stack of the calling goroutine,
+// so that it must not be stored anywhere that outlives the current call,
+// as pointers hidden from escape analysis may be. It reports false when the
+// stack bounds cannot be read.
+func OnStack(p unsafe.Pointer) bool {
+	lo, hi := StackBounds()
+	return lo <= uintptr(p) && uintptr(p) < hi
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+var (
+	stackGlobal int
+	stackHeap   *int
+)
+
+//go:noinline
+func stackGrow(n int) (lo, hi uintptr) {
+	var pad [256]byte
+	if n == 0 {
+		return StackBounds()
+	}
+	lo, hi = stackGrow(n - 1)
+	return lo + uintptr(pad[0]), hi
+}
+
+func TestStackBounds(t *testing.T) {
+	lo, hi := StackBounds()
+	if lo == 0 && hi == 0 {
+		if runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" {
+			t.Fatal("StackBounds = 0, 0")
+		}
+		t.Skip("stack bounds not readable on " + runtime.GOARCH)
+	}
+	if lo >= hi {
+		t.Fatalf("StackBounds = %#x, %#x", lo, hi)
+	}
+
+	var local int
+	if !OnStack(unsafe.Pointer(&local)) {
+		t.Errorf("OnStack(&local) = false, bounds [%#x, %#x)", lo, hi)
+	}
+	if OnStack(unsafe.Pointer(&stackGlobal)) {
+		t.Error("OnStack(&global) = true")
+	}
+	stackHeap = new(int)
+	if OnStack(unsafe.Pointer(stackHeap)) {
+		t.Error("OnStack of a heap allocation = true")
+	}
+
+	// Deep recursion moves the stack to a larger one.
+	glo, ghi := stackGrow(1000)
+	if ghi-glo <= hi-lo {
+		t.Errorf("stack of %d bytes did not grow from %d bytes", ghi-glo, hi-lo)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// numLargestStructs is the number of structs TypeStats.Largest holds.
+const numLargestStructs = 10
+
+// TypeStats summarizes the type descriptors of a binary, as reported by
+// Types, to tell which types it is made of.
+type TypeStats struct {
+	// Types is the number of types.
+	Types int
+
+	// Kinds holds the number of types of each kind, indexed by Kind.
+	Kinds [UnsafePointer + 1]int
+
+	// StructSizes is the histogram of the sizes of the struct types:
+	// StructSizes[0] counts the empty structs, and StructSizes[i] those
+	// of size in [1<<(i-1), 1<<i).
+	StructSizes [65]int
+
+	// Largest holds the largest struct types, at most 10, by decreasing
+	// size.
+	Largest []StructStat
+
+	// Generic is the number of instantiations of generic types.
+	Generic int
+}
+
+// StructStat describes the size of a struct type.
+type StructStat struct {
+	Type    *StructType
+	Size    uintptr
+	Padding uintptr // bytes not used by the fields
+}
+
+// Stats returns statistics about the types of Types in the loaded modules.
+// The types are visited one at a time; only the largest structs are kept.
+// Like Types, Stats requires the runtime module layout, and returns the
+// error of Types, wrapping ErrUnsupported, otherwise.
+func Stats() (TypeStats, error) {
+	var s TypeStats
+	err := Types(func(t *rtype) bool {
+		s.add(t)
+		return true
+	})
+	return s, err
+}
+
+func (s *TypeStats) add(t *rtype) {
+	s.Types++
+	if k := t.Kind(); int(k) < len(s.Kinds) {
+		s.Kinds[k]++
+	}
+	if t.HasName() && strings.IndexByte(t.Name(), '[') >= 0 {
+		s.Generic++
+	}
+	if t.Kind() != Struct {
+		return
+	}
+	s.StructSizes[bits.Len64(uint64(t.size))]++
+
+	n := len(s.Largest)
+	if n == numLargestStructs && t.size <= s.Largest[n-1].Size {
+		return
+	}
+	st := (*StructType)(unsafe.Pointer(t))
+	i := sort.Search(n, func(i int) bool { return s.Largest[i].Size < t.size })
+	if n < numLargestStructs {
+		s.Largest = append(s.Largest, StructStat{})
+	}
+	copy(s.Largest[i+1:], s.Largest[i:])
+	s.Largest[i] = StructStat{Type: st, Size: t.size, Padding: structPadding(st)}
+}
+
+// structPadding returns the number of bytes of a value of type t that
+// belong to none of its fields.
+func structPadding(t *StructType) uintptr {
+	used := uintptr(0)
+	for i := range t.Fields {
+		used += t.Fields[i].typ.size
+	}
+	return t.size - used
+}
+
+// String formats s as a report over several lines.
+func (s *TypeStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d types, %d generic instantiations\n", s.Types, s.Generic)
+	for k, n := range s.Kinds {
+		if n > 0 {
+			fmt.Fprintf(&b, "  %-15s %d\n", Kind(k), n)
+		}
+	}
+	fmt.Fprintf(&b, "struct sizes:\n")
+	for i, n := range s.StructSizes {
+		switch {
+		case n == 0:
+		case i == 0:
+			fmt.Fprintf(&b, "  %-15s %d\n", "0", n)
+		default:
+			fmt.Fprintf(&b, "  %-15s %d\n", "<2^"+strconv.Itoa(i), n)
+		}
+	}
+	fmt.Fprintf(&b, "largest structs:\n")
+	for _, l := range s.Largest {
+		fmt.Fprintf(&b, "  %-8d %-8d %s\n", l.Size, l.Padding, l.Type.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestStats(t *testing.T) {
+	s, err := Stats()
+	if !modulesSupported {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("Stats error = %v, want ErrUnsupported", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds, structs, sizes := 0, 0, 0
+	for k, n := range s.Kinds {
+		kinds += n
+		if Kind(k) == Struct {
+			structs = n
+		}
+	}
+	for _, n := range s.StructSizes {
+		sizes += n
+	}
+	if s.Types == 0 || kinds != s.Types {
+		t.Errorf("%d types, %d by kind", s.Types, kinds)
+	}
+	if sizes != structs {
+		t.Errorf("%d structs in the size histogram, want %d", sizes, structs)
+	}
+	if len(s.Largest) != numLargestStructs {
+		t.Fatalf("%d largest structs, want %d", len(s.Largest), numLargestStructs)
+	}
+	for i, l := range s.Largest {
+		if i > 0 && l.Size > s.Largest[i-1].Size {
+			t.Errorf("Largest[%d] of size %d follows a smaller struct", i, l.Size)
+		}
+		if l.Size != l.Type.size || l.Padding > l.Size {
+			t.Errorf("Largest[%d] = %+v does not describe %s", i, l, l.Type)
+		}
+	}
+	out := s.String()
+	for _, want := range []string{"types,", "struct sizes:", "largest structs:", "struct "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("String() lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStructPadding(t *testing.T) {
+	type padded struct {
+		A int8
+		B int64
+		C int8
+	}
+	st := (*StructType)(unsafe.Pointer(TypeFor[padded]()))
+	if got, want := structPadding(st), st.size-10; got != want {
+		t.Errorf("structPadding = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.20
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+// unsafeString returns a string of length n whose data starts at p.
+func unsafeString(p *byte, n int) string {
+	return unsafe.String(p, n)
+}
@@ -0,0 +1,17 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.19
+
+package reflection
+
+// Offset returns the byte offset of the field within its struct.
+func (f *StructField) Offset() uintptr {
+	return f.OffsetEmbed >> 1
+}
+
+// Embedded reports whether the field is an embedded field.
+func (f *StructField) Embedded() bool {
+	return f.OffsetEmbed&1 != 0
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"math/bits"
+	"sync"
+	"unsafe"
+)
+
+// Swapper returns a function that swaps the elements of the slice held by s,
+// as reflect.Swapper does. The function panics if an index is out of range
+// of the length of the slice when Swapper was called.
+//
+// Unlike reflect.Swapper, Swapper allocates nothing but the returned
+// closure. Elements with pointers are swapped word by word, each word with
+// the write barrier its kind requires, and elements without pointers of up
+// to 256 bytes likewise; larger ones are moved through a scratch buffer
+// taken from a pool, by size class, for the duration of the swap. Swapper
+// returns a *KindError if s does not hold a slice.
+func Swapper(s interface{}) (func(i, j int), error) {
+	e := efaceOf(&s)
+	if k := kindOf(e.Type); k != Slice {
+		return nil, &KindError{Op: "Swapper", Kind: k}
+	}
+	et := (*SliceType)(unsafe.Pointer(e.Type)).Elem
+	hdr := *(*SliceHeader)(e.Word)
+	n, size := uint(hdr.Len), et.size
+	at := func(i int) unsafe.Pointer {
+		return Add(hdr.Data, uintptr(i)*size, "i < n")
+	}
+
+	if !HasPointers(et) && size <= 8 && uintptr(et.align) >= size {
+		switch size {
+		case 0:
+			return func(i, j int) {
+				if uint(i) >= n || uint(j) >= n {
+					panic("reflection: Swapper index out of range")
+				}
+			}, nil
+		case 1:
+			return swapWord[uint8](hdr.Data, n), nil
+		case 2:
+			return swapWord[uint16](hdr.Data, n), nil
+		case 4:
+			return swapWord[uint32](hdr.Data, n), nil
+		case 8:
+			return swapWord[uint64](hdr.Data, n), nil
+		}
+	}
+	if et.Kind() == String {
+		return swapWord[string](hdr.Data, n), nil
+	}
+	if size == ptrSize && et.ptrdata == ptrSize {
+		return swapWord[unsafe.Pointer](hdr.Data, n), nil
+	}
+
+	mask := swapMask(et)
+	return func(i, j int) {
+		if uint(i) >= n || uint(j) >= n {
+			panic("reflection: Swapper index out of range")
+		}
+		if i != j {
+			swapValues(et, mask, at(i), at(j))
+		}
+	}, nil
+}
+
+// swapWord returns a swapper of the n values of type W at data.
+func swapWord[W uint8 | uint16 | uint32 | uint64 | string | unsafe.Pointer](data unsafe.Pointer, n uint) func(i, j int) {
+	s := unsafe.Slice((*W)(data), n)
+	return func(i, j int) {
+		if uint(i) >= n || uint(j) >= n {
+			panic("reflection: Swapper index out of range")
+		}
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// swapMasks caches the pointer bitmaps of the element types of Swapper.
+var swapMasks TypeMap[[]byte]
+
+// swapMask returns the pointer bitmap of t, or nil if t has no pointers.
+func swapMask(t *rtype) []byte {
+	if !HasPointers(t) {
+		return nil
+	}
+	return swapMasks.GetOrCompute(t, gcMask)
+}
+
+// swapBufferMin is the size from which swapValues moves values without
+// pointers through a scratch buffer rather than word by word.
+const swapBufferMin = 256
+
+// swapBuffers holds scratch buffers for swapValues, by the log2 of their
+// size rounded up to a power of two.
+var swapBuffers [bits.UintSize]sync.Pool
+
+// swapValues swaps the values of type t at a and b, with mask the pointer
+// bitmap of t.
+func swapValues(t *rtype, mask []byte, a, b unsafe.Pointer) {
+	size := t.size
+	if mask == nil && size >= swapBufferMin {
+		class := bits.Len(uint(size - 1))
+		p, _ := swapBuffers[class].Get().(*[]byte)
+		if p == nil {
+			buf := make([]byte, 1<<class)
+			p = &buf
+		}
+		tmp := (*p)[:size]
+		x, y := unsafe.Slice((*byte)(a), size), unsafe.Slice((*byte)(b), size)
+		copy(tmp, x)
+		copy(x, y)
+		copy(y, tmp)
+		swapBuffers[class].Put(p)
+		return
+	}
+
+	// Values with pointers are pointer-aligned and a whole number of words
+	// long; the words the garbage collector scans are swapped as pointers.
+	var w uintptr
+	if mask != nil || t.align >= uint8(ptrSize) {
+		for ; (w+1)*ptrSize <= size; w++ {
+			pa, pb := Add(a, w*ptrSize, "w < size"), Add(b, w*ptrSize, "w < size")
+			if w < uintptr(len(mask))*8 && mask[w/8]&(1<<(w%8)) != 0 {
+				*(*unsafe.Pointer)(pa), *(*unsafe.Pointer)(pb) = *(*unsafe.Pointer)(pb), *(*unsafe.Pointer)(pa)
+			} else {
+				*(*uintptr)(pa), *(*uintptr)(pb) = *(*uintptr)(pb), *(*uintptr)(pa)
+			}
+		}
+	}
+	for off := w * ptrSize; off < size; off++ {
+		pa, pb := (*byte)(Add(a, off, "off < size")), (*byte)(Add(b, off, "off < size"))
+		*pa, *pb = *pb, *pa
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+type swapMixed struct {
+	A byte
+	P *int
+	B [5]byte
+	S string
+	C uint16
+}
+
+// testSwapper checks that Swapper permutes a slice of n values made by gen
+// as reflect.Swapper does.
+func testSwapper[T any](t *testing.T, name string, n int, gen func(i int) T) {
+	t.Run(name, func(t *testing.T) {
+		got, want := make([]T, n), make([]T, n)
+		for i := range got {
+			got[i], want[i] = gen(i), gen(i)
+		}
+		swap, err := Swapper(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		swapWant := reflect.Swapper(want)
+		r := rand.New(rand.NewSource(int64(n)))
+		for k := 0; k < 4*n; k++ {
+			i, j := r.Intn(n), r.Intn(n)
+			swap(i, j)
+			swapWant(i, j)
+		}
+		runtime.GC() // Catch pointers lost to the write barriers.
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Swapper permuted %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSwapper(t *testing.T) {
+	const n = 17
+	ints := make([]int, n)
+	for i := range ints {
+		ints[i] = i
+	}
+	testSwapper(t, "uint8", n, func(i int) uint8 { return uint8(i) })
+	testSwapper(t, "int16", n, func(i int) int16 { return int16(i) })
+	testSwapper(t, "float32", n, func(i int) float32 { return float32(i) })
+	testSwapper(t, "int64", n, func(i int) int64 { return int64(i) << 40 })
+	testSwapper(t, "[3]byte", n, func(i int) [3]byte { return [3]byte{byte(i), 1, byte(-i)} })
+	testSwapper(t, "[2]uint32", n, func(i int) [2]uint32 { return [2]uint32{uint32(i), 1} })
+	testSwapper(t, "string", n, func(i int) string { return string(rune('a' + i)) })
+	testSwapper(t, "pointer", n, func(i int) *int { return &ints[i] })
+	testSwapper(t, "map", n, func(i int) map[int]int { return map[int]int{i: i} })
+	testSwapper(t, "slice", n, func(i int) []int { return ints[:i] })
+	testSwapper(t, "interface", n, func(i int) interface{} { return i })
+	testSwapper(t, "mixed", n, func(i int) swapMixed {
+		return swapMixed{A: byte(i), P: &ints[i], B: [5]byte{byte(i)}, S: string(rune('a' + i)), C: uint16(i)}
+	})
+	testSwapper(t, "[40]int64", n, func(i int) (a [40]int64) {
+		a[0], a[39] = int64(i), int64(-i)
+		return a
+	})
+	testSwapper(t, "[301]byte", n, func(i int) (a [301]byte) {
+		a[0], a[300] = byte(i), byte(-i)
+		return a
+	})
+	testSwapper(t, "[40]*int", n, func(i int) (a [40]*int) {
+		a[0], a[39] = &ints[i], &ints[n-1-i]
+		return a
+	})
+	testSwapper(t, "struct{}", n, func(int) struct{} { return struct{}{} })
+}
+
+func TestSwapperErrors(t *testing.T) {
+	var ke *KindError
+	if _, err := Swapper([2]int{}); !errors.As(err, &ke) || ke.Kind != Array {
+		t.Errorf("Swapper of an array = %v, want a *KindError", err)
+	}
+	if _, err := Swapper(nil); !errors.As(err, &ke) || ke.Kind != Invalid {
+		t.Errorf("Swapper of nil = %v, want a *KindError", err)
+	}
+
+	for _, s := range []interface{}{make([]int, 3), make([]string, 3), make([]swapMixed, 3), make([]struct{}, 3), make([][300]byte, 3)} {
+		swap, err := Swapper(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ij := range [][2]int{{0, 3}, {3, 0}, {-1, 0}, {0, -1}} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%T: swap(%d, %d) did not panic", s, ij[0], ij[1])
+					}
+				}()
+				swap(ij[0], ij[1])
+			}()
+		}
+	}
+}
+
+func TestSwapperAllocs(t *testing.T) {
+	for _, s := range []interface{}{make([]swapMixed, 8), make([][300]byte, 8)} {
+		swap, err := Swapper(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		swap(0, 7) // Fill the buffer pool.
+		if allocs := testing.AllocsPerRun(100, func() { swap(1, 6) }); allocs != 0 {
+			t.Errorf("%T: swap allocates %v times", s, allocs)
+		}
+	}
+}
+
+func BenchmarkSwapper(b *testing.B) {
+	s := make([]swapMixed, 64)
+	swap, _ := Swapper(s)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		swap(i&63, (i*7)&63)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"unsafe"
+)
+
+// Action tells Traverse how to go on after visiting a value.
+type Action int
+
+const (
+	// Continue goes on into the values the visited value holds.
+	Continue Action = iota
+
+	// SkipChildren goes on past the values the visited value holds.
+	SkipChildren
+
+	// Stop ends the traversal.
+	Stop
+)
+
+// Traverse calls visit with each value reachable from the value held by v,
+// in depth-first order, the value before the values it holds, with its path
+// from v, its type and a pointer to it, and goes on as the returned Action
+// tells. Each pair of a type and a pointer is visited at most once, so that
+// the traversal ends on cyclic values.
+//
+// The values held by a value are:
+//   - the fields of a struct, at the path of the struct followed by
+//     ".Name", including unexported and blank fields;
+//   - the elements of an array or a slice, followed by "[i]";
+//   - the entries of a map, the element of the entry of key k followed by
+//     "[k]", and the key followed by "{k}", with k quoted for a string key
+//     and formatted as fmt.Sprint does otherwise;
+//   - the value a non-nil pointer points to, and the value a non-nil
+//     interface holds, at the same path, as selectors in Go dereference
+//     pointers.
+//
+// The root is at the empty path, and is the copy of the value held by v:
+// it is held by a pointer to values that should be written through. The
+// pointers to the keys and elements of maps are only valid during the call
+// to visit, and must not be written through; the map must not be modified
+// during the traversal. Channels, functions and unsafe pointers hold no
+// values.
+func Traverse(v interface{}, visit func(path string, t *rtype, p unsafe.Pointer) Action) {
+	e := efaceOf(&v)
+	if e.Type == nil {
+		return
+	}
+	tr := traverser{visit: visit, seen: make(map[typedPointer]bool)}
+	tr.walk(e.Type, e.data(), "")
+	runtime.KeepAlive(v)
+}
+
+// traverser walks a value for Traverse.
+type traverser struct {
+	visit func(path string, t *rtype, p unsafe.Pointer) Action
+	seen  map[typedPointer]bool // values visited
+	stop  bool
+}
+
+// walk visits the value of type t at p, at path, and the values it holds.
+func (tr *traverser) walk(t *rtype, p unsafe.Pointer, path string) {
+	q := typedPointer{t, p}
+	if tr.stop || tr.seen[q] {
+		return
+	}
+	tr.seen[q] = true
+	switch tr.visit(path, t, p) {
+	case SkipChildren:
+		return
+	case Stop:
+		tr.stop = true
+		return
+	}
+
+	switch t.Kind() {
+	case Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			f := &st.Fields[i]
+			tr.walk(f.typ, Add(p, f.Offset(), "the field lies within the struct"), path+"."+f.Name.Name())
+		}
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			tr.walk(at.Elem, Add(p, i*at.Elem.size, "i < len"), path+"["+strconv.Itoa(int(i))+"]")
+		}
+	case Slice:
+		et := (*SliceType)(unsafe.Pointer(t)).Elem
+		hdr := (*SliceHeader)(p)
+		for i := 0; i < hdr.Len; i++ {
+			tr.walk(et, Add(hdr.Data, uintptr(i)*et.size, "i < len"), path+"["+strconv.Itoa(i)+"]")
+		}
+	case Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		h := *(*unsafe.Pointer)(p)
+		if h == nil {
+			return
+		}
+		mapRange(mt, h, func(key, elem unsafe.Pointer) bool {
+			k := mapKeyString(mt.Key, key)
+			tr.walk(mt.Key, key, path+"{"+k+"}")
+			tr.walk(mt.Elem, elem, path+"["+k+"]")
+			return !tr.stop
+		})
+	case Ptr:
+		if q := *(*unsafe.Pointer)(p); q != nil {
+			tr.walk((*PtrType)(unsafe.Pointer(t)).Elem, q, path)
+		}
+	case Interface:
+		var dt *rtype
+		if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+			dt = (*InterfaceHeader)(p).Type
+		} else if tab := (*IfaceHeader)(p).Tab; tab != nil {
+			dt = tab.Type
+		}
+		if dt == nil {
+			return
+		}
+		// The data word holds a value of a direct type itself, and a
+		// pointer to it otherwise.
+		word := Add(p, ptrSize, "the data word follows the type word")
+		if ifaceIndir(dt) {
+			word = *(*unsafe.Pointer)(word)
+		}
+		tr.walk(dt, word, path)
+	}
+}
+
+// mapKeyString formats the map key of type t at p for a path of Traverse.
+func mapKeyString(t *rtype, p unsafe.Pointer) string {
+	if t.Kind() == String {
+		return strconv.Quote(*(*string)(p))
+	}
+	return fmt.Sprint(PackEface(t, p))
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type traverseNode struct {
+	Name  string
+	Kids  []*traverseNode
+	Attrs map[string]int8
+	Any   interface{}
+	Arr   [1]uint8
+	_     int8
+	next  *traverseNode
+}
+
+// traverseVisits returns the path and type of each value Traverse visits
+// from v, skipping the children at the path skip and stopping at stop; "-"
+// matches no path.
+func traverseVisits(v interface{}, skip, stop string) []string {
+	var visits []string
+	Traverse(v, func(path string, t *rtype, p unsafe.Pointer) Action {
+		visits = append(visits, path+" "+t.String())
+		switch path {
+		case skip:
+			return SkipChildren
+		case stop:
+			return Stop
+		}
+		return Continue
+	})
+	return visits
+}
+
+func TestTraverse(t *testing.T) {
+	leaf := &traverseNode{Name: "leaf"}
+	root := &traverseNode{
+		Name:  "root",
+		Kids:  []*traverseNode{leaf, leaf},
+		Attrs: map[string]int8{"a\"": 1},
+		Any:   int16(2),
+	}
+	root.next = root
+	leaf.Any = leaf
+
+	node := "reflection.traverseNode"
+	want := []string{
+		" *" + node,
+		" " + node,
+		".Name string",
+		".Kids []*" + node,
+		".Kids[0] *" + node,
+		".Kids[0] " + node,
+		".Kids[0].Name string",
+		".Kids[0].Kids []*" + node,
+		".Kids[0].Attrs map[string]int8",
+		".Kids[0].Any interface {}",
+		".Kids[0].Any *" + node,
+		".Kids[0].Arr [1]uint8",
+		".Kids[0].Arr[0] uint8",
+		".Kids[0]._ int8",
+		".Kids[0].next *" + node,
+		".Kids[1] *" + node,
+		`.Attrs map[string]int8`,
+		`.Attrs{"a\""} string`,
+		`.Attrs["a\""] int8`,
+		".Any interface {}",
+		".Any int16",
+		".Arr [1]uint8",
+		".Arr[0] uint8",
+		"._ int8",
+		".next *" + node,
+	}
+	if got := traverseVisits(root, "-", "-"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Traverse visited\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	got := traverseVisits(root, ".Kids", ".Any")
+	want = []string{" *" + node, " " + node, ".Name string", ".Kids []*" + node, ".Attrs map[string]int8", `.Attrs{"a\""} string`, `.Attrs["a\""] int8`, ".Any interface {}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Traverse with SkipChildren and Stop visited\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	if got := traverseVisits(nil, "-", "-"); got != nil {
+		t.Errorf("Traverse(nil) visited %q", got)
+	}
+	if got, want := traverseVisits(map[[2]int]bool{{1, 2}: true}, "-", "-"), []string{
+		" map[[2]int]bool", "{[1 2]} [2]int", "{[1 2]}[0] int", "{[1 2]}[1] int", "[[1 2]] bool",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Traverse of a map visited %q, want %q", got, want)
+	}
+	var e error
+	if got, want := traverseVisits([]interface{}{e, (*int)(nil)}, "-", "-"), []string{
+		" []interface {}", "[0] interface {}", "[1] interface {}", "[1] *int",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Traverse of nil values visited %q, want %q", got, want)
+	}
+}
+
+func TestTraverseWrite(t *testing.T) {
+	v := &traverseNode{Name: "a", Arr: [1]uint8{1}}
+	Traverse(v, func(path string, t *rtype, p unsafe.Pointer) Action {
+		if path == ".Arr[0]" {
+			*(*uint8)(p) = 9
+		}
+		return Continue
+	})
+	if v.Arr[0] != 9 {
+		t.Errorf("write through a pointer of Traverse lost: %v", v.Arr)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"unsafe"
+)
+
+// ErrRuntimePanic is wrapped by the errors that the Try functions return in
+// place of a panic raised by the runtime. The message of the panic follows.
+var ErrRuntimePanic = errors.New("reflection: runtime panic")
+
+// Try calls fn, converting a panic raised by the runtime during the call,
+// such as an index out of range, a nil dereference or the hash of an
+// unhashable type, into an error wrapping ErrRuntimePanic. Panics raised
+// by other code are propagated. A memory fault on an address outside the
+// first page, which otherwise crashes the program, also becomes an error,
+// as debug.SetPanicOnFault arranges for the duration of the call.
+//
+// Not every failure can be converted. The runtime does not panic but aborts
+// the program when it detects corrupted state, such as a bad pointer met by
+// the garbage collector, a concurrent map write, or a panic while pinned by
+// ProcPin; and a write through a wrong but valid pointer corrupts memory
+// silently, to fail later, if at all.
+func Try(fn func()) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer recoverPanic(&err)
+	fn()
+	return nil
+}
+
+// recoverPanic converts a runtime panic into *errp, keeping its message.
+// Any other panic is propagated.
+func recoverPanic(errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(runtime.Error); !ok {
+		panic(r)
+	}
+	*errp = fmt.Errorf("%w: %v", ErrRuntimePanic, r)
+}
+
+// TryAsStructType is like AsStructType, but also checks that the fields of t
+// lie within its size, and returns an error wrapping
+// ErrRuntimePanic if reading the descriptor panics, as Try does.
+func TryAsStructType(t *rtype) (st *StructType, err error) {
+	if k := kindOf(t); k != Struct {
+		return nil, &KindError{Op: "TryAsStructType", Kind: k}
+	}
+	perr := Try(func() {
+		st = (*StructType)(unsafe.Pointer(t))
+		for i := range st.Fields {
+			f := &st.Fields[i]
+			if f.typ == nil || f.Offset() > t.size || f.typ.size > t.size-f.Offset() {
+				st, err = nil, fmt.Errorf("reflection: field %d of %s out of bounds", i, t)
+				return
+			}
+		}
+	})
+	if perr != nil {
+		return nil, perr
+	}
+	return st, err
+}
+
+// TryUnpackEface returns the dynamic type of i and a pointer to its value,
+// as held by the interface, which must not be modified. It returns a
+// *KindError for a nil interface, and an error if the data word of an
+// indirect interface is nil or not aligned for the type.
+func TryUnpackEface(i interface{}) (t *rtype, p unsafe.Pointer, err error) {
+	e := efaceOf(&i)
+	if e.Type == nil {
+		return nil, nil, &KindError{Op: "TryUnpackEface", Kind: Invalid}
+	}
+	if ifaceIndir(e.Type) {
+		if e.Word == nil && e.Type.size != 0 {
+			return nil, nil, fmt.Errorf("%w: data of %s interface", ErrNilPointer, e.Type)
+		}
+		if uintptr(e.Word)%uintptr(e.Type.align) != 0 {
+			return nil, nil, fmt.Errorf("reflection: misaligned data of %s interface at %p", e.Type, e.Word)
+		}
+	}
+	return e.Type, e.data(), nil
+}
+
+// TryFieldPointer returns a pointer to the field named by path, as OffsetOf
+// accepts it, of the struct pointed to by v. It returns a *KindError if v
+// does not hold a pointer to a struct, ErrNilPointer if the pointer is nil,
+// an error wrapping ErrFieldNotFound if path names no field, and an error
+// wrapping ErrMisaligned if the field is not aligned for its type, which
+// only a corrupted descriptor causes.
+func TryFieldPointer(v interface{}, path string) (p unsafe.Pointer, err error) {
+	e := efaceOf(&v)
+	if k := kindOf(e.Type); k != Ptr {
+		return nil, &KindError{Op: "TryFieldPointer", Kind: k}
+	}
+	st := elem(e.Type)
+	if k := st.Kind(); k != Struct {
+		return nil, &KindError{Op: "TryFieldPointer", Kind: k}
+	}
+	if e.Word == nil {
+		return nil, fmt.Errorf("%w: TryFieldPointer of %s", ErrNilPointer, e.Type)
+	}
+	var off uintptr
+	var ft *rtype
+	if perr := Try(func() { off, ft, _, err = lookupField(st, "TryFieldPointer", path) }); perr != nil {
+		return nil, perr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if off > st.size || ft.size > st.size-off {
+		return nil, fmt.Errorf("reflection: field %s of %s out of bounds", path, st)
+	}
+	p = Add(e.Word, off, "the field lies within the struct")
+	if uintptr(p)%uintptr(ft.align) != 0 {
+		return nil, fmt.Errorf("%w: field %s of %s at %p", ErrMisaligned, path, st, p)
+	}
+	return p, nil
+}
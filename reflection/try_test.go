@@ -0,0 +1,120 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// tryBadAddr is an unmapped address past the first page, laundered through
+// memory so that vet and checkptr do not see the conversion.
+var tryBadAddr = func() unsafe.Pointer {
+	u := uintptr(0xdead0000)
+	return *(*unsafe.Pointer)(unsafe.Pointer(&u))
+}()
+
+func TestTry(t *testing.T) {
+	var s []int
+	var m map[string]int
+	var p *fieldT
+	tests := []struct {
+		name string
+		fn   func()
+		msg  string
+	}{
+		{"index", func() { _ = s[3] }, "index out of range"},
+		{"nil map", func() { m["x"] = 1 }, "nil map"},
+		{"nil pointer", func() { _ = p.Name }, "nil pointer"},
+		{"unhashable", func() { _ = map[interface{}]int{[]int{}: 1} }, "unhashable"},
+		{"fault", func() { _ = *(*int)(tryBadAddr) }, "invalid memory address"},
+	}
+	for _, tt := range tests {
+		err := Try(tt.fn)
+		if !errors.Is(err, ErrRuntimePanic) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("Try(%s) = %v, want ErrRuntimePanic mentioning %q", tt.name, err, tt.msg)
+		}
+	}
+	if err := Try(func() {}); err != nil {
+		t.Errorf("Try of a function that returns = %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "not a runtime error" {
+			t.Errorf("Try recovered a panic of other code, then panicked with %v", r)
+		}
+	}()
+	Try(func() { panic("not a runtime error") })
+	t.Error("Try returned from a panic of other code")
+}
+
+func TestTryAsStructType(t *testing.T) {
+	st, err := TryAsStructType(TypeFor[fieldT]())
+	if err != nil || st == nil || len(st.Fields) != 4 {
+		t.Errorf("TryAsStructType(fieldT) = %v, %v", st, err)
+	}
+	var ke *KindError
+	if _, err := TryAsStructType(TypeFor[int]()); !errors.As(err, &ke) {
+		t.Errorf("TryAsStructType(int) = %v, want a *KindError", err)
+	}
+	if _, err := TryAsStructType(nil); !errors.As(err, &ke) {
+		t.Errorf("TryAsStructType(nil) = %v, want a *KindError", err)
+	}
+}
+
+func TestTryUnpackEface(t *testing.T) {
+	x := 1
+	for _, v := range []interface{}{42, "s", &x, fieldT{Name: "n"}, struct{}{}, [0]int{}} {
+		typ, p, err := TryUnpackEface(v)
+		if err != nil || typ != efaceOf(&v).Type {
+			t.Errorf("TryUnpackEface(%#v) = %v, %v", v, typ, err)
+			continue
+		}
+		// A pointer-shaped value is held in the data word itself.
+		word := efaceOf(&v).Word
+		if !ifaceIndir(typ) {
+			p = *(*unsafe.Pointer)(p)
+		}
+		if p != word {
+			t.Errorf("TryUnpackEface(%#v) data = %p, want %p", v, p, word)
+		}
+	}
+	var ke *KindError
+	if _, _, err := TryUnpackEface(nil); !errors.As(err, &ke) {
+		t.Errorf("TryUnpackEface(nil) = %v, want a *KindError", err)
+	}
+
+	// A corrupted interface holding no data for a non-empty value.
+	var bad interface{} = fieldT{}
+	efaceOf(&bad).Word = nil
+	if _, _, err := TryUnpackEface(bad); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("TryUnpackEface of nil data = %v, want ErrNilPointer", err)
+	}
+}
+
+func TestTryFieldPointer(t *testing.T) {
+	v := &fieldT{Name: "n", Inner: fieldInner{X: 7}}
+	p, err := TryFieldPointer(v, "Inner.X")
+	if err != nil || p != unsafe.Pointer(&v.Inner.X) {
+		t.Errorf("TryFieldPointer(Inner.X) = %p, %v, want %p", p, err, &v.Inner.X)
+	}
+	if p, err := TryFieldPointer(v, "n"); err != nil || p != unsafe.Pointer(&v.n) {
+		t.Errorf("TryFieldPointer(n) = %p, %v, want %p", p, err, &v.n)
+	}
+	if _, err := TryFieldPointer(v, "Missing"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("TryFieldPointer(Missing) = %v, want ErrFieldNotFound", err)
+	}
+	if _, err := TryFieldPointer((*fieldT)(nil), "Name"); !errors.Is(err, ErrNilPointer) {
+		t.Errorf("TryFieldPointer of a nil pointer = %v, want ErrNilPointer", err)
+	}
+	var ke *KindError
+	for _, v := range []interface{}{fieldT{}, new(int), nil} {
+		if _, err := TryFieldPointer(v, "Name"); !errors.As(err, &ke) {
+			t.Errorf("TryFieldPointer(%T) = %v, want a *KindError", v, err)
+		}
+	}
+}
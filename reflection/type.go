@@ -3,9 +3,19 @@
 // license that can be found in the LICENSE file.
 
 // Package reflection exports of stdlib reflect package.
+//
+// The package reads the runtime's type descriptors and memory layouts
+// directly, and calls runtime functions through linkname, so it is tied to
+// the Go releases it mirrors. The darkness_safe build tag narrows this,
+// implementing the map operations and the accessors of type descriptors with
+// package reflect, and making the operations reflect cannot do return
+// ErrUnsupported.
 package reflection
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
 	"unsafe"
 )
 
@@ -15,12 +25,25 @@ type StringHeader struct {
 	Len  int
 }
 
+// SliceHeader is the header for a slice type.
+type SliceHeader struct {
+	Data unsafe.Pointer
+	Len  int
+	Cap  int
+}
+
 // InterfaceHeader is the header for an interface{} value.
 type InterfaceHeader struct {
 	Type *rtype         // 8 bytes for the pointer to the actual struct data followed by
 	Word unsafe.Pointer // 8 bytes for the pointer to the type information
 }
 
+// IfaceHeader is the header for a value of a non-empty interface type.
+type IfaceHeader struct {
+	Tab  *ITab          // itab of the interface type and the dynamic type
+	Word unsafe.Pointer // data word, as in InterfaceHeader
+}
+
 // tflag is used by an rtype to signal what extra type information is
 // available in the memory directly following the rtype value.
 //
@@ -30,6 +53,33 @@ type InterfaceHeader struct {
 //	runtime/type.go
 type tflag uint8
 
+var tflagNames = []string{
+	"uncommon",
+	"extraStar",
+	"named",
+	"regularMemory",
+	"gcMaskOnDemand",
+	"directIface",
+}
+
+// String returns the names of the flags set in f, separated by '|'.
+func (f tflag) String() string {
+	if f == 0 {
+		return "0"
+	}
+	var s []byte
+	for i, name := range tflagNames {
+		if f&(1<<i) == 0 {
+			continue
+		}
+		if len(s) > 0 {
+			s = append(s, '|')
+		}
+		s = append(s, name...)
+	}
+	return string(s)
+}
+
 const (
 	// TflagUncommon means that there is a pointer, *uncommonType,
 	// just beyond the outer type structure.
@@ -56,6 +106,104 @@ const (
 	// TflagRegularMemory means that equal and hash functions can treat
 	// this type as a single region of t.size bytes.
 	TflagRegularMemory tflag = 1 << 3
+
+	// TflagGCMaskOnDemand means that the GC pointer bitmask is computed
+	// on demand by the runtime, and the gcdata field is effectively a
+	// **byte instead of a *byte. Only used since Go 1.24.
+	TflagGCMaskOnDemand tflag = 1 << 4
+
+	// TflagDirectIface means that a value of this type is stored directly
+	// in the data field of an interface, instead of indirectly.
+	// Toolchains before Go 1.26 record this in the kind field instead,
+	// see KindDirectIface.
+	TflagDirectIface tflag = 1 << 5
+)
+
+// A Kind represents the specific kind of type that a rtype represents.
+// The zero Kind is not a valid kind.
+type Kind uint
+
+const (
+	Invalid Kind = iota
+	Bool
+	Int
+	Int8
+	Int16
+	Int32
+	Int64
+	Uint
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	Uintptr
+	Float32
+	Float64
+	Complex64
+	Complex128
+	Array
+	Chan
+	Func
+	Interface
+	Map
+	Ptr
+	Slice
+	String
+	Struct
+	UnsafePointer
+)
+
+var kindNames = []string{
+	Invalid:       "invalid",
+	Bool:          "bool",
+	Int:           "int",
+	Int8:          "int8",
+	Int16:         "int16",
+	Int32:         "int32",
+	Int64:         "int64",
+	Uint:          "uint",
+	Uint8:         "uint8",
+	Uint16:        "uint16",
+	Uint32:        "uint32",
+	Uint64:        "uint64",
+	Uintptr:       "uintptr",
+	Float32:       "float32",
+	Float64:       "float64",
+	Complex64:     "complex64",
+	Complex128:    "complex128",
+	Array:         "array",
+	Chan:          "chan",
+	Func:          "func",
+	Interface:     "interface",
+	Map:           "map",
+	Ptr:           "ptr",
+	Slice:         "slice",
+	String:        "string",
+	Struct:        "struct",
+	UnsafePointer: "unsafe.Pointer",
+}
+
+// String returns the name of k.
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "kind" + strconv.Itoa(int(k))
+}
+
+// Bits of the kind field of an rtype besides the Kind itself.
+const (
+	// KindDirectIface means that a value of the type is stored directly in
+	// the data word of an interface. Since Go 1.26 the runtime records this
+	// in TflagDirectIface instead.
+	KindDirectIface uint8 = 1 << 5
+
+	// KindGCProg means that the gcdata of the type is a GC program rather
+	// than a pointer bitmask. Only used before Go 1.24.
+	KindGCProg uint8 = 1 << 6
+
+	// KindMask selects the Kind from the kind field.
+	KindMask uint8 = (1 << 5) - 1
 )
 
 type rtype struct {
@@ -74,18 +222,258 @@ type rtype struct {
 	ptrToThis TypeOff // type for pointer to this type, may be zero
 }
 
+// kindOf returns the kind of t, or Invalid if t is nil.
+func kindOf(t *rtype) Kind {
+	if t == nil {
+		return Invalid
+	}
+	return t.Kind()
+}
+
+// ifaceIndir reports whether t is stored indirectly in an interface value.
+func ifaceIndir(t *rtype) bool {
+	return t.kind&KindDirectIface == 0 && t.tflag&TflagDirectIface == 0
+}
+
 // StructType represents a struct type.
+//
+// Fields are in declaration order. The gc compiler never reorders fields, so
+// this is also the order of increasing offsets, except that zero-sized fields
+// may share the offset of the next field.
+//
+// Fields aliases the type descriptor, usually in read-only memory of the
+// binary, which the runtime and reflect rely on. It must never be modified,
+// nor sorted in place: use FieldsCopy, or the permutations of the
+// SortFieldsBy functions, instead.
 type StructType struct {
 	rtype
 	PkgPath Name
-	Fields  []StructField // sorted by offset
+	Fields  []StructField // in declaration order
+}
+
+// Field returns the i'th field of the struct type.
+// It panics if i is not in the range [0, NumField()).
+func (t *StructType) Field(i int) *StructField {
+	return &t.Fields[i]
+}
+
+// FieldsCopy returns a copy of the fields of the struct type, which may be
+// reordered freely.
+func (t *StructType) FieldsCopy() []StructField {
+	return append([]StructField(nil), t.Fields...)
+}
+
+// Range calls fn for each field of the struct type in order, until fn
+// returns false.
+func (t *StructType) Range(fn func(i int, f *StructField) bool) {
+	for i := range t.Fields {
+		if !fn(i, &t.Fields[i]) {
+			return
+		}
+	}
+}
+
+// ArrayType represents a fixed array type.
+type ArrayType struct {
+	rtype
+	Elem  *rtype // array element type
+	Slice *rtype // slice type
+	Len   uintptr
+}
+
+// ChanDir represents a channel type's direction.
+type ChanDir int
+
+const (
+	RecvDir ChanDir             = 1 << iota // <-chan
+	SendDir                                 // chan<-
+	BothDir = RecvDir | SendDir             // chan
+)
+
+// ChanType represents a channel type.
+type ChanType struct {
+	rtype
+	Elem *rtype  // channel element type
+	Dir  uintptr // channel direction (ChanDir)
+}
+
+// FuncType represents a function type.
+//
+// A *rtype for each in and out parameter is stored in an array that
+// directly follows the FuncType (and possibly its UncommonType).
+type FuncType struct {
+	rtype
+	InCount  uint16
+	OutCount uint16 // top bit is set if last input parameter is ...
+}
+
+// params returns the input parameter types followed by the output ones.
+func (t *FuncType) params() []*rtype {
+	n := t.NumIn() + t.NumOut()
+	if n == 0 {
+		return nil
+	}
+	uadd := unsafe.Sizeof(*t)
+	if t.tflag&TflagUncommon != 0 {
+		uadd += unsafe.Sizeof(UncommonType{})
+	}
+	return unsafe.Slice((**rtype)(Add(unsafe.Pointer(t), uadd, "n > 0")), n)
+}
+
+// Imethod represents a method on an interface type.
+type Imethod struct {
+	Name NameOff // name of method
+	Typ  TypeOff // .(*FuncType) underneath
+}
+
+// InterfaceType represents an interface type.
+type InterfaceType struct {
+	rtype
+	PkgPath Name      // import path
+	Methods []Imethod // sorted by hash
+}
+
+// IfaceMethod is a method of an interface type, with its name and type resolved.
+type IfaceMethod struct {
+	Name    string
+	PkgPath string // empty for exported methods
+	Type    *FuncType
+}
+
+// MethodSet returns the methods of the interface type, sorted by name.
+// Methods of embedded interfaces are included: the compiler flattens them
+// into the method list of the embedding interface.
+func (t *InterfaceType) MethodSet() []IfaceMethod {
+	ms := make([]IfaceMethod, len(t.Methods))
+	for i, m := range t.Methods {
+		n := t.NameOff(m.Name)
+		ms[i] = IfaceMethod{
+			Name: n.Name(),
+			Type: (*FuncType)(unsafe.Pointer(t.TypeOff(m.Typ))),
+		}
+		if !n.IsExported() {
+			ms[i].PkgPath = methodPkgPath(n, t.PkgPath.Name())
+		}
+	}
+	return ms
+}
+
+// PtrType represents a pointer type.
+type PtrType struct {
+	rtype
+	Elem *rtype // pointer element (pointed at) type
+}
+
+// SliceType represents a slice type.
+type SliceType struct {
+	rtype
+	Elem *rtype // slice element type
 }
 
 // StructField represents a struct field.
+//
+// The meaning of OffsetEmbed depends on the toolchain that built the binary,
+// use the Offset and Embedded methods to decode it.
 type StructField struct {
 	Name        Name    // name is always non-empty
 	typ         *rtype  // type of field
-	OffsetEmbed uintptr // byte offset of field<<1 | isEmbedded
+	OffsetEmbed uintptr // byte offset of field<<1 | isEmbedded before Go 1.19, byte offset of field since
+}
+
+// Type returns the type of the field.
+func (f *StructField) Type() *rtype {
+	return f.typ
+}
+
+// Method is a method on a non-interface type.
+type Method struct {
+	Name NameOff // name of method
+	Mtyp TypeOff // method type (without receiver)
+	Ifn  TextOff // fn used in interface call (one-word receiver)
+	Tfn  TextOff // fn used for normal method call
+}
+
+// UncommonType is present only for defined types or types with methods
+// (if T is a defined type, the uncommonTypes for T and *T have methods).
+// Using a pointer to this struct reduces the overall size required
+// to describe a non-defined type with no methods.
+type UncommonType struct {
+	PkgPath NameOff // import path; empty for built-in types like int, string
+	Mcount  uint16  // number of methods
+	Xcount  uint16  // number of exported methods
+	Moff    uint32  // offset from this uncommontype to [mcount]Method
+	_       uint32  // unused
+}
+
+// Methods returns the methods of the type, exported ones first.
+func (t *UncommonType) Methods() []Method {
+	if t.Mcount == 0 {
+		return nil
+	}
+	return unsafe.Slice((*Method)(Add(unsafe.Pointer(t), uintptr(t.Moff), "t.mcount > 0")), t.Mcount)
+}
+
+// Uncommon returns the UncommonType data of t, or nil if t has none.
+func (t *rtype) Uncommon() *UncommonType {
+	if t.tflag&TflagUncommon == 0 {
+		return nil
+	}
+	switch t.Kind() {
+	case Struct:
+		type u struct {
+			StructType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Ptr:
+		type u struct {
+			PtrType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Func:
+		type u struct {
+			FuncType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Slice:
+		type u struct {
+			SliceType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Array:
+		type u struct {
+			ArrayType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Chan:
+		type u struct {
+			ChanType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Map:
+		type u struct {
+			MapType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	case Interface:
+		type u struct {
+			InterfaceType
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	default:
+		type u struct {
+			rtype
+			u UncommonType
+		}
+		return &(*u)(unsafe.Pointer(t)).u
+	}
 }
 
 // Add returns p+x.
@@ -95,8 +483,12 @@ type StructField struct {
 // record why the addition is safe, which is to say why the addition
 // does not cause x to advance to the very end of p's allocation
 // and therefore point incorrectly at the next block in memory.
+//
+// The addition is done with unsafe.Add rather than through uintptr
+// so that the result stays derived from p as the checkptr
+// instrumentation requires.
 func Add(p unsafe.Pointer, x uintptr, whySafe string) unsafe.Pointer {
-	return unsafe.Pointer(uintptr(p) + x)
+	return unsafe.Add(p, x)
 }
 
 // Name is an encoded type Name with optional extra data.
@@ -106,15 +498,13 @@ func Add(p unsafe.Pointer, x uintptr, whySafe string) unsafe.Pointer {
 //	1<<0 the Name is exported
 //	1<<1 tag data follows the Name
 //	1<<2 pkgPath nameOff follows the Name and tag
+//	1<<3 the Name is of an embedded (a.k.a. anonymous) field
 //
-// The next two bytes are the data length:
-//
-//	 l := uint16(data[1])<<8 | uint16(data[2])
+// Following that, there is a varint-encoded length of the Name,
+// followed by the Name itself.
 //
-// Bytes [3:3+l] are the string data.
-//
-// If tag data follows then bytes 3+l and 3+l+1 are the tag length,
-// with the data following.
+// If tag data is present, it also has a varint-encoded length
+// followed by the tag itself.
 //
 // If the import path follows, then 4 bytes at the end of
 // the data form a nameOff. The import path is only set for concrete
@@ -122,6 +512,13 @@ func Add(p unsafe.Pointer, x uintptr, whySafe string) unsafe.Pointer {
 //
 // If a Name starts with "*", then the exported bit represents
 // whether the pointed to type is exported.
+//
+// Note: this encoding must match here and in:
+//
+//	cmd/compile/internal/reflectdata/reflect.go
+//	cmd/link/internal/ld/decodesym.go
+//	runtime/type.go
+//	internal/reflectlite/type.go
 type Name struct {
 	bytes *byte
 }
@@ -134,104 +531,276 @@ func (n Name) IsExported() bool {
 	return (*n.bytes)&(1<<0) != 0
 }
 
+func (n Name) HasTag() bool {
+	return (*n.bytes)&(1<<1) != 0
+}
+
+func (n Name) IsEmbedded() bool {
+	return (*n.bytes)&(1<<3) != 0
+}
+
+// readVarint parses a varint as encoded by encoding/binary.
+// It returns the number of encoded bytes and the encoded value.
+func (n Name) readVarint(off int) (int, int) {
+	v := 0
+	for i := 0; ; i++ {
+		x := *n.Data(off+i, "read varint")
+		v += int(x&0x7f) << (7 * i)
+		if x&0x80 == 0 {
+			return i + 1, v
+		}
+	}
+}
+
 func (n Name) NameLen() int {
-	return int(uint16(*n.Data(1, "name len field"))<<8 | uint16(*n.Data(2, "name len field")))
+	_, l := n.readVarint(1)
+	return l
 }
 
 func (n Name) TagLen() int {
-	if *n.Data(0, "name flag field")&(1<<1) == 0 {
+	if !n.HasTag() {
 		return 0
 	}
-	off := 3 + n.NameLen()
-	return int(uint16(*n.Data(off, "name taglen field"))<<8 | uint16(*n.Data(off+1, "name taglen field")))
+	i, l := n.readVarint(1)
+	_, l2 := n.readVarint(1 + i + l)
+	return l2
 }
 
 func (n Name) Name() (s string) {
-	if n.bytes == nil {
+	p, l := n.nameData()
+	if l == 0 {
 		return
 	}
-	b := (*[4]byte)(unsafe.Pointer(n.bytes))
-
-	hdr := (*StringHeader)(unsafe.Pointer(&s))
-	hdr.Data = unsafe.Pointer(&b[3])
-	hdr.Len = int(b[1])<<8 | int(b[2])
-	return s
+	return unsafeString(p, l)
 }
 
-func (n Name) Tag() (s string) {
-	tl := n.TagLen()
-	if tl == 0 {
+func (n Name) Tag() string {
+	p, l := n.tagData()
+	if l == 0 {
 		return ""
 	}
-	nl := n.NameLen()
-	hdr := (*StringHeader)(unsafe.Pointer(&s))
-	hdr.Data = unsafe.Pointer(n.Data(3+nl+2, "non-empty string"))
-	hdr.Len = tl
-	return s
+	return unsafeString(p, l)
+}
+
+// nameData returns the address and length of the name bytes of n.
+// The address is nil if the name is empty.
+func (n Name) nameData() (*byte, int) {
+	if n.bytes == nil {
+		return nil, 0
+	}
+	i, l := n.readVarint(1)
+	if l == 0 {
+		return nil, 0
+	}
+	return n.Data(1+i, "non-empty string"), l
+}
+
+// tagData returns the address and length of the tag bytes of n.
+// The address is nil if the tag is empty.
+func (n Name) tagData() (*byte, int) {
+	if n.bytes == nil || !n.HasTag() {
+		return nil, 0
+	}
+	i, l := n.readVarint(1)
+	i2, l2 := n.readVarint(1 + i + l)
+	if l2 == 0 {
+		return nil, 0
+	}
+	return n.Data(1+i+l+i2, "non-empty string"), l2
+}
+
+// NameBytes returns the name of n as a view of its encoded data, which must
+// not be modified. Its capacity is its length, so that appending to it
+// copies it rather than overwriting the data that follows.
+func (n Name) NameBytes() []byte {
+	p, l := n.nameData()
+	if l == 0 {
+		return nil
+	}
+	return unsafe.Slice(p, l)
+}
+
+// TagBytes returns the tag of n as a view of its encoded data, as NameBytes
+// does for the name.
+func (n Name) TagBytes() []byte {
+	p, l := n.tagData()
+	if l == 0 {
+		return nil
+	}
+	return unsafe.Slice(p, l)
+}
+
+// AppendTo appends the name of n to dst and returns the extended buffer.
+// The name is copied from the encoded data, without building a string.
+func (n Name) AppendTo(dst []byte) []byte {
+	return append(dst, n.NameBytes()...)
+}
+
+// AppendTag appends the tag of n to dst and returns the extended buffer.
+func (n Name) AppendTag(dst []byte) []byte {
+	return append(dst, n.TagBytes()...)
 }
 
 func (n Name) PkgPath() string {
 	if n.bytes == nil || *n.Data(0, "name flag field")&(1<<2) == 0 {
 		return ""
 	}
-	off := 3 + n.NameLen()
-	if tl := n.TagLen(); tl > 0 {
-		off += 2 + tl
+	i, l := n.readVarint(1)
+	off := 1 + i + l
+	if n.HasTag() {
+		i2, l2 := n.readVarint(off)
+		off += i2 + l2
 	}
 	var nameOff int32
 	// Note that this field may not be aligned in memory,
 	// so we cannot use a direct int32 assignment here.
-	copy((*[4]byte)(unsafe.Pointer(&nameOff))[:], (*[4]byte)(unsafe.Pointer(n.Data(off, "name offset field")))[:])
+	// It is copied byte by byte so that no array type extending
+	// past the encoded Name is materialized.
+	b := (*[4]byte)(unsafe.Pointer(&nameOff))
+	for i := range b {
+		b[i] = *n.Data(off+i, "name offset field")
+	}
 	pkgPathName := Name{(*byte)(ResolveTypeOff(unsafe.Pointer(n.bytes), nameOff))}
 	return pkgPathName.Name()
 }
 
+// Equal reports whether n and other have the same name and tag, regardless
+// of their flags. It compares the encoded bytes in place. The zero Name has
+// an empty name and tag, as has the encoding of an empty name.
+func (n Name) Equal(other Name) bool {
+	if n.bytes == other.bytes {
+		return true
+	}
+	return string(n.NameBytes()) == string(other.NameBytes()) &&
+		string(n.TagBytes()) == string(other.TagBytes())
+}
+
+// EqualString reports whether the name of n is s, without materializing it.
+func (n Name) EqualString(s string) bool {
+	if n.bytes == nil {
+		return s == ""
+	}
+	i, l := n.readVarint(1)
+	if l != len(s) {
+		return false
+	}
+	return l == 0 || string(unsafe.Slice(n.Data(1+i, "non-empty string"), l)) == s
+}
+
+// Compare returns an integer comparing n and other by name, then by tag.
+// The result is 0 if n.Equal(other), -1 if n < other, and +1 if n > other.
+// Like Equal, it compares the encoded bytes in place.
+func (n Name) Compare(other Name) int {
+	if n.bytes == other.bytes {
+		return 0
+	}
+	if c := bytes.Compare(n.NameBytes(), other.NameBytes()); c != 0 {
+		return c
+	}
+	return bytes.Compare(n.TagBytes(), other.TagBytes())
+}
+
+// writeVarint writes n to buf in varint form. Returns the
+// number of bytes written. n must be nonnegative.
+// Writes at most 10 bytes.
+func writeVarint(buf []byte, n int) int {
+	for i := 0; ; i++ {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			buf[i] = b
+			return i + 1
+		}
+		buf[i] = b | 0x80
+	}
+}
+
+// NewName encodes the name n with the given tag and exported flag.
+// It panics if the name or the tag is too long; see NewNameErr.
 func NewName(n, tag string, exported bool) Name {
-	if len(n) > 1<<16-1 {
-		panic("reflect.nameFrom: name too long: " + n)
+	name, err := NewNameErr(n, tag, exported)
+	if err != nil {
+		panic(err)
 	}
-	if len(tag) > 1<<16-1 {
-		panic("reflect.nameFrom: tag too long: " + tag)
+	return name
+}
+
+// maxNameLen is the limit the compiler and the reflect package put on the
+// length of names and tags.
+const maxNameLen = 1 << 29
+
+// NewNameErr is like NewName but returns ErrNameTooLong, instead of
+// panicking, if the name or the tag is too long.
+func NewNameErr(n, tag string, exported bool) (Name, error) {
+	if len(n) >= maxNameLen {
+		return Name{}, fmt.Errorf("%w: name %s...", ErrNameTooLong, n[:1024])
 	}
+	if len(tag) >= maxNameLen {
+		return Name{}, fmt.Errorf("%w: tag %s...", ErrNameTooLong, tag[:1024])
+	}
+	var nameLen [10]byte
+	var tagLen [10]byte
+	nameLenLen := writeVarint(nameLen[:], len(n))
+	tagLenLen := writeVarint(tagLen[:], len(tag))
 
 	var bits byte
-	l := 1 + 2 + len(n)
+	l := 1 + nameLenLen + len(n)
 	if exported {
 		bits |= 1 << 0
 	}
 	if len(tag) > 0 {
-		l += 2 + len(tag)
+		l += tagLenLen + len(tag)
 		bits |= 1 << 1
 	}
 
 	b := make([]byte, l)
 	b[0] = bits
-	b[1] = uint8(len(n) >> 8)
-	b[2] = uint8(len(n))
-	copy(b[3:], n)
+	copy(b[1:], nameLen[:nameLenLen])
+	copy(b[1+nameLenLen:], n)
 	if len(tag) > 0 {
-		tb := b[3+len(n):]
-		tb[0] = uint8(len(tag) >> 8)
-		tb[1] = uint8(len(tag))
-		copy(tb[2:], tag)
+		tb := b[1+nameLenLen+len(n):]
+		copy(tb, tagLen[:tagLenLen])
+		copy(tb[tagLenLen:], tag)
 	}
 
-	return Name{bytes: &b[0]}
+	return Name{bytes: &b[0]}, nil
 }
 
-//go:linkname ResolveNameOff reflect.resolveNameOff
+//go:linkname runtimeResolveNameOff reflect.resolveNameOff
 
-// ResolveNameOff resolves a name offset from a base pointer.
-// The (*rtype).nameOff method is a convenience wrapper for this function.
+// runtimeResolveNameOff resolves a name offset from a base pointer.
 // Implemented in the runtime package.
-func ResolveNameOff(ptrInModule unsafe.Pointer, off int32) unsafe.Pointer
+func runtimeResolveNameOff(ptrInModule unsafe.Pointer, off int32) unsafe.Pointer
 
-//go:linkname ResolveTypeOff reflect.resolveTypeOff
+//go:linkname runtimeResolveTypeOff reflect.resolveTypeOff
 
-// ResolveTypeOff resolves an *rtype offset from a base type.
-// The (*rtype).typeOff method is a convenience wrapper for this function.
+// runtimeResolveTypeOff resolves an *rtype offset from a base type.
 // Implemented in the runtime package.
-func ResolveTypeOff(rtype unsafe.Pointer, off int32) unsafe.Pointer
+func runtimeResolveTypeOff(rtype unsafe.Pointer, off int32) unsafe.Pointer
+
+// ResolveNameOff resolves a name offset from a base pointer, as selected by
+// SetResolver. The (*rtype).nameOff method is a convenience wrapper for this
+// function.
+func ResolveNameOff(ptrInModule unsafe.Pointer, off int32) unsafe.Pointer {
+	if resolverMode() == ResolveModules {
+		if p, ok := moduleResolveNameOff(ptrInModule, off); ok {
+			return p
+		}
+	}
+	return runtimeResolveNameOff(ptrInModule, off)
+}
+
+// ResolveTypeOff resolves an *rtype offset from a base type, as selected by
+// SetResolver. The (*rtype).typeOff method is a convenience wrapper for this
+// function.
+func ResolveTypeOff(rtype unsafe.Pointer, off int32) unsafe.Pointer {
+	if resolverMode() == ResolveModules {
+		if p, ok := moduleResolveTypeOff(rtype, off); ok {
+			return p
+		}
+	}
+	return runtimeResolveTypeOff(rtype, off)
+}
 
 //go:linkname ResolveTextOff reflect.resolveTextOff
 
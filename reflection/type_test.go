@@ -0,0 +1,127 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type typeKey struct{ A, B int }
+
+func TestKeyElemArrayLen(t *testing.T) {
+	tests := []interface{}{
+		map[string]int{}, map[typeKey][]byte{}, map[*int]struct{}{},
+		[0]int{}, [3]string{}, [1 << 10]byte{},
+		[]int{}, (*int)(nil), (chan bool)(nil), 0, "", typeKey{},
+	}
+	for _, v := range tests {
+		rt := reflect.TypeOf(v)
+		typ := FromReflect(rt)
+		var ke *KindError
+
+		key, err := typ.Key()
+		if rt.Kind() == reflect.Map {
+			if err != nil || ToReflect(key) != rt.Key() {
+				t.Errorf("Key(%v) = %v, %v, want %v", rt, ToReflect(key), err, rt.Key())
+			}
+		} else if !errors.As(err, &ke) || ke.Op != "Key" || key != nil {
+			t.Errorf("Key(%v) = %v, want a *KindError", rt, err)
+		}
+
+		n, err := typ.ArrayLen()
+		if rt.Kind() == reflect.Array {
+			if err != nil || n != rt.Len() {
+				t.Errorf("ArrayLen(%v) = %d, %v, want %d", rt, n, err, rt.Len())
+			}
+		} else if !errors.As(err, &ke) || ke.Op != "ArrayLen" || Kind(rt.Kind()) != ke.Kind {
+			t.Errorf("ArrayLen(%v) = %v, want a *KindError for %v", rt, err, rt.Kind())
+		}
+
+		elem, err := typ.Elem()
+		switch rt.Kind() {
+		case reflect.Array, reflect.Chan, reflect.Map, reflect.Ptr, reflect.Slice:
+			if err != nil || ToReflect(elem) != rt.Elem() {
+				t.Errorf("Elem(%v) = %v, %v, want %v", rt, ToReflect(elem), err, rt.Elem())
+			}
+		default:
+			if !errors.As(err, &ke) || ke.Op != "Elem" {
+				t.Errorf("Elem(%v) = %v, want a *KindError", rt, err)
+			}
+		}
+	}
+}
+
+type typeReadCloser interface {
+	io.Reader
+	Close() error
+	reset(n int) bool
+}
+
+func TestMethodSet(t *testing.T) {
+	for _, rt := range []reflect.Type{
+		reflect.TypeOf((*typeReadCloser)(nil)).Elem(),
+		reflect.TypeOf((*io.ReadWriter)(nil)).Elem(),
+		reflect.TypeOf((*interface{})(nil)).Elem(),
+	} {
+		it := (*InterfaceType)(unsafe.Pointer(FromReflect(rt)))
+		ms := it.MethodSet()
+		if len(ms) != rt.NumMethod() {
+			t.Fatalf("%v: %d methods, want %d", rt, len(ms), rt.NumMethod())
+		}
+		for i, m := range ms {
+			want := rt.Method(i)
+			if m.Name != want.Name || m.PkgPath != want.PkgPath || ToReflect(&m.Type.rtype) != want.Type {
+				t.Errorf("%v: method %d = %s %q %s, want %s %q %s", rt, i,
+					m.Name, m.PkgPath, &m.Type.rtype, want.Name, want.PkgPath, want.Type)
+			}
+		}
+	}
+
+	// The methods of the embedded io.Reader are flattened, sorted by name
+	// along with the others: Close, Read, then the unexported reset.
+	ms := (*InterfaceType)(unsafe.Pointer(TypeFor[typeReadCloser]())).MethodSet()
+	var names []string
+	for _, m := range ms {
+		names = append(names, m.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Close", "Read", "reset"}) {
+		t.Errorf("methods %v, want [Close Read reset]", names)
+	}
+	if pkg := ms[2].PkgPath; pkg != reflect.TypeOf(typeKey{}).PkgPath() {
+		t.Errorf("reset: PkgPath = %q, want this package", pkg)
+	}
+	if ms[1].Type.NumIn() != 1 || ms[1].Type.NumOut() != 2 {
+		t.Errorf("Read has %d parameters and %d results, want 1 and 2", ms[1].Type.NumIn(), ms[1].Type.NumOut())
+	}
+}
+
+func TestStructTypeRange(t *testing.T) {
+	st, _ := AsStructType(TypeFor[struct {
+		A int
+		B string
+		C []byte
+	}]())
+	for _, stop := range []int{-1, 0, 1} {
+		var got []string
+		st.Range(func(i int, f *StructField) bool {
+			if f != &st.Fields[i] {
+				t.Errorf("Range passed field %d at %p, want %p", i, f, &st.Fields[i])
+			}
+			got = append(got, f.Name.Name())
+			return i != stop
+		})
+		want := []string{"A", "B", "C"}
+		if stop >= 0 {
+			want = want[:stop+1]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Range stopping after %d visited %v, want %v", stop, got, want)
+		}
+	}
+}
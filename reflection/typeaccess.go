@@ -0,0 +1,237 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darkness_safe
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// This file holds the accessors of type descriptors that read their layout.
+// Under the darkness_safe build tag, typeaccess_safe.go implements them with
+// package reflect instead.
+
+// TypeFor returns the type descriptor of T without creating a value of T.
+//
+// It boxes a nil *T, which is pointer-shaped and therefore does not allocate,
+// and follows the pointer element type. For an interface type T the result is
+// the interface type itself.
+func TypeFor[T any]() *rtype {
+	var p *T
+	var i interface{} = p
+	return (*PtrType)(unsafe.Pointer(efaceOf(&i).Type)).Elem
+}
+
+// lookupField resolves the dotted path within t and returns the accumulated
+// offset, the type of the last field and its index within its own struct
+// type.
+func lookupField(t *rtype, op, path string) (uintptr, *rtype, int, error) {
+	var off uintptr
+	typ := t
+	for {
+		if typ.Kind() != Struct {
+			return 0, nil, 0, &KindError{Op: op, Kind: typ.Kind()}
+		}
+		name := path
+		rest := ""
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			name, rest = path[:i], path[i+1:]
+		}
+		st := (*StructType)(unsafe.Pointer(typ))
+		i := fieldIndex(st, name)
+		if i < 0 {
+			return 0, nil, 0, fmt.Errorf("%w: %q in %s", ErrFieldNotFound, name, typ)
+		}
+		f := &st.Fields[i]
+		off += f.Offset()
+		if rest == "" {
+			return off, f.typ, i, nil
+		}
+		typ, path = f.typ, rest
+	}
+}
+
+// Kind returns the specific kind of t.
+func (t *rtype) Kind() Kind {
+	return Kind(t.kind & KindMask)
+}
+
+// String returns the string form of t, as reflect.Type.String does.
+func (t *rtype) String() string {
+	s := t.NameOff(t.str).Name()
+	if t.tflag&TflagExtraStar != 0 {
+		return s[1:]
+	}
+	return s
+}
+
+// HasName reports whether t is a named (defined) type.
+func (t *rtype) HasName() bool {
+	return t.tflag&TflagNamed != 0
+}
+
+// Name returns the name of t within its package for a defined type,
+// or the empty string otherwise, as reflect.Type.Name does.
+func (t *rtype) Name() string {
+	if !t.HasName() {
+		return ""
+	}
+	s := t.String()
+	i := len(s) - 1
+	sqBrackets := 0
+	for i >= 0 && (s[i] != '.' || sqBrackets != 0) {
+		switch s[i] {
+		case ']':
+			sqBrackets++
+		case '[':
+			sqBrackets--
+		}
+		i--
+	}
+	return s[i+1:]
+}
+
+// PkgPath returns the import path of a defined type,
+// or the empty string otherwise, as reflect.Type.PkgPath does.
+func (t *rtype) PkgPath() string {
+	if !t.HasName() {
+		return ""
+	}
+	u := t.Uncommon()
+	if u == nil {
+		return ""
+	}
+	return t.NameOff(u.PkgPath).Name()
+}
+
+// Size returns the number of bytes needed to store a value of type t.
+func (t *rtype) Size() uintptr {
+	return t.size
+}
+
+// Align returns the alignment in bytes of a value of type t.
+func (t *rtype) Align() int {
+	return int(t.align)
+}
+
+// FieldAlign returns the alignment in bytes of a value of type t
+// when used as a field in a struct.
+func (t *rtype) FieldAlign() int {
+	return int(t.fieldAlign)
+}
+
+// NumMethod returns the number of exported methods in the method set of t,
+// or the number of methods of t if it is an interface type, as
+// reflect.Type.NumMethod does.
+func (t *rtype) NumMethod() int {
+	if t.Kind() == Interface {
+		return len((*InterfaceType)(unsafe.Pointer(t)).Methods)
+	}
+	u := t.Uncommon()
+	if u == nil {
+		return 0
+	}
+	return int(u.Xcount)
+}
+
+// Elem returns the element type of t.
+// It returns a *KindError if t is not an array, chan, map, pointer or slice type.
+func (t *rtype) Elem() (*rtype, error) {
+	switch t.Kind() {
+	case Array:
+		return (*ArrayType)(unsafe.Pointer(t)).Elem, nil
+	case Chan:
+		return (*ChanType)(unsafe.Pointer(t)).Elem, nil
+	case Map:
+		return (*MapType)(unsafe.Pointer(t)).Elem, nil
+	case Ptr:
+		return (*PtrType)(unsafe.Pointer(t)).Elem, nil
+	case Slice:
+		return (*SliceType)(unsafe.Pointer(t)).Elem, nil
+	}
+	return nil, &KindError{Op: "Elem", Kind: t.Kind()}
+}
+
+// Key returns the key type of t.
+// It returns a *KindError if t is not a map type.
+func (t *rtype) Key() (*rtype, error) {
+	if t.Kind() != Map {
+		return nil, &KindError{Op: "Key", Kind: t.Kind()}
+	}
+	return (*MapType)(unsafe.Pointer(t)).Key, nil
+}
+
+// ArrayLen returns the length of t.
+// It returns a *KindError if t is not an array type.
+func (t *rtype) ArrayLen() (int, error) {
+	if t.Kind() != Array {
+		return 0, &KindError{Op: "ArrayLen", Kind: t.Kind()}
+	}
+	return int((*ArrayType)(unsafe.Pointer(t)).Len), nil
+}
+
+// NumField returns the number of fields of the struct type.
+func (t *StructType) NumField() int {
+	return len(t.Fields)
+}
+
+// NumIn returns the number of input parameters.
+func (t *FuncType) NumIn() int {
+	return int(t.InCount)
+}
+
+// NumOut returns the number of output parameters.
+func (t *FuncType) NumOut() int {
+	return int(t.OutCount & (1<<15 - 1))
+}
+
+// IsVariadic reports whether the final input parameter is a "..." parameter.
+func (t *FuncType) IsVariadic() bool {
+	return t.OutCount&(1<<15) != 0
+}
+
+// In returns the type of the i'th input parameter.
+func (t *FuncType) In(i int) *rtype {
+	return t.params()[i]
+}
+
+// Out returns the type of the i'th output parameter.
+func (t *FuncType) Out(i int) *rtype {
+	return t.params()[t.NumIn()+i]
+}
+
+func (t liteType) NumField() int {
+	if t.Kind() != Struct {
+		panic("reflection: NumField of non-struct type " + t.String())
+	}
+	return len((*StructType)(unsafe.Pointer(t.rtype)).Fields)
+}
+
+func (t liteType) Field(i int) LiteStructField {
+	if t.Kind() != Struct {
+		panic("reflection: Field of non-struct type " + t.String())
+	}
+	st := (*StructType)(unsafe.Pointer(t.rtype))
+	f := &st.Fields[i]
+	sf := LiteStructField{
+		Name:      f.Name.Name(),
+		Type:      toLite(f.typ),
+		Tag:       reflect.StructTag(f.Name.Tag()),
+		Offset:    f.Offset(),
+		Index:     []int{i},
+		Anonymous: f.Embedded(),
+	}
+	if !f.Name.IsExported() {
+		sf.PkgPath = f.Name.PkgPath()
+		if sf.PkgPath == "" {
+			sf.PkgPath = st.PkgPath.Name()
+		}
+	}
+	return sf
+}
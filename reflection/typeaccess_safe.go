@@ -0,0 +1,149 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darkness_safe
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The accessors of type descriptors below answer from package reflect, which
+// only needs the descriptor pointer, rather than from the layout this
+// package mirrors; see typeaccess.go for their documentation.
+
+func TypeFor[T any]() *rtype {
+	return FromReflect(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func lookupField(t *rtype, op, path string) (uintptr, *rtype, int, error) {
+	var off uintptr
+	typ := ToReflect(t)
+	for {
+		if typ.Kind() != reflect.Struct {
+			return 0, nil, 0, &KindError{Op: op, Kind: Kind(typ.Kind())}
+		}
+		name := path
+		rest := ""
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			name, rest = path[:i], path[i+1:]
+		}
+		i := 0
+		for i < typ.NumField() && typ.Field(i).Name != name {
+			i++
+		}
+		if i == typ.NumField() {
+			return 0, nil, 0, fmt.Errorf("%w: %q in %s", ErrFieldNotFound, name, typ)
+		}
+		f := typ.Field(i)
+		off += f.Offset
+		if rest == "" {
+			return off, FromReflect(f.Type), i, nil
+		}
+		typ, path = f.Type, rest
+	}
+}
+
+func (t *rtype) Kind() Kind {
+	return Kind(ToReflect(t).Kind())
+}
+
+func (t *rtype) String() string {
+	return ToReflect(t).String()
+}
+
+func (t *rtype) HasName() bool {
+	return ToReflect(t).Name() != ""
+}
+
+func (t *rtype) Name() string {
+	return ToReflect(t).Name()
+}
+
+func (t *rtype) PkgPath() string {
+	return ToReflect(t).PkgPath()
+}
+
+func (t *rtype) Size() uintptr {
+	return ToReflect(t).Size()
+}
+
+func (t *rtype) Align() int {
+	return ToReflect(t).Align()
+}
+
+func (t *rtype) FieldAlign() int {
+	return ToReflect(t).FieldAlign()
+}
+
+func (t *rtype) NumMethod() int {
+	return ToReflect(t).NumMethod()
+}
+
+func (t *rtype) Elem() (*rtype, error) {
+	switch k := t.Kind(); k {
+	case Array, Chan, Map, Ptr, Slice:
+		return FromReflect(ToReflect(t).Elem()), nil
+	default:
+		return nil, &KindError{Op: "Elem", Kind: k}
+	}
+}
+
+func (t *rtype) Key() (*rtype, error) {
+	if k := t.Kind(); k != Map {
+		return nil, &KindError{Op: "Key", Kind: k}
+	}
+	return FromReflect(ToReflect(t).Key()), nil
+}
+
+func (t *rtype) ArrayLen() (int, error) {
+	if k := t.Kind(); k != Array {
+		return 0, &KindError{Op: "ArrayLen", Kind: k}
+	}
+	return ToReflect(t).Len(), nil
+}
+
+func (t *StructType) NumField() int {
+	return ToReflect(&t.rtype).NumField()
+}
+
+func (t *FuncType) NumIn() int {
+	return ToReflect(&t.rtype).NumIn()
+}
+
+func (t *FuncType) NumOut() int {
+	return ToReflect(&t.rtype).NumOut()
+}
+
+func (t *FuncType) IsVariadic() bool {
+	return ToReflect(&t.rtype).IsVariadic()
+}
+
+func (t *FuncType) In(i int) *rtype {
+	return FromReflect(ToReflect(&t.rtype).In(i))
+}
+
+func (t *FuncType) Out(i int) *rtype {
+	return FromReflect(ToReflect(&t.rtype).Out(i))
+}
+
+func (t liteType) NumField() int {
+	return ToReflect(t.rtype).NumField()
+}
+
+func (t liteType) Field(i int) LiteStructField {
+	f := ToReflect(t.rtype).Field(i)
+	return LiteStructField{
+		Name:      f.Name,
+		PkgPath:   f.PkgPath,
+		Type:      toLite(FromReflect(f.Type)),
+		Tag:       f.Tag,
+		Offset:    f.Offset,
+		Index:     f.Index,
+		Anonymous: f.Anonymous,
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"reflect"
+	_ "unsafe" // for go:linkname
+)
+
+// PtrTo returns the pointer type with element t, as reflect.PointerTo does.
+//
+// It uses the pointer type recorded in t when there is one, then looks for a
+// pointer type the linker emitted for t, and only then has reflect build it.
+func PtrTo(t *rtype) (*rtype, error) {
+	if t == nil {
+		return nil, &KindError{Op: "PtrTo", Kind: Invalid}
+	}
+	if t.ptrToThis != 0 {
+		return t.TypeOff(t.ptrToThis), nil
+	}
+	for _, p := range typesByString("*" + t.String()) {
+		if p.Kind() == Ptr && elem(p) == t {
+			return p, nil
+		}
+	}
+	return FromReflect(reflect.PointerTo(ToReflect(t))), nil
+}
+
+// TypeByName returns a type whose String is name, or nil if none is found.
+//
+// The linker only records the unnamed composite types of a program, so
+// defined types are searched among the types of the itabs of ITabs: the
+// interface types and the concrete types, and their element types for
+// pointers, the binary converts to interfaces. Where ITabs is not supported,
+// only the unnamed types are found.
+func TypeByName(name string) *rtype {
+	if ts := typesByString(name); len(ts) > 0 {
+		return ts[0]
+	}
+	var found *rtype
+	_ = ITabs(func(it *ITab) bool {
+		switch {
+		case it.Inter.String() == name:
+			found = &it.Inter.rtype
+		case it.Type.String() == name:
+			found = it.Type
+		case it.Type.Kind() == Ptr && elem(it.Type).String() == name:
+			found = elem(it.Type)
+		}
+		return found == nil
+	})
+	return found
+}
+
+//go:linkname typesByString reflect.typesByString
+
+// typesByString returns all types known to the linker whose String is s.
+// Implemented in the reflect package.
+func typesByString(s string) []*rtype
@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type (
+	ptrToUsed   struct{ A int }
+	ptrToUnused struct{ B string }
+	ptrToNamed  []ptrToUnused
+)
+
+// ptrToTypes makes *ptrToUsed known to the linker, and not *ptrToUnused.
+var ptrToTypes = []interface{}{&ptrToUsed{}, ptrToUnused{}, ptrToNamed{}}
+
+func TestPtrTo(t *testing.T) {
+	for _, rt := range []reflect.Type{
+		reflect.TypeOf(ptrToUsed{}),
+		reflect.TypeOf(ptrToUnused{}),
+		reflect.TypeOf(ptrToNamed{}),
+		reflect.TypeOf(0),
+		reflect.TypeOf([]int{}),
+		reflect.TypeOf(map[string][]ptrToUnused{}),
+		reflect.TypeOf(struct{ C, d int }{}),
+		reflect.TypeOf(&ptrToUsed{}),
+		reflect.TypeOf(func(ptrToUnused) {}),
+	} {
+		typ := FromReflect(rt)
+		p, err := PtrTo(typ)
+		if err != nil {
+			t.Fatalf("PtrTo(%v) = %v", rt, err)
+		}
+		// reflect.PointerTo, whatever way it finds or builds the type,
+		// returns the same descriptor.
+		if want := FromReflect(reflect.PointerTo(rt)); p != want {
+			t.Errorf("PtrTo(%v) = %p (%s), want %p (%s)", rt, p, p, want, want)
+		}
+		if p.Kind() != Ptr || elem(p) != typ {
+			t.Errorf("PtrTo(%v) = %s, want a pointer to it", rt, p)
+		}
+		if again, _ := PtrTo(typ); again != p {
+			t.Errorf("PtrTo(%v) twice = %p, then %p", rt, p, again)
+		}
+	}
+
+	var ke *KindError
+	if _, err := PtrTo(nil); !errors.As(err, &ke) {
+		t.Errorf("PtrTo(nil) = %v, want a *KindError", err)
+	}
+}
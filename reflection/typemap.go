@@ -0,0 +1,125 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// typeMapShards is the number of independently locked shards of a TypeMap.
+const typeMapShards = 32
+
+// TypeMap is a map from types to values of type V, safe for concurrent use.
+// The zero value is an empty map ready to use.
+//
+// Types are keyed by descriptor identity. The map is split in shards picked
+// by the hash the compiler computed for the type, each with its own lock, so
+// that lookups of different types rarely contend.
+type TypeMap[V any] struct {
+	shards [typeMapShards]typeMapShard[V]
+}
+
+type typeMapShard[V any] struct {
+	sync.RWMutex
+	m map[*rtype]*typeMapEntry[V]
+}
+
+// typeMapEntry holds the value of a type, once ready is set.
+type typeMapEntry[V any] struct {
+	once  sync.Once
+	ready uint32 // atomic
+	v     V
+}
+
+func (m *TypeMap[V]) shard(t *rtype) *typeMapShard[V] {
+	return &m.shards[t.hash%typeMapShards]
+}
+
+func (s *typeMapShard[V]) load(t *rtype) *typeMapEntry[V] {
+	s.RLock()
+	e := s.m[t]
+	s.RUnlock()
+	return e
+}
+
+// Get returns the value stored for t, if any.
+func (m *TypeMap[V]) Get(t *rtype) (V, bool) {
+	if e := m.shard(t).load(t); e != nil && atomic.LoadUint32(&e.ready) != 0 {
+		return e.v, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put stores v for t.
+func (m *TypeMap[V]) Put(t *rtype, v V) {
+	e := &typeMapEntry[V]{v: v, ready: 1}
+	e.once.Do(func() {})
+	s := m.shard(t)
+	s.Lock()
+	if s.m == nil {
+		s.m = make(map[*rtype]*typeMapEntry[V])
+	}
+	s.m[t] = e
+	s.Unlock()
+}
+
+// GetOrCompute returns the value stored for t, computing it with compute and
+// storing it if there is none. Concurrent calls for the same type run compute
+// only once: the other callers wait for its result. If compute panics, the
+// waiting callers get the zero value of V.
+func (m *TypeMap[V]) GetOrCompute(t *rtype, compute func(t *rtype) V) V {
+	s := m.shard(t)
+	e := s.load(t)
+	if e == nil {
+		s.Lock()
+		if e = s.m[t]; e == nil {
+			if s.m == nil {
+				s.m = make(map[*rtype]*typeMapEntry[V])
+			}
+			e = new(typeMapEntry[V])
+			s.m[t] = e
+		}
+		s.Unlock()
+	}
+	if atomic.LoadUint32(&e.ready) == 0 {
+		e.once.Do(func() {
+			e.v = compute(t)
+			atomic.StoreUint32(&e.ready, 1)
+		})
+	}
+	return e.v
+}
+
+// Delete removes the value stored for t, if any.
+func (m *TypeMap[V]) Delete(t *rtype) {
+	s := m.shard(t)
+	s.Lock()
+	delete(s.m, t)
+	s.Unlock()
+}
+
+// TypeSet is a set of types, safe for concurrent use.
+// The zero value is an empty set ready to use.
+type TypeSet struct {
+	m TypeMap[struct{}]
+}
+
+// Has reports whether t is in the set.
+func (s *TypeSet) Has(t *rtype) bool {
+	_, ok := s.m.Get(t)
+	return ok
+}
+
+// Add adds t to the set and reports whether it was not already there.
+func (s *TypeSet) Add(t *rtype) bool {
+	added := false
+	s.m.GetOrCompute(t, func(*rtype) struct{} {
+		added = true
+		return struct{}{}
+	})
+	return added
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTypeMap(t *testing.T) {
+	var m TypeMap[string]
+	it, st := TypeFor[int](), TypeFor[string]()
+	if v, ok := m.Get(it); ok {
+		t.Errorf("Get on the zero TypeMap = %q, true", v)
+	}
+	m.Put(it, "int")
+	m.Put(st, "string")
+	if v, ok := m.Get(it); !ok || v != "int" {
+		t.Errorf("Get(int) = %q, %v, want int", v, ok)
+	}
+	if v := m.GetOrCompute(st, func(*rtype) string { return "computed" }); v != "string" {
+		t.Errorf("GetOrCompute of a stored type = %q, want string", v)
+	}
+	m.Put(it, "again")
+	if v, _ := m.Get(it); v != "again" {
+		t.Errorf("Get after a second Put = %q, want again", v)
+	}
+	m.Delete(it)
+	if _, ok := m.Get(it); ok {
+		t.Error("Get after Delete reported a value")
+	}
+	if v := m.GetOrCompute(it, func(t *rtype) string { return t.String() }); v != "int" {
+		t.Errorf("GetOrCompute after Delete = %q, want int", v)
+	}
+	if v, ok := m.Get(it); !ok || v != "int" {
+		t.Errorf("Get after GetOrCompute = %q, %v, want int", v, ok)
+	}
+}
+
+func TestTypeMapGetOrComputeOnce(t *testing.T) {
+	var m TypeMap[int]
+	var calls int32
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			v := m.GetOrCompute(TypeFor[float64](), func(*rtype) int {
+				atomic.AddInt32(&calls, 1)
+				return 42
+			})
+			if v != 42 {
+				t.Errorf("GetOrCompute = %d, want 42", v)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("compute ran %d times, want 1", calls)
+	}
+}
+
+func TestTypeSet(t *testing.T) {
+	var s TypeSet
+	it := TypeFor[int]()
+	if s.Has(it) {
+		t.Error("zero TypeSet has int")
+	}
+	if !s.Add(it) {
+		t.Error("first Add(int) = false")
+	}
+	if s.Add(it) {
+		t.Error("second Add(int) = true")
+	}
+	if !s.Has(it) || s.Has(TypeFor[uint]()) {
+		t.Errorf("Has(int), Has(uint) = %v, %v, want true, false", s.Has(it), s.Has(TypeFor[uint]()))
+	}
+}
+
+// typeMapBenchTypes are the keys of the TypeMap benchmarks.
+var typeMapBenchTypes = []*rtype{
+	TypeFor[int](), TypeFor[string](), TypeFor[[]byte](), TypeFor[map[string]int](),
+	TypeFor[fieldT](), TypeFor[*fieldT](), TypeFor[error](), TypeFor[float64](),
+	TypeFor[chan int](), TypeFor[struct{ A, B int }](), TypeFor[[4]uint16](), TypeFor[func()](),
+	TypeFor[uint8](), TypeFor[int32](), TypeFor[bool](), TypeFor[complex128](),
+}
+
+// lockedTypeMap is the plain locked map TypeMap is measured against.
+type lockedTypeMap struct {
+	sync.RWMutex
+	m map[*rtype]int
+}
+
+func (m *lockedTypeMap) getOrCompute(t *rtype, compute func(*rtype) int) int {
+	m.RLock()
+	v, ok := m.m[t]
+	m.RUnlock()
+	if ok {
+		return v
+	}
+	m.Lock()
+	defer m.Unlock()
+	if v, ok := m.m[t]; ok {
+		return v
+	}
+	v = compute(t)
+	m.m[t] = v
+	return v
+}
+
+func BenchmarkTypeMap(b *testing.B) {
+	compute := func(t *rtype) int { return int(t.size) }
+	types := typeMapBenchTypes
+
+	var tm TypeMap[int]
+	var sm sync.Map
+	lm := &lockedTypeMap{m: make(map[*rtype]int)}
+	for _, t := range types {
+		tm.Put(t, compute(t))
+		sm.Store(t, compute(t))
+		lm.m[t] = compute(t)
+	}
+
+	b.Run("Get/TypeMap", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				tm.Get(types[i%len(types)])
+			}
+		})
+	})
+	b.Run("Get/sync.Map", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				v, _ := sm.Load(types[i%len(types)])
+				_ = v.(int)
+			}
+		})
+	})
+	b.Run("Get/RWMutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				lm.RLock()
+				_ = lm.m[types[i%len(types)]]
+				lm.RUnlock()
+			}
+		})
+	})
+
+	b.Run("GetOrCompute/TypeMap", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				tm.GetOrCompute(types[i%len(types)], compute)
+			}
+		})
+	})
+	b.Run("GetOrCompute/sync.Map", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				t := types[i%len(types)]
+				if _, ok := sm.Load(t); !ok {
+					sm.LoadOrStore(t, compute(t))
+				}
+			}
+		})
+	})
+	b.Run("GetOrCompute/RWMutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				lm.getOrCompute(types[i%len(types)], compute)
+			}
+		})
+	})
+}
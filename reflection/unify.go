@@ -0,0 +1,92 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+)
+
+// typeRegistry maps fingerprints to the types registered with them, in
+// registration order. Distinct types may share a fingerprint, so each
+// fingerprint keeps a bucket of types that are not Identical to each other.
+//
+// Entries are never evicted, as the types they are keyed by are never freed:
+// the descriptors of a binary and its plugins live as long as the program,
+// and so do those reflect creates, which it caches itself. Each map thus
+// holds at most one entry per type fingerprinted, which is bounded by the
+// number of types the program uses.
+var typeRegistry struct {
+	sync.RWMutex
+	fingerprints map[*rtype]uint64
+	canonical    map[uint64][]*rtype
+}
+
+// cachedFingerprint returns the fingerprint of t, computing it at most once.
+func cachedFingerprint(t *rtype) uint64 {
+	typeRegistry.RLock()
+	fp, ok := typeRegistry.fingerprints[t]
+	typeRegistry.RUnlock()
+	if ok {
+		return fp
+	}
+	fp = Fingerprint(t)
+	typeRegistry.Lock()
+	if typeRegistry.fingerprints == nil {
+		typeRegistry.fingerprints = make(map[*rtype]uint64)
+	}
+	typeRegistry.fingerprints[t] = fp
+	typeRegistry.Unlock()
+	return fp
+}
+
+// UnifyType returns the canonical representative of t: the first type
+// passed to UnifyType that is Identical to t.
+//
+// When a plugin and the host binary both compile the same package, each has
+// its own type descriptor for "the same" type, and caches keyed by *rtype see
+// two different types. Keying them by UnifyType(t) instead restores identity.
+// It is safe for concurrent use.
+func UnifyType(t *rtype) *rtype {
+	fp := cachedFingerprint(t)
+	typeRegistry.RLock()
+	c := identicalIn(typeRegistry.canonical[fp], t)
+	typeRegistry.RUnlock()
+	if c != nil {
+		return c
+	}
+	typeRegistry.Lock()
+	defer typeRegistry.Unlock()
+	if c := identicalIn(typeRegistry.canonical[fp], t); c != nil {
+		return c
+	}
+	if typeRegistry.canonical == nil {
+		typeRegistry.canonical = make(map[uint64][]*rtype)
+	}
+	typeRegistry.canonical[fp] = append(typeRegistry.canonical[fp], t)
+	return t
+}
+
+// identicalIn returns the type of bucket that is Identical to t, or nil.
+// It compares the types structurally, as Identical does once fingerprints
+// match, without taking the lock of typeRegistry, which the caller holds.
+func identicalIn(bucket []*rtype, t *rtype) *rtype {
+	for _, c := range bucket {
+		if c == t {
+			return c
+		}
+		id := identity{cmpTags: true}
+		if c.Kind() == t.Kind() && c.size == t.size && id.typ(c, t) {
+			return c
+		}
+	}
+	return nil
+}
+
+// SameType reports whether a and b describe the same type, either because they
+// are the same descriptor or because they are Identical, as duplicates of one
+// type in a plugin and its host are.
+func SameType(a, b *rtype) bool {
+	return Identical(a, b)
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUnifyType(t *testing.T) {
+	a, b := fpTypesA(), fpTypesB()
+	for i := range a {
+		ua := UnifyType(a[i])
+		ub := UnifyType(b[i])
+		same := Fingerprint(a[i]) == Fingerprint(b[i])
+		if UnifyType(ua) != ua {
+			t.Errorf("%s: UnifyType is not idempotent", a[i])
+		}
+		if got := ua == ub; got != same {
+			t.Errorf("%s: UnifyType of the two declarations equal = %v, want %v", a[i], got, same)
+		}
+		if got := SameType(a[i], b[i]); got != same {
+			t.Errorf("%s: SameType = %v, want %v", a[i], got, same)
+		}
+	}
+	if !SameType(a[0], a[0]) || SameType(a[0], nil) || SameType(nil, a[0]) {
+		t.Error("SameType of a type with itself or nil is wrong")
+	}
+}
+
+func TestUnifyTypeConcurrent(t *testing.T) {
+	types := append(fpTypesA(), fpTypesB()...)
+	var wg sync.WaitGroup
+	results := make([][]*rtype, 8)
+	for g := range results {
+		g := g
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, typ := range types {
+				results[g] = append(results[g], UnifyType(typ))
+			}
+		}()
+	}
+	wg.Wait()
+	for g := 1; g < len(results); g++ {
+		for i := range types {
+			if results[g][i] != results[0][i] {
+				t.Errorf("%s: goroutines disagree on the canonical type", types[i])
+			}
+		}
+	}
+}
+
+type unifyCollideA struct{ X int }
+
+type unifyCollideB struct{ Y string }
+
+func TestUnifyTypeCollision(t *testing.T) {
+	// Distinct types sharing a fingerprint, as a hash collision would have
+	// them, must stay distinct.
+	a, b := TypeFor[unifyCollideA](), TypeFor[unifyCollideB]()
+	fp := cachedFingerprint(b)
+	typeRegistry.Lock()
+	if typeRegistry.canonical == nil {
+		typeRegistry.canonical = make(map[uint64][]*rtype)
+	}
+	typeRegistry.canonical[fp] = append(typeRegistry.canonical[fp], a)
+	typeRegistry.Unlock()
+	defer func() {
+		typeRegistry.Lock()
+		delete(typeRegistry.canonical, fp)
+		typeRegistry.Unlock()
+	}()
+	if got := UnifyType(b); got != b {
+		t.Errorf("UnifyType(%s) = %s, want itself", b, got)
+	}
+	if got := UnifyType(b); got != b {
+		t.Errorf("UnifyType(%s) again = %s, want itself", b, got)
+	}
+	if UnifyType(a) == b {
+		t.Errorf("UnifyType(%s) = %s", a, b)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Validate walks the type rt with both package reflect and this package in
+// lockstep and reports the first place where they disagree, comparing kinds,
+// sizes, alignments, struct fields (names, offsets, tags, embeddedness),
+// method counts and element, key, field and parameter types.
+//
+// It is meant to be called against the caller's own types, typically from a
+// test or at program start, to assert that this package decodes them
+// correctly on the toolchain that built the binary.
+func Validate(rt reflect.Type) error {
+	if rt == nil {
+		return fmt.Errorf("reflection: Validate of nil reflect.Type")
+	}
+	v := validator{seen: make(map[reflect.Type]bool)}
+	return v.validate(rt.String(), rt, FromReflect(rt))
+}
+
+// ValidateValue is like Validate for the dynamic type of i.
+func ValidateValue(i interface{}) error {
+	return Validate(reflect.TypeOf(i))
+}
+
+type validator struct {
+	seen map[reflect.Type]bool
+}
+
+// mismatch returns the error reported for a divergence at path.
+func mismatch(path, what string, want, got interface{}) error {
+	return fmt.Errorf("reflection: %s: %s mismatch: reflect has %v, reflection has %v", path, what, want, got)
+}
+
+func (v *validator) validate(path string, rt reflect.Type, t *rtype) error {
+	if t == nil {
+		return mismatch(path, "type", rt, nil)
+	}
+	if v.seen[rt] {
+		return nil
+	}
+	v.seen[rt] = true
+
+	if uint(rt.Kind()) != uint(t.Kind()) {
+		return mismatch(path, "kind", rt.Kind(), t.Kind())
+	}
+	if rt.String() != t.String() {
+		return mismatch(path, "string", rt.String(), t.String())
+	}
+	if rt.Size() != t.Size() {
+		return mismatch(path, "size", rt.Size(), t.Size())
+	}
+	if rt.Align() != t.Align() {
+		return mismatch(path, "align", rt.Align(), t.Align())
+	}
+	if rt.FieldAlign() != t.FieldAlign() {
+		return mismatch(path, "field align", rt.FieldAlign(), t.FieldAlign())
+	}
+	if rt.NumMethod() != t.NumMethod() {
+		return mismatch(path, "method count", rt.NumMethod(), t.NumMethod())
+	}
+
+	switch rt.Kind() {
+	case reflect.Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		if uintptr(rt.Len()) != at.Len {
+			return mismatch(path, "array length", rt.Len(), at.Len)
+		}
+		return v.validate(path+".Elem", rt.Elem(), at.Elem)
+	case reflect.Chan:
+		ct := (*ChanType)(unsafe.Pointer(t))
+		if int(rt.ChanDir()) != int(ct.Dir) {
+			return mismatch(path, "channel direction", rt.ChanDir(), ct.Dir)
+		}
+		return v.validate(path+".Elem", rt.Elem(), ct.Elem)
+	case reflect.Func:
+		ft := (*FuncType)(unsafe.Pointer(t))
+		if rt.NumIn() != ft.NumIn() {
+			return mismatch(path, "input count", rt.NumIn(), ft.NumIn())
+		}
+		if rt.NumOut() != ft.NumOut() {
+			return mismatch(path, "output count", rt.NumOut(), ft.NumOut())
+		}
+		if rt.IsVariadic() != ft.IsVariadic() {
+			return mismatch(path, "variadic", rt.IsVariadic(), ft.IsVariadic())
+		}
+		for i := 0; i < rt.NumIn(); i++ {
+			if err := v.validate(fmt.Sprintf("%s.In(%d)", path, i), rt.In(i), ft.In(i)); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < rt.NumOut(); i++ {
+			if err := v.validate(fmt.Sprintf("%s.Out(%d)", path, i), rt.Out(i), ft.Out(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		mt := (*MapType)(unsafe.Pointer(t))
+		if err := v.validate(path+".Key", rt.Key(), mt.Key); err != nil {
+			return err
+		}
+		return v.validate(path+".Elem", rt.Elem(), mt.Elem)
+	case reflect.Ptr:
+		return v.validate(path+".Elem", rt.Elem(), (*PtrType)(unsafe.Pointer(t)).Elem)
+	case reflect.Slice:
+		return v.validate(path+".Elem", rt.Elem(), (*SliceType)(unsafe.Pointer(t)).Elem)
+	case reflect.Struct:
+		st := (*StructType)(unsafe.Pointer(t))
+		if rt.NumField() != len(st.Fields) {
+			return mismatch(path, "field count", rt.NumField(), len(st.Fields))
+		}
+		for i := range st.Fields {
+			rf, f := rt.Field(i), &st.Fields[i]
+			fpath := path + "." + rf.Name
+			if rf.Name != f.Name.Name() {
+				return mismatch(fpath, "field name", rf.Name, f.Name.Name())
+			}
+			if string(rf.Tag) != f.Name.Tag() {
+				return mismatch(fpath, "field tag", rf.Tag, f.Name.Tag())
+			}
+			if rf.Offset != f.Offset() {
+				return mismatch(fpath, "field offset", rf.Offset, f.Offset())
+			}
+			if rf.Anonymous != f.Embedded() {
+				return mismatch(fpath, "field embeddedness", rf.Anonymous, f.Embedded())
+			}
+			if err := v.validate(fpath, rf.Type, f.typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
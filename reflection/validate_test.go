@@ -0,0 +1,85 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validateList struct {
+	Next *validateList
+	Val  int
+}
+
+type validateT struct {
+	fieldT
+	A    int8 `json:"a"`
+	B    [3]uint16
+	C    map[string][]*validateList
+	D    chan<- error
+	E    func(validateList, ...string) (io.Reader, error)
+	F    interface{ M() }
+	_    int32
+	Tail struct{}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []interface{}{
+		0, "", 1.5, complex64(0), validateT{}, &validateList{}, []validateT{},
+		map[validateList]bool{}, make(chan struct{}), func() {}, [0]int{},
+		(*io.ReadWriter)(nil),
+	}
+	for _, v := range tests {
+		if err := ValidateValue(v); err != nil {
+			t.Errorf("ValidateValue(%T): %v", v, err)
+		}
+	}
+	if err := Validate(nil); err == nil {
+		t.Error("Validate(nil) succeeded")
+	}
+}
+
+// The types below are declared twice, in different functions, with the
+// same names and strings but different structures, so that the divergence
+// is found below the top-level comparisons.
+
+func validateTypesA() []reflect.Type {
+	type slice []int
+	type fn func(int) string
+	type out func() (int, error)
+	type param func(validateList)
+	type st struct{ A int }
+	return []reflect.Type{reflect.TypeOf(slice{}), reflect.TypeOf(fn(nil)), reflect.TypeOf(out(nil)), reflect.TypeOf(param(nil)), reflect.TypeOf(st{})}
+}
+
+func validateTypesB() []*rtype {
+	type slice []uint
+	type fn func(uint) string
+	type out func() (int, io.Reader)
+	type param func(fieldInner)
+	type st struct{ B int }
+	return []*rtype{TypeFor[slice](), TypeFor[fn](), TypeFor[out](), TypeFor[param](), TypeFor[st]()}
+}
+
+func TestValidateMismatch(t *testing.T) {
+	want := []string{
+		"reflection.slice.Elem: kind mismatch",
+		"reflection.fn.In(0): kind mismatch",
+		"reflection.out.Out(1): string mismatch",
+		"reflection.param.In(0): string mismatch",
+		"reflection.st.A: field name mismatch",
+	}
+	a, b := validateTypesA(), validateTypesB()
+	for i, rt := range a {
+		v := validator{seen: make(map[reflect.Type]bool)}
+		err := v.validate(rt.String(), rt, b[i])
+		if err == nil || !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("validate(%v) = %v, want an error containing %q", rt, err, want[i])
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+var (
+	// ErrRequired is reported by the "required" rule for a zero field.
+	ErrRequired = errors.New("reflection: required field is zero")
+
+	// ErrNil is reported by the "nonnil" rule for a nil field.
+	ErrNil = errors.New("reflection: field is nil")
+
+	// ErrUnknownRule is reported for a tag token with no registered rule.
+	ErrUnknownRule = errors.New("reflection: unknown validation rule")
+)
+
+// Rule checks the value of type t at p, a field tagged with the token the
+// rule is registered for.
+type Rule func(t *rtype, p unsafe.Pointer) error
+
+// FieldError is a field failing a rule of ValidateTags.
+type FieldError struct {
+	// Path locates the field from the validated value, such as
+	// ".Users[3].Name".
+	Path string
+
+	// Rule is the tag token of the rule.
+	Rule string
+
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return "reflection: " + e.Path + ": " + e.Rule + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// rules maps the tag tokens to their rules.
+var rules = struct {
+	sync.RWMutex
+	m map[string]Rule
+}{m: map[string]Rule{
+	"required": requiredRule,
+	"nonnil":   nonNilRule,
+}}
+
+// RegisterRule registers the rule checking the fields tagged with token for
+// ValidateTags, replacing any rule registered for it. The rules "required",
+// which reports ErrRequired for a zero value as IsZero defines it, and
+// "nonnil", which reports ErrNil for a nil pointer, map, slice, channel,
+// func or interface, are registered from the start.
+func RegisterRule(token string, rule Rule) {
+	rules.Lock()
+	rules.m[token] = rule
+	rules.Unlock()
+}
+
+func lookupRule(token string) Rule {
+	rules.RLock()
+	rule := rules.m[token]
+	rules.RUnlock()
+	return rule
+}
+
+func requiredRule(t *rtype, p unsafe.Pointer) error {
+	if IsZero(t, p) {
+		return ErrRequired
+	}
+	return nil
+}
+
+func nonNilRule(t *rtype, p unsafe.Pointer) error {
+	isNil, err := IsNilAt(t, p)
+	switch {
+	case err != nil:
+		return &KindError{Op: "nonnil", Kind: t.Kind()}
+	case isNil:
+		return ErrNil
+	}
+	return nil
+}
+
+// ValidateOptions controls ValidateTags.
+type ValidateOptions struct {
+	// Unexported also checks the unexported fields, and walks into them.
+	Unexported bool
+}
+
+// ValidateTags checks the fields of the value held by v against the rules
+// their tags of key tagKey list, separated by commas, such as
+// `validate:"required,nonnil"`, and returns a FieldError for each failure,
+// including tokens with no registered rule, in field order.
+//
+// It walks the structs nested in v, through pointers, slices, arrays and
+// interfaces, and checks each element of a slice or an array; each pointer
+// is followed once, so that cyclic values are walked once. Map elements are
+// not walked, nor unexported fields unless allowed by the options, except
+// embedded ones, whose exported fields are promoted. The
+// fields are read through their offsets, without reflect.Value, and the
+// tags of a struct type are parsed once.
+func ValidateTags(v interface{}, tagKey string) []FieldError {
+	var o ValidateOptions
+	return o.ValidateTags(v, tagKey)
+}
+
+// ValidateTags is like the top-level ValidateTags, with the options of o.
+func (o *ValidateOptions) ValidateTags(v interface{}, tagKey string) []FieldError {
+	e := efaceOf(&v)
+	if e.Type == nil {
+		return nil
+	}
+	w := tagValidator{opts: o, tagKey: tagKey}
+	w.walk(e.Type, e.data(), "")
+	return w.errs
+}
+
+// tagValidator walks a value for ValidateTags.
+type tagValidator struct {
+	opts   *ValidateOptions
+	tagKey string
+	seen   map[typedPointer]bool // pointers followed
+	errs   []FieldError
+}
+
+// walk checks the value of type t at p, at path.
+func (w *tagValidator) walk(t *rtype, p unsafe.Pointer, path string) {
+	if !mayHoldStruct(t) {
+		return
+	}
+	switch t.Kind() {
+	case Struct:
+		w.walkStruct((*StructType)(unsafe.Pointer(t)), p, path)
+	case Ptr:
+		q := typedPointer{(*PtrType)(unsafe.Pointer(t)).Elem, *(*unsafe.Pointer)(p)}
+		if q.p == nil || w.seen[q] {
+			return
+		}
+		if w.seen == nil {
+			w.seen = make(map[typedPointer]bool)
+		}
+		w.seen[q] = true
+		w.walk(q.t, q.p, path)
+	case Interface:
+		var e InterfaceHeader
+		if len((*InterfaceType)(unsafe.Pointer(t)).Methods) == 0 {
+			e = *(*InterfaceHeader)(p)
+		} else if tab := (*IfaceHeader)(p).Tab; tab != nil {
+			e = InterfaceHeader{Type: tab.Type, Word: (*IfaceHeader)(p).Word}
+		}
+		if e.Type != nil {
+			w.walk(e.Type, e.data(), path)
+		}
+	case Array:
+		at := (*ArrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < at.Len; i++ {
+			w.walk(at.Elem, Add(p, i*at.Elem.size, "i < len"), path+"["+strconv.Itoa(int(i))+"]")
+		}
+	case Slice:
+		et := (*SliceType)(unsafe.Pointer(t)).Elem
+		hdr := (*SliceHeader)(p)
+		for i := 0; i < hdr.Len; i++ {
+			w.walk(et, Add(hdr.Data, uintptr(i)*et.size, "i < len"), path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (w *tagValidator) walkStruct(st *StructType, p unsafe.Pointer, path string) {
+	for _, f := range validationPlan(st, w.tagKey, w.opts.Unexported) {
+		fp := Add(p, f.offset, "the field lies within the struct")
+		fpath := path + "." + f.name
+		for _, token := range f.tokens {
+			rule := lookupRule(token)
+			if rule == nil {
+				w.errs = append(w.errs, FieldError{Path: fpath, Rule: token, Err: ErrUnknownRule})
+				continue
+			}
+			if err := rule(f.typ, fp); err != nil {
+				w.errs = append(w.errs, FieldError{Path: fpath, Rule: token, Err: err})
+			}
+		}
+		w.walk(f.typ, fp, fpath)
+	}
+}
+
+// typedPointer is a pointer to a value of type t.
+type typedPointer struct {
+	t *rtype
+	p unsafe.Pointer
+}
+
+// validatedField is a field checked or walked by ValidateTags.
+type validatedField struct {
+	name   string
+	offset uintptr
+	typ    *rtype
+	tokens []string // of the rules of its tag
+}
+
+// validationPlanKey identifies the fields of a struct type that ValidateTags
+// checks or walks into.
+type validationPlanKey struct {
+	t          *rtype
+	tagKey     string
+	unexported bool
+}
+
+// validationPlans caches validationPlan by validationPlanKey.
+var validationPlans sync.Map
+
+// validationPlan returns the fields of st with rules or holding structs.
+func validationPlan(st *StructType, tagKey string, unexported bool) []validatedField {
+	key := validationPlanKey{&st.rtype, tagKey, unexported}
+	if plan, ok := validationPlans.Load(key); ok {
+		return plan.([]validatedField)
+	}
+	var plan []validatedField
+	for i := range st.Fields {
+		f := &st.Fields[i]
+		var tokens []string
+		if unexported || f.Name.IsExported() {
+			if tag, ok := reflect.StructTag(f.Name.Tag()).Lookup(tagKey); ok && tag != "" {
+				tokens = strings.Split(tag, ",")
+			}
+		} else if !f.Embedded() {
+			// The fields of an embedded struct of unexported type are
+			// promoted, so it is walked, but not checked.
+			continue
+		}
+		if tokens != nil || mayHoldStruct(f.typ) {
+			plan = append(plan, validatedField{f.Name.Name(), f.Offset(), f.typ, tokens})
+		}
+	}
+	validationPlans.Store(key, plan)
+	return plan
+}
+
+// mayHoldStruct reports whether a value of type t may hold a struct that
+// ValidateTags walks: whether t is a struct, an interface, or a pointer,
+// slice or array leading to one.
+func mayHoldStruct(t *rtype) bool {
+	for {
+		switch t.Kind() {
+		case Struct, Interface:
+			return true
+		case Ptr, Slice, Array:
+			t = elem(t)
+		default:
+			return false
+		}
+	}
+}
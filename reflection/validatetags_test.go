@@ -0,0 +1,174 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type validateUser struct {
+	Name  string            `validate:"required"`
+	Tags  []string          `validate:"nonnil"`
+	Attrs map[string]string `validate:"nonnil"`
+	Age   int               `validate:"required,vtestPositive"`
+	Bad   int               `validate:"nosuchrule"`
+	Empty int               `validate:""`
+	note  string            `validate:"required"`
+}
+
+type validateInner struct {
+	ID int `validate:"required"`
+}
+
+type validateEmbedded struct {
+	Code string `validate:"required"`
+}
+
+type validateNode struct {
+	Val  *int          `validate:"nonnil"`
+	Next *validateNode `validate:"nonnil"`
+}
+
+type validateRoot struct {
+	validateEmbedded
+	Users  []validateUser
+	Owner  *validateUser
+	Pair   [2]validateInner
+	Any    interface{}
+	Shape  moduleShape
+	ByName map[string]validateInner
+	Count  int `validate:"nonnil"`
+	hidden validateInner
+}
+
+func init() {
+	RegisterRule("vtestPositive", func(t *rtype, p unsafe.Pointer) error {
+		if t.Kind() == Int && *(*int)(p) < 0 {
+			return errors.New("negative")
+		}
+		return nil
+	})
+}
+
+func validatePaths(errs []FieldError) []string {
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path+" "+e.Rule)
+	}
+	return paths
+}
+
+func TestValidateTags(t *testing.T) {
+	one := 1
+	loop := &validateNode{Val: &one}
+	loop.Next = loop
+	v := &validateRoot{
+		Users: []validateUser{
+			{Name: "a", Tags: []string{}, Attrs: map[string]string{}, Age: 1},
+			{Age: -1},
+		},
+		Pair:   [2]validateInner{{ID: 1}},
+		Any:    loop,
+		ByName: map[string]validateInner{"x": {}},
+	}
+	want := []string{
+		".validateEmbedded.Code required",
+		".Users[0].Bad nosuchrule",
+		".Users[1].Name required",
+		".Users[1].Tags nonnil",
+		".Users[1].Attrs nonnil",
+		".Users[1].Age vtestPositive",
+		".Users[1].Bad nosuchrule",
+		".Pair[1].ID required",
+		".Count nonnil",
+	}
+	errs := ValidateTags(v, "validate")
+	if got := validatePaths(errs); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateTags = %q, want %q", got, want)
+	}
+	for _, e := range errs {
+		var want error
+		switch e.Rule {
+		case "required":
+			want = ErrRequired
+		case "nonnil":
+			want = ErrNil
+			if e.Path == ".Count" {
+				var ke *KindError
+				if !errors.As(&e, &ke) || ke.Kind != Int {
+					t.Errorf("%s: %v, want a *KindError", e.Path, e.Err)
+				}
+				continue
+			}
+		case "nosuchrule":
+			want = ErrUnknownRule
+		default:
+			continue
+		}
+		if !errors.Is(&e, want) {
+			t.Errorf("%s: %v, want %v", e.Path, e.Err, want)
+		}
+	}
+
+	// The cycle is walked once, and a nil pointer stops the walk.
+	loop.Next = &validateNode{}
+	got := validatePaths(ValidateTags(validateRoot{validateEmbedded: validateEmbedded{"c"}, Any: loop, Pair: [2]validateInner{{1}, {2}}, Count: 1}, "validate"))
+	if want := []string{".Any.Next.Val nonnil", ".Any.Next.Next nonnil", ".Count nonnil"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateTags of a list = %q, want %q", got, want)
+	}
+
+	if errs := ValidateTags(nil, "validate"); errs != nil {
+		t.Errorf("ValidateTags(nil) = %v", errs)
+	}
+	if errs := ValidateTags(validateUser{}, "json"); errs != nil {
+		t.Errorf("ValidateTags with another key = %v", errs)
+	}
+}
+
+func TestValidateTagsUnexported(t *testing.T) {
+	v := validateRoot{validateEmbedded: validateEmbedded{"c"}, Pair: [2]validateInner{{1}, {2}}, Count: 1, Owner: &validateUser{Name: "o", Tags: []string{}, Attrs: map[string]string{}, Age: 1, Bad: 1}}
+	o := ValidateOptions{Unexported: true}
+	got := validatePaths(o.ValidateTags(v, "validate"))
+	want := []string{".Owner.Bad nosuchrule", ".Owner.note required", ".Count nonnil", ".hidden.ID required"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateTags with Unexported = %q, want %q", got, want)
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	e := &FieldError{Path: ".A.B", Rule: "required", Err: ErrRequired}
+	if got, want := e.Error(), "reflection: .A.B: required: "+ErrRequired.Error(); got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+	if !errors.Is(e, ErrRequired) {
+		t.Error("FieldError does not unwrap to its Err")
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	type T struct {
+		X int `validate:"vtestReplace"`
+	}
+	fail := errors.New("fail")
+	RegisterRule("vtestReplace", func(*rtype, unsafe.Pointer) error { return fail })
+	if errs := ValidateTags(T{}, "validate"); len(errs) != 1 || !errors.Is(&errs[0], fail) {
+		t.Errorf("ValidateTags = %v, want one failure", errs)
+	}
+	RegisterRule("vtestReplace", func(*rtype, unsafe.Pointer) error { return nil })
+	if errs := ValidateTags(T{}, "validate"); errs != nil {
+		t.Errorf("ValidateTags after replacing the rule = %v", errs)
+	}
+}
+
+func BenchmarkValidateTags(b *testing.B) {
+	v := &validateRoot{Users: make([]validateUser, 100)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateTags(v, "validate")
+	}
+}
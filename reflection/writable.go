@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"unsafe"
+)
+
+//go:linkname verifyNotInHeapPtr reflect.verifyNotInHeapPtr
+
+// verifyNotInHeapPtr reports whether p lies outside of the spans of the
+// heap. Unlike findObject, which throws on an address in a span not in use,
+// it accepts any address.
+// Implemented in the runtime package.
+func verifyNotInHeapPtr(p uintptr) bool
+
+// inHeap reports whether p lies in the spans of the heap, which hold the
+// heap objects and the goroutine stacks, and are mapped writable.
+func inHeap(p unsafe.Pointer) bool {
+	return !verifyNotInHeapPtr(uintptr(p))
+}
+
+// IsWritable reports whether the memory at p may be written to, so that
+// unsafe writes can be refused with an error rather than fault, as writing
+// to the bytes of a string literal or to a type descriptor does.
+//
+// The heap, which holds heap objects and goroutine stacks, and the data and
+// bss sections of the loaded modules are writable. Any other address, such
+// as one in the text or read-only data of a module, or in memory allocated
+// outside of Go, is reported not writable, as is every address outside of
+// the heap where the runtime module layout is not available, as Modules
+// explains.
+func IsWritable(p unsafe.Pointer) bool {
+	if p == nil {
+		return false
+	}
+	if inHeap(p) {
+		return true
+	}
+	addr := uintptr(p)
+	writable := false
+	rangeModules(func(md *moduledata) bool {
+		writable = md.writable(addr)
+		return !writable
+	})
+	return writable
+}
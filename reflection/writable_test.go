@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflection
+
+import (
+	"testing"
+	"unsafe"
+)
+
+var (
+	writableGlobal  = 1
+	writableLiteral = "a string literal"
+)
+
+func TestIsWritable(t *testing.T) {
+	heap := make([]byte, 64)
+	var stack [4]int
+	big := new([1 << 20]byte)
+	kernel := ^uintptr(0) &^ 0xfff // never mapped for user space
+	tests := []struct {
+		name string
+		p    unsafe.Pointer
+		want bool
+	}{
+		{"nil", nil, false},
+		{"heap slice", unsafe.Pointer(&heap[0]), true},
+		{"heap slice end", unsafe.Pointer(&heap[63]), true},
+		{"large heap object", unsafe.Pointer(&big[1<<19]), true},
+		{"stack", unsafe.Pointer(&stack[1]), true},
+		{"string literal", (*StringHeader)(unsafe.Pointer(&writableLiteral)).Data, false},
+		{"type descriptor", unsafe.Pointer(TypeFor[fieldT]()), false},
+		{"unmapped", *(*unsafe.Pointer)(unsafe.Pointer(&kernel)), false},
+		// Global variables are only known with the module layout.
+		{"global", unsafe.Pointer(&writableGlobal), modulesSupported},
+	}
+	for _, tt := range tests {
+		if got := IsWritable(tt.p); got != tt.want {
+			t.Errorf("IsWritable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}